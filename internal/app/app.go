@@ -58,6 +58,13 @@ func New() (*App, error) {
 	shareRepo := repository.NewShareRepository(pool)
 	trashRepo := repository.NewTrashRepository(pool)
 	jobRepo := repository.NewJobRepository(pool)
+	scheduleRepo := repository.NewScheduleRepository(pool)
+	pipelineRepo := repository.NewPipelineRepository(pool)
+	indexRepo := repository.NewIndexRepository(pool)
+	savedSearchRepo := repository.NewSavedSearchRepository(pool)
+	tagRepo := repository.NewTagRepository(pool)
+	metadataRepo := repository.NewMetadataRepository(pool)
+	quickAccessRepo := repository.NewQuickAccessRepository(pool)
 	slog.Info("database ready")
 
 	authService, err := service.NewAuthService(cfg.JWTSecret, cfg.JWTAccessTTL, cfg.JWTRefreshTTL, userRepo, tokenRepo)
@@ -73,24 +80,72 @@ func New() (*App, error) {
 	go hub.Run()
 
 	directoryService := service.NewDirectoryService(store, bus)
-	directoryHandler := handler.NewDirectoryHandler(directoryService)
 	fileService := service.NewFileService(store, cfg.AllowedMIMETypes, cfg.ThumbnailRoot, bus)
-	fileHandler := handler.NewFileHandler(fileService, cfg.MaxUploadSize)
+	archiveService := service.NewArchiveService(store, cfg.ArchiveTicketTTL)
+	fileHandler := handler.NewFileHandler(fileService, archiveService, cfg.MaxUploadSize)
+	archiveHandler := handler.NewArchiveHandler(archiveService)
 	trashService, err := service.NewTrashService(store, cfg.TrashRoot, trashRepo)
 	if err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize trash service: %w", err)
 	}
-	trashService.SetThumbnailRoot(cfg.ThumbnailRoot)
+	thumbnailService, err := service.NewThumbnailService(fileService, store, bus, service.ThumbnailOptions{
+		MaxBytes:      cfg.ThumbnailMaxBytes,
+		Workers:       cfg.ThumbnailWorkers,
+		Sizes:         cfg.ThumbnailPregenSizes,
+		SweepInterval: cfg.ThumbnailSweepInterval,
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize thumbnail cache: %w", err)
+	}
+	fileService.UseThumbnails(thumbnailService)
+	imageMetadataService := service.NewImageMetadataService(0)
+	fileService.UseImageMetadata(imageMetadataService)
+	directoryService.UseImageMetadata(imageMetadataService)
+	trashService.UseThumbnails(thumbnailService)
 	auditService := service.NewAuditService(auditRepo)
 	auditHandler := handler.NewAuditHandler(auditService)
+	archiveService.UseAudit(auditService)
 	docsHandler := handler.NewDocsHandler("./docs/openapi.yaml")
 	operationsService := service.NewOperationsService(store, trashService, auditService, bus)
 	operationsHandler := handler.NewOperationsHandler(operationsService)
-	jobService := service.NewJobService(operationsService, jobRepo, bus)
+	jobService := service.NewJobService(operationsService, jobRepo, bus, service.JobWorkerOptions{
+		Workers:           cfg.JobWorkers,
+		QuickWorkers:      cfg.JobQuickWorkers,
+		MaxPerUser:        cfg.JobMaxPerUser,
+		ResumeInterrupted: cfg.JobResumeInterrupted,
+	})
+	jobService.UseThumbnails(thumbnailService)
 	jobsHandler := handler.NewJobsHandler(jobService)
+	schedulerService := service.NewSchedulerService(scheduleRepo, userRepo, jobService)
+	scheduleHandler := handler.NewScheduleHandler(schedulerService)
+	pipelineService := service.NewPipelineService(pipelineRepo, userRepo, jobService)
+	jobService.OnJobFinished(pipelineService.JobFinished)
+	pipelineHandler := handler.NewPipelineHandler(pipelineService)
+	indexService := service.NewIndexService(store, indexRepo, bus, service.IndexOptions{
+		Interval:       cfg.SearchIndexInterval,
+		ContentMaxSize: cfg.SearchContentMaxSize,
+	})
 	searchService := service.NewSearchService(store, cfg.SearchMaxDepth, cfg.SearchTimeout)
+	searchService.UseIndex(indexService)
 	searchHandler := handler.NewSearchHandler(searchService)
+	savedSearchService := service.NewSavedSearchService(savedSearchRepo, searchService, bus)
+	savedSearchHandler := handler.NewSavedSearchHandler(savedSearchService)
+	tagService := service.NewTagService(tagRepo, store, auditService, bus)
+	operationsService.UseTags(tagService)
+	trashService.UseTags(tagService)
+	directoryService.UseTags(tagService)
+	fileService.UseTags(tagService)
+	searchService.UseTags(tagService)
+	tagHandler := handler.NewTagHandler(tagService)
+	metadataService := service.NewMetadataService(metadataRepo, store, auditService, bus)
+	operationsService.UseMetadata(metadataService)
+	trashService.UseMetadata(metadataService)
+	fileService.UseMetadata(metadataService)
+	searchService.UseMetadata(metadataService)
+	metadataHandler := handler.NewMetadataHandler(metadataService)
+	directoryHandler := handler.NewDirectoryHandler(directoryService, savedSearchService)
 	userHandler := handler.NewUserHandler(authService)
 	storageHandler := handler.NewStorageHandler(store, []string{cfg.TrashRoot, cfg.ThumbnailRoot, cfg.ChunkTempDir})
 	shareService := service.NewShareService(shareRepo)
@@ -101,6 +156,14 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("failed to initialize chunked upload service: %w", err)
 	}
 	chunkedUploadHandler := handler.NewChunkedUploadHandler(chunkedUploadService, cfg.ChunkMaxSize)
+	quickAccessService := service.NewQuickAccessService(quickAccessRepo, store, fileService)
+	operationsService.UseQuickAccess(quickAccessService)
+	trashService.UseQuickAccess(quickAccessService)
+	fileService.UseQuickAccess(quickAccessService)
+	metadataService.UseQuickAccess(quickAccessService)
+	chunkedUploadService.UseQuickAccess(quickAccessService)
+	quickAccessHandler := handler.NewQuickAccessHandler(quickAccessService)
+	previewHandler := handler.NewPreviewHandler(service.NewPreviewService(fileService))
 
 	appRouter := router.New(cfg, authMiddleware, router.Handlers{
 		Auth:          authHandler,
@@ -108,17 +171,34 @@ func New() (*App, error) {
 		File:          fileHandler,
 		Operations:    operationsHandler,
 		Search:        searchHandler,
+		SavedSearches: savedSearchHandler,
 		Audit:         auditHandler,
 		Jobs:          jobsHandler,
+		Schedules:     scheduleHandler,
+		Pipelines:     pipelineHandler,
 		Docs:          docsHandler,
 		User:          userHandler,
 		Storage:       storageHandler,
 		Share:         shareHandler,
 		ChunkedUpload: chunkedUploadHandler,
+		Archive:       archiveHandler,
+		Tags:          tagHandler,
+		Metadata:      metadataHandler,
+		QuickAccess:   quickAccessHandler,
+		Preview:       previewHandler,
 	}, hub)
 
 	cleanupCtx, cleanupCancel := context.WithCancel(context.Background())
 	go chunkedUploadService.StartCleanupTicker(cleanupCtx, cfg.ChunkExpiry)
+	go jobService.Run(cleanupCtx)
+	go pipelineService.Run(cleanupCtx)
+	go indexService.Run(cleanupCtx)
+	go thumbnailService.Run(cleanupCtx)
+	go savedSearchService.Run(cleanupCtx)
+	go jobService.StartRetentionTicker(cleanupCtx, cfg.JobRetention)
+	if cfg.SchedulerEnabled {
+		go schedulerService.Run(cleanupCtx)
+	}
 
 	server := &http.Server{
 		Addr:              ":" + cfg.ServerPort,
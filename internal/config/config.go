@@ -28,9 +28,28 @@ type Config struct {
 	AuthRateLimitRPM        int
 	SearchMaxDepth          int
 	SearchTimeout           time.Duration
+	SearchIndexInterval     time.Duration
+	SearchContentMaxSize    int64
 	AllowedMIMETypes        []string
 	TrashRoot               string
 	ThumbnailRoot           string
+	ArchiveTicketTTL        time.Duration
+
+	// Thumbnail cache
+	ThumbnailMaxBytes      int64
+	ThumbnailWorkers       int
+	ThumbnailPregenSizes   []int
+	ThumbnailSweepInterval time.Duration
+
+	// Background jobs
+	JobWorkers           int
+	JobQuickWorkers      int
+	JobMaxPerUser        int
+	JobResumeInterrupted bool
+	JobRetention         time.Duration
+
+	// Scheduled jobs
+	SchedulerEnabled bool
 
 	// Chunked uploads
 	ChunkTempDir string
@@ -63,9 +82,25 @@ func Load() (*Config, error) {
 		AuthRateLimitRPM:        getInt("AUTH_RATE_LIMIT_RPM", 60),
 		SearchMaxDepth:          getInt("SEARCH_MAX_DEPTH", 10),
 		SearchTimeout:           getDuration("SEARCH_TIMEOUT", 30*time.Second),
+		SearchIndexInterval:     getDuration("SEARCH_INDEX_INTERVAL", time.Hour),
+		SearchContentMaxSize:    getInt64("SEARCH_CONTENT_MAX_SIZE", 20971520),
 		AllowedMIMETypes:        splitCSV(strings.TrimSpace(os.Getenv("ALLOWED_MIME_TYPES"))),
 		TrashRoot:               getEnv("TRASH_ROOT", "./data/.trash"),
 		ThumbnailRoot:           getEnv("THUMBNAIL_ROOT", "./data/.thumbnails"),
+		ArchiveTicketTTL:        getDuration("ARCHIVE_TICKET_TTL", 5*time.Minute),
+
+		ThumbnailMaxBytes:      getInt64("THUMBNAIL_MAX_BYTES", 1073741824),
+		ThumbnailWorkers:       getInt("THUMBNAIL_WORKERS", 2),
+		ThumbnailPregenSizes:   getIntList("THUMBNAIL_PREGEN_SIZES", []int{256}),
+		ThumbnailSweepInterval: getDuration("THUMBNAIL_SWEEP_INTERVAL", 24*time.Hour),
+
+		JobWorkers:           getInt("JOB_WORKERS", 2),
+		JobQuickWorkers:      getInt("JOB_QUICK_WORKERS", 1),
+		JobMaxPerUser:        getInt("JOB_MAX_PER_USER", 2),
+		JobResumeInterrupted: getBool("JOB_RESUME_INTERRUPTED", false),
+		JobRetention:         getDuration("JOB_RETENTION", 720*time.Hour),
+
+		SchedulerEnabled: getBool("SCHEDULER_ENABLED", true),
 
 		ChunkTempDir: getEnv("CHUNK_TEMP_DIR", "./data/.chunks"),
 		ChunkMaxSize: getInt64("CHUNK_MAX_SIZE", 50*1024*1024),
@@ -122,6 +157,44 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("THUMBNAIL_ROOT cannot be empty")
 	}
 
+	if c.ArchiveTicketTTL <= 0 {
+		return fmt.Errorf("ARCHIVE_TICKET_TTL must be positive")
+	}
+
+	if c.ThumbnailMaxBytes < 0 {
+		return fmt.Errorf("THUMBNAIL_MAX_BYTES cannot be negative")
+	}
+
+	if c.ThumbnailWorkers < 1 {
+		return fmt.Errorf("THUMBNAIL_WORKERS must be at least 1")
+	}
+
+	for _, size := range c.ThumbnailPregenSizes {
+		if size < 32 || size > 2048 {
+			return fmt.Errorf("THUMBNAIL_PREGEN_SIZES must be between 32 and 2048")
+		}
+	}
+
+	if c.ThumbnailSweepInterval <= 0 {
+		return fmt.Errorf("THUMBNAIL_SWEEP_INTERVAL must be positive")
+	}
+
+	if c.JobWorkers < 1 {
+		return fmt.Errorf("JOB_WORKERS must be at least 1")
+	}
+
+	if c.JobQuickWorkers < 0 {
+		return fmt.Errorf("JOB_QUICK_WORKERS cannot be negative")
+	}
+
+	if c.JobMaxPerUser < 0 {
+		return fmt.Errorf("JOB_MAX_PER_USER cannot be negative")
+	}
+
+	if c.JobRetention < 0 {
+		return fmt.Errorf("JOB_RETENTION cannot be negative")
+	}
+
 	if strings.TrimSpace(c.ChunkTempDir) == "" {
 		return fmt.Errorf("CHUNK_TEMP_DIR cannot be empty")
 	}
@@ -186,6 +259,20 @@ func getInt64(key string, fallback int64) int64 {
 	return v
 }
 
+func getBool(key string, fallback bool) bool {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return fallback
+	}
+
+	return v
+}
+
 func getDuration(key string, fallback time.Duration) time.Duration {
 	raw := strings.TrimSpace(os.Getenv(key))
 	if raw == "" {
@@ -200,6 +287,24 @@ func getDuration(key string, fallback time.Duration) time.Duration {
 	return v
 }
 
+func getIntList(key string, fallback []int) []int {
+	parts := splitCSV(os.Getenv(key))
+	if len(parts) == 0 {
+		return fallback
+	}
+
+	out := make([]int, 0, len(parts))
+	for _, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return fallback
+		}
+		out = append(out, v)
+	}
+
+	return out
+}
+
 func splitCSV(raw string) []string {
 	if strings.TrimSpace(raw) == "" {
 		return nil
@@ -13,6 +13,60 @@ var initialMigrationSQL string
 //go:embed migrations/002_security_hardening.up.sql
 var securityHardeningSQL string
 
+//go:embed migrations/003_job_cancellation.up.sql
+var jobCancellationSQL string
+
+//go:embed migrations/004_durable_jobs.up.sql
+var durableJobsSQL string
+
+//go:embed migrations/005_job_workers.up.sql
+var jobWorkersSQL string
+
+//go:embed migrations/006_job_progress.up.sql
+var jobProgressSQL string
+
+//go:embed migrations/007_job_retries.up.sql
+var jobRetriesSQL string
+
+//go:embed migrations/008_schedules.up.sql
+var schedulesSQL string
+
+//go:embed migrations/009_pipelines.up.sql
+var pipelinesSQL string
+
+//go:embed migrations/010_job_actor.up.sql
+var jobActorSQL string
+
+//go:embed migrations/011_file_index.up.sql
+var fileIndexSQL string
+
+//go:embed migrations/012_file_content.up.sql
+var fileContentSQL string
+
+//go:embed migrations/013_search_filters.up.sql
+var searchFiltersSQL string
+
+//go:embed migrations/014_saved_searches.up.sql
+var savedSearchesSQL string
+
+//go:embed migrations/015_file_tags.up.sql
+var fileTagsSQL string
+
+//go:embed migrations/016_file_metadata.up.sql
+var fileMetadataSQL string
+
+//go:embed migrations/017_quick_access.up.sql
+var quickAccessSQL string
+
+//go:embed migrations/018_thumbnail_jobs.up.sql
+var thumbnailJobsSQL string
+
+//go:embed migrations/019_trashed_favorites.up.sql
+var trashedFavoritesSQL string
+
+//go:embed migrations/020_job_owner_starts.up.sql
+var jobOwnerStartsSQL string
+
 var requiredTables = []string{
 	"users",
 	"refresh_tokens",
@@ -55,6 +109,96 @@ func (db *DB) EnsureSchema(ctx context.Context) error {
 		return fmt.Errorf("apply security hardening migration: %w", err)
 	}
 
+	// 003: job cancellation (widen jobs status check).
+	if err := db.applyJobCancellation(ctx); err != nil {
+		return fmt.Errorf("apply job cancellation migration: %w", err)
+	}
+
+	// 004: durable job queue (request payloads, heartbeats, interrupted state).
+	if err := db.applyDurableJobs(ctx); err != nil {
+		return fmt.Errorf("apply durable jobs migration: %w", err)
+	}
+
+	// 005: job worker pool (owners and lanes).
+	if err := db.applyJobWorkers(ctx); err != nil {
+		return fmt.Errorf("apply job workers migration: %w", err)
+	}
+
+	// 006: byte-level job progress.
+	if err := db.applyJobProgress(ctx); err != nil {
+		return fmt.Errorf("apply job progress migration: %w", err)
+	}
+
+	// 007: job retries (parent job link).
+	if err := db.applyJobRetries(ctx); err != nil {
+		return fmt.Errorf("apply job retries migration: %w", err)
+	}
+
+	// 008: scheduled jobs.
+	if err := db.applySchedules(ctx); err != nil {
+		return fmt.Errorf("apply schedules migration: %w", err)
+	}
+
+	// 009: job pipelines.
+	if err := db.applyPipelines(ctx); err != nil {
+		return fmt.Errorf("apply pipelines migration: %w", err)
+	}
+
+	// 010: role and IP of the job owner, for auditing.
+	if err := db.applyJobActor(ctx); err != nil {
+		return fmt.Errorf("apply job actor migration: %w", err)
+	}
+
+	// 011: search index table.
+	if err := db.applyFileIndex(ctx); err != nil {
+		return fmt.Errorf("apply file index migration: %w", err)
+	}
+
+	// 012: extracted document text for content search.
+	if err := db.applyFileContent(ctx); err != nil {
+		return fmt.Errorf("apply file content migration: %w", err)
+	}
+
+	// 013: category, creation time and uploader of indexed entries.
+	if err := db.applySearchFilters(ctx); err != nil {
+		return fmt.Errorf("apply search filters migration: %w", err)
+	}
+
+	// 014: saved searches shown as smart folders.
+	if err := db.applySavedSearches(ctx); err != nil {
+		return fmt.Errorf("apply saved searches migration: %w", err)
+	}
+
+	// 015: file and folder tags.
+	if err := db.applyFileTags(ctx); err != nil {
+		return fmt.Errorf("apply file tags migration: %w", err)
+	}
+
+	// 016: custom metadata fields and values.
+	if err := db.applyFileMetadata(ctx); err != nil {
+		return fmt.Errorf("apply file metadata migration: %w", err)
+	}
+
+	// 017: per-user favorites and recent files.
+	if err := db.applyQuickAccess(ctx); err != nil {
+		return fmt.Errorf("apply quick access migration: %w", err)
+	}
+
+	// 018: thumbnail pre-generation jobs.
+	if err := db.applyThumbnailJobs(ctx); err != nil {
+		return fmt.Errorf("apply thumbnail jobs migration: %w", err)
+	}
+
+	// 019: favorites parked with trash records.
+	if err := db.applyTrashedFavorites(ctx); err != nil {
+		return fmt.Errorf("apply trashed favorites migration: %w", err)
+	}
+
+	// 020: last job start per owner for fair claiming.
+	if err := db.applyJobOwnerStarts(ctx); err != nil {
+		return fmt.Errorf("apply job owner starts migration: %w", err)
+	}
+
 	slog.Info("database schema ensured")
 	return nil
 }
@@ -86,6 +230,290 @@ func (db *DB) applySecurityHardening(ctx context.Context) error {
 	return nil
 }
 
+// applyJobCancellation runs migration 003 when the jobs status check does not
+// accept 'cancelled' yet.
+func (db *DB) applyJobCancellation(ctx context.Context) error {
+	var allowsCancelled bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_constraint
+			WHERE conrelid = 'jobs'::regclass
+			  AND conname = 'jobs_status_check'
+			  AND pg_get_constraintdef(oid) LIKE '%cancelled%'
+		)
+	`).Scan(&allowsCancelled)
+	if err != nil {
+		return fmt.Errorf("check jobs_status_check constraint: %w", err)
+	}
+
+	if !allowsCancelled {
+		slog.Info("applying job cancellation migration (003)")
+		if _, err := db.Pool.Exec(ctx, jobCancellationSQL); err != nil {
+			return fmt.Errorf("exec job cancellation SQL: %w", err)
+		}
+		slog.Info("job cancellation migration applied")
+	}
+
+	return nil
+}
+
+// applyDurableJobs runs migration 004 when jobs has no request column yet.
+func (db *DB) applyDurableJobs(ctx context.Context) error {
+	var hasColumn bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public'
+			  AND table_name = 'jobs'
+			  AND column_name = 'request'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return fmt.Errorf("check jobs request column: %w", err)
+	}
+
+	if !hasColumn {
+		slog.Info("applying durable jobs migration (004)")
+		if _, err := db.Pool.Exec(ctx, durableJobsSQL); err != nil {
+			return fmt.Errorf("exec durable jobs SQL: %w", err)
+		}
+		slog.Info("durable jobs migration applied")
+	}
+
+	return nil
+}
+
+// applyJobWorkers runs migration 005 when jobs has no lane column yet.
+func (db *DB) applyJobWorkers(ctx context.Context) error {
+	var hasColumn bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public'
+			  AND table_name = 'jobs'
+			  AND column_name = 'lane'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return fmt.Errorf("check jobs lane column: %w", err)
+	}
+
+	if !hasColumn {
+		slog.Info("applying job workers migration (005)")
+		if _, err := db.Pool.Exec(ctx, jobWorkersSQL); err != nil {
+			return fmt.Errorf("exec job workers SQL: %w", err)
+		}
+		slog.Info("job workers migration applied")
+	}
+
+	return nil
+}
+
+// applyJobProgress runs migration 006 when jobs has no bytes_done column yet.
+func (db *DB) applyJobProgress(ctx context.Context) error {
+	var hasColumn bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public'
+			  AND table_name = 'jobs'
+			  AND column_name = 'bytes_done'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return fmt.Errorf("check jobs bytes_done column: %w", err)
+	}
+
+	if !hasColumn {
+		slog.Info("applying job progress migration (006)")
+		if _, err := db.Pool.Exec(ctx, jobProgressSQL); err != nil {
+			return fmt.Errorf("exec job progress SQL: %w", err)
+		}
+		slog.Info("job progress migration applied")
+	}
+
+	return nil
+}
+
+// applyJobRetries runs migration 007 when jobs has no parent_job_id column yet.
+func (db *DB) applyJobRetries(ctx context.Context) error {
+	var hasColumn bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public'
+			  AND table_name = 'jobs'
+			  AND column_name = 'parent_job_id'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return fmt.Errorf("check jobs parent_job_id column: %w", err)
+	}
+
+	if !hasColumn {
+		slog.Info("applying job retries migration (007)")
+		if _, err := db.Pool.Exec(ctx, jobRetriesSQL); err != nil {
+			return fmt.Errorf("exec job retries SQL: %w", err)
+		}
+		slog.Info("job retries migration applied")
+	}
+
+	return nil
+}
+
+// applySchedules runs migration 008 when the schedules table is missing.
+func (db *DB) applySchedules(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'schedules'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check schedules table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying schedules migration (008)")
+		if _, err := db.Pool.Exec(ctx, schedulesSQL); err != nil {
+			return fmt.Errorf("exec schedules SQL: %w", err)
+		}
+		slog.Info("schedules migration applied")
+	}
+
+	return nil
+}
+
+// applyPipelines runs migration 009 when the pipelines table is missing.
+func (db *DB) applyPipelines(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'pipelines'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check pipelines table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying pipelines migration (009)")
+		if _, err := db.Pool.Exec(ctx, pipelinesSQL); err != nil {
+			return fmt.Errorf("exec pipelines SQL: %w", err)
+		}
+		slog.Info("pipelines migration applied")
+	}
+
+	return nil
+}
+
+// applyJobActor runs migration 010 when jobs has no owner_role column yet.
+func (db *DB) applyJobActor(ctx context.Context) error {
+	var hasColumn bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public'
+			  AND table_name = 'jobs'
+			  AND column_name = 'owner_role'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return fmt.Errorf("check jobs owner_role column: %w", err)
+	}
+
+	if !hasColumn {
+		slog.Info("applying job actor migration (010)")
+		if _, err := db.Pool.Exec(ctx, jobActorSQL); err != nil {
+			return fmt.Errorf("exec job actor SQL: %w", err)
+		}
+		slog.Info("job actor migration applied")
+	}
+
+	return nil
+}
+
+// applyFileIndex runs migration 011 when the file_index table is missing.
+func (db *DB) applyFileIndex(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'file_index'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check file_index table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying file index migration (011)")
+		if _, err := db.Pool.Exec(ctx, fileIndexSQL); err != nil {
+			return fmt.Errorf("exec file index SQL: %w", err)
+		}
+		slog.Info("file index migration applied")
+	}
+
+	return nil
+}
+
+// applyFileContent runs migration 012 when the file_content table is missing.
+func (db *DB) applyFileContent(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'file_content'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check file_content table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying file content migration (012)")
+		if _, err := db.Pool.Exec(ctx, fileContentSQL); err != nil {
+			return fmt.Errorf("exec file content SQL: %w", err)
+		}
+		slog.Info("file content migration applied")
+	}
+
+	return nil
+}
+
+// applySearchFilters runs migration 013 when file_index has no owner column
+// yet.
+func (db *DB) applySearchFilters(ctx context.Context) error {
+	var hasColumn bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public'
+			  AND table_name = 'file_index'
+			  AND column_name = 'owner'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return fmt.Errorf("check file_index owner column: %w", err)
+	}
+
+	if !hasColumn {
+		slog.Info("applying search filters migration (013)")
+		if _, err := db.Pool.Exec(ctx, searchFiltersSQL); err != nil {
+			return fmt.Errorf("exec search filters SQL: %w", err)
+		}
+		slog.Info("search filters migration applied")
+	}
+
+	return nil
+}
+
 func (db *DB) hasAllRequiredTables(ctx context.Context) (bool, error) {
 	var count int
 	err := db.Pool.QueryRow(ctx, `
@@ -100,3 +528,187 @@ func (db *DB) hasAllRequiredTables(ctx context.Context) (bool, error) {
 
 	return count == len(requiredTables), nil
 }
+
+// applySavedSearches runs migration 014 when the saved_searches table does
+// not exist yet.
+func (db *DB) applySavedSearches(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'saved_searches'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check saved_searches table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying saved searches migration (014)")
+		if _, err := db.Pool.Exec(ctx, savedSearchesSQL); err != nil {
+			return fmt.Errorf("exec saved searches SQL: %w", err)
+		}
+		slog.Info("saved searches migration applied")
+	}
+
+	return nil
+}
+
+// applyFileTags runs migration 015 when the file_tags table does not exist
+// yet.
+func (db *DB) applyFileTags(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'file_tags'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check file_tags table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying file tags migration (015)")
+		if _, err := db.Pool.Exec(ctx, fileTagsSQL); err != nil {
+			return fmt.Errorf("exec file tags SQL: %w", err)
+		}
+		slog.Info("file tags migration applied")
+	}
+
+	return nil
+}
+
+// applyFileMetadata runs migration 016 when the file_metadata table does not
+// exist yet.
+func (db *DB) applyFileMetadata(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'file_metadata'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check file_metadata table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying file metadata migration (016)")
+		if _, err := db.Pool.Exec(ctx, fileMetadataSQL); err != nil {
+			return fmt.Errorf("exec file metadata SQL: %w", err)
+		}
+		slog.Info("file metadata migration applied")
+	}
+
+	return nil
+}
+
+// applyQuickAccess runs migration 017 when the favorites table does not exist
+// yet.
+func (db *DB) applyQuickAccess(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'favorites'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check favorites table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying quick access migration (017)")
+		if _, err := db.Pool.Exec(ctx, quickAccessSQL); err != nil {
+			return fmt.Errorf("exec quick access SQL: %w", err)
+		}
+		slog.Info("quick access migration applied")
+	}
+
+	return nil
+}
+
+// applyThumbnailJobs runs migration 018 when the jobs operation check does not
+// accept 'thumbnails' yet.
+func (db *DB) applyThumbnailJobs(ctx context.Context) error {
+	var allowsThumbnails bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_constraint
+			WHERE conrelid = 'jobs'::regclass
+			  AND conname = 'jobs_operation_check'
+			  AND pg_get_constraintdef(oid) LIKE '%thumbnails%'
+		)
+	`).Scan(&allowsThumbnails)
+	if err != nil {
+		return fmt.Errorf("check jobs_operation_check constraint: %w", err)
+	}
+
+	if !allowsThumbnails {
+		slog.Info("applying thumbnail jobs migration (018)")
+		if _, err := db.Pool.Exec(ctx, thumbnailJobsSQL); err != nil {
+			return fmt.Errorf("exec thumbnail jobs SQL: %w", err)
+		}
+		slog.Info("thumbnail jobs migration applied")
+	}
+
+	return nil
+}
+
+// applyTrashedFavorites runs migration 019 when favorites has no trash_id
+// column yet.
+func (db *DB) applyTrashedFavorites(ctx context.Context) error {
+	var hasColumn bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public'
+			  AND table_name = 'favorites'
+			  AND column_name = 'trash_id'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return fmt.Errorf("check favorites trash_id column: %w", err)
+	}
+
+	if !hasColumn {
+		slog.Info("applying trashed favorites migration (019)")
+		if _, err := db.Pool.Exec(ctx, trashedFavoritesSQL); err != nil {
+			return fmt.Errorf("exec trashed favorites SQL: %w", err)
+		}
+		slog.Info("trashed favorites migration applied")
+	}
+
+	return nil
+}
+
+// applyJobOwnerStarts runs migration 020 when the job_owner_starts table does
+// not exist yet.
+func (db *DB) applyJobOwnerStarts(ctx context.Context) error {
+	var hasTable bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			  AND table_name = 'job_owner_starts'
+		)
+	`).Scan(&hasTable)
+	if err != nil {
+		return fmt.Errorf("check job_owner_starts table: %w", err)
+	}
+
+	if !hasTable {
+		slog.Info("applying job owner starts migration (020)")
+		if _, err := db.Pool.Exec(ctx, jobOwnerStartsSQL); err != nil {
+			return fmt.Errorf("exec job owner starts SQL: %w", err)
+		}
+		slog.Info("job owner starts migration applied")
+	}
+
+	return nil
+}
@@ -0,0 +1,7 @@
+-- ══════════════════════════════════════════════════════════════
+-- Job cancellation: allow jobs to end in the 'cancelled' state
+-- ══════════════════════════════════════════════════════════════
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+    CHECK (status IN ('queued', 'running', 'completed', 'partial', 'failed', 'cancelled'));
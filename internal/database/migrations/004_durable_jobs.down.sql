@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_jobs_queued;
+
+UPDATE jobs SET status = 'failed' WHERE status = 'interrupted';
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+    CHECK (status IN ('queued', 'running', 'completed', 'partial', 'failed', 'cancelled'));
+
+DELETE FROM jobs WHERE operation NOT IN ('copy', 'move', 'delete');
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_operation_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_operation_check
+    CHECK (operation IN ('copy', 'move', 'delete'));
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS cancel_requested;
+ALTER TABLE jobs DROP COLUMN IF EXISTS heartbeat_at;
+ALTER TABLE jobs DROP COLUMN IF EXISTS request;
@@ -0,0 +1,22 @@
+-- ══════════════════════════════════════════════════════════════
+-- Durable job queue: persisted requests, heartbeats, interrupted state
+-- ══════════════════════════════════════════════════════════════
+
+-- The original payload so a queued job can be picked up after a restart.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS request JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- Refreshed by the worker while a job runs; stale heartbeats mark the job interrupted.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMPTZ;
+
+-- Set when a running job is cancelled; the owning worker polls it.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_operation_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_operation_check
+    CHECK (operation IN ('copy', 'move', 'delete', 'compress', 'decompress'));
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+    CHECK (status IN ('queued', 'running', 'completed', 'partial', 'failed', 'cancelled', 'interrupted'));
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queued ON jobs(created_at) WHERE status = 'queued';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_jobs_queued_lane;
+CREATE INDEX IF NOT EXISTS idx_jobs_queued ON jobs(created_at) WHERE status = 'queued';
+DROP INDEX IF EXISTS idx_jobs_owner_running;
+DROP INDEX IF EXISTS idx_jobs_owner_started;
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_lane_check;
+ALTER TABLE jobs DROP COLUMN IF EXISTS lane;
+ALTER TABLE jobs DROP COLUMN IF EXISTS owner_username;
+ALTER TABLE jobs DROP COLUMN IF EXISTS owner_id;
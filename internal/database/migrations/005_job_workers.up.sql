@@ -0,0 +1,18 @@
+-- ══════════════════════════════════════════════════════════════
+-- Job worker pool: owners for per-user fairness, quick/bulk lanes
+-- ══════════════════════════════════════════════════════════════
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS owner_username TEXT NOT NULL DEFAULT '';
+
+-- Deletes and moves are usually a rename and run in their own lane so a long
+-- copy cannot hold them up.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS lane TEXT NOT NULL DEFAULT 'bulk';
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_lane_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_lane_check CHECK (lane IN ('quick', 'bulk'));
+UPDATE jobs SET lane = 'quick' WHERE operation IN ('delete', 'move');
+
+CREATE INDEX IF NOT EXISTS idx_jobs_owner_started ON jobs(owner_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_owner_running ON jobs(owner_id) WHERE status = 'running';
+DROP INDEX IF EXISTS idx_jobs_queued;
+CREATE INDEX IF NOT EXISTS idx_jobs_queued_lane ON jobs(lane, created_at) WHERE status = 'queued';
@@ -0,0 +1,3 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS current_item;
+ALTER TABLE jobs DROP COLUMN IF EXISTS bytes_total;
+ALTER TABLE jobs DROP COLUMN IF EXISTS bytes_done;
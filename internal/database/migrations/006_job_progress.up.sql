@@ -0,0 +1,7 @@
+-- ══════════════════════════════════════════════════════════════
+-- Byte-level job progress, refreshed with each heartbeat
+-- ══════════════════════════════════════════════════════════════
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS bytes_done BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS bytes_total BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS current_item TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_jobs_parent;
+ALTER TABLE jobs DROP COLUMN IF EXISTS parent_job_id;
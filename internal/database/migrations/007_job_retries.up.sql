@@ -0,0 +1,6 @@
+-- ══════════════════════════════════════════════════════════════
+-- Job retries: a retry job points at the job whose failed items it re-runs
+-- ══════════════════════════════════════════════════════════════
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS parent_job_id UUID REFERENCES jobs(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_jobs_parent ON jobs(parent_job_id) WHERE parent_job_id IS NOT NULL;
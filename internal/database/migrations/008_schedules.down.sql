@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS schedule_runs;
+DROP TABLE IF EXISTS schedules;
+
+DELETE FROM jobs WHERE operation = 'empty_trash';
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_operation_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_operation_check
+    CHECK (operation IN ('copy', 'move', 'delete', 'compress', 'decompress'));
@@ -0,0 +1,42 @@
+-- ══════════════════════════════════════════════════════════════
+-- Scheduled jobs: cron schedules with an operation template and run history
+-- ══════════════════════════════════════════════════════════════
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_operation_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_operation_check
+    CHECK (operation IN ('copy', 'move', 'delete', 'compress', 'decompress', 'empty_trash'));
+
+CREATE TABLE IF NOT EXISTS schedules (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name           TEXT NOT NULL,
+    cron_expr      TEXT NOT NULL,
+    timezone       TEXT NOT NULL DEFAULT 'UTC',
+    misfire_policy TEXT NOT NULL DEFAULT 'run_once'
+                   CHECK (misfire_policy IN ('skip', 'run_once')),
+    enabled        BOOLEAN NOT NULL DEFAULT true,
+    request        JSONB NOT NULL,
+    owner_id       TEXT NOT NULL DEFAULT '',
+    owner_username TEXT NOT NULL DEFAULT '',
+    owner_role     TEXT NOT NULL DEFAULT '',
+    next_run_at    TIMESTAMPTZ,
+    last_run_at    TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_due   ON schedules(next_run_at) WHERE enabled;
+CREATE INDEX IF NOT EXISTS idx_schedules_owner ON schedules(owner_id);
+
+-- One row per due occurrence: the job it queued, or why none was queued.
+CREATE TABLE IF NOT EXISTS schedule_runs (
+    id            BIGSERIAL PRIMARY KEY,
+    schedule_id   UUID NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    job_id        UUID REFERENCES jobs(id) ON DELETE SET NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    triggered_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    status        TEXT NOT NULL CHECK (status IN ('queued', 'missed', 'failed')),
+    missed_runs   INT NOT NULL DEFAULT 0,
+    error         TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_runs_schedule ON schedule_runs(schedule_id, triggered_at DESC);
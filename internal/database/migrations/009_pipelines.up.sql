@@ -0,0 +1,37 @@
+-- ══════════════════════════════════════════════════════════════
+-- Job pipelines: sequential steps, each run as a job
+-- ══════════════════════════════════════════════════════════════
+
+CREATE TABLE IF NOT EXISTS pipelines (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name           TEXT NOT NULL DEFAULT '',
+    status         TEXT NOT NULL DEFAULT 'running'
+                   CHECK (status IN ('running', 'completed', 'partial', 'failed', 'cancelled')),
+    current_step   INT NOT NULL DEFAULT 0,
+    owner_id       TEXT NOT NULL DEFAULT '',
+    owner_username TEXT NOT NULL DEFAULT '',
+    owner_role     TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_pipelines_running ON pipelines(updated_at) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_pipelines_owner   ON pipelines(owner_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS pipeline_steps (
+    pipeline_id UUID NOT NULL REFERENCES pipelines(id) ON DELETE CASCADE,
+    step_index  INT NOT NULL,
+    step_key    TEXT NOT NULL,
+    request     JSONB NOT NULL,
+    on_failure  TEXT NOT NULL DEFAULT 'stop' CHECK (on_failure IN ('stop', 'continue')),
+    job_id      UUID REFERENCES jobs(id) ON DELETE SET NULL,
+    status      TEXT NOT NULL DEFAULT 'pending'
+                CHECK (status IN ('pending', 'running', 'completed', 'partial', 'failed', 'cancelled', 'skipped')),
+    error       TEXT NOT NULL DEFAULT '',
+    started_at  TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    PRIMARY KEY (pipeline_id, step_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pipeline_steps_job ON pipeline_steps(job_id);
@@ -0,0 +1,2 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS owner_ip;
+ALTER TABLE jobs DROP COLUMN IF EXISTS owner_role;
@@ -0,0 +1,7 @@
+-- ══════════════════════════════════════════════════════════════
+-- Job actor: role and client IP of the user who created a job, so the
+-- operations it runs are audited with the same actor
+-- ══════════════════════════════════════════════════════════════
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS owner_role TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS owner_ip   TEXT NOT NULL DEFAULT '';
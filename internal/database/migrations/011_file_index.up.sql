@@ -0,0 +1,27 @@
+-- ══════════════════════════════════════════════════════════════
+-- Search index: one row per file or directory under the storage root
+-- ══════════════════════════════════════════════════════════════
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS file_index (
+    path        TEXT PRIMARY KEY,
+    parent      TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    name_lower  TEXT NOT NULL,
+    type        TEXT NOT NULL CHECK (type IN ('file', 'directory')),
+    size        BIGINT NOT NULL DEFAULT 0,
+    mime_type   TEXT NOT NULL DEFAULT '',
+    extension   TEXT NOT NULL DEFAULT '',
+    permissions TEXT NOT NULL DEFAULT '',
+    modified_at TIMESTAMPTZ NOT NULL,
+    indexed_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Substring matches on the name use the trigram index, subtree filters the
+-- prefix index on path.
+CREATE INDEX IF NOT EXISTS idx_file_index_name_trgm ON file_index USING gin (name_lower gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_file_index_path_prefix ON file_index(path text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_file_index_parent ON file_index(parent);
+CREATE INDEX IF NOT EXISTS idx_file_index_extension ON file_index(extension);
+CREATE INDEX IF NOT EXISTS idx_file_index_indexed_at ON file_index(indexed_at);
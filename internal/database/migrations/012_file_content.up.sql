@@ -0,0 +1,16 @@
+-- ══════════════════════════════════════════════════════════════
+-- Content search: extracted text of indexed documents
+-- ══════════════════════════════════════════════════════════════
+
+-- size and modified_at record the file version the text was extracted from,
+-- so unchanged files are not extracted again.
+CREATE TABLE IF NOT EXISTS file_content (
+    path        TEXT PRIMARY KEY REFERENCES file_index(path) ON DELETE CASCADE,
+    size        BIGINT NOT NULL,
+    modified_at TIMESTAMPTZ NOT NULL,
+    body        TEXT NOT NULL DEFAULT '',
+    document    TSVECTOR NOT NULL,
+    indexed_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_content_document ON file_content USING gin (document);
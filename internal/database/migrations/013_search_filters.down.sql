@@ -0,0 +1,14 @@
+ALTER TABLE file_content DROP CONSTRAINT IF EXISTS file_content_path_fkey;
+ALTER TABLE file_content ADD CONSTRAINT file_content_path_fkey
+    FOREIGN KEY (path) REFERENCES file_index(path) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_file_index_mime_type;
+DROP INDEX IF EXISTS idx_file_index_owner;
+DROP INDEX IF EXISTS idx_file_index_category;
+DROP INDEX IF EXISTS idx_file_index_created_at;
+DROP INDEX IF EXISTS idx_file_index_modified_at;
+DROP INDEX IF EXISTS idx_file_index_size;
+
+ALTER TABLE file_index DROP COLUMN IF EXISTS created_at;
+ALTER TABLE file_index DROP COLUMN IF EXISTS owner;
+ALTER TABLE file_index DROP COLUMN IF EXISTS category;
@@ -0,0 +1,21 @@
+-- ══════════════════════════════════════════════════════════════
+-- Search filters: category, creation time and uploader of indexed entries
+-- ══════════════════════════════════════════════════════════════
+
+ALTER TABLE file_index ADD COLUMN IF NOT EXISTS category   TEXT NOT NULL DEFAULT '';
+ALTER TABLE file_index ADD COLUMN IF NOT EXISTS owner      TEXT NOT NULL DEFAULT '';
+ALTER TABLE file_index ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ;
+UPDATE file_index SET created_at = modified_at WHERE created_at IS NULL;
+ALTER TABLE file_index ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_file_index_size        ON file_index(size);
+CREATE INDEX IF NOT EXISTS idx_file_index_modified_at ON file_index(modified_at);
+CREATE INDEX IF NOT EXISTS idx_file_index_created_at  ON file_index(created_at);
+CREATE INDEX IF NOT EXISTS idx_file_index_category    ON file_index(category);
+CREATE INDEX IF NOT EXISTS idx_file_index_owner       ON file_index(owner) WHERE owner <> '';
+CREATE INDEX IF NOT EXISTS idx_file_index_mime_type   ON file_index(mime_type text_pattern_ops);
+
+-- Moves rewrite indexed paths in place, so extracted text follows them.
+ALTER TABLE file_content DROP CONSTRAINT IF EXISTS file_content_path_fkey;
+ALTER TABLE file_content ADD CONSTRAINT file_content_path_fkey
+    FOREIGN KEY (path) REFERENCES file_index(path) ON DELETE CASCADE ON UPDATE CASCADE;
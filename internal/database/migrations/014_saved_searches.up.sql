@@ -0,0 +1,18 @@
+-- ══════════════════════════════════════════════════════════════
+-- Saved searches: named search definitions shown as smart folders
+-- ══════════════════════════════════════════════════════════════
+
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name           TEXT NOT NULL,
+    query          JSONB NOT NULL,
+    shared         BOOLEAN NOT NULL DEFAULT false,
+    owner_id       TEXT NOT NULL DEFAULT '',
+    owner_username TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Names are unique per owner, ignoring case.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_searches_owner_name ON saved_searches(owner_id, lower(name));
+CREATE INDEX IF NOT EXISTS idx_saved_searches_shared ON saved_searches(shared) WHERE shared;
@@ -0,0 +1,17 @@
+-- ══════════════════════════════════════════════════════════════
+-- File tags: labels on files and folders, keyed by path
+-- ══════════════════════════════════════════════════════════════
+
+-- Tags of live entries have an empty trash_id. Trashing an entry parks its
+-- tags under the trash record so restoring brings them back.
+CREATE TABLE IF NOT EXISTS file_tags (
+    path       TEXT NOT NULL,
+    tag        TEXT NOT NULL,
+    trash_id   TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (trash_id, path, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_tags_path ON file_tags(path text_pattern_ops) WHERE trash_id = '';
+CREATE INDEX IF NOT EXISTS idx_file_tags_tag ON file_tags(tag) WHERE trash_id = '';
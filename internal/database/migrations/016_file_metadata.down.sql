@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS file_metadata;
+DROP TABLE IF EXISTS metadata_fields;
@@ -0,0 +1,31 @@
+-- ══════════════════════════════════════════════════════════════
+-- Custom metadata: typed fields defined by admins, values per path
+-- ══════════════════════════════════════════════════════════════
+
+CREATE TABLE IF NOT EXISTS metadata_fields (
+    name        TEXT PRIMARY KEY,
+    type        TEXT NOT NULL,
+    options     TEXT[] NOT NULL DEFAULT '{}',
+    description TEXT NOT NULL DEFAULT '',
+    created_by  TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- value_text holds every value in its canonical form; number and date values
+-- are also kept typed so search filters can compare them. Values of live
+-- entries have an empty trash_id, like file_tags.
+CREATE TABLE IF NOT EXISTS file_metadata (
+    path         TEXT NOT NULL,
+    field        TEXT NOT NULL REFERENCES metadata_fields(name) ON DELETE CASCADE,
+    trash_id     TEXT NOT NULL DEFAULT '',
+    value_text   TEXT NOT NULL,
+    value_number DOUBLE PRECISION,
+    value_date   DATE,
+    updated_by   TEXT NOT NULL DEFAULT '',
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (trash_id, path, field)
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_metadata_path ON file_metadata(path text_pattern_ops) WHERE trash_id = '';
+CREATE INDEX IF NOT EXISTS idx_file_metadata_field ON file_metadata(field, value_text) WHERE trash_id = '';
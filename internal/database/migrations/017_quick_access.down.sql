@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS recent_files;
+DROP TABLE IF EXISTS favorites;
@@ -0,0 +1,26 @@
+-- ══════════════════════════════════════════════════════════════
+-- Quick access: per-user favorites and recently used files
+-- ══════════════════════════════════════════════════════════════
+
+-- Favorites are listed by position, lowest first.
+CREATE TABLE IF NOT EXISTS favorites (
+    user_id    TEXT NOT NULL,
+    path       TEXT NOT NULL,
+    position   INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorites_path ON favorites(path text_pattern_ops);
+
+-- Each file appears once per user with the last way it was used.
+CREATE TABLE IF NOT EXISTS recent_files (
+    user_id     TEXT NOT NULL,
+    path        TEXT NOT NULL,
+    action      TEXT NOT NULL,
+    accessed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recent_files_user ON recent_files(user_id, accessed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_recent_files_path ON recent_files(path text_pattern_ops);
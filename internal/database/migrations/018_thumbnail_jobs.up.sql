@@ -0,0 +1,7 @@
+-- ══════════════════════════════════════════════════════════════
+-- Thumbnail jobs: allow the 'thumbnails' job operation
+-- ══════════════════════════════════════════════════════════════
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_operation_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_operation_check
+    CHECK (operation IN ('copy', 'move', 'delete', 'compress', 'decompress', 'empty_trash', 'thumbnails'));
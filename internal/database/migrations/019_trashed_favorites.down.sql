@@ -0,0 +1,4 @@
+DELETE FROM favorites WHERE trash_id <> '';
+ALTER TABLE favorites DROP CONSTRAINT IF EXISTS favorites_pkey;
+ALTER TABLE favorites ADD PRIMARY KEY (user_id, path);
+ALTER TABLE favorites DROP COLUMN IF EXISTS trash_id;
@@ -0,0 +1,9 @@
+-- ══════════════════════════════════════════════════════════════
+-- Trashed favorites: favorites of trashed entries are parked under the
+-- trash record and come back when the entry is restored
+-- ══════════════════════════════════════════════════════════════
+
+-- Favorites of live entries have an empty trash_id, like file_tags.
+ALTER TABLE favorites ADD COLUMN IF NOT EXISTS trash_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE favorites DROP CONSTRAINT IF EXISTS favorites_pkey;
+ALTER TABLE favorites ADD PRIMARY KEY (trash_id, user_id, path);
@@ -0,0 +1,15 @@
+-- ══════════════════════════════════════════════════════════════
+-- Job owner starts: when each owner's last job started, so claiming the
+-- next job reads one row per owner instead of their job history
+-- ══════════════════════════════════════════════════════════════
+
+CREATE TABLE IF NOT EXISTS job_owner_starts (
+    owner_id   TEXT PRIMARY KEY,
+    started_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO job_owner_starts (owner_id, started_at)
+SELECT owner_id, MAX(started_at) FROM jobs
+WHERE started_at IS NOT NULL
+GROUP BY owner_id
+ON CONFLICT (owner_id) DO NOTHING;
@@ -3,18 +3,23 @@ package event
 type Type string
 
 const (
-	TypeFileCreated      Type = "file.created"
-	TypeFileUploaded     Type = "file.uploaded"
-	TypeFileDeleted      Type = "file.deleted"
-	TypeFileMoved        Type = "file.moved"
-	TypeFileCopied       Type = "file.copied"
-	TypeDirCreated       Type = "dir.created"
-	TypeJobStarted       Type = "job.started"
-	TypeJobProgress      Type = "job.progress"
-	TypeJobCompleted     Type = "job.completed"
-	TypeJobFailed        Type = "job.failed"
-	TypeFileCompressed   Type = "file.compressed"
-	TypeFileDecompressed Type = "file.decompressed"
+	TypeFileCreated         Type = "file.created"
+	TypeFileUploaded        Type = "file.uploaded"
+	TypeFileDeleted         Type = "file.deleted"
+	TypeFileRestored        Type = "file.restored"
+	TypeFileMoved           Type = "file.moved"
+	TypeFileCopied          Type = "file.copied"
+	TypeDirCreated          Type = "dir.created"
+	TypeJobStarted          Type = "job.started"
+	TypeJobProgress         Type = "job.progress"
+	TypeJobCompleted        Type = "job.completed"
+	TypeJobFailed           Type = "job.failed"
+	TypeJobCancelled        Type = "job.cancelled"
+	TypeFileCompressed      Type = "file.compressed"
+	TypeFileDecompressed    Type = "file.decompressed"
+	TypeSearchUpdated       Type = "search.updated"
+	TypeFileTagged          Type = "file.tagged"
+	TypeFileMetadataChanged Type = "file.metadata"
 )
 
 type Event struct {
@@ -23,6 +28,9 @@ type Event struct {
 	Payload   interface{} `json:"payload"`
 	Timestamp string      `json:"timestamp"`
 	ActorID   string      `json:"actor_id,omitempty"` // Who triggered the event
+	// Audience limits WebSocket delivery to these user IDs; empty means
+	// every connected user.
+	Audience []string `json:"-"`
 }
 
 type Bus interface {
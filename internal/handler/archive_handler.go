@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
+)
+
+type ArchiveHandler struct {
+	service *service.ArchiveService
+}
+
+func NewArchiveHandler(service *service.ArchiveService) *ArchiveHandler {
+	return &ArchiveHandler{service: service}
+}
+
+// Download streams the selected paths as a single archive.
+func (h *ArchiveHandler) Download(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.ArchiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	prepared, err := h.service.Prepare(payload)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.service.LogDownload(payload, actorFromRequest(r))
+	h.stream(w, r, prepared)
+}
+
+// CreateTicket stores the selection and returns a URL that downloads it once
+// without an Authorization header.
+func (h *ArchiveHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.ArchiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	ticket, err := h.service.CreateTicket(payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, ticket, nil)
+}
+
+// TicketDownload streams the archive stored under a download ticket.
+func (h *ArchiveHandler) TicketDownload(w http.ResponseWriter, r *http.Request) {
+	request, err := h.service.RedeemTicket(chi.URLParam(r, "ticket"), clientIP(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	prepared, err := h.service.Prepare(request)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.stream(w, r, prepared)
+}
+
+func (h *ArchiveHandler) stream(w http.ResponseWriter, r *http.Request, prepared service.PreparedArchive) {
+	w.Header().Set("Content-Type", prepared.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": prepared.Name}))
+	w.Header().Set("Cache-Control", "no-store")
+
+	// Headers are already sent once streaming starts, so failures can only
+	// be logged; the client sees a truncated archive.
+	if err := h.service.Write(r.Context(), w, prepared); err != nil {
+		slog.Warn("archive stream aborted", "name", prepared.Name, "error", err.Error())
+	}
+}
@@ -42,7 +42,7 @@ func (h *ChunkedUploadHandler) Init(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.InitUpload(r.Context(), req)
+	resp, err := h.service.InitUpload(r.Context(), req, actorFromRequest(r))
 	if err != nil {
 		writeError(w, err)
 		return
@@ -12,11 +12,14 @@ import (
 )
 
 type DirectoryHandler struct {
-	service *service.DirectoryService
+	service       *service.DirectoryService
+	savedSearches *service.SavedSearchService
 }
 
-func NewDirectoryHandler(service *service.DirectoryService) *DirectoryHandler {
-	return &DirectoryHandler{service: service}
+// NewDirectoryHandler serves listings and trees of storage directories and,
+// below /.searches, of the saved search folders of savedSearches.
+func NewDirectoryHandler(service *service.DirectoryService, savedSearches *service.SavedSearchService) *DirectoryHandler {
+	return &DirectoryHandler{service: service, savedSearches: savedSearches}
 }
 
 func (h *DirectoryHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -27,8 +30,18 @@ func (h *DirectoryHandler) List(w http.ResponseWriter, r *http.Request) {
 	limit := parseIntOrDefault(query.Get("limit"), 50)
 	sortBy := strings.TrimSpace(query.Get("sort"))
 	order := strings.TrimSpace(query.Get("order"))
-
-	data, meta, err := h.service.List(r.Context(), requestedPath, page, limit, sortBy, order)
+	cursor := strings.TrimSpace(query.Get("cursor"))
+	tag := strings.TrimSpace(query.Get("tag"))
+	dimensions := parseBoolOrDefault(query.Get("dimensions"), false)
+
+	var data model.DirectoryListData
+	var meta model.Meta
+	var err error
+	if h.savedSearches != nil && service.IsVirtualPath(requestedPath) {
+		data, meta, err = h.savedSearches.ListFolder(r.Context(), requestedPath, page, limit, sortBy, order, cursor, tag, actorFromRequest(r))
+	} else {
+		data, meta, err = h.service.List(r.Context(), requestedPath, page, limit, sortBy, order, cursor, tag, dimensions)
+	}
 	if err != nil {
 		writeError(w, err)
 		return
@@ -68,12 +81,34 @@ func (h *DirectoryHandler) Tree(w http.ResponseWriter, r *http.Request) {
 	page := parseIntOrDefault(query.Get("page"), 1)
 	limit := parseIntOrDefault(query.Get("limit"), 200)
 
+	if h.savedSearches != nil && service.IsVirtualPath(requestedPath) {
+		data, meta, err := h.savedSearches.TreeFolder(r.Context(), requestedPath, page, limit, actorFromRequest(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeSuccess(w, http.StatusOK, data, &meta)
+		return
+	}
+
 	data, meta, err := h.service.Tree(r.Context(), requestedPath, depth, includeFiles, page, limit)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
 
+	// The first page of the root tree also shows the saved searches folder.
+	if h.savedSearches != nil && data.Path == "/" && page <= 1 {
+		node, err := h.savedSearches.RootNode(r.Context(), actorFromRequest(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if node != nil {
+			data.Nodes = append(data.Nodes, *node)
+		}
+	}
+
 	writeSuccess(w, http.StatusOK, data, &meta)
 }
 
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"go-file-explorer/internal/model"
 	"go-file-explorer/internal/service"
@@ -18,11 +19,12 @@ import (
 
 type FileHandler struct {
 	service       *service.FileService
+	archives      *service.ArchiveService
 	maxUploadSize int64
 }
 
-func NewFileHandler(service *service.FileService, maxUploadSize int64) *FileHandler {
-	return &FileHandler{service: service, maxUploadSize: maxUploadSize}
+func NewFileHandler(service *service.FileService, archives *service.ArchiveService, maxUploadSize int64) *FileHandler {
+	return &FileHandler{service: service, archives: archives, maxUploadSize: maxUploadSize}
 }
 
 func (h *FileHandler) Upload(w http.ResponseWriter, r *http.Request) {
@@ -67,7 +69,7 @@ func (h *FileHandler) Upload(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		uploaded, uploadErr := h.service.Upload(r.Context(), destination, part.FileName(), conflictPolicy, part)
+		uploaded, uploadErr := h.service.Upload(r.Context(), destination, part.FileName(), conflictPolicy, part, actorFromRequest(r))
 		if uploadErr != nil {
 			if isPayloadTooLarge(uploadErr) {
 				writeError(w, apierror.New("PAYLOAD_TOO_LARGE", "request body exceeds MAX_UPLOAD_SIZE", "MAX_UPLOAD_SIZE", http.StatusRequestEntityTooLarge))
@@ -113,6 +115,10 @@ func (h *FileHandler) Download(w http.ResponseWriter, r *http.Request) {
 	}
 
 	archive := strings.EqualFold(r.URL.Query().Get("archive"), "true")
+	if archive && strings.EqualFold(r.URL.Query().Get("resumable"), "true") {
+		h.downloadResumableArchive(w, r, requestedPath)
+		return
+	}
 	if archive {
 		directory, archiveName, err := h.service.GetDirectoryForArchive(requestedPath)
 		if err != nil {
@@ -134,6 +140,7 @@ func (h *FileHandler) Download(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer file.Close()
+	h.service.Downloaded(r.Context(), requestedPath, actorFromRequest(r))
 
 	filename := filepath.Base(requestedPath)
 	if decoded, decodeErr := strconv.Unquote(`"` + filename + `"`); decodeErr == nil {
@@ -146,6 +153,26 @@ func (h *FileHandler) Download(w http.ResponseWriter, r *http.Request) {
 	http.ServeContent(w, r, filename, info.ModTime(), file)
 }
 
+// downloadResumableArchive serves a directory as an uncompressed zip with an
+// exact Content-Length. Range requests are honoured while the ETag still
+// matches; once the tree changes If-Range falls back to a full response and
+// If-Match fails with 412, so a resume never stitches two snapshots together.
+func (h *FileHandler) downloadResumableArchive(w http.ResponseWriter, r *http.Request, requestedPath string) {
+	layout, archiveName, err := h.archives.PrepareResumable(requestedPath)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer layout.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": archiveName}))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("ETag", layout.ETag())
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, archiveName, time.Time{}, io.NewSectionReader(layout, 0, layout.Size()))
+}
+
 func (h *FileHandler) Preview(w http.ResponseWriter, r *http.Request) {
 	requestedPath := strings.TrimSpace(r.URL.Query().Get("path"))
 	if requestedPath == "" {
@@ -159,6 +186,7 @@ func (h *FileHandler) Preview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer file.Close()
+	h.service.Previewed(r.Context(), requestedPath, actorFromRequest(r))
 
 	filename := filepath.Base(requestedPath)
 	w.Header().Set("Content-Type", mimeType)
@@ -183,7 +211,13 @@ func (h *FileHandler) Thumbnail(w http.ResponseWriter, r *http.Request) {
 		size = 2048
 	}
 
-	file, info, err := h.service.GetThumbnail(requestedPath, size)
+	format, ok := negotiateThumbnailFormat(r)
+	if !ok {
+		writeError(w, apierror.New("BAD_REQUEST", "unsupported thumbnail format", r.URL.Query().Get("format"), http.StatusBadRequest))
+		return
+	}
+
+	file, info, err := h.service.GetThumbnail(requestedPath, size, format)
 	if err != nil {
 		var apiErr *apierror.APIError
 		if errors.As(err, &apiErr) && apiErr.Code == "UNSUPPORTED_TYPE" {
@@ -196,13 +230,73 @@ func (h *FileHandler) Thumbnail(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	filename := filepath.Base(requestedPath) + ".jpg"
-	w.Header().Set("Content-Type", "image/jpeg")
+	filename := filepath.Base(requestedPath) + format.Extension
+	w.Header().Set("Content-Type", format.MIMEType)
 	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
 	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	w.Header().Set("Vary", "Accept")
 	http.ServeContent(w, r, filename, info.ModTime(), file)
 }
 
+// negotiateThumbnailFormat picks the thumbnail format from the 'format' query
+// parameter or, without one, from the Accept header. Among equally acceptable
+// formats the default JPEG wins, so browsers sending "image/*" keep getting
+// the smallest files. It reports false for an unknown 'format' value.
+func negotiateThumbnailFormat(r *http.Request) (service.ThumbnailFormat, bool) {
+	if requested := strings.TrimSpace(r.URL.Query().Get("format")); requested != "" {
+		return service.ThumbnailFormatByName(requested)
+	}
+
+	accept := strings.TrimSpace(r.Header.Get("Accept"))
+	if accept == "" {
+		return service.ThumbnailJPEG, true
+	}
+
+	best, bestQuality := service.ThumbnailJPEG, 0.0
+	for _, format := range service.ThumbnailFormats {
+		if quality := acceptQuality(accept, format.MIMEType); quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best, true
+}
+
+// acceptQuality returns the q-value an Accept header gives mediaType, taken
+// from its most specific matching range.
+func acceptQuality(accept string, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		rangeType, params, _ := strings.Cut(part, ";")
+		rangeType = strings.ToLower(strings.TrimSpace(rangeType))
+
+		matched := -1
+		switch rangeType {
+		case mediaType:
+			matched = 2
+		case mainType + "/*":
+			matched = 1
+		case "*/*":
+			matched = 0
+		}
+		if matched <= specificity {
+			continue
+		}
+
+		rangeQuality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					rangeQuality = parsed
+				}
+			}
+		}
+		quality, specificity = rangeQuality, matched
+	}
+	return quality
+}
+
 func (h *FileHandler) Info(w http.ResponseWriter, r *http.Request) {
 	requestedPath := strings.TrimSpace(r.URL.Query().Get("path"))
 	if requestedPath == "" {
@@ -210,7 +304,7 @@ func (h *FileHandler) Info(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	item, err := h.service.GetInfo(requestedPath)
+	item, err := h.service.GetInfo(r.Context(), requestedPath)
 	if err != nil {
 		writeError(w, err)
 		return
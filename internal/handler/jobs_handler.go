@@ -2,8 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 
@@ -38,6 +41,54 @@ func (h *JobsHandler) CreateOperationJob(w http.ResponseWriter, r *http.Request)
 	writeSuccess(w, http.StatusAccepted, job, nil)
 }
 
+func (h *JobsHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	items, meta, err := h.service.ListJobs(model.JobQuery{
+		Status:    strings.TrimSpace(query.Get("status")),
+		Operation: strings.TrimSpace(query.Get("operation")),
+		Owner:     strings.TrimSpace(query.Get("owner")),
+		From:      strings.TrimSpace(query.Get("from")),
+		To:        strings.TrimSpace(query.Get("to")),
+		Page:      parseIntOrDefault(query.Get("page"), 1),
+		Limit:     parseIntOrDefault(query.Get("limit"), 50),
+	}, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, model.JobListData{Items: items}, &meta)
+}
+
+func (h *JobsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.JobDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	result, err := h.service.DeleteJobs(payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, result, nil)
+}
+
+func (h *JobsHandler) QueueStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.QueueStats()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, stats, nil)
+}
+
 func (h *JobsHandler) GetJob(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "job_id")
 	if jobID == "" {
@@ -54,6 +105,69 @@ func (h *JobsHandler) GetJob(w http.ResponseWriter, r *http.Request) {
 	writeSuccess(w, http.StatusOK, job, nil)
 }
 
+func (h *JobsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "job_id")
+	if jobID == "" {
+		writeError(w, apierror.New("BAD_REQUEST", "job_id is required", "job_id", http.StatusBadRequest))
+		return
+	}
+
+	job, err := h.service.CancelJob(jobID, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// A running job only acknowledges the request here; it reports
+	// "cancelled" once the operation has stopped.
+	status := http.StatusOK
+	if job.Status == "running" {
+		status = http.StatusAccepted
+	}
+	writeSuccess(w, status, job, nil)
+}
+
+func (h *JobsHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "job_id")
+	if jobID == "" {
+		writeError(w, apierror.New("BAD_REQUEST", "job_id is required", "job_id", http.StatusBadRequest))
+		return
+	}
+
+	job, err := h.service.ResumeJob(jobID, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusAccepted, job, nil)
+}
+
+func (h *JobsHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	jobID := chi.URLParam(r, "job_id")
+	if jobID == "" {
+		writeError(w, apierror.New("BAD_REQUEST", "job_id is required", "job_id", http.StatusBadRequest))
+		return
+	}
+
+	// The body is optional; an empty one keeps the original conflict policy.
+	var payload model.JobRetryRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	job, err := h.service.RetryJob(jobID, payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusAccepted, job, nil)
+}
+
 func (h *JobsHandler) GetJobItems(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "job_id")
 	if jobID == "" {
@@ -86,7 +200,7 @@ func (h *JobsHandler) Stream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ch, err := h.service.Subscribe(jobID)
+	ch, err := h.service.Subscribe(jobID, actorFromRequest(r))
 	if err != nil {
 		writeError(w, err)
 		return
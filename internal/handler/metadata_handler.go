@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
+)
+
+type MetadataHandler struct {
+	service *service.MetadataService
+}
+
+func NewMetadataHandler(service *service.MetadataService) *MetadataHandler {
+	return &MetadataHandler{service: service}
+}
+
+func (h *MetadataHandler) ListFields(w http.ResponseWriter, r *http.Request) {
+	fields, err := h.service.Fields(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, fields, nil)
+}
+
+func (h *MetadataHandler) CreateField(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.MetadataFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	field, err := h.service.CreateField(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, field, nil)
+}
+
+func (h *MetadataHandler) UpdateField(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.MetadataFieldUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	field, err := h.service.UpdateField(r.Context(), chi.URLParam(r, "name"), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, field, nil)
+}
+
+func (h *MetadataHandler) DeleteField(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteField(r.Context(), chi.URLParam(r, "name"), actorFromRequest(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, map[string]any{"deleted": true}, nil)
+}
+
+func (h *MetadataHandler) Get(w http.ResponseWriter, r *http.Request) {
+	requestedPath := strings.TrimSpace(r.URL.Query().Get("path"))
+	if requestedPath == "" {
+		writeError(w, apierror.New("BAD_REQUEST", "query parameter 'path' is required", "path", http.StatusBadRequest))
+		return
+	}
+
+	metadata, err := h.service.Get(r.Context(), requestedPath)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, metadata, nil)
+}
+
+func (h *MetadataHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.MetadataPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	metadata, err := h.service.Patch(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, metadata, nil)
+}
@@ -5,11 +5,13 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"go-file-explorer/internal/model"
 	"go-file-explorer/internal/service"
+	"go-file-explorer/internal/util"
 	"go-file-explorer/pkg/apierror"
 )
 
@@ -153,7 +155,17 @@ func (h *OperationsHandler) PermanentDeleteTrash(w http.ResponseWriter, r *http.
 }
 
 func (h *OperationsHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
-	count, err := h.service.EmptyTrash(r.Context(), actorFromRequest(r))
+	var olderThan time.Duration
+	if raw := strings.TrimSpace(r.URL.Query().Get("older_than")); raw != "" {
+		parsed, err := util.ParseAge(raw)
+		if err != nil {
+			writeError(w, apierror.New("BAD_REQUEST", "invalid older_than (e.g. 30d, 12h)", raw, http.StatusBadRequest))
+			return
+		}
+		olderThan = parsed
+	}
+
+	count, err := h.service.EmptyTrash(r.Context(), olderThan, actorFromRequest(r))
 	if err != nil {
 		writeError(w, err)
 		return
@@ -189,7 +201,7 @@ func (h *OperationsHandler) Decompress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.service.Decompress(r.Context(), payload.Source, payload.Destination, payload.ConflictPolicy, actorFromRequest(r))
+	result, err := h.service.Decompress(r.Context(), payload, actorFromRequest(r))
 	if err != nil {
 		// If it's a conflict error with data, we want to return the data (list of conflicts)
 		if apiErr, ok := err.(*apierror.APIError); ok && apiErr.Code == "CONFLICT" {
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
+)
+
+type PipelineHandler struct {
+	service *service.PipelineService
+}
+
+func NewPipelineHandler(service *service.PipelineService) *PipelineHandler {
+	return &PipelineHandler{service: service}
+}
+
+func (h *PipelineHandler) Create(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.PipelineRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	pipeline, err := h.service.Create(payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusAccepted, pipeline, nil)
+}
+
+func (h *PipelineHandler) Get(w http.ResponseWriter, r *http.Request) {
+	pipeline, err := h.service.Get(chi.URLParam(r, "pipeline_id"), actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, pipeline, nil)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
+)
+
+type PreviewHandler struct {
+	service *service.PreviewService
+}
+
+func NewPreviewHandler(service *service.PreviewService) *PreviewHandler {
+	return &PreviewHandler{service: service}
+}
+
+// Structured returns the first lines of a text file, a page of a CSV/TSV
+// table or an indented JSON document, read only as far as needed.
+func (h *PreviewHandler) Structured(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	requestedPath := strings.TrimSpace(query.Get("path"))
+	if requestedPath == "" {
+		writeError(w, apierror.New("BAD_REQUEST", "query parameter 'path' is required", "path", http.StatusBadRequest))
+		return
+	}
+
+	preview, err := h.service.Preview(r.Context(), requestedPath, service.PreviewOptions{
+		Kind:  query.Get("kind"),
+		Lines: parseIntOrDefault(query.Get("lines"), 0),
+		Page:  parseIntOrDefault(query.Get("page"), 1),
+		Limit: parseIntOrDefault(query.Get("limit"), 0),
+	}, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, preview, nil)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
+)
+
+type QuickAccessHandler struct {
+	service *service.QuickAccessService
+}
+
+func NewQuickAccessHandler(service *service.QuickAccessService) *QuickAccessHandler {
+	return &QuickAccessHandler{service: service}
+}
+
+func (h *QuickAccessHandler) Favorites(w http.ResponseWriter, r *http.Request) {
+	favorites, err := h.service.Favorites(r.Context(), actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, favorites, nil)
+}
+
+func (h *QuickAccessHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.FavoriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	favorites, err := h.service.Pin(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, favorites, nil)
+}
+
+func (h *QuickAccessHandler) Unpin(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.FavoriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	favorites, err := h.service.Unpin(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, favorites, nil)
+}
+
+func (h *QuickAccessHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.FavoriteOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	favorites, err := h.service.Reorder(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, favorites, nil)
+}
+
+func (h *QuickAccessHandler) Recent(w http.ResponseWriter, r *http.Request) {
+	recent, err := h.service.Recent(r.Context(), parseIntOrDefault(r.URL.Query().Get("limit"), 0), actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, recent, nil)
+}
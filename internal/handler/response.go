@@ -74,6 +74,22 @@ func writeError(w http.ResponseWriter, err error) {
 		status = http.StatusNotFound
 		body.Code = "NOT_FOUND"
 		body.Message = "Job not found"
+	} else if errors.Is(err, model.ErrScheduleNotFound) {
+		status = http.StatusNotFound
+		body.Code = "NOT_FOUND"
+		body.Message = "Schedule not found"
+	} else if errors.Is(err, model.ErrPipelineNotFound) {
+		status = http.StatusNotFound
+		body.Code = "NOT_FOUND"
+		body.Message = "Pipeline not found"
+	} else if errors.Is(err, model.ErrSavedSearchNotFound) {
+		status = http.StatusNotFound
+		body.Code = "NOT_FOUND"
+		body.Message = "Saved search not found"
+	} else if errors.Is(err, model.ErrMetadataFieldNotFound) {
+		status = http.StatusNotFound
+		body.Code = "NOT_FOUND"
+		body.Message = "Metadata field not found"
 	} else if errors.Is(err, model.ErrShareNotFound) {
 		status = http.StatusNotFound
 		body.Code = "NOT_FOUND"
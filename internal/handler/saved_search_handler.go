@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
+)
+
+type SavedSearchHandler struct {
+	service *service.SavedSearchService
+}
+
+func NewSavedSearchHandler(service *service.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{service: service}
+}
+
+func (h *SavedSearchHandler) Create(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.SavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	search, err := h.service.Create(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, search, nil)
+}
+
+func (h *SavedSearchHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	items, meta, err := h.service.List(r.Context(), actorFromRequest(r),
+		parseIntOrDefault(query.Get("page"), 1),
+		parseIntOrDefault(query.Get("limit"), 50))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, model.SavedSearchListData{Items: items}, &meta)
+}
+
+func (h *SavedSearchHandler) Get(w http.ResponseWriter, r *http.Request) {
+	search, err := h.service.Get(r.Context(), chi.URLParam(r, "search_id"), actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, search, nil)
+}
+
+func (h *SavedSearchHandler) Update(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.SavedSearchUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	search, err := h.service.Update(r.Context(), chi.URLParam(r, "search_id"), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, search, nil)
+}
+
+func (h *SavedSearchHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "search_id")
+	if err := h.service.Delete(r.Context(), id, actorFromRequest(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, map[string]any{"deleted": true}, nil)
+}
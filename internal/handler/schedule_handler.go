@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
+)
+
+type ScheduleHandler struct {
+	service *service.SchedulerService
+}
+
+func NewScheduleHandler(service *service.SchedulerService) *ScheduleHandler {
+	return &ScheduleHandler{service: service}
+}
+
+func (h *ScheduleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	schedule, err := h.service.Create(payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, schedule, nil)
+}
+
+func (h *ScheduleHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	items, meta, err := h.service.List(actorFromRequest(r),
+		parseIntOrDefault(query.Get("page"), 1),
+		parseIntOrDefault(query.Get("limit"), 50))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, model.ScheduleListData{Items: items}, &meta)
+}
+
+func (h *ScheduleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	schedule, err := h.service.Get(chi.URLParam(r, "schedule_id"), actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, schedule, nil)
+}
+
+func (h *ScheduleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.ScheduleUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	schedule, err := h.service.Update(chi.URLParam(r, "schedule_id"), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, schedule, nil)
+}
+
+func (h *ScheduleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "schedule_id")
+	if err := h.service.Delete(id, actorFromRequest(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, map[string]any{"deleted": true}, nil)
+}
+
+func (h *ScheduleHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	runs, meta, err := h.service.Runs(chi.URLParam(r, "schedule_id"), actorFromRequest(r),
+		parseIntOrDefault(query.Get("page"), 1),
+		parseIntOrDefault(query.Get("limit"), 50))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, runs, &meta)
+}
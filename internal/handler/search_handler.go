@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
+	"go-file-explorer/internal/model"
 	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
 )
 
 type SearchHandler struct {
@@ -16,14 +20,7 @@ func NewSearchHandler(service *service.SearchService) *SearchHandler {
 }
 
 func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
-	query := strings.TrimSpace(r.URL.Query().Get("q"))
-	path := strings.TrimSpace(r.URL.Query().Get("path"))
-	itemType := strings.TrimSpace(r.URL.Query().Get("type"))
-	ext := strings.TrimSpace(r.URL.Query().Get("ext"))
-	page := parseIntOrDefault(r.URL.Query().Get("page"), 1)
-	limit := parseIntOrDefault(r.URL.Query().Get("limit"), 20)
-
-	data, meta, err := h.service.Search(r.Context(), query, path, itemType, ext, page, limit)
+	data, meta, err := h.service.Search(r.Context(), searchQueryFromRequest(r))
 	if err != nil {
 		writeError(w, err)
 		return
@@ -31,3 +28,116 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 
 	writeSuccess(w, http.StatusOK, data, &meta)
 }
+
+// Quick answers quick open lookups, typed a few letters at a time, with the
+// best fuzzy matches.
+func (h *SearchHandler) Quick(w http.ResponseWriter, r *http.Request) {
+	data, err := h.service.Quick(r.Context(), searchQueryFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, data, nil)
+}
+
+// Stream writes search matches as they are found, as NDJSON or, when the
+// client asks for text/event-stream or format=sse, as server-sent events.
+// Each match is an "item" record and the stream ends with a "summary" record,
+// or an "error" record if the search fails after it started.
+func (h *SearchHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, apierror.New("INTERNAL_ERROR", "streaming not supported", "", http.StatusInternalServerError))
+		return
+	}
+
+	sse := r.URL.Query().Get("format") == "sse" || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	started := false
+	send := func(kind string, payload any) error {
+		if !started {
+			started = true
+			if sse {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Connection", "keep-alive")
+			} else {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+			}
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("X-Accel-Buffering", "no")
+			w.WriteHeader(http.StatusOK)
+		}
+
+		var err error
+		if sse {
+			data, marshalErr := json.Marshal(payload)
+			if marshalErr != nil {
+				return marshalErr
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", kind, data)
+		} else {
+			err = json.NewEncoder(w).Encode(map[string]any{"type": kind, kind: payload})
+		}
+		if err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	summary, err := h.service.Stream(r.Context(), searchQueryFromRequest(r), func(item model.FileItem) error {
+		return send("item", item)
+	})
+	if err != nil {
+		if !started {
+			writeError(w, err)
+			return
+		}
+		if r.Context().Err() == nil {
+			_ = send("error", model.APIError{Code: "INTERNAL_ERROR", Message: "search failed"})
+		}
+		return
+	}
+
+	_ = send("summary", summary)
+}
+
+func searchQueryFromRequest(r *http.Request) model.SearchQuery {
+	params := r.URL.Query()
+	return model.SearchQuery{
+		Query:          strings.TrimSpace(params.Get("q")),
+		Content:        strings.TrimSpace(params.Get("content")),
+		Path:           strings.TrimSpace(params.Get("path")),
+		Exclude:        params["exclude"],
+		Type:           strings.TrimSpace(params.Get("type")),
+		Extension:      strings.TrimSpace(params.Get("ext")),
+		MimeType:       strings.TrimSpace(params.Get("mime")),
+		Category:       strings.TrimSpace(params.Get("category")),
+		MinSize:        strings.TrimSpace(params.Get("min_size")),
+		MaxSize:        strings.TrimSpace(params.Get("max_size")),
+		ModifiedAfter:  strings.TrimSpace(params.Get("modified_after")),
+		ModifiedBefore: strings.TrimSpace(params.Get("modified_before")),
+		CreatedAfter:   strings.TrimSpace(params.Get("created_after")),
+		CreatedBefore:  strings.TrimSpace(params.Get("created_before")),
+		Name:           strings.TrimSpace(params.Get("name")),
+		Regex:          strings.TrimSpace(params.Get("regex")),
+		Owner:          strings.TrimSpace(params.Get("owner")),
+		Tag:            strings.TrimSpace(params.Get("tag")),
+		Meta:           params["meta"],
+		Sort:           strings.TrimSpace(params.Get("sort")),
+		Mode:           strings.TrimSpace(params.Get("mode")),
+		Page:           parseIntOrDefault(params.Get("page"), 1),
+		Limit:          parseIntOrDefault(params.Get("limit"), 0),
+		Cursor:         strings.TrimSpace(params.Get("cursor")),
+	}
+}
+
+func (h *SearchHandler) Reindex(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.Reindex(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusAccepted, status, nil)
+}
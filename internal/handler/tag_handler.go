@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/service"
+	"go-file-explorer/pkg/apierror"
+)
+
+type TagHandler struct {
+	service *service.TagService
+}
+
+func NewTagHandler(service *service.TagService) *TagHandler {
+	return &TagHandler{service: service}
+}
+
+func (h *TagHandler) Get(w http.ResponseWriter, r *http.Request) {
+	requestedPath := strings.TrimSpace(r.URL.Query().Get("path"))
+	if requestedPath == "" {
+		writeError(w, apierror.New("BAD_REQUEST", "query parameter 'path' is required", "path", http.StatusBadRequest))
+		return
+	}
+
+	tags, err := h.service.Get(r.Context(), requestedPath)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, tags, nil)
+}
+
+func (h *TagHandler) Add(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	tags, err := h.service.Add(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, tags, nil)
+}
+
+func (h *TagHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	tags, err := h.service.Remove(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, tags, nil)
+}
+
+func (h *TagHandler) Bulk(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var payload model.BulkTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, apierror.New("BAD_REQUEST", "invalid JSON body", "", http.StatusBadRequest))
+		return
+	}
+
+	result, err := h.service.Bulk(r.Context(), payload, actorFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, result, nil)
+}
+
+func (h *TagHandler) List(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.service.List(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, tags, nil)
+}
@@ -25,6 +25,18 @@ var (
 	// Job related errors
 	ErrJobNotFound = errors.New("job not found")
 
+	// Schedule related errors
+	ErrScheduleNotFound = errors.New("schedule not found")
+
+	// Pipeline related errors
+	ErrPipelineNotFound = errors.New("pipeline not found")
+
+	// Saved search related errors
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+
+	// Metadata related errors
+	ErrMetadataFieldNotFound = errors.New("metadata field not found")
+
 	// Share related errors
 	ErrShareNotFound = errors.New("share not found")
 	ErrShareExpired  = errors.New("share expired")
@@ -9,6 +9,7 @@ type TreeNode struct {
 	HasChildren bool       `json:"has_children"`
 	ItemCount   *int       `json:"item_count,omitempty"`
 	ModifiedAt  time.Time  `json:"modified_at"`
+	Virtual     bool       `json:"virtual,omitempty"`
 	Children    []TreeNode `json:"children,omitempty"`
 }
 
@@ -31,3 +32,21 @@ type AuditQuery struct {
 type AuditListData struct {
 	Items []AuditEntry `json:"items"`
 }
+
+// JobQuery filters the job list. Status and Operation accept comma-separated
+// values; Owner matches an owner id or username. OwnerID, when set, restricts
+// the list to one owner and is not taken from the request.
+type JobQuery struct {
+	Status    string
+	Operation string
+	Owner     string
+	OwnerID   string
+	From      string
+	To        string
+	Page      int
+	Limit     int
+}
+
+type JobListData struct {
+	Items []JobData `json:"items"`
+}
@@ -0,0 +1,117 @@
+package model
+
+import "time"
+
+// IndexEntry is one file or directory in the search index. Paths are API
+// paths relative to the storage root.
+type IndexEntry struct {
+	Path        string
+	Parent      string
+	Name        string
+	Type        string
+	Size        int64
+	MimeType    string
+	Extension   string
+	Category    string
+	Permissions string
+	ModifiedAt  time.Time
+	// CreatedAt is when the entry was first indexed, or its modification
+	// time if that is earlier.
+	CreatedAt time.Time
+	// Owner is the username of the uploader, empty for other files.
+	Owner string
+	// Snippet is the highlighted content match of a content search, or the
+	// highlighted name of a fuzzy match.
+	Snippet string
+	// Score ranks a fuzzy match; higher is better.
+	Score int
+}
+
+// ContentStamp identifies the file version stored text was extracted from.
+type ContentStamp struct {
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// SearchQuery holds the raw filters of a /search request. Query may use the
+// search syntax (ext:pdf size:>10MB ...); the service parses it into a
+// SearchFilter. The JSON form, without paging, is what a saved search stores.
+type SearchQuery struct {
+	Query          string   `json:"q,omitempty"`
+	Content        string   `json:"content,omitempty"`
+	Path           string   `json:"path,omitempty"`
+	Exclude        []string `json:"exclude,omitempty"`
+	Type           string   `json:"type,omitempty"`
+	Extension      string   `json:"ext,omitempty"`
+	MimeType       string   `json:"mime,omitempty"`
+	Category       string   `json:"category,omitempty"`
+	MinSize        string   `json:"min_size,omitempty"`
+	MaxSize        string   `json:"max_size,omitempty"`
+	ModifiedAfter  string   `json:"modified_after,omitempty"`
+	ModifiedBefore string   `json:"modified_before,omitempty"`
+	CreatedAfter   string   `json:"created_after,omitempty"`
+	CreatedBefore  string   `json:"created_before,omitempty"`
+	Name           string   `json:"name,omitempty"`
+	Regex          string   `json:"regex,omitempty"`
+	Owner          string   `json:"owner,omitempty"`
+	Sort           string   `json:"sort,omitempty"`
+	Mode           string   `json:"mode,omitempty"`
+	Tag            string   `json:"tag,omitempty"`
+	Meta           []string `json:"meta,omitempty"`
+	Page           int      `json:"-"`
+	Limit          int      `json:"-"`
+	Cursor         string   `json:"-"`
+}
+
+// SearchFilter is a parsed search. Terms must all appear in the name,
+// case-insensitively; sizes are inclusive bounds, After times inclusive and
+// Before times exclusive. Sort is a field name, prefixed with "-" for
+// descending order. Fuzzy matches Terms as fuzzy patterns instead of
+// substrings and ranks the results. Entries must carry every tag in Tags and
+// match every custom metadata filter in Metadata. A non-nil After starts the results after that position
+// instead of at Page.
+type SearchFilter struct {
+	Terms          []string
+	Content        string
+	Path           string
+	Exclude        []string
+	Type           string
+	Extensions     []string
+	MimeType       string
+	Category       string
+	MinSize        *int64
+	MaxSize        *int64
+	ModifiedAfter  *time.Time
+	ModifiedBefore *time.Time
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	NameGlob       string
+	NameRegex      string
+	Owner          string
+	Sort           string
+	Fuzzy          bool
+	Tags           []string
+	Metadata       []MetadataFilter
+	Page           int
+	Limit          int
+	After          *Cursor
+}
+
+// SearchSummary closes a streamed search. Truncated means more matches exist
+// beyond the ones streamed and TimedOut that the walk hit SEARCH_TIMEOUT;
+// either way NextCursor resumes after the last match sent.
+type SearchSummary struct {
+	Count      int    `json:"count"`
+	Truncated  bool   `json:"truncated"`
+	TimedOut   bool   `json:"timed_out"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type IndexStatus struct {
+	Ready          bool   `json:"ready"`
+	Running        bool   `json:"running"`
+	Entries        int    `json:"entries"`
+	LastStartedAt  string `json:"last_started_at,omitempty"`
+	LastFinishedAt string `json:"last_finished_at,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+}
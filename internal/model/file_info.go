@@ -17,8 +17,19 @@ type FileItem struct {
 	ModifiedAt   time.Time `json:"modified_at"`
 	CreatedAt    time.Time `json:"created_at"`
 	MatchContext string    `json:"match_context,omitempty"`
+	Score        int       `json:"score,omitempty"`
 	Permissions  string    `json:"permissions"`
 	ItemCount    *int      `json:"item_count,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	// Metadata holds custom metadata values by field name; only file info
+	// includes it.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// ImageMetadata is read from image files: everything for file info,
+	// only the dimensions for listings that ask for them.
+	ImageMetadata *ImageMetadata `json:"image_metadata,omitempty"`
+	// Virtual marks read-only entries that do not exist in storage, such as
+	// saved search folders.
+	Virtual bool `json:"virtual,omitempty"`
 }
 
 type DirectoryListData struct {
@@ -0,0 +1,34 @@
+package model
+
+// ImageMetadata is what is read from an image file itself: its format and
+// displayed dimensions, EXIF camera data and PNG text. Directory listings
+// only fill Format, Width and Height.
+type ImageMetadata struct {
+	Format      string `json:"format"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Orientation int    `json:"orientation,omitempty"`
+	CameraMake  string `json:"camera_make,omitempty"`
+	CameraModel string `json:"camera_model,omitempty"`
+	LensModel   string `json:"lens_model,omitempty"`
+	Software    string `json:"software,omitempty"`
+	// CapturedAt is RFC 3339 when the image records its UTC offset and a
+	// local time without offset otherwise.
+	CapturedAt string `json:"captured_at,omitempty"`
+	ISO        int    `json:"iso,omitempty"`
+	// ExposureTime is in seconds, as a fraction such as "1/250" below one
+	// second.
+	ExposureTime string            `json:"exposure_time,omitempty"`
+	FNumber      float64           `json:"f_number,omitempty"`
+	FocalLength  float64           `json:"focal_length,omitempty"`
+	GPS          *GPSPosition      `json:"gps,omitempty"`
+	Text         map[string]string `json:"text,omitempty"`
+}
+
+// GPSPosition is a position in decimal degrees; Altitude is in metres above
+// sea level.
+type GPSPosition struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Altitude  *float64 `json:"altitude,omitempty"`
+}
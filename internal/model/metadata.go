@@ -0,0 +1,64 @@
+package model
+
+// MetadataField is a custom attribute admins define for files and folders.
+// Type is string, number, date or enum; Options lists the values of an enum.
+type MetadataField struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Options     []string `json:"options,omitempty"`
+	Description string   `json:"description,omitempty"`
+	CreatedBy   string   `json:"created_by,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+type MetadataFieldRequest struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Options     []string `json:"options"`
+	Description string   `json:"description"`
+}
+
+// MetadataFieldUpdateRequest changes the given parts of a field. The type of
+// a field cannot change and enum options can only be added.
+type MetadataFieldUpdateRequest struct {
+	Description *string  `json:"description"`
+	Options     []string `json:"options"`
+}
+
+type MetadataFieldListData struct {
+	Items []MetadataField `json:"items"`
+}
+
+// FileMetadata are the custom metadata values of one file or folder, by
+// field name. Numbers are JSON numbers, dates YYYY-MM-DD strings.
+type FileMetadata struct {
+	Path   string         `json:"path"`
+	Values map[string]any `json:"values"`
+}
+
+// MetadataPatchRequest sets the given values of a path; a null value removes
+// the field.
+type MetadataPatchRequest struct {
+	Path   string         `json:"path"`
+	Values map[string]any `json:"values"`
+}
+
+// MetadataValue is a stored value: Text is its canonical form, Number is set
+// for number fields.
+type MetadataValue struct {
+	Field  string
+	Type   string
+	Text   string
+	Number *float64
+}
+
+// MetadataFilter matches entries whose Field compares to Value with Op (=,
+// !=, >, >=, < or <=). Type is the type of the field, set once the filter is
+// checked against the field definitions.
+type MetadataFilter struct {
+	Field string
+	Op    string
+	Value string
+	Type  string
+}
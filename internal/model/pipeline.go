@@ -0,0 +1,46 @@
+package model
+
+// PipelineRequest submits job steps that run one after another. A step may
+// reference the results of earlier steps with {steps.<id>.<field>}.
+type PipelineRequest struct {
+	Name  string                `json:"name"`
+	Steps []PipelineStepRequest `json:"steps"`
+}
+
+type PipelineStepRequest struct {
+	// ID names the step for references; it defaults to the step's index.
+	ID string `json:"id,omitempty"`
+	// OnFailure is "stop" (default) or "continue".
+	OnFailure string              `json:"on_failure,omitempty"`
+	Request   JobOperationRequest `json:"request"`
+}
+
+type PipelineData struct {
+	PipelineID    string         `json:"pipeline_id"`
+	Name          string         `json:"name,omitempty"`
+	Status        string         `json:"status"`
+	CurrentStep   int            `json:"current_step"`
+	OwnerID       string         `json:"owner_id"`
+	OwnerUsername string         `json:"owner_username"`
+	OwnerRole     string         `json:"-"`
+	Steps         []PipelineStep `json:"steps"`
+	CreatedAt     string         `json:"created_at"`
+	UpdatedAt     string         `json:"updated_at"`
+	FinishedAt    string         `json:"finished_at,omitempty"`
+}
+
+// PipelineStep is one step of a pipeline. JobStatus is the current status of
+// its job, which is ahead of Status while the step is running.
+type PipelineStep struct {
+	Index     int                 `json:"index"`
+	ID        string              `json:"id"`
+	OnFailure string              `json:"on_failure"`
+	Request   JobOperationRequest `json:"request"`
+	JobID     string              `json:"job_id,omitempty"`
+	JobStatus string              `json:"job_status,omitempty"`
+	Status    string              `json:"status"`
+	Error     string              `json:"error,omitempty"`
+	StartedAt string              `json:"started_at,omitempty"`
+	// FinishedAt is when the step's job ended or the step was skipped.
+	FinishedAt string `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,39 @@
+package model
+
+// FilePreview is a structured preview of the start of a text file. Kind is
+// text, csv or json and says which of Text, Table and JSON is set.
+// Truncated is set when the file holds more than the preview shows.
+type FilePreview struct {
+	Path      string        `json:"path"`
+	Name      string        `json:"name"`
+	Kind      string        `json:"kind"`
+	Size      int64         `json:"size"`
+	Encoding  string        `json:"encoding"`
+	Truncated bool          `json:"truncated"`
+	Text      *TextPreview  `json:"text,omitempty"`
+	Table     *TablePreview `json:"table,omitempty"`
+	JSON      *JSONPreview  `json:"json,omitempty"`
+}
+
+// TextPreview holds the first lines of a text file, without line endings.
+type TextPreview struct {
+	Language string   `json:"language"`
+	Lines    []string `json:"lines"`
+}
+
+// TablePreview is one page of the rows of a CSV or TSV file after its
+// header row.
+type TablePreview struct {
+	Delimiter string     `json:"delimiter"`
+	Header    []string   `json:"header"`
+	Rows      [][]string `json:"rows"`
+	Page      int        `json:"page"`
+	Limit     int        `json:"limit"`
+	HasMore   bool       `json:"has_more"`
+}
+
+// JSONPreview is the indented start of a JSON document.
+type JSONPreview struct {
+	Content string `json:"content"`
+	Lines   int    `json:"lines"`
+}
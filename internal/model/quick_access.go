@@ -0,0 +1,34 @@
+package model
+
+// FavoriteRequest pins or unpins one path for the current user.
+type FavoriteRequest struct {
+	Path string `json:"path"`
+}
+
+// FavoriteOrderRequest lists every favorite of the user in the new order.
+type FavoriteOrderRequest struct {
+	Paths []string `json:"paths"`
+}
+
+type FavoriteListData struct {
+	Items []FileItem `json:"items"`
+}
+
+// RecentFile is a file the user recently downloaded, previewed, uploaded or
+// edited, with the last of those actions.
+type RecentFile struct {
+	FileItem
+	Action     string `json:"action"`
+	AccessedAt string `json:"accessed_at"`
+}
+
+type RecentListData struct {
+	Items []RecentFile `json:"items"`
+}
+
+// RecentEntry is a stored recent file of one user.
+type RecentEntry struct {
+	Path       string
+	Action     string
+	AccessedAt string
+}
@@ -11,10 +11,11 @@ type UploadFailure struct {
 }
 
 type UploadItem struct {
-	Name     string `json:"name"`
-	Path     string `json:"path"`
-	Size     int64  `json:"size"`
-	MimeType string `json:"mime_type"`
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	MimeType   string `json:"mime_type"`
+	UploadedBy string `json:"uploaded_by,omitempty"`
 }
 
 type UploadResponse struct {
@@ -130,12 +131,16 @@ type RefreshRequest struct {
 }
 
 type JobOperationRequest struct {
-	Operation      string   `json:"operation"`
-	Sources        []string `json:"sources,omitempty"`
-	Destination    string   `json:"destination,omitempty"`
-	Name           string   `json:"name,omitempty"` // Added for compress
-	Paths          []string `json:"paths,omitempty"`
-	ConflictPolicy string   `json:"conflict_policy,omitempty"`
+	Operation       string   `json:"operation"`
+	Sources         []string `json:"sources,omitempty"`
+	Destination     string   `json:"destination,omitempty"`
+	Name            string   `json:"name,omitempty"` // Added for compress
+	Paths           []string `json:"paths,omitempty"`
+	ConflictPolicy  string   `json:"conflict_policy,omitempty"`
+	Entries         []string `json:"entries,omitempty"`          // decompress only
+	StripComponents int      `json:"strip_components,omitempty"` // decompress only
+	Flatten         bool     `json:"flatten,omitempty"`          // decompress only
+	OlderThan       string   `json:"older_than,omitempty"`       // empty_trash only, e.g. "30d"
 }
 
 type JobItemResult struct {
@@ -147,19 +152,34 @@ type JobItemResult struct {
 }
 
 type JobData struct {
-	JobID          string          `json:"job_id"`
-	Operation      string          `json:"operation"`
-	Status         string          `json:"status"`
-	ConflictPolicy string          `json:"conflict_policy,omitempty"`
-	TotalItems     int             `json:"total_items"`
-	ProcessedItems int             `json:"processed_items"`
-	SuccessItems   int             `json:"success_items"`
-	FailedItems    int             `json:"failed_items"`
-	Progress       int             `json:"progress"`
-	CreatedAt      string          `json:"created_at"`
-	StartedAt      string          `json:"started_at,omitempty"`
-	FinishedAt     string          `json:"finished_at,omitempty"`
-	Items          []JobItemResult `json:"items,omitempty"`
+	JobID           string          `json:"job_id"`
+	Operation       string          `json:"operation"`
+	Status          string          `json:"status"`
+	ConflictPolicy  string          `json:"conflict_policy,omitempty"`
+	TotalItems      int             `json:"total_items"`
+	ProcessedItems  int             `json:"processed_items"`
+	SuccessItems    int             `json:"success_items"`
+	FailedItems     int             `json:"failed_items"`
+	Progress        int             `json:"progress"`
+	BytesDone       int64           `json:"bytes_done,omitempty"`
+	BytesTotal      int64           `json:"bytes_total,omitempty"`
+	CurrentItem     string          `json:"current_item,omitempty"`
+	Throughput      float64         `json:"throughput,omitempty"`
+	ETASeconds      int64           `json:"eta_seconds,omitempty"`
+	CancelRequested bool            `json:"cancel_requested,omitempty"`
+	OwnerID         string          `json:"owner_id,omitempty"`
+	OwnerUsername   string          `json:"owner_username,omitempty"`
+	OwnerRole       string          `json:"-"`
+	OwnerIP         string          `json:"-"`
+	Lane            string          `json:"lane,omitempty"`
+	QueuePosition   int             `json:"queue_position,omitempty"`
+	QueueDepth      int             `json:"queue_depth,omitempty"`
+	ParentJobID     string          `json:"parent_job_id,omitempty"`
+	RetryJobIDs     []string        `json:"retry_job_ids,omitempty"`
+	CreatedAt       string          `json:"created_at"`
+	StartedAt       string          `json:"started_at,omitempty"`
+	FinishedAt      string          `json:"finished_at,omitempty"`
+	Items           []JobItemResult `json:"items,omitempty"`
 }
 
 type JobItemsData struct {
@@ -167,6 +187,43 @@ type JobItemsData struct {
 	Items []JobItemResult `json:"items"`
 }
 
+// JobRetryRequest optionally overrides the conflict policy of a retry job.
+type JobRetryRequest struct {
+	ConflictPolicy string `json:"conflict_policy"`
+}
+
+// JobDeleteRequest selects finished jobs to delete, by id, by finish time or
+// both. Jobs that are still queued, running or interrupted are never deleted.
+type JobDeleteRequest struct {
+	JobIDs         []string `json:"job_ids"`
+	FinishedBefore string   `json:"finished_before"`
+}
+
+type JobDeleteResponse struct {
+	Deleted []string `json:"deleted"`
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+type JobLaneStats struct {
+	Lane    string `json:"lane"`
+	Workers int    `json:"workers"`
+	Queued  int    `json:"queued"`
+	Running int    `json:"running"`
+}
+
+type JobOwnerStats struct {
+	OwnerID       string `json:"owner_id"`
+	OwnerUsername string `json:"owner_username"`
+	Queued        int    `json:"queued"`
+	Running       int    `json:"running"`
+}
+
+type JobQueueStats struct {
+	MaxPerUser int             `json:"max_per_user"`
+	Lanes      []JobLaneStats  `json:"lanes"`
+	Owners     []JobOwnerStats `json:"owners"`
+}
+
 type StorageStats struct {
 	TotalSize      int64  `json:"total_size"`
 	TotalSizeHuman string `json:"total_size_human"`
@@ -230,13 +287,36 @@ type CompressResponse struct {
 }
 
 type DecompressRequest struct {
-	Source         string `json:"source"`
-	Destination    string `json:"destination"`
-	ConflictPolicy string `json:"conflict_policy,omitempty"`
+	Source          string   `json:"source"`
+	Destination     string   `json:"destination"`
+	ConflictPolicy  string   `json:"conflict_policy,omitempty"`
+	Entries         []string `json:"entries,omitempty"`
+	StripComponents int      `json:"strip_components,omitempty"`
+	Flatten         bool     `json:"flatten,omitempty"`
+}
+
+type DecompressEntryResult struct {
+	Entry  string `json:"entry"`
+	Path   string `json:"path,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
 }
 
 type DecompressResponse struct {
-	Destination string   `json:"destination"`
-	Files       []string `json:"files"`
-	Conflicts   []string `json:"conflicts,omitempty"`
+	Destination string                  `json:"destination"`
+	Files       []string                `json:"files"`
+	Conflicts   []string                `json:"conflicts,omitempty"`
+	Entries     []DecompressEntryResult `json:"entries,omitempty"`
+}
+
+type ArchiveRequest struct {
+	Paths  []string `json:"paths"`
+	Format string   `json:"format,omitempty"`
+	Name   string   `json:"name,omitempty"`
+}
+
+type ArchiveTicket struct {
+	Ticket      string `json:"ticket"`
+	DownloadURL string `json:"download_url"`
+	ExpiresAt   string `json:"expires_at"`
 }
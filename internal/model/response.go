@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type APIResponse struct {
 	Success bool      `json:"success"`
 	Data    any       `json:"data,omitempty"`
@@ -14,8 +16,21 @@ type APIError struct {
 }
 
 type Meta struct {
-	Page       int `json:"page"`
-	Limit      int `json:"limit"`
-	Total      int `json:"total"`
-	TotalPages int `json:"total_pages"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int    `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Cursor is the decoded form of an opaque pagination cursor: the sort key of
+// the last item of a page and its path or name, which break ties. Orders
+// without a stable key resume at Offset instead.
+type Cursor struct {
+	Path   string    `json:"p,omitempty"`
+	Name   string    `json:"n,omitempty"`
+	Type   string    `json:"t,omitempty"`
+	Size   int64     `json:"s,omitempty"`
+	Time   time.Time `json:"m,omitzero"`
+	Offset int       `json:"o,omitempty"`
 }
@@ -0,0 +1,41 @@
+package model
+
+// SavedSearch is a named search definition. It is listed as a read-only
+// folder at Path whose contents are the current results of Query. Shared
+// searches are visible to every user.
+type SavedSearch struct {
+	ID            string      `json:"id"`
+	Name          string      `json:"name"`
+	Query         SearchQuery `json:"query"`
+	Shared        bool        `json:"shared"`
+	Path          string      `json:"path"`
+	OwnerID       string      `json:"owner_id"`
+	OwnerUsername string      `json:"owner_username"`
+	CreatedAt     string      `json:"created_at"`
+	UpdatedAt     string      `json:"updated_at"`
+}
+
+type SavedSearchRequest struct {
+	Name   string      `json:"name"`
+	Query  SearchQuery `json:"query"`
+	Shared bool        `json:"shared"`
+}
+
+// SavedSearchUpdateRequest changes the fields that are present.
+type SavedSearchUpdateRequest struct {
+	Name   *string      `json:"name"`
+	Query  *SearchQuery `json:"query"`
+	Shared *bool        `json:"shared"`
+}
+
+type SavedSearchListData struct {
+	Items []SavedSearch `json:"items"`
+}
+
+// SavedSearchUpdate is the payload of a search.updated event: the results of
+// the saved search at Path may have changed and should be listed again.
+type SavedSearchUpdate struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
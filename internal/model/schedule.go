@@ -0,0 +1,62 @@
+package model
+
+// Schedule queues a job from an operation template each time its cron
+// expression fires. Paths in the template may contain date placeholders.
+type Schedule struct {
+	ID            string              `json:"id"`
+	Name          string              `json:"name"`
+	Cron          string              `json:"cron"`
+	Timezone      string              `json:"timezone"`
+	MisfirePolicy string              `json:"misfire_policy"`
+	Enabled       bool                `json:"enabled"`
+	Request       JobOperationRequest `json:"request"`
+	OwnerID       string              `json:"owner_id"`
+	OwnerUsername string              `json:"owner_username"`
+	OwnerRole     string              `json:"-"`
+	NextRunAt     string              `json:"next_run_at,omitempty"`
+	LastRunAt     string              `json:"last_run_at,omitempty"`
+	CreatedAt     string              `json:"created_at"`
+	UpdatedAt     string              `json:"updated_at"`
+}
+
+type ScheduleRequest struct {
+	Name          string              `json:"name"`
+	Cron          string              `json:"cron"`
+	Timezone      string              `json:"timezone"`
+	MisfirePolicy string              `json:"misfire_policy"`
+	Enabled       *bool               `json:"enabled"`
+	Request       JobOperationRequest `json:"request"`
+}
+
+// ScheduleUpdateRequest changes the fields that are present.
+type ScheduleUpdateRequest struct {
+	Name          *string              `json:"name"`
+	Cron          *string              `json:"cron"`
+	Timezone      *string              `json:"timezone"`
+	MisfirePolicy *string              `json:"misfire_policy"`
+	Enabled       *bool                `json:"enabled"`
+	Request       *JobOperationRequest `json:"request"`
+}
+
+// ScheduleRun records one due occurrence of a schedule: the job it queued,
+// or why none was queued. JobStatus is the current status of that job.
+type ScheduleRun struct {
+	ID           int64  `json:"id"`
+	ScheduleID   string `json:"schedule_id"`
+	JobID        string `json:"job_id,omitempty"`
+	JobStatus    string `json:"job_status,omitempty"`
+	ScheduledFor string `json:"scheduled_for"`
+	TriggeredAt  string `json:"triggered_at"`
+	Status       string `json:"status"`
+	MissedRuns   int    `json:"missed_runs,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type ScheduleListData struct {
+	Items []Schedule `json:"items"`
+}
+
+type ScheduleRunsData struct {
+	ScheduleID string        `json:"schedule_id"`
+	Items      []ScheduleRun `json:"items"`
+}
@@ -0,0 +1,40 @@
+package model
+
+// TagRequest adds or removes tags of one path.
+type TagRequest struct {
+	Path string   `json:"path"`
+	Tags []string `json:"tags"`
+}
+
+// FileTags are the tags of one file or folder, sorted by name.
+type FileTags struct {
+	Path string   `json:"path"`
+	Tags []string `json:"tags"`
+}
+
+// BulkTagRequest adds and removes tags on every path of a selection.
+type BulkTagRequest struct {
+	Paths  []string `json:"paths"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+type BulkTagResponse struct {
+	Updated []FileTags   `json:"updated"`
+	Failed  []TagFailure `json:"failed"`
+}
+
+type TagFailure struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// TagCount is a tag and how many files and folders carry it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TagListData struct {
+	Items []TagCount `json:"items"`
+}
@@ -0,0 +1,489 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/util"
+)
+
+type IndexRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewIndexRepository(pool *pgxpool.Pool) *IndexRepository {
+	return &IndexRepository{pool: pool}
+}
+
+// Upsert inserts or refreshes entries and stamps them with indexedAt.
+func (r *IndexRepository) Upsert(ctx context.Context, entries []model.IndexEntry, indexedAt time.Time) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, entry := range entries {
+		batch.Queue(
+			`INSERT INTO file_index (path, parent, name, name_lower, type, size, mime_type, extension, category,
+			                         permissions, modified_at, created_at, indexed_at)
+			 VALUES ($1, $2, $3, lower($3), $4, $5, $6, $7, $8, $9, $10, LEAST($10, $11), $11)
+			 ON CONFLICT (path) DO UPDATE SET
+			     parent = EXCLUDED.parent, name = EXCLUDED.name, name_lower = EXCLUDED.name_lower,
+			     type = EXCLUDED.type, size = EXCLUDED.size, mime_type = EXCLUDED.mime_type,
+			     extension = EXCLUDED.extension, category = EXCLUDED.category,
+			     permissions = EXCLUDED.permissions, modified_at = EXCLUDED.modified_at,
+			     created_at = LEAST(file_index.created_at, EXCLUDED.created_at),
+			     indexed_at = EXCLUDED.indexed_at`,
+			entry.Path, entry.Parent, entry.Name, entry.Type, entry.Size, entry.MimeType,
+			entry.Extension, entry.Category, entry.Permissions, entry.ModifiedAt, indexedAt)
+	}
+
+	results := r.pool.SendBatch(ctx, batch)
+	defer results.Close()
+	for range entries {
+		if _, err := results.Exec(); err != nil {
+			return fmt.Errorf("upsert index entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteTree removes path and everything below it.
+func (r *IndexRepository) DeleteTree(ctx context.Context, path string) error {
+	if path == "/" {
+		if _, err := r.pool.Exec(ctx, `DELETE FROM file_index`); err != nil {
+			return fmt.Errorf("clear index: %w", err)
+		}
+		return nil
+	}
+
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM file_index WHERE path = $1 OR path LIKE $2`,
+		path, escapeLike(path)+"/%")
+	if err != nil {
+		return fmt.Errorf("delete index tree: %w", err)
+	}
+	return nil
+}
+
+// MoveTree rewrites the paths of from and everything below it to to, keeping
+// owners, creation times and extracted text. Entries already at to are
+// replaced.
+func (r *IndexRepository) MoveTree(ctx context.Context, from string, to string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin move index tree: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM file_index WHERE path = $1 OR path LIKE $2`,
+		to, escapeLike(to)+"/%"); err != nil {
+		return fmt.Errorf("clear move target: %w", err)
+	}
+
+	slash := strings.LastIndex(to, "/")
+	parent, name := to[:slash], to[slash+1:]
+	if parent == "" {
+		parent = "/"
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE file_index SET
+		     path = $2 || substr(path, length($1) + 1),
+		     parent = CASE WHEN path = $1 THEN $4 ELSE $2 || substr(parent, length($1) + 1) END,
+		     name = CASE WHEN path = $1 THEN $5 ELSE name END,
+		     name_lower = CASE WHEN path = $1 THEN lower($5) ELSE name_lower END
+		 WHERE path = $1 OR path LIKE $3`,
+		from, to, escapeLike(from)+"/%", parent, name); err != nil {
+		return fmt.Errorf("move index tree: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit move index tree: %w", err)
+	}
+	return nil
+}
+
+// SetOwner records the uploader of an indexed file.
+func (r *IndexRepository) SetOwner(ctx context.Context, path string, owner string) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE file_index SET owner = $2 WHERE path = $1`, path, owner); err != nil {
+		return fmt.Errorf("set index owner: %w", err)
+	}
+	return nil
+}
+
+// Prune removes entries at or below path that were not refreshed since
+// before, and returns how many were removed.
+func (r *IndexRepository) Prune(ctx context.Context, path string, before time.Time) (int64, error) {
+	var tag pgconn.CommandTag
+	var err error
+	if path == "/" {
+		tag, err = r.pool.Exec(ctx, `DELETE FROM file_index WHERE indexed_at < $1`, before)
+	} else {
+		tag, err = r.pool.Exec(ctx,
+			`DELETE FROM file_index WHERE (path = $1 OR path LIKE $2) AND indexed_at < $3`,
+			path, escapeLike(path)+"/%", before)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("prune index: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *IndexRepository) Count(ctx context.Context) (int, error) {
+	var total int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM file_index`).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count index entries: %w", err)
+	}
+	return total, nil
+}
+
+// indexSortColumns maps SearchFilter.Sort fields to columns.
+var indexSortColumns = map[string]string{
+	"name":     "fi.name_lower",
+	"path":     "fi.path",
+	"type":     "fi.type",
+	"size":     "fi.size",
+	"modified": "fi.modified_at",
+	"created":  "fi.created_at",
+}
+
+// indexQuery is the FROM and WHERE part of a search, with its arguments.
+type indexQuery struct {
+	from    string
+	where   []string
+	args    []any
+	snippet string
+	ranked  bool
+}
+
+func (q *indexQuery) arg(value any) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *indexQuery) whereClause() string {
+	if len(q.where) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(q.where, " AND ")
+}
+
+func buildIndexQuery(filter model.SearchFilter) *indexQuery {
+	q := &indexQuery{from: "file_index fi", snippet: "''"}
+	if content := strings.TrimSpace(filter.Content); content != "" {
+		// Snippets are built from HTML-escaped text, so only the <mark> tags
+		// in them are markup.
+		q.from = fmt.Sprintf("file_index fi JOIN file_content fc ON fc.path = fi.path, websearch_to_tsquery('simple', %s) q", q.arg(content))
+		q.snippet = `ts_headline('simple',
+		            replace(replace(replace(fc.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), q,
+		            'StartSel="<mark>", StopSel="</mark>", MaxWords=24, MinWords=8, MaxFragments=2, FragmentDelimiter=" … "')`
+		q.ranked = true
+		q.where = append(q.where, "fc.document @@ q")
+	}
+
+	if path := strings.TrimSpace(filter.Path); path != "" && path != "/" {
+		q.where = append(q.where, "fi.path LIKE "+q.arg(escapeLike(path)+"/%"))
+	}
+	for _, excluded := range filter.Exclude {
+		q.where = append(q.where, fmt.Sprintf("fi.path <> %s AND fi.path NOT LIKE %s", q.arg(excluded), q.arg(escapeLike(excluded)+"/%")))
+	}
+	for _, term := range filter.Terms {
+		q.where = append(q.where, "fi.name_lower LIKE "+q.arg("%"+escapeLike(strings.ToLower(term))+"%"))
+	}
+	if filter.Type != "" {
+		q.where = append(q.where, "fi.type = "+q.arg(filter.Type))
+	}
+	if len(filter.Extensions) > 0 {
+		q.where = append(q.where, "fi.extension = ANY("+q.arg(filter.Extensions)+")")
+	}
+	if mimeType := strings.TrimSpace(filter.MimeType); mimeType != "" {
+		if prefix, ok := strings.CutSuffix(mimeType, "/*"); ok {
+			q.where = append(q.where, "fi.mime_type LIKE "+q.arg(escapeLike(prefix)+"/%"))
+		} else {
+			q.where = append(q.where, "fi.mime_type = "+q.arg(mimeType))
+		}
+	}
+	if filter.Category != "" {
+		q.where = append(q.where, "fi.category = "+q.arg(filter.Category))
+	}
+	if filter.MinSize != nil {
+		q.where = append(q.where, "fi.size >= "+q.arg(*filter.MinSize))
+	}
+	if filter.MaxSize != nil {
+		q.where = append(q.where, "fi.size <= "+q.arg(*filter.MaxSize))
+	}
+	if filter.ModifiedAfter != nil {
+		q.where = append(q.where, "fi.modified_at >= "+q.arg(*filter.ModifiedAfter))
+	}
+	if filter.ModifiedBefore != nil {
+		q.where = append(q.where, "fi.modified_at < "+q.arg(*filter.ModifiedBefore))
+	}
+	if filter.CreatedAfter != nil {
+		q.where = append(q.where, "fi.created_at >= "+q.arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		q.where = append(q.where, "fi.created_at < "+q.arg(*filter.CreatedBefore))
+	}
+	if filter.NameGlob != "" {
+		q.where = append(q.where, "fi.name_lower LIKE "+q.arg(globToLike(strings.ToLower(filter.NameGlob))))
+	}
+	if filter.NameRegex != "" {
+		// The service has already checked that the pattern translates.
+		if pattern, err := util.PostgresRegex(filter.NameRegex); err == nil {
+			q.where = append(q.where, "fi.name ~ "+q.arg(pattern))
+		} else {
+			q.where = append(q.where, "false")
+		}
+	}
+	if filter.Owner != "" {
+		q.where = append(q.where, "fi.owner = "+q.arg(filter.Owner))
+	}
+	for _, tag := range filter.Tags {
+		q.where = append(q.where, "EXISTS (SELECT 1 FROM file_tags ft WHERE ft.trash_id = '' AND ft.path = fi.path AND ft.tag = "+q.arg(tag)+")")
+	}
+	for _, meta := range filter.Metadata {
+		q.where = append(q.where, metadataCondition(q, meta))
+	}
+	return q
+}
+
+// metadataCondition matches entries whose value of a custom metadata field
+// compares to meta.Value; numbers and dates compare by value, other types as
+// text. The service has already checked the value against the field type.
+func metadataCondition(q *indexQuery, meta model.MetadataFilter) string {
+	column, value := "fm.value_text", any(meta.Value)
+	switch meta.Type {
+	case "number":
+		if number, err := strconv.ParseFloat(meta.Value, 64); err == nil {
+			column, value = "fm.value_number", number
+		}
+	case "date":
+		if date, err := time.Parse("2006-01-02", meta.Value); err == nil {
+			column, value = "fm.value_date", date
+		}
+	}
+	op := meta.Op
+	if op == "!=" {
+		op = "<>"
+	}
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM file_metadata fm WHERE fm.trash_id = '' AND fm.path = fi.path AND fm.field = %s AND %s %s %s)",
+		q.arg(meta.Field), column, op, q.arg(value))
+}
+
+// CountMatches returns how many entries match filter, ignoring its page and
+// cursor.
+func (r *IndexRepository) CountMatches(ctx context.Context, filter model.SearchFilter) (int, error) {
+	q := buildIndexQuery(filter)
+	var total int
+	if err := r.pool.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s %s", q.from, q.whereClause()), q.args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count index entries: %w", err)
+	}
+	return total, nil
+}
+
+// Search returns one page of matching entries and whether more follow it.
+// The page starts after filter.After when set, otherwise at filter.Page.
+// Content matches are ordered by relevance unless the filter sorts by another
+// field; relevance has no stable key, so its cursors carry an offset.
+func (r *IndexRepository) Search(ctx context.Context, filter model.SearchFilter) ([]model.IndexEntry, bool, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	q := buildIndexQuery(filter)
+	offset := (filter.Page - 1) * filter.Limit
+
+	order := "fi.name_lower, fi.path"
+	column, descending := "fi.name_lower", false
+	sortField, sortDescending := strings.CutPrefix(filter.Sort, "-")
+	if sortColumn, ok := indexSortColumns[sortField]; ok {
+		column, descending = sortColumn, sortDescending
+		direction := "ASC"
+		if descending {
+			direction = "DESC"
+		}
+		order = fmt.Sprintf("%s %s, fi.path", column, direction)
+	} else if q.ranked {
+		column = ""
+		order = "ts_rank_cd(fc.document, q) DESC, fi.name_lower, fi.path"
+	}
+
+	if after := filter.After; after != nil {
+		offset = 0
+		if column == "" {
+			offset = after.Offset
+		} else {
+			var key any
+			switch column {
+			case "fi.name_lower":
+				key = strings.ToLower(after.Name)
+			case "fi.path":
+				key = after.Path
+			case "fi.type":
+				key = after.Type
+			case "fi.size":
+				key = after.Size
+			default:
+				key = after.Time
+			}
+			op := ">"
+			if descending {
+				op = "<"
+			}
+			keyArg, pathArg := q.arg(key), q.arg(after.Path)
+			q.where = append(q.where, fmt.Sprintf("(%s %s %s OR (%s = %s AND fi.path > %s))", column, op, keyArg, column, keyArg, pathArg))
+		}
+	}
+
+	// One row beyond the page tells whether another page follows.
+	dataQuery := fmt.Sprintf(
+		`SELECT %s, %s
+		 FROM %s %s
+		 ORDER BY %s
+		 LIMIT %s OFFSET %s`, indexEntryColumns, q.snippet, q.from, q.whereClause(), order, q.arg(filter.Limit+1), q.arg(offset))
+
+	entries, err := r.queryEntries(ctx, dataQuery, q.args)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(entries) > filter.Limit {
+		return entries[:filter.Limit], true, nil
+	}
+	return entries, false, nil
+}
+
+// FuzzyCandidates returns up to limit entries matching filter, whose terms
+// are taken as fuzzy patterns: a term matches names that contain its letters
+// in order or that are similar to it by trigrams, and terms with a slash are
+// matched against the path. The caller scores the candidates; the most
+// similar come first so the limit keeps the likeliest ones.
+func (r *IndexRepository) FuzzyCandidates(ctx context.Context, filter model.SearchFilter, limit int) ([]model.IndexEntry, error) {
+	terms := filter.Terms
+	filter.Terms = nil
+	q := buildIndexQuery(filter)
+
+	similarity := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		column := "fi.name_lower"
+		if strings.Contains(term, "/") {
+			column = "lower(fi.path)"
+		}
+		termArg := q.arg(term)
+		q.where = append(q.where, fmt.Sprintf("(%s LIKE %s OR word_similarity(%s, %s) >= 0.3)",
+			column, q.arg(subsequenceLike(term)), termArg, column))
+		similarity = append(similarity, fmt.Sprintf("word_similarity(%s, %s)", termArg, column))
+	}
+	if len(similarity) == 0 {
+		similarity = append(similarity, "0")
+	}
+
+	dataQuery := fmt.Sprintf(
+		`SELECT %s, ''
+		 FROM %s %s
+		 ORDER BY %s DESC, length(fi.name), fi.path
+		 LIMIT %s`, indexEntryColumns, q.from, q.whereClause(), strings.Join(similarity, " + "), q.arg(limit))
+
+	return r.queryEntries(ctx, dataQuery, q.args)
+}
+
+// indexEntryColumns are the columns scanned into an IndexEntry, before its
+// snippet.
+const indexEntryColumns = `fi.path, fi.parent, fi.name, fi.type, fi.size, fi.mime_type, fi.extension, fi.category,
+		        fi.permissions, fi.modified_at, fi.created_at, fi.owner`
+
+func (r *IndexRepository) queryEntries(ctx context.Context, query string, args []any) ([]model.IndexEntry, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query index entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]model.IndexEntry, 0)
+	for rows.Next() {
+		var e model.IndexEntry
+		if err := rows.Scan(&e.Path, &e.Parent, &e.Name, &e.Type, &e.Size, &e.MimeType, &e.Extension, &e.Category,
+			&e.Permissions, &e.ModifiedAt, &e.CreatedAt, &e.Owner, &e.Snippet); err != nil {
+			return nil, fmt.Errorf("scan index entry: %w", err)
+		}
+		e.ModifiedAt = e.ModifiedAt.UTC()
+		e.CreatedAt = e.CreatedAt.UTC()
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query index entries: %w", err)
+	}
+	return entries, nil
+}
+
+// ContentStamps returns the stamps of the stored text of the given paths.
+// Paths without stored text are absent from the map.
+func (r *IndexRepository) ContentStamps(ctx context.Context, paths []string) (map[string]model.ContentStamp, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT path, size, modified_at FROM file_content WHERE path = ANY($1)`, paths)
+	if err != nil {
+		return nil, fmt.Errorf("query content stamps: %w", err)
+	}
+	defer rows.Close()
+
+	stamps := make(map[string]model.ContentStamp, len(paths))
+	for rows.Next() {
+		var path string
+		var stamp model.ContentStamp
+		if err := rows.Scan(&path, &stamp.Size, &stamp.ModifiedAt); err != nil {
+			return nil, fmt.Errorf("scan content stamp: %w", err)
+		}
+		stamps[path] = stamp
+	}
+	return stamps, rows.Err()
+}
+
+// UpsertContent stores the extracted text of an indexed file.
+func (r *IndexRepository) UpsertContent(ctx context.Context, path string, stamp model.ContentStamp, body string) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO file_content (path, size, modified_at, body, document, indexed_at)
+		 VALUES ($1, $2, $3, $4, to_tsvector('simple', $4), now())
+		 ON CONFLICT (path) DO UPDATE SET
+		     size = EXCLUDED.size, modified_at = EXCLUDED.modified_at, body = EXCLUDED.body,
+		     document = EXCLUDED.document, indexed_at = EXCLUDED.indexed_at`,
+		path, stamp.Size, stamp.ModifiedAt, body)
+	if err != nil {
+		return fmt.Errorf("upsert file content: %w", err)
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// subsequenceLike turns s into a LIKE pattern matching text that contains
+// its characters in order.
+func subsequenceLike(s string) string {
+	var pattern strings.Builder
+	pattern.WriteString("%")
+	for _, r := range s {
+		pattern.WriteString(escapeLike(string(r)))
+		pattern.WriteString("%")
+	}
+	return pattern.String()
+}
+
+// globToLike turns a glob with * and ? wildcards into a LIKE pattern.
+func globToLike(glob string) string {
+	return strings.NewReplacer(`*`, `%`, `?`, `_`).Replace(escapeLike(glob))
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -20,32 +22,38 @@ func NewJobRepository(pool *pgxpool.Pool) *JobRepository {
 	return &JobRepository{pool: pool}
 }
 
-func (r *JobRepository) Create(ctx context.Context, job model.JobData) error {
-	var startedAt, finishedAt *time.Time
+// jobColumns is the column list read by scanJob.
+const jobColumns = `id, operation, status, conflict_policy,
+		        total_items, processed_items, success_items, failed_items, progress,
+		        cancel_requested, owner_id, owner_username, owner_role, owner_ip, lane,
+		        bytes_done, bytes_total, current_item,
+		        COALESCE(parent_job_id::text, ''),
+		        created_at, started_at, finished_at`
 
-	if job.StartedAt != "" {
-		t, err := time.Parse(time.RFC3339Nano, job.StartedAt)
-		if err == nil {
-			startedAt = &t
-		}
-	}
-	if job.FinishedAt != "" {
-		t, err := time.Parse(time.RFC3339Nano, job.FinishedAt)
-		if err == nil {
-			finishedAt = &t
-		}
-	}
+// jobClaimLockKey is the advisory lock that serializes claims, so two workers
+// cannot both start a job for an owner who is one below the concurrency cap.
+const jobClaimLockKey = 7316001
 
+func (r *JobRepository) Create(ctx context.Context, job model.JobData, request model.JobOperationRequest) error {
+	startedAt := parseJobTime(job.StartedAt)
+	finishedAt := parseJobTime(job.FinishedAt)
 	createdAt, _ := time.Parse(time.RFC3339Nano, job.CreatedAt)
 
-	_, err := r.pool.Exec(ctx,
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("encode job request: %w", err)
+	}
+
+	_, err = r.pool.Exec(ctx,
 		`INSERT INTO jobs (id, operation, status, conflict_policy,
 		  total_items, processed_items, success_items, failed_items, progress,
-		  created_at, started_at, finished_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		  created_at, started_at, finished_at, request, owner_id, owner_username, owner_role, owner_ip,
+		  lane, parent_job_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, NULLIF($19, '')::uuid)`,
 		job.JobID, job.Operation, job.Status, job.ConflictPolicy,
 		job.TotalItems, job.ProcessedItems, job.SuccessItems, job.FailedItems, job.Progress,
-		createdAt, startedAt, finishedAt)
+		createdAt, startedAt, finishedAt, payload, job.OwnerID, job.OwnerUsername, job.OwnerRole, job.OwnerIP,
+		job.Lane, job.ParentJobID)
 	if err != nil {
 		return fmt.Errorf("create job: %w", err)
 	}
@@ -53,27 +61,17 @@ func (r *JobRepository) Create(ctx context.Context, job model.JobData) error {
 }
 
 func (r *JobRepository) Update(ctx context.Context, job model.JobData) error {
-	var startedAt, finishedAt *time.Time
-
-	if job.StartedAt != "" {
-		t, err := time.Parse(time.RFC3339Nano, job.StartedAt)
-		if err == nil {
-			startedAt = &t
-		}
-	}
-	if job.FinishedAt != "" {
-		t, err := time.Parse(time.RFC3339Nano, job.FinishedAt)
-		if err == nil {
-			finishedAt = &t
-		}
-	}
+	startedAt := parseJobTime(job.StartedAt)
+	finishedAt := parseJobTime(job.FinishedAt)
 
 	_, err := r.pool.Exec(ctx,
-		`UPDATE jobs SET status = $2, processed_items = $3, success_items = $4,
-		  failed_items = $5, progress = $6, started_at = $7, finished_at = $8
+		`UPDATE jobs SET status = $2, total_items = $3, processed_items = $4, success_items = $5,
+		  failed_items = $6, progress = $7, started_at = $8, finished_at = $9,
+		  bytes_done = $10, bytes_total = $11, current_item = $12
 		 WHERE id = $1`,
-		job.JobID, job.Status, job.ProcessedItems, job.SuccessItems,
-		job.FailedItems, job.Progress, startedAt, finishedAt)
+		job.JobID, job.Status, job.TotalItems, job.ProcessedItems, job.SuccessItems,
+		job.FailedItems, job.Progress, startedAt, finishedAt,
+		job.BytesDone, job.BytesTotal, job.CurrentItem)
 	if err != nil {
 		return fmt.Errorf("update job: %w", err)
 	}
@@ -81,38 +79,435 @@ func (r *JobRepository) Update(ctx context.Context, job model.JobData) error {
 }
 
 func (r *JobRepository) FindByID(ctx context.Context, jobID string) (model.JobData, error) {
-	var job model.JobData
-	var createdAt time.Time
-	var startedAt, finishedAt *time.Time
+	job, err := scanJob(r.pool.QueryRow(ctx,
+		`SELECT `+jobColumns+`
+		 FROM jobs WHERE id = $1`, jobID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.JobData{}, model.ErrJobNotFound
+	}
+	if err != nil {
+		return model.JobData{}, fmt.Errorf("find job: %w", err)
+	}
+	return job, nil
+}
+
+// ClaimNext marks the next queued job in one of lanes as running and returns
+// it together with its stored request. Owners take turns: the owner whose
+// last job started longest ago goes first, going by job_owner_starts rather
+// than the owner's job history, and owners already running maxPerUser jobs
+// are passed over (0 disables the cap). model.ErrJobNotFound means nothing is
+// claimable right now. A job whose stored request cannot be
+// decoded is marked failed in the same transaction and reported as an error.
+func (r *JobRepository) ClaimNext(ctx context.Context, lanes []string, maxPerUser int) (model.JobData, model.JobOperationRequest, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("begin claim: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, jobClaimLockKey); err != nil {
+		return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("lock job claim: %w", err)
+	}
+
+	var payload []byte
+	job, err := scanJob(tx.QueryRow(ctx,
+		`UPDATE jobs SET status = 'running', progress = 5, started_at = now(),
+		  heartbeat_at = now(), finished_at = NULL, cancel_requested = false
+		 WHERE id = (
+		   SELECT q.id FROM jobs q
+		   WHERE q.status = 'queued'
+		     AND q.lane = ANY($1)
+		     AND ($2::int <= 0 OR (
+		       SELECT COUNT(*) FROM jobs r
+		       WHERE r.status = 'running' AND r.owner_id = q.owner_id
+		     ) < $2::int)
+		   ORDER BY (
+		       SELECT o.started_at FROM job_owner_starts o WHERE o.owner_id = q.owner_id
+		     ) ASC NULLS FIRST,
+		     q.created_at, q.id
+		   LIMIT 1
+		   FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+jobColumns+`, request`, lanes, maxPerUser), &payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.JobData{}, model.JobOperationRequest{}, model.ErrJobNotFound
+	}
+	if err != nil {
+		return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("claim job: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO job_owner_starts (owner_id, started_at) VALUES ($1, now())
+		 ON CONFLICT (owner_id) DO UPDATE SET started_at = EXCLUDED.started_at`,
+		job.OwnerID); err != nil {
+		return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("record job owner start: %w", err)
+	}
+
+	var request model.JobOperationRequest
+	if decodeErr := json.Unmarshal(payload, &request); decodeErr != nil {
+		// A request that cannot be read never will be, so the job fails
+		// here instead of being left running for the reaper to requeue.
+		reason := "decode job request: " + decodeErr.Error()
+		if _, err := tx.Exec(ctx,
+			`UPDATE jobs SET status = 'failed', progress = 100, processed_items = 1,
+			  failed_items = 1, finished_at = now()
+			 WHERE id = $1`, job.JobID); err != nil {
+			return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("fail undecodable job: %w", err)
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO job_items (job_id, status, reason) VALUES ($1, 'failed', $2)`,
+			job.JobID, reason); err != nil {
+			return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("save undecodable job item: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("commit claim: %w", err)
+		}
+		return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("job %s: %s", job.JobID, reason)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.JobData{}, model.JobOperationRequest{}, fmt.Errorf("commit claim: %w", err)
+	}
+	return job, request, nil
+}
+
+// FindRequest returns the operation request a job was created with.
+func (r *JobRepository) FindRequest(ctx context.Context, jobID string) (model.JobOperationRequest, error) {
+	var payload []byte
+	err := r.pool.QueryRow(ctx, `SELECT request FROM jobs WHERE id = $1`, jobID).Scan(&payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.JobOperationRequest{}, model.ErrJobNotFound
+	}
+	if err != nil {
+		return model.JobOperationRequest{}, fmt.Errorf("find job request: %w", err)
+	}
+
+	var request model.JobOperationRequest
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return model.JobOperationRequest{}, fmt.Errorf("decode job request: %w", err)
+		}
+	}
+	return request, nil
+}
 
+// RetryJobIDs returns the ids of the jobs created as retries of jobID, oldest
+// first.
+func (r *JobRepository) RetryJobIDs(ctx context.Context, jobID string) ([]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id::text FROM jobs WHERE parent_job_id = $1 ORDER BY created_at, id`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("query retry jobs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan retry job: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Heartbeat records that the worker running job is still alive, stores its
+// progress and reports whether a cancel was requested. model.ErrJobNotFound
+// means the job is no longer running, e.g. because another instance marked it
+// interrupted, so the worker no longer owns it.
+func (r *JobRepository) Heartbeat(ctx context.Context, job model.JobData) (bool, error) {
+	var cancelRequested bool
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, operation, status, conflict_policy,
-		        total_items, processed_items, success_items, failed_items, progress,
-		        created_at, started_at, finished_at
-		 FROM jobs WHERE id = $1`, jobID).
-		Scan(&job.JobID, &job.Operation, &job.Status, &job.ConflictPolicy,
-			&job.TotalItems, &job.ProcessedItems, &job.SuccessItems,
-			&job.FailedItems, &job.Progress,
-			&createdAt, &startedAt, &finishedAt)
+		`UPDATE jobs SET heartbeat_at = now(), progress = $2,
+		  bytes_done = $3, bytes_total = $4, current_item = $5
+		 WHERE id = $1 AND status = 'running'
+		 RETURNING cancel_requested`,
+		job.JobID, job.Progress, job.BytesDone, job.BytesTotal, job.CurrentItem).Scan(&cancelRequested)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, model.ErrJobNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("job heartbeat: %w", err)
+	}
+	return cancelRequested, nil
+}
 
+// RequestCancel cancels a queued or interrupted job outright and flags a
+// running one for its worker. model.ErrJobNotFound means no job in one of
+// those states exists.
+func (r *JobRepository) RequestCancel(ctx context.Context, jobID string) (model.JobData, error) {
+	job, err := scanJob(r.pool.QueryRow(ctx,
+		`UPDATE jobs SET
+		  status = CASE WHEN status = 'running' THEN status ELSE 'cancelled' END,
+		  progress = CASE WHEN status = 'running' THEN progress ELSE 100 END,
+		  finished_at = CASE WHEN status = 'running' THEN finished_at ELSE now() END,
+		  cancel_requested = (status = 'running')
+		 WHERE id = $1 AND status IN ('queued', 'running', 'interrupted')
+		 RETURNING `+jobColumns, jobID))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return model.JobData{}, model.ErrJobNotFound
 	}
 	if err != nil {
-		return model.JobData{}, fmt.Errorf("find job: %w", err)
+		return model.JobData{}, fmt.Errorf("cancel job: %w", err)
 	}
+	return job, nil
+}
 
-	job.CreatedAt = createdAt.Format(time.RFC3339Nano)
-	if startedAt != nil {
-		job.StartedAt = startedAt.Format(time.RFC3339Nano)
-	}
-	if finishedAt != nil {
-		job.FinishedAt = finishedAt.Format(time.RFC3339Nano)
+// InterruptStale marks running jobs whose heartbeat is older than staleBefore
+// as interrupted and returns how many were changed.
+func (r *JobRepository) InterruptStale(ctx context.Context, staleBefore time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE jobs SET status = 'interrupted'
+		 WHERE status = 'running' AND (heartbeat_at IS NULL OR heartbeat_at < $1)`, staleBefore)
+	if err != nil {
+		return 0, fmt.Errorf("interrupt stale jobs: %w", err)
 	}
+	return tag.RowsAffected(), nil
+}
 
+// Requeue puts an interrupted job back in the queue. The job starts over from
+// its stored request. model.ErrJobNotFound means the job is not interrupted.
+func (r *JobRepository) Requeue(ctx context.Context, jobID string) (model.JobData, error) {
+	job, err := scanJob(r.pool.QueryRow(ctx,
+		`UPDATE jobs SET status = 'queued', progress = 0, processed_items = 0,
+		  success_items = 0, failed_items = 0, started_at = NULL, finished_at = NULL,
+		  heartbeat_at = NULL, cancel_requested = false,
+		  bytes_done = 0, bytes_total = 0, current_item = ''
+		 WHERE id = $1 AND status = 'interrupted'
+		 RETURNING `+jobColumns, jobID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.JobData{}, model.ErrJobNotFound
+	}
+	if err != nil {
+		return model.JobData{}, fmt.Errorf("requeue job: %w", err)
+	}
 	return job, nil
 }
 
+// RequeueInterrupted puts every interrupted job back in the queue.
+func (r *JobRepository) RequeueInterrupted(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE jobs SET status = 'queued', progress = 0, processed_items = 0,
+		  success_items = 0, failed_items = 0, started_at = NULL, finished_at = NULL,
+		  heartbeat_at = NULL, cancel_requested = false,
+		  bytes_done = 0, bytes_total = 0, current_item = ''
+		 WHERE status = 'interrupted'`)
+	if err != nil {
+		return 0, fmt.Errorf("requeue interrupted jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// finishedJobStatuses are the states a job never leaves on its own.
+var finishedJobStatuses = []string{"completed", "partial", "failed", "cancelled"}
+
+func (r *JobRepository) List(ctx context.Context, query model.JobQuery) ([]model.JobData, model.Meta, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit <= 0 {
+		query.Limit = 50
+	}
+	if query.Limit > 200 {
+		query.Limit = 200
+	}
+
+	where := make([]string, 0)
+	args := make([]any, 0)
+	argIdx := 1
+
+	if statuses := splitFilter(query.Status); len(statuses) > 0 {
+		where = append(where, fmt.Sprintf("status = ANY($%d)", argIdx))
+		args = append(args, statuses)
+		argIdx++
+	}
+	if operations := splitFilter(query.Operation); len(operations) > 0 {
+		where = append(where, fmt.Sprintf("operation = ANY($%d)", argIdx))
+		args = append(args, operations)
+		argIdx++
+	}
+	if ownerID := strings.TrimSpace(query.OwnerID); ownerID != "" {
+		where = append(where, fmt.Sprintf("owner_id = $%d", argIdx))
+		args = append(args, ownerID)
+		argIdx++
+	}
+	if owner := strings.TrimSpace(query.Owner); owner != "" {
+		where = append(where, fmt.Sprintf("(owner_id = $%d OR lower(owner_username) = lower($%d))", argIdx, argIdx))
+		args = append(args, owner)
+		argIdx++
+	}
+	if from := strings.TrimSpace(query.From); from != "" {
+		where = append(where, fmt.Sprintf("created_at >= $%d::timestamptz", argIdx))
+		args = append(args, from)
+		argIdx++
+	}
+	if to := strings.TrimSpace(query.To); to != "" {
+		where = append(where, fmt.Sprintf("created_at <= $%d::timestamptz", argIdx))
+		args = append(args, to)
+		argIdx++
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM jobs "+whereClause, args...).Scan(&total); err != nil {
+		return nil, model.Meta{}, fmt.Errorf("count jobs: %w", err)
+	}
+
+	totalPages := 0
+	if total > 0 {
+		totalPages = (total + query.Limit - 1) / query.Limit
+	}
+	meta := model.Meta{Page: query.Page, Limit: query.Limit, Total: total, TotalPages: totalPages}
+
+	offset := (query.Page - 1) * query.Limit
+	dataQuery := fmt.Sprintf(
+		`SELECT `+jobColumns+`
+		 FROM jobs %s
+		 ORDER BY created_at DESC, id
+		 LIMIT $%d OFFSET $%d`, whereClause, argIdx, argIdx+1)
+	args = append(args, query.Limit, offset)
+
+	rows, err := r.pool.Query(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, model.Meta{}, fmt.Errorf("query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]model.JobData, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, model.Meta{}, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, meta, rows.Err()
+}
+
+// DeleteFinished deletes finished jobs (and their items) matching ids and/or
+// finishedBefore, limited to ownerID when it is not empty. It returns the ids
+// that were deleted.
+func (r *JobRepository) DeleteFinished(ctx context.Context, ids []string, finishedBefore *time.Time, ownerID string) ([]string, error) {
+	where := []string{"status = ANY($1)"}
+	args := []any{finishedJobStatuses}
+	argIdx := 2
+
+	if len(ids) > 0 {
+		where = append(where, fmt.Sprintf("id::text = ANY($%d)", argIdx))
+		args = append(args, ids)
+		argIdx++
+	}
+	if finishedBefore != nil {
+		where = append(where, fmt.Sprintf("finished_at < $%d", argIdx))
+		args = append(args, *finishedBefore)
+		argIdx++
+	}
+	if ownerID != "" {
+		where = append(where, fmt.Sprintf("owner_id = $%d", argIdx))
+		args = append(args, ownerID)
+	}
+
+	rows, err := r.pool.Query(ctx, "DELETE FROM jobs WHERE "+strings.Join(where, " AND ")+" RETURNING id::text", args...)
+	if err != nil {
+		return nil, fmt.Errorf("delete jobs: %w", err)
+	}
+	defer rows.Close()
+
+	deleted := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan deleted job: %w", err)
+		}
+		deleted = append(deleted, id)
+	}
+	return deleted, rows.Err()
+}
+
+// PurgeFinished deletes finished jobs that finished before cutoff. Their
+// items go with them through ON DELETE CASCADE.
+func (r *JobRepository) PurgeFinished(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM jobs WHERE status = ANY($1) AND finished_at < $2`,
+		finishedJobStatuses, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("purge finished jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// QueuePosition returns the 1-based position of a queued job among the queued
+// jobs of its lane, by creation order, and the number of jobs queued there.
+func (r *JobRepository) QueuePosition(ctx context.Context, jobID string) (int, int, error) {
+	var position, depth int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FILTER (WHERE (q.created_at, q.id) <= (j.created_at, j.id)),
+		        COUNT(*)
+		 FROM jobs j
+		 JOIN jobs q ON q.lane = j.lane AND q.status = 'queued'
+		 WHERE j.id = $1`, jobID).Scan(&position, &depth)
+	if err != nil {
+		return 0, 0, fmt.Errorf("job queue position: %w", err)
+	}
+	return position, depth, nil
+}
+
+// QueueStats counts queued and running jobs per lane and per owner.
+func (r *JobRepository) QueueStats(ctx context.Context) ([]model.JobLaneStats, []model.JobOwnerStats, error) {
+	laneRows, err := r.pool.Query(ctx,
+		`SELECT lane,
+		        COUNT(*) FILTER (WHERE status = 'queued'),
+		        COUNT(*) FILTER (WHERE status = 'running')
+		 FROM jobs
+		 WHERE status IN ('queued', 'running')
+		 GROUP BY lane
+		 ORDER BY lane`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query lane stats: %w", err)
+	}
+	defer laneRows.Close()
+
+	lanes := make([]model.JobLaneStats, 0)
+	for laneRows.Next() {
+		var lane model.JobLaneStats
+		if err := laneRows.Scan(&lane.Lane, &lane.Queued, &lane.Running); err != nil {
+			return nil, nil, fmt.Errorf("scan lane stats: %w", err)
+		}
+		lanes = append(lanes, lane)
+	}
+	if err := laneRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	ownerRows, err := r.pool.Query(ctx,
+		`SELECT owner_id, MAX(owner_username),
+		        COUNT(*) FILTER (WHERE status = 'queued'),
+		        COUNT(*) FILTER (WHERE status = 'running')
+		 FROM jobs
+		 WHERE status IN ('queued', 'running')
+		 GROUP BY owner_id
+		 ORDER BY owner_id`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query owner stats: %w", err)
+	}
+	defer ownerRows.Close()
+
+	owners := make([]model.JobOwnerStats, 0)
+	for ownerRows.Next() {
+		var owner model.JobOwnerStats
+		if err := ownerRows.Scan(&owner.OwnerID, &owner.OwnerUsername, &owner.Queued, &owner.Running); err != nil {
+			return nil, nil, fmt.Errorf("scan owner stats: %w", err)
+		}
+		owners = append(owners, owner)
+	}
+	return lanes, owners, ownerRows.Err()
+}
+
 func (r *JobRepository) SaveItems(ctx context.Context, jobID string, items []model.JobItemResult) error {
 	if len(items) == 0 {
 		return nil
@@ -138,6 +533,50 @@ func (r *JobRepository) SaveItems(ctx context.Context, jobID string, items []mod
 	return nil
 }
 
+// SuccessfulItems returns every item of a job that succeeded.
+func (r *JobRepository) SuccessfulItems(ctx context.Context, jobID string) ([]model.JobItemResult, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT source, dest, path, status, reason
+		 FROM job_items WHERE job_id = $1 AND status = 'success'
+		 ORDER BY id`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("query successful job items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]model.JobItemResult, 0)
+	for rows.Next() {
+		var item model.JobItemResult
+		if err := rows.Scan(&item.From, &item.To, &item.Path, &item.Status, &item.Reason); err != nil {
+			return nil, fmt.Errorf("scan job item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// UnsuccessfulItems returns every item of a job that did not succeed.
+func (r *JobRepository) UnsuccessfulItems(ctx context.Context, jobID string) ([]model.JobItemResult, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT source, dest, path, status, reason
+		 FROM job_items WHERE job_id = $1 AND status <> 'success'
+		 ORDER BY id`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("query unsuccessful job items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]model.JobItemResult, 0)
+	for rows.Next() {
+		var item model.JobItemResult
+		if err := rows.Scan(&item.From, &item.To, &item.Path, &item.Status, &item.Reason); err != nil {
+			return nil, fmt.Errorf("scan job item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
 func (r *JobRepository) GetItems(ctx context.Context, jobID string, page int, limit int) ([]model.JobItemResult, model.Meta, error) {
 	if page < 1 {
 		page = 1
@@ -184,3 +623,50 @@ func (r *JobRepository) GetItems(ctx context.Context, jobID string, page int, li
 	meta := model.Meta{Page: page, Limit: limit, Total: total, TotalPages: totalPages}
 	return items, meta, rows.Err()
 }
+
+func scanJob(row pgx.Row, extra ...any) (model.JobData, error) {
+	var job model.JobData
+	var createdAt time.Time
+	var startedAt, finishedAt *time.Time
+
+	dest := []any{&job.JobID, &job.Operation, &job.Status, &job.ConflictPolicy,
+		&job.TotalItems, &job.ProcessedItems, &job.SuccessItems,
+		&job.FailedItems, &job.Progress,
+		&job.CancelRequested, &job.OwnerID, &job.OwnerUsername, &job.OwnerRole, &job.OwnerIP, &job.Lane,
+		&job.BytesDone, &job.BytesTotal, &job.CurrentItem,
+		&job.ParentJobID,
+		&createdAt, &startedAt, &finishedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return model.JobData{}, err
+	}
+
+	job.CreatedAt = createdAt.Format(time.RFC3339Nano)
+	if startedAt != nil {
+		job.StartedAt = startedAt.Format(time.RFC3339Nano)
+	}
+	if finishedAt != nil {
+		job.FinishedAt = finishedAt.Format(time.RFC3339Nano)
+	}
+	return job, nil
+}
+
+func splitFilter(raw string) []string {
+	values := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		if trimmed := strings.ToLower(strings.TrimSpace(part)); trimmed != "" {
+			values = append(values, trimmed)
+		}
+	}
+	return values
+}
+
+func parseJobTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-file-explorer/internal/model"
+)
+
+// MetadataRepository stores custom metadata fields and their values by API
+// path. Live values have an empty trash_id; values of trashed entries keep
+// their original paths under the trash record ID.
+type MetadataRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMetadataRepository(pool *pgxpool.Pool) *MetadataRepository {
+	return &MetadataRepository{pool: pool}
+}
+
+// metadataFieldColumns is the column list read by scanMetadataField.
+const metadataFieldColumns = `name, type, options, description, created_by, created_at, updated_at`
+
+// CreateField stores a new field and reports false when the name is taken.
+func (r *MetadataRepository) CreateField(ctx context.Context, field model.MetadataField) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`INSERT INTO metadata_fields (name, type, options, description, created_by)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (name) DO NOTHING`,
+		field.Name, field.Type, field.Options, field.Description, field.CreatedBy)
+	if err != nil {
+		return false, fmt.Errorf("create metadata field: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpdateField saves the options and description of a field.
+func (r *MetadataRepository) UpdateField(ctx context.Context, field model.MetadataField) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE metadata_fields SET options = $2, description = $3, updated_at = now() WHERE name = $1`,
+		field.Name, field.Options, field.Description)
+	if err != nil {
+		return fmt.Errorf("update metadata field: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrMetadataFieldNotFound
+	}
+	return nil
+}
+
+// DeleteField removes a field and every value of it.
+func (r *MetadataRepository) DeleteField(ctx context.Context, name string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM metadata_fields WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("delete metadata field: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrMetadataFieldNotFound
+	}
+	return nil
+}
+
+func (r *MetadataRepository) FindField(ctx context.Context, name string) (model.MetadataField, error) {
+	field, err := scanMetadataField(r.pool.QueryRow(ctx,
+		`SELECT `+metadataFieldColumns+` FROM metadata_fields WHERE name = $1`, name))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.MetadataField{}, model.ErrMetadataFieldNotFound
+	}
+	if err != nil {
+		return model.MetadataField{}, fmt.Errorf("query metadata field: %w", err)
+	}
+	return field, nil
+}
+
+// Fields returns every field by name.
+func (r *MetadataRepository) Fields(ctx context.Context) ([]model.MetadataField, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+metadataFieldColumns+` FROM metadata_fields ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("query metadata fields: %w", err)
+	}
+	defer rows.Close()
+
+	fields := make([]model.MetadataField, 0)
+	for rows.Next() {
+		field, err := scanMetadataField(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan metadata field: %w", err)
+		}
+		fields = append(fields, field)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query metadata fields: %w", err)
+	}
+	return fields, nil
+}
+
+// Values returns the values of path with the types of their fields.
+func (r *MetadataRepository) Values(ctx context.Context, path string) ([]model.MetadataValue, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT fm.field, mf.type, fm.value_text, fm.value_number
+		 FROM file_metadata fm JOIN metadata_fields mf ON mf.name = fm.field
+		 WHERE fm.trash_id = '' AND fm.path = $1
+		 ORDER BY fm.field`, path)
+	if err != nil {
+		return nil, fmt.Errorf("query file metadata: %w", err)
+	}
+	defer rows.Close()
+
+	values := make([]model.MetadataValue, 0)
+	for rows.Next() {
+		var value model.MetadataValue
+		if err := rows.Scan(&value.Field, &value.Type, &value.Text, &value.Number); err != nil {
+			return nil, fmt.Errorf("scan file metadata: %w", err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query file metadata: %w", err)
+	}
+	return values, nil
+}
+
+// Apply sets and removes values of path in one transaction.
+func (r *MetadataRepository) Apply(ctx context.Context, path string, set []model.MetadataValue, remove []string, updatedBy string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin update file metadata: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, value := range set {
+		var date *string
+		if value.Type == "date" {
+			date = &value.Text
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO file_metadata (path, field, value_text, value_number, value_date, updated_by)
+			 VALUES ($1, $2, $3, $4, $5::date, $6)
+			 ON CONFLICT (trash_id, path, field) DO UPDATE
+			 SET value_text = EXCLUDED.value_text, value_number = EXCLUDED.value_number,
+			     value_date = EXCLUDED.value_date, updated_by = EXCLUDED.updated_by, updated_at = now()`,
+			path, value.Field, value.Text, value.Number, date, updatedBy); err != nil {
+			return fmt.Errorf("set file metadata: %w", err)
+		}
+	}
+	if len(remove) > 0 {
+		if _, err := tx.Exec(ctx,
+			`DELETE FROM file_metadata WHERE trash_id = '' AND path = $1 AND field = ANY($2)`,
+			path, remove); err != nil {
+			return fmt.Errorf("remove file metadata: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit update file metadata: %w", err)
+	}
+	return nil
+}
+
+// MoveTree rewrites the paths of the values of from and everything below it
+// to to. Values left at to by entries that no longer exist are dropped.
+func (r *MetadataRepository) MoveTree(ctx context.Context, from string, to string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin move file metadata: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := clearMetadataTree(ctx, tx, to); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE file_metadata SET path = $2 || substr(path, length($1) + 1)
+		 WHERE trash_id = '' AND (path = $1 OR path LIKE $3)`,
+		from, to, escapeLike(from)+"/%"); err != nil {
+		return fmt.Errorf("move file metadata: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit move file metadata: %w", err)
+	}
+	return nil
+}
+
+// CopyTree gives to and everything below it the values of the matching
+// entries below from, replacing the values they had.
+func (r *MetadataRepository) CopyTree(ctx context.Context, from string, to string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin copy file metadata: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := clearMetadataTree(ctx, tx, to); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO file_metadata (path, field, value_text, value_number, value_date, updated_by, updated_at)
+		 SELECT $2 || substr(path, length($1) + 1), field, value_text, value_number, value_date, updated_by, updated_at
+		 FROM file_metadata
+		 WHERE trash_id = '' AND (path = $1 OR path LIKE $3)`,
+		from, to, escapeLike(from)+"/%"); err != nil {
+		return fmt.Errorf("copy file metadata: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit copy file metadata: %w", err)
+	}
+	return nil
+}
+
+func clearMetadataTree(ctx context.Context, tx pgx.Tx, path string) error {
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM file_metadata WHERE trash_id = '' AND (path = $1 OR path LIKE $2)`,
+		path, escapeLike(path)+"/%"); err != nil {
+		return fmt.Errorf("clear file metadata: %w", err)
+	}
+	return nil
+}
+
+// Trash parks the values of path and everything below it under trashID.
+func (r *MetadataRepository) Trash(ctx context.Context, path string, trashID string) error {
+	if _, err := r.pool.Exec(ctx,
+		`UPDATE file_metadata SET trash_id = $3 WHERE trash_id = '' AND (path = $1 OR path LIKE $2)`,
+		path, escapeLike(path)+"/%", trashID); err != nil {
+		return fmt.Errorf("trash file metadata: %w", err)
+	}
+	return nil
+}
+
+// Restore brings back the values parked under trashID, replacing values left
+// at the same paths.
+func (r *MetadataRepository) Restore(ctx context.Context, trashID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin restore file metadata: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM file_metadata live USING file_metadata trashed
+		 WHERE live.trash_id = '' AND trashed.trash_id = $1
+		   AND live.path = trashed.path AND live.field = trashed.field`,
+		trashID); err != nil {
+		return fmt.Errorf("clear restored file metadata: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE file_metadata SET trash_id = '' WHERE trash_id = $1`, trashID); err != nil {
+		return fmt.Errorf("restore file metadata: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit restore file metadata: %w", err)
+	}
+	return nil
+}
+
+// Purge removes the values parked under trashID.
+func (r *MetadataRepository) Purge(ctx context.Context, trashID string) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM file_metadata WHERE trash_id = $1`, trashID); err != nil {
+		return fmt.Errorf("purge file metadata: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrashed removes the values of every trashed entry.
+func (r *MetadataRepository) PurgeTrashed(ctx context.Context) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM file_metadata WHERE trash_id <> ''`); err != nil {
+		return fmt.Errorf("purge trashed file metadata: %w", err)
+	}
+	return nil
+}
+
+func scanMetadataField(row pgx.Row) (model.MetadataField, error) {
+	var field model.MetadataField
+	var createdAt, updatedAt time.Time
+	if err := row.Scan(&field.Name, &field.Type, &field.Options, &field.Description,
+		&field.CreatedBy, &createdAt, &updatedAt); err != nil {
+		return model.MetadataField{}, err
+	}
+	field.CreatedAt = createdAt.Format(time.RFC3339Nano)
+	field.UpdatedAt = updatedAt.Format(time.RFC3339Nano)
+	return field, nil
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-file-explorer/internal/model"
+)
+
+type PipelineRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPipelineRepository(pool *pgxpool.Pool) *PipelineRepository {
+	return &PipelineRepository{pool: pool}
+}
+
+// Create stores a pipeline together with its steps.
+func (r *PipelineRepository) Create(ctx context.Context, pipeline model.PipelineData) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin create pipeline: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO pipelines (id, name, status, current_step, owner_id, owner_username, owner_role, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)`,
+		pipeline.PipelineID, pipeline.Name, pipeline.Status, pipeline.CurrentStep,
+		pipeline.OwnerID, pipeline.OwnerUsername, pipeline.OwnerRole, parseJobTime(pipeline.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("create pipeline: %w", err)
+	}
+
+	for _, step := range pipeline.Steps {
+		payload, err := json.Marshal(step.Request)
+		if err != nil {
+			return fmt.Errorf("encode pipeline step request: %w", err)
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO pipeline_steps (pipeline_id, step_index, step_key, request, on_failure, status)
+			 VALUES ($1, $2, $3, $4, $5, $6)`,
+			pipeline.PipelineID, step.Index, step.ID, payload, step.OnFailure, step.Status); err != nil {
+			return fmt.Errorf("create pipeline step: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit create pipeline: %w", err)
+	}
+	return nil
+}
+
+// FindByID returns a pipeline with its steps and the current status of each
+// step's job.
+func (r *PipelineRepository) FindByID(ctx context.Context, id string) (model.PipelineData, error) {
+	var pipeline model.PipelineData
+	var createdAt, updatedAt time.Time
+	var finishedAt *time.Time
+
+	err := r.pool.QueryRow(ctx,
+		`SELECT id::text, name, status, current_step, owner_id, owner_username, owner_role,
+		        created_at, updated_at, finished_at
+		 FROM pipelines WHERE id::text = $1`, id).Scan(
+		&pipeline.PipelineID, &pipeline.Name, &pipeline.Status, &pipeline.CurrentStep,
+		&pipeline.OwnerID, &pipeline.OwnerUsername, &pipeline.OwnerRole,
+		&createdAt, &updatedAt, &finishedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.PipelineData{}, model.ErrPipelineNotFound
+	}
+	if err != nil {
+		return model.PipelineData{}, fmt.Errorf("find pipeline: %w", err)
+	}
+	pipeline.CreatedAt = createdAt.Format(time.RFC3339Nano)
+	pipeline.UpdatedAt = updatedAt.Format(time.RFC3339Nano)
+	if finishedAt != nil {
+		pipeline.FinishedAt = finishedAt.Format(time.RFC3339Nano)
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT ps.step_index, ps.step_key, ps.on_failure, ps.request,
+		        COALESCE(ps.job_id::text, ''), COALESCE(j.status, ''),
+		        ps.status, ps.error, ps.started_at, ps.finished_at
+		 FROM pipeline_steps ps
+		 LEFT JOIN jobs j ON j.id = ps.job_id
+		 WHERE ps.pipeline_id = $1
+		 ORDER BY ps.step_index`, pipeline.PipelineID)
+	if err != nil {
+		return model.PipelineData{}, fmt.Errorf("query pipeline steps: %w", err)
+	}
+	defer rows.Close()
+
+	pipeline.Steps = make([]model.PipelineStep, 0)
+	for rows.Next() {
+		var step model.PipelineStep
+		var payload []byte
+		var startedAt, stepFinishedAt *time.Time
+		if err := rows.Scan(&step.Index, &step.ID, &step.OnFailure, &payload,
+			&step.JobID, &step.JobStatus, &step.Status, &step.Error, &startedAt, &stepFinishedAt); err != nil {
+			return model.PipelineData{}, fmt.Errorf("scan pipeline step: %w", err)
+		}
+		if err := json.Unmarshal(payload, &step.Request); err != nil {
+			return model.PipelineData{}, fmt.Errorf("decode pipeline step request: %w", err)
+		}
+		if startedAt != nil {
+			step.StartedAt = startedAt.Format(time.RFC3339Nano)
+		}
+		if stepFinishedAt != nil {
+			step.FinishedAt = stepFinishedAt.Format(time.RFC3339Nano)
+		}
+		pipeline.Steps = append(pipeline.Steps, step)
+	}
+	return pipeline, rows.Err()
+}
+
+// Running returns the IDs of running pipelines, least recently updated first.
+func (r *PipelineRepository) Running(ctx context.Context, limit int) ([]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id::text FROM pipelines WHERE status = 'running'
+		 ORDER BY updated_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query running pipelines: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan pipeline id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PipelineForJob returns the ID of the pipeline a job was started by, or ""
+// when the job is not part of a pipeline.
+func (r *PipelineRepository) PipelineForJob(ctx context.Context, jobID string) (string, error) {
+	var id string
+	err := r.pool.QueryRow(ctx,
+		`SELECT pipeline_id::text FROM pipeline_steps WHERE job_id::text = $1`, jobID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("find pipeline for job: %w", err)
+	}
+	return id, nil
+}
+
+// ClaimStep marks a pending step as running. It only succeeds for one
+// caller, so a step starts a single job.
+func (r *PipelineRepository) ClaimStep(ctx context.Context, id string, index int) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE pipeline_steps SET status = 'running', started_at = now()
+		 WHERE pipeline_id = $1 AND step_index = $2 AND status = 'pending'`, id, index)
+	if err != nil {
+		return false, fmt.Errorf("claim pipeline step: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PipelineRepository) SetStepJob(ctx context.Context, id string, index int, jobID string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE pipeline_steps SET job_id = $3 WHERE pipeline_id = $1 AND step_index = $2`, id, index, jobID)
+	if err != nil {
+		return fmt.Errorf("set pipeline step job: %w", err)
+	}
+	return nil
+}
+
+// FinishStep records the outcome of a running step. It only succeeds for one
+// caller.
+func (r *PipelineRepository) FinishStep(ctx context.Context, id string, index int, status string, reason string) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE pipeline_steps SET status = $3, error = $4, finished_at = now()
+		 WHERE pipeline_id = $1 AND step_index = $2 AND status = 'running'`, id, index, status, reason)
+	if err != nil {
+		return false, fmt.Errorf("finish pipeline step: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// MoveTo advances a running pipeline from step from to step to. It only
+// succeeds for the caller that still sees step from.
+func (r *PipelineRepository) MoveTo(ctx context.Context, id string, from int, to int) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE pipelines SET current_step = $3, updated_at = now()
+		 WHERE id = $1 AND current_step = $2 AND status = 'running'`, id, from, to)
+	if err != nil {
+		return false, fmt.Errorf("advance pipeline: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Finish sets the final status of a running pipeline and marks the steps
+// that never started as skipped.
+func (r *PipelineRepository) Finish(ctx context.Context, id string, status string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin finish pipeline: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE pipelines SET status = $2, updated_at = now(), finished_at = now()
+		 WHERE id = $1 AND status = 'running'`, id, status)
+	if err != nil {
+		return fmt.Errorf("finish pipeline: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE pipeline_steps SET status = 'skipped', finished_at = now()
+		 WHERE pipeline_id = $1 AND status = 'pending'`, id); err != nil {
+		return fmt.Errorf("skip pipeline steps: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit finish pipeline: %w", err)
+	}
+	return nil
+}
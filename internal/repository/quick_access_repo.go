@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-file-explorer/internal/model"
+)
+
+// QuickAccessRepository stores the favorites and recent files of each user
+// by API path. Favorites of live entries have an empty trash_id; favorites of
+// trashed entries keep their original paths under the trash record ID.
+type QuickAccessRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewQuickAccessRepository(pool *pgxpool.Pool) *QuickAccessRepository {
+	return &QuickAccessRepository{pool: pool}
+}
+
+// Favorites returns the favorite paths of userID in their order.
+func (r *QuickAccessRepository) Favorites(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT path FROM favorites WHERE trash_id = '' AND user_id = $1 ORDER BY position, path`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query favorites: %w", err)
+	}
+	defer rows.Close()
+
+	paths := make([]string, 0)
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("scan favorite: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query favorites: %w", err)
+	}
+	return paths, nil
+}
+
+// AddFavorite appends path to the favorites of userID unless it is already
+// there.
+func (r *QuickAccessRepository) AddFavorite(ctx context.Context, userID string, path string) error {
+	if _, err := r.pool.Exec(ctx,
+		`INSERT INTO favorites (user_id, path, position)
+		 SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM favorites WHERE trash_id = '' AND user_id = $1
+		 ON CONFLICT DO NOTHING`,
+		userID, path); err != nil {
+		return fmt.Errorf("add favorite: %w", err)
+	}
+	return nil
+}
+
+func (r *QuickAccessRepository) RemoveFavorite(ctx context.Context, userID string, path string) error {
+	if _, err := r.pool.Exec(ctx,
+		`DELETE FROM favorites WHERE trash_id = '' AND user_id = $1 AND path = $2`, userID, path); err != nil {
+		return fmt.Errorf("remove favorite: %w", err)
+	}
+	return nil
+}
+
+// ReorderFavorites gives the favorites of userID the order of paths.
+func (r *QuickAccessRepository) ReorderFavorites(ctx context.Context, userID string, paths []string) error {
+	if _, err := r.pool.Exec(ctx,
+		`UPDATE favorites f SET position = o.position
+		 FROM unnest($2::text[]) WITH ORDINALITY AS o(path, position)
+		 WHERE f.trash_id = '' AND f.user_id = $1 AND f.path = o.path`,
+		userID, paths); err != nil {
+		return fmt.Errorf("reorder favorites: %w", err)
+	}
+	return nil
+}
+
+// Touch makes path the most recent file of userID and keeps only the keep
+// most recent ones.
+func (r *QuickAccessRepository) Touch(ctx context.Context, userID string, path string, action string, keep int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin touch recent file: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO recent_files (user_id, path, action) VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, path) DO UPDATE SET action = EXCLUDED.action, accessed_at = now()`,
+		userID, path, action); err != nil {
+		return fmt.Errorf("touch recent file: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM recent_files WHERE user_id = $1 AND path IN (
+		   SELECT path FROM recent_files WHERE user_id = $1
+		   ORDER BY accessed_at DESC, path OFFSET $2)`,
+		userID, keep); err != nil {
+		return fmt.Errorf("trim recent files: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit touch recent file: %w", err)
+	}
+	return nil
+}
+
+// Recent returns up to limit recent files of userID, most recent first.
+func (r *QuickAccessRepository) Recent(ctx context.Context, userID string, limit int) ([]model.RecentEntry, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT path, action, accessed_at FROM recent_files WHERE user_id = $1
+		 ORDER BY accessed_at DESC, path LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query recent files: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]model.RecentEntry, 0)
+	for rows.Next() {
+		var entry model.RecentEntry
+		var accessedAt time.Time
+		if err := rows.Scan(&entry.Path, &entry.Action, &accessedAt); err != nil {
+			return nil, fmt.Errorf("scan recent file: %w", err)
+		}
+		entry.AccessedAt = accessedAt.Format(time.RFC3339Nano)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query recent files: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *QuickAccessRepository) RemoveRecent(ctx context.Context, userID string, path string) error {
+	if _, err := r.pool.Exec(ctx,
+		`DELETE FROM recent_files WHERE user_id = $1 AND path = $2`, userID, path); err != nil {
+		return fmt.Errorf("remove recent file: %w", err)
+	}
+	return nil
+}
+
+// MoveTree rewrites the favorites and recent files of every user at from and
+// below it to to. Entries left at to by files that no longer exist are
+// dropped.
+func (r *QuickAccessRepository) MoveTree(ctx context.Context, from string, to string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin move quick access: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Favorites parked with trash records keep their original paths.
+	for _, target := range []struct{ table, live string }{
+		{"favorites", "trash_id = '' AND "},
+		{"recent_files", ""},
+	} {
+		table, live := target.table, target.live
+		if _, err := tx.Exec(ctx,
+			`DELETE FROM `+table+` WHERE `+live+`(path = $1 OR path LIKE $2)`,
+			to, escapeLike(to)+"/%"); err != nil {
+			return fmt.Errorf("clear %s move target: %w", table, err)
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE `+table+` SET path = $2 || substr(path, length($1) + 1)
+			 WHERE `+live+`(path = $1 OR path LIKE $3)`,
+			from, to, escapeLike(from)+"/%"); err != nil {
+			return fmt.Errorf("move %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit move quick access: %w", err)
+	}
+	return nil
+}
+
+// Trash parks the favorites of every user at path and below it under
+// trashID and removes the recent files there.
+func (r *QuickAccessRepository) Trash(ctx context.Context, path string, trashID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin trash quick access: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE favorites SET trash_id = $3 WHERE trash_id = '' AND (path = $1 OR path LIKE $2)`,
+		path, escapeLike(path)+"/%", trashID); err != nil {
+		return fmt.Errorf("trash favorites: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM recent_files WHERE path = $1 OR path LIKE $2`,
+		path, escapeLike(path)+"/%"); err != nil {
+		return fmt.Errorf("delete recent files: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit trash quick access: %w", err)
+	}
+	return nil
+}
+
+// RestoreFavorites brings back the favorites parked under trashID, replacing
+// favorites left at the same paths.
+func (r *QuickAccessRepository) RestoreFavorites(ctx context.Context, trashID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin restore favorites: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM favorites live USING favorites trashed
+		 WHERE live.trash_id = '' AND trashed.trash_id = $1
+		   AND live.user_id = trashed.user_id AND live.path = trashed.path`,
+		trashID); err != nil {
+		return fmt.Errorf("clear restored favorites: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE favorites SET trash_id = '' WHERE trash_id = $1`, trashID); err != nil {
+		return fmt.Errorf("restore favorites: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit restore favorites: %w", err)
+	}
+	return nil
+}
+
+// PurgeFavorites removes the favorites parked under trashID.
+func (r *QuickAccessRepository) PurgeFavorites(ctx context.Context, trashID string) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM favorites WHERE trash_id = $1`, trashID); err != nil {
+		return fmt.Errorf("purge favorites: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrashedFavorites removes the favorites of every trashed entry.
+func (r *QuickAccessRepository) PurgeTrashedFavorites(ctx context.Context) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM favorites WHERE trash_id <> ''`); err != nil {
+		return fmt.Errorf("purge trashed favorites: %w", err)
+	}
+	return nil
+}
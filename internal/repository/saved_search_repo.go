@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-file-explorer/internal/model"
+)
+
+type SavedSearchRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSavedSearchRepository(pool *pgxpool.Pool) *SavedSearchRepository {
+	return &SavedSearchRepository{pool: pool}
+}
+
+// savedSearchColumns is the column list read by scanSavedSearch.
+const savedSearchColumns = `id, name, query, shared, owner_id, owner_username, created_at, updated_at`
+
+// savedSearchVisible matches the searches a user can see: their own and the
+// shared ones. An empty user matches every search.
+const savedSearchVisible = `($1 = '' OR owner_id = $1 OR shared)`
+
+func (r *SavedSearchRepository) Create(ctx context.Context, search model.SavedSearch) error {
+	payload, err := json.Marshal(search.Query)
+	if err != nil {
+		return fmt.Errorf("encode saved search query: %w", err)
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO saved_searches (id, name, query, shared, owner_id, owner_username, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $7)`,
+		search.ID, search.Name, payload, search.Shared, search.OwnerID, search.OwnerUsername,
+		parseJobTime(search.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("create saved search: %w", err)
+	}
+	return nil
+}
+
+// Update saves the name, query and sharing of a saved search.
+func (r *SavedSearchRepository) Update(ctx context.Context, search model.SavedSearch) error {
+	payload, err := json.Marshal(search.Query)
+	if err != nil {
+		return fmt.Errorf("encode saved search query: %w", err)
+	}
+
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE saved_searches SET name = $2, query = $3, shared = $4, updated_at = now()
+		 WHERE id = $1`,
+		search.ID, search.Name, payload, search.Shared)
+	if err != nil {
+		return fmt.Errorf("update saved search: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+func (r *SavedSearchRepository) FindByID(ctx context.Context, id string) (model.SavedSearch, error) {
+	search, err := scanSavedSearch(r.pool.QueryRow(ctx,
+		`SELECT `+savedSearchColumns+` FROM saved_searches WHERE id::text = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.SavedSearch{}, model.ErrSavedSearchNotFound
+	}
+	if err != nil {
+		return model.SavedSearch{}, fmt.Errorf("find saved search: %w", err)
+	}
+	return search, nil
+}
+
+// NameTaken reports whether ownerID already has a search called name,
+// ignoring case and the search exceptID.
+func (r *SavedSearchRepository) NameTaken(ctx context.Context, ownerID string, name string, exceptID string) (bool, error) {
+	var taken bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM saved_searches
+			WHERE owner_id = $1 AND lower(name) = lower($2) AND id::text <> $3
+		)`, ownerID, name, exceptID).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("check saved search name: %w", err)
+	}
+	return taken, nil
+}
+
+// List returns the searches visible to userID by name.
+func (r *SavedSearchRepository) List(ctx context.Context, userID string, page int, limit int) ([]model.SavedSearch, model.Meta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM saved_searches WHERE `+savedSearchVisible, userID).Scan(&total); err != nil {
+		return nil, model.Meta{}, fmt.Errorf("count saved searches: %w", err)
+	}
+
+	totalPages := 0
+	if total > 0 {
+		totalPages = (total + limit - 1) / limit
+	}
+	meta := model.Meta{Page: page, Limit: limit, Total: total, TotalPages: totalPages}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+savedSearchColumns+`
+		 FROM saved_searches WHERE `+savedSearchVisible+`
+		 ORDER BY lower(name), created_at
+		 LIMIT $2 OFFSET $3`, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, model.Meta{}, fmt.Errorf("query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches, err := collectSavedSearches(rows)
+	if err != nil {
+		return nil, model.Meta{}, err
+	}
+	return searches, meta, nil
+}
+
+// Visible returns every search visible to userID by name, or every search
+// when userID is empty.
+func (r *SavedSearchRepository) Visible(ctx context.Context, userID string) ([]model.SavedSearch, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+savedSearchColumns+`
+		 FROM saved_searches WHERE `+savedSearchVisible+`
+		 ORDER BY lower(name), created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	return collectSavedSearches(rows)
+}
+
+func (r *SavedSearchRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM saved_searches WHERE id::text = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete saved search: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+func collectSavedSearches(rows pgx.Rows) ([]model.SavedSearch, error) {
+	searches := make([]model.SavedSearch, 0)
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan saved search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+	return searches, rows.Err()
+}
+
+func scanSavedSearch(row pgx.Row) (model.SavedSearch, error) {
+	var search model.SavedSearch
+	var payload []byte
+	var createdAt, updatedAt time.Time
+
+	if err := row.Scan(&search.ID, &search.Name, &payload, &search.Shared,
+		&search.OwnerID, &search.OwnerUsername, &createdAt, &updatedAt); err != nil {
+		return model.SavedSearch{}, err
+	}
+
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &search.Query); err != nil {
+			return model.SavedSearch{}, fmt.Errorf("decode saved search query: %w", err)
+		}
+	}
+	search.CreatedAt = createdAt.Format(time.RFC3339Nano)
+	search.UpdatedAt = updatedAt.Format(time.RFC3339Nano)
+	return search, nil
+}
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-file-explorer/internal/model"
+)
+
+type ScheduleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewScheduleRepository(pool *pgxpool.Pool) *ScheduleRepository {
+	return &ScheduleRepository{pool: pool}
+}
+
+// scheduleColumns is the column list read by scanSchedule.
+const scheduleColumns = `id, name, cron_expr, timezone, misfire_policy, enabled, request,
+		        owner_id, owner_username, owner_role,
+		        next_run_at, last_run_at, created_at, updated_at`
+
+func (r *ScheduleRepository) Create(ctx context.Context, schedule model.Schedule) error {
+	payload, err := json.Marshal(schedule.Request)
+	if err != nil {
+		return fmt.Errorf("encode schedule request: %w", err)
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO schedules (id, name, cron_expr, timezone, misfire_policy, enabled, request,
+		  owner_id, owner_username, owner_role, next_run_at, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)`,
+		schedule.ID, schedule.Name, schedule.Cron, schedule.Timezone, schedule.MisfirePolicy,
+		schedule.Enabled, payload, schedule.OwnerID, schedule.OwnerUsername, schedule.OwnerRole,
+		parseJobTime(schedule.NextRunAt), parseJobTime(schedule.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("create schedule: %w", err)
+	}
+	return nil
+}
+
+// Update saves the editable fields of a schedule and its next run time.
+func (r *ScheduleRepository) Update(ctx context.Context, schedule model.Schedule) error {
+	payload, err := json.Marshal(schedule.Request)
+	if err != nil {
+		return fmt.Errorf("encode schedule request: %w", err)
+	}
+
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE schedules SET name = $2, cron_expr = $3, timezone = $4, misfire_policy = $5,
+		  enabled = $6, request = $7, next_run_at = $8, updated_at = now()
+		 WHERE id = $1`,
+		schedule.ID, schedule.Name, schedule.Cron, schedule.Timezone, schedule.MisfirePolicy,
+		schedule.Enabled, payload, parseJobTime(schedule.NextRunAt))
+	if err != nil {
+		return fmt.Errorf("update schedule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrScheduleNotFound
+	}
+	return nil
+}
+
+// Disable stops a schedule from firing until it is enabled again.
+func (r *ScheduleRepository) Disable(ctx context.Context, id string) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE schedules SET enabled = false, updated_at = now() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("disable schedule: %w", err)
+	}
+	return nil
+}
+
+func (r *ScheduleRepository) FindByID(ctx context.Context, id string) (model.Schedule, error) {
+	schedule, err := scanSchedule(r.pool.QueryRow(ctx,
+		`SELECT `+scheduleColumns+` FROM schedules WHERE id::text = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Schedule{}, model.ErrScheduleNotFound
+	}
+	if err != nil {
+		return model.Schedule{}, fmt.Errorf("find schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// List returns schedules by name, limited to ownerID when it is not empty.
+func (r *ScheduleRepository) List(ctx context.Context, ownerID string, page int, limit int) ([]model.Schedule, model.Meta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM schedules WHERE ($1 = '' OR owner_id = $1)`, ownerID).Scan(&total); err != nil {
+		return nil, model.Meta{}, fmt.Errorf("count schedules: %w", err)
+	}
+
+	totalPages := 0
+	if total > 0 {
+		totalPages = (total + limit - 1) / limit
+	}
+	meta := model.Meta{Page: page, Limit: limit, Total: total, TotalPages: totalPages}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+scheduleColumns+`
+		 FROM schedules WHERE ($1 = '' OR owner_id = $1)
+		 ORDER BY lower(name), created_at
+		 LIMIT $2 OFFSET $3`, ownerID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, model.Meta{}, fmt.Errorf("query schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]model.Schedule, 0)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, model.Meta{}, fmt.Errorf("scan schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, meta, rows.Err()
+}
+
+func (r *ScheduleRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM schedules WHERE id::text = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete schedule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrScheduleNotFound
+	}
+	return nil
+}
+
+// Due returns enabled schedules whose next run is at or before now.
+func (r *ScheduleRepository) Due(ctx context.Context, now time.Time, limit int) ([]model.Schedule, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+scheduleColumns+`
+		 FROM schedules
+		 WHERE enabled AND next_run_at <= $1
+		 ORDER BY next_run_at
+		 LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query due schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]model.Schedule, 0)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// Advance moves a due schedule from dueAt to next. It only succeeds for the
+// caller that still sees dueAt, so a run is claimed by exactly one instance.
+func (r *ScheduleRepository) Advance(ctx context.Context, id string, dueAt time.Time, next *time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE schedules SET next_run_at = $3, last_run_at = now()
+		 WHERE id = $1 AND enabled AND next_run_at = $2`, id, dueAt, next)
+	if err != nil {
+		return false, fmt.Errorf("advance schedule: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *ScheduleRepository) AddRun(ctx context.Context, run model.ScheduleRun) error {
+	scheduledFor, _ := time.Parse(time.RFC3339Nano, run.ScheduledFor)
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO schedule_runs (schedule_id, job_id, scheduled_for, status, missed_runs, error)
+		 VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6)`,
+		run.ScheduleID, run.JobID, scheduledFor, run.Status, run.MissedRuns, run.Error)
+	if err != nil {
+		return fmt.Errorf("add schedule run: %w", err)
+	}
+	return nil
+}
+
+// Runs returns the run history of a schedule, newest first, with the current
+// status of each queued job.
+func (r *ScheduleRepository) Runs(ctx context.Context, scheduleID string, page int, limit int) ([]model.ScheduleRun, model.Meta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM schedule_runs WHERE schedule_id = $1`, scheduleID).Scan(&total); err != nil {
+		return nil, model.Meta{}, fmt.Errorf("count schedule runs: %w", err)
+	}
+
+	totalPages := 0
+	if total > 0 {
+		totalPages = (total + limit - 1) / limit
+	}
+	meta := model.Meta{Page: page, Limit: limit, Total: total, TotalPages: totalPages}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT sr.id, sr.schedule_id::text, COALESCE(sr.job_id::text, ''), COALESCE(j.status, ''),
+		        sr.scheduled_for, sr.triggered_at, sr.status, sr.missed_runs, sr.error
+		 FROM schedule_runs sr
+		 LEFT JOIN jobs j ON j.id = sr.job_id
+		 WHERE sr.schedule_id = $1
+		 ORDER BY sr.triggered_at DESC, sr.id DESC
+		 LIMIT $2 OFFSET $3`, scheduleID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, model.Meta{}, fmt.Errorf("query schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]model.ScheduleRun, 0)
+	for rows.Next() {
+		var run model.ScheduleRun
+		var scheduledFor, triggeredAt time.Time
+		if err := rows.Scan(&run.ID, &run.ScheduleID, &run.JobID, &run.JobStatus,
+			&scheduledFor, &triggeredAt, &run.Status, &run.MissedRuns, &run.Error); err != nil {
+			return nil, model.Meta{}, fmt.Errorf("scan schedule run: %w", err)
+		}
+		run.ScheduledFor = scheduledFor.Format(time.RFC3339Nano)
+		run.TriggeredAt = triggeredAt.Format(time.RFC3339Nano)
+		runs = append(runs, run)
+	}
+	return runs, meta, rows.Err()
+}
+
+func scanSchedule(row pgx.Row) (model.Schedule, error) {
+	var schedule model.Schedule
+	var payload []byte
+	var nextRunAt, lastRunAt *time.Time
+	var createdAt, updatedAt time.Time
+
+	if err := row.Scan(&schedule.ID, &schedule.Name, &schedule.Cron, &schedule.Timezone,
+		&schedule.MisfirePolicy, &schedule.Enabled, &payload,
+		&schedule.OwnerID, &schedule.OwnerUsername, &schedule.OwnerRole,
+		&nextRunAt, &lastRunAt, &createdAt, &updatedAt); err != nil {
+		return model.Schedule{}, err
+	}
+
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &schedule.Request); err != nil {
+			return model.Schedule{}, fmt.Errorf("decode schedule request: %w", err)
+		}
+	}
+	if nextRunAt != nil {
+		schedule.NextRunAt = nextRunAt.Format(time.RFC3339Nano)
+	}
+	if lastRunAt != nil {
+		schedule.LastRunAt = lastRunAt.Format(time.RFC3339Nano)
+	}
+	schedule.CreatedAt = createdAt.Format(time.RFC3339Nano)
+	schedule.UpdatedAt = updatedAt.Format(time.RFC3339Nano)
+	return schedule, nil
+}
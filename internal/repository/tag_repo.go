@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-file-explorer/internal/model"
+)
+
+// TagRepository stores the tags of files and folders by API path. Live tags
+// have an empty trash_id; tags of trashed entries keep their original paths
+// under the trash record ID.
+type TagRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTagRepository(pool *pgxpool.Pool) *TagRepository {
+	return &TagRepository{pool: pool}
+}
+
+// Add tags path, ignoring tags it already has.
+func (r *TagRepository) Add(ctx context.Context, path string, tags []string, createdBy string) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO file_tags (path, tag, created_by)
+		 SELECT $1, tag, $3 FROM unnest($2::text[]) AS tag
+		 ON CONFLICT DO NOTHING`,
+		path, tags, createdBy)
+	if err != nil {
+		return fmt.Errorf("add file tags: %w", err)
+	}
+	return nil
+}
+
+func (r *TagRepository) Remove(ctx context.Context, path string, tags []string) error {
+	if _, err := r.pool.Exec(ctx,
+		`DELETE FROM file_tags WHERE trash_id = '' AND path = $1 AND tag = ANY($2)`,
+		path, tags); err != nil {
+		return fmt.Errorf("remove file tags: %w", err)
+	}
+	return nil
+}
+
+// ForPaths returns the tags of the given paths, sorted by name. Paths
+// without tags are absent from the map.
+func (r *TagRepository) ForPaths(ctx context.Context, paths []string) (map[string][]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT path, tag FROM file_tags WHERE trash_id = '' AND path = ANY($1) ORDER BY path, tag`, paths)
+	if err != nil {
+		return nil, fmt.Errorf("query file tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var path, tag string
+		if err := rows.Scan(&path, &tag); err != nil {
+			return nil, fmt.Errorf("scan file tag: %w", err)
+		}
+		tags[path] = append(tags[path], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query file tags: %w", err)
+	}
+	return tags, nil
+}
+
+// Counts returns every tag in use with the number of entries carrying it,
+// most used first.
+func (r *TagRepository) Counts(ctx context.Context) ([]model.TagCount, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT tag, COUNT(*) FROM file_tags WHERE trash_id = '' GROUP BY tag ORDER BY COUNT(*) DESC, tag`)
+	if err != nil {
+		return nil, fmt.Errorf("count file tags: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]model.TagCount, 0)
+	for rows.Next() {
+		var count model.TagCount
+		if err := rows.Scan(&count.Name, &count.Count); err != nil {
+			return nil, fmt.Errorf("scan tag count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("count file tags: %w", err)
+	}
+	return counts, nil
+}
+
+// MoveTree rewrites the paths of the tags of from and everything below it to
+// to. Tags left at to by entries that no longer exist are dropped.
+func (r *TagRepository) MoveTree(ctx context.Context, from string, to string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin move file tags: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM file_tags WHERE trash_id = '' AND (path = $1 OR path LIKE $2)`,
+		to, escapeLike(to)+"/%"); err != nil {
+		return fmt.Errorf("clear file tags move target: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE file_tags SET path = $2 || substr(path, length($1) + 1)
+		 WHERE trash_id = '' AND (path = $1 OR path LIKE $3)`,
+		from, to, escapeLike(from)+"/%"); err != nil {
+		return fmt.Errorf("move file tags: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit move file tags: %w", err)
+	}
+	return nil
+}
+
+// Trash parks the tags of path and everything below it under trashID.
+func (r *TagRepository) Trash(ctx context.Context, path string, trashID string) error {
+	if _, err := r.pool.Exec(ctx,
+		`UPDATE file_tags SET trash_id = $3 WHERE trash_id = '' AND (path = $1 OR path LIKE $2)`,
+		path, escapeLike(path)+"/%", trashID); err != nil {
+		return fmt.Errorf("trash file tags: %w", err)
+	}
+	return nil
+}
+
+// Restore brings back the tags parked under trashID, replacing tags left at
+// the same paths.
+func (r *TagRepository) Restore(ctx context.Context, trashID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin restore file tags: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM file_tags live USING file_tags trashed
+		 WHERE live.trash_id = '' AND trashed.trash_id = $1
+		   AND live.path = trashed.path AND live.tag = trashed.tag`,
+		trashID); err != nil {
+		return fmt.Errorf("clear restored file tags: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE file_tags SET trash_id = '' WHERE trash_id = $1`, trashID); err != nil {
+		return fmt.Errorf("restore file tags: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit restore file tags: %w", err)
+	}
+	return nil
+}
+
+// Purge removes the tags parked under trashID.
+func (r *TagRepository) Purge(ctx context.Context, trashID string) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM file_tags WHERE trash_id = $1`, trashID); err != nil {
+		return fmt.Errorf("purge file tags: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrashed removes the tags of every trashed entry.
+func (r *TagRepository) PurgeTrashed(ctx context.Context) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM file_tags WHERE trash_id <> ''`); err != nil {
+		return fmt.Errorf("purge trashed file tags: %w", err)
+	}
+	return nil
+}
@@ -17,13 +17,21 @@ type Handlers struct {
 	File          *handler.FileHandler
 	Operations    *handler.OperationsHandler
 	Search        *handler.SearchHandler
+	SavedSearches *handler.SavedSearchHandler
 	Audit         *handler.AuditHandler
 	Jobs          *handler.JobsHandler
+	Schedules     *handler.ScheduleHandler
+	Pipelines     *handler.PipelineHandler
 	Docs          *handler.DocsHandler
 	User          *handler.UserHandler
 	Storage       *handler.StorageHandler
 	Share         *handler.ShareHandler
 	ChunkedUpload *handler.ChunkedUploadHandler
+	Archive       *handler.ArchiveHandler
+	Tags          *handler.TagHandler
+	Metadata      *handler.MetadataHandler
+	QuickAccess   *handler.QuickAccessHandler
+	Preview       *handler.PreviewHandler
 }
 
 func New(
@@ -62,7 +70,10 @@ func New(
 		api.With(streaming, authMiddleware.RequireAuth).Get("/files/download", h.File.Download)
 		api.With(streaming, authMiddleware.RequireAuth).Get("/files/preview", h.File.Preview)
 		api.With(streaming, authMiddleware.RequireAuth).Get("/files/thumbnail", h.File.Thumbnail)
+		api.With(streaming, authMiddleware.RequireAuth).Post("/files/archive", h.Archive.Download)
+		api.With(streaming).Get("/files/archive/{ticket}", h.Archive.TicketDownload)
 		api.With(streaming, authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Get("/jobs/{job_id}/stream", h.Jobs.Stream)
+		api.With(streaming, authMiddleware.RequireAuth).Get("/search/stream", h.Search.Stream)
 		api.With(streaming).Get("/public/shares/{token}", h.Share.PublicDownload)
 
 		// Chunked uploads — chunk write uses streaming; init/complete/abort are lightweight JSON.
@@ -99,6 +110,24 @@ func New(
 			std.With(authMiddleware.RequireAuth).Get("/files", h.Directory.List)
 			std.With(authMiddleware.RequireAuth).Get("/tree", h.Directory.Tree)
 			std.With(authMiddleware.RequireAuth).Get("/files/info", h.File.Info)
+			std.With(authMiddleware.RequireAuth).Get("/files/preview/structured", h.Preview.Structured)
+			std.With(authMiddleware.RequireAuth).Post("/files/archive/tickets", h.Archive.CreateTicket)
+			std.With(authMiddleware.RequireAuth).Get("/files/tags", h.Tags.Get)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/files/tags", h.Tags.Add)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Delete("/files/tags", h.Tags.Remove)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/files/tags/bulk", h.Tags.Bulk)
+			std.With(authMiddleware.RequireAuth).Get("/tags", h.Tags.List)
+			std.With(authMiddleware.RequireAuth).Get("/files/metadata", h.Metadata.Get)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Patch("/files/metadata", h.Metadata.Patch)
+			std.With(authMiddleware.RequireAuth).Get("/metadata/fields", h.Metadata.ListFields)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("admin")).Post("/metadata/fields", h.Metadata.CreateField)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("admin")).Put("/metadata/fields/{name}", h.Metadata.UpdateField)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("admin")).Delete("/metadata/fields/{name}", h.Metadata.DeleteField)
+			std.With(authMiddleware.RequireAuth).Get("/favorites", h.QuickAccess.Favorites)
+			std.With(authMiddleware.RequireAuth).Post("/favorites", h.QuickAccess.Pin)
+			std.With(authMiddleware.RequireAuth).Delete("/favorites", h.QuickAccess.Unpin)
+			std.With(authMiddleware.RequireAuth).Put("/favorites/order", h.QuickAccess.Reorder)
+			std.With(authMiddleware.RequireAuth).Get("/recent", h.QuickAccess.Recent)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Put("/files/rename", h.Operations.Rename)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Put("/files/move", h.Operations.Move)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/files/copy", h.Operations.Copy)
@@ -110,13 +139,43 @@ func New(
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Delete("/trash/{id}", h.Operations.PermanentDeleteTrash)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Delete("/trash", h.Operations.EmptyTrash)
 			std.With(authMiddleware.RequireAuth).Get("/search", h.Search.Search)
+			std.With(authMiddleware.RequireAuth).Get("/search/quick", h.Search.Quick)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("admin")).Post("/search/reindex", h.Search.Reindex)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("admin")).Get("/audit", h.Audit.List)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Get("/jobs", h.Jobs.List)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Delete("/jobs", h.Jobs.Delete)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/jobs/operations", h.Jobs.CreateOperationJob)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("admin")).Get("/jobs/queue", h.Jobs.QueueStats)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Get("/jobs/{job_id}", h.Jobs.GetJob)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Get("/jobs/{job_id}/items", h.Jobs.GetJobItems)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/jobs/{job_id}/cancel", h.Jobs.Cancel)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/jobs/{job_id}/resume", h.Jobs.Resume)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/jobs/{job_id}/retry", h.Jobs.Retry)
 			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/directories", h.Directory.Create)
 			std.With(authMiddleware.RequireAuth).Get("/storage/stats", h.Storage.Stats)
 
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Post("/pipelines", h.Pipelines.Create)
+			std.With(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin")).Get("/pipelines/{pipeline_id}", h.Pipelines.Get)
+
+			std.Route("/schedules", func(schedules chi.Router) {
+				schedules.Use(authMiddleware.RequireAuth, authMiddleware.RequireRoles("editor", "admin"))
+				schedules.Get("/", h.Schedules.List)
+				schedules.Post("/", h.Schedules.Create)
+				schedules.Get("/{schedule_id}", h.Schedules.Get)
+				schedules.Put("/{schedule_id}", h.Schedules.Update)
+				schedules.Delete("/{schedule_id}", h.Schedules.Delete)
+				schedules.Get("/{schedule_id}/runs", h.Schedules.Runs)
+			})
+
+			std.Route("/saved-searches", func(searches chi.Router) {
+				searches.Use(authMiddleware.RequireAuth)
+				searches.Get("/", h.SavedSearches.List)
+				searches.Post("/", h.SavedSearches.Create)
+				searches.Get("/{search_id}", h.SavedSearches.Get)
+				searches.Put("/{search_id}", h.SavedSearches.Update)
+				searches.Delete("/{search_id}", h.SavedSearches.Delete)
+			})
+
 			std.Route("/shares", func(shares chi.Router) {
 				shares.Use(authMiddleware.RequireAuth)
 				shares.With(authMiddleware.RequireRoles("editor", "admin")).Post("/", h.Share.Create)
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/storage"
+	"go-file-explorer/internal/util"
+	"go-file-explorer/pkg/apierror"
+)
+
+const (
+	ArchiveFormatZip = "zip"
+	ArchiveFormatTar = "tar"
+
+	maxArchiveSelection = 1000
+)
+
+// PreparedArchive is a validated selection ready to be streamed.
+type PreparedArchive struct {
+	Name        string
+	Format      string
+	ContentType string
+	Entries     []util.ArchiveEntry
+}
+
+type archiveTicket struct {
+	request   model.ArchiveRequest
+	actor     model.AuditActor
+	expiresAt time.Time
+}
+
+// ArchiveService streams multi-selection downloads and hands out short-lived,
+// single-use tickets so the same download can be started from a plain
+// browser link.
+type ArchiveService struct {
+	store     storage.Storage
+	ticketTTL time.Duration
+	crcs      *util.CRCCache
+	audit     *AuditService
+
+	mu      sync.Mutex
+	tickets map[string]archiveTicket
+}
+
+func NewArchiveService(store storage.Storage, ticketTTL time.Duration) *ArchiveService {
+	if ticketTTL <= 0 {
+		ticketTTL = 5 * time.Minute
+	}
+
+	return &ArchiveService{
+		store:     store,
+		ticketTTL: ticketTTL,
+		crcs:      util.NewCRCCache(0),
+		tickets:   map[string]archiveTicket{},
+	}
+}
+
+// UseAudit records archive downloads in the audit log: direct downloads
+// under the requesting user, ticket downloads under the user who created the
+// ticket.
+func (s *ArchiveService) UseAudit(audit *AuditService) {
+	s.audit = audit
+}
+
+// Prepare validates the selection and lists every entry that will be written.
+// Paths nested under another selected directory are folded into it, clashing
+// top-level names get a numeric suffix and internal storage entries are skipped.
+func (s *ArchiveService) Prepare(request model.ArchiveRequest) (PreparedArchive, error) {
+	format := strings.ToLower(strings.TrimSpace(request.Format))
+	if format == "" {
+		format = ArchiveFormatZip
+	}
+	if format != ArchiveFormatZip && format != ArchiveFormatTar {
+		return PreparedArchive{}, apierror.New("BAD_REQUEST", "invalid format (allowed: zip|tar)", request.Format, http.StatusBadRequest)
+	}
+
+	if len(request.Paths) == 0 {
+		return PreparedArchive{}, apierror.New("BAD_REQUEST", "at least one path is required", "paths", http.StatusBadRequest)
+	}
+	if len(request.Paths) > maxArchiveSelection {
+		return PreparedArchive{}, apierror.New("BAD_REQUEST", fmt.Sprintf("at most %d paths can be archived at once", maxArchiveSelection), "paths", http.StatusBadRequest)
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		name = "download"
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".zip"), ".tar")
+	safeName, err := util.SanitizeFilename(name, false)
+	if err != nil {
+		return PreparedArchive{}, err
+	}
+
+	selected := make([]string, 0, len(request.Paths))
+	seen := map[string]bool{}
+	for _, raw := range request.Paths {
+		apiPath := normalizeAPIPath(raw)
+		if isInternalStoragePath(apiPath) {
+			return PreparedArchive{}, apierror.New("BAD_REQUEST", "path is not downloadable", apiPath, http.StatusBadRequest)
+		}
+		if seen[apiPath] {
+			continue
+		}
+		seen[apiPath] = true
+		selected = append(selected, apiPath)
+	}
+
+	// Shorter paths first so a selected directory swallows its descendants.
+	sort.Slice(selected, func(i, j int) bool { return len(selected[i]) < len(selected[j]) })
+	roots := make([]string, 0, len(selected))
+	for _, candidate := range selected {
+		covered := false
+		for _, root := range roots {
+			if root == "/" || strings.HasPrefix(candidate, root+"/") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			roots = append(roots, candidate)
+		}
+	}
+	sort.Strings(roots)
+
+	usedNames := map[string]bool{}
+	entries := make([]util.ArchiveEntry, 0, len(roots))
+	for _, apiPath := range roots {
+		resolved, err := s.store.Resolve(apiPath)
+		if err != nil {
+			return PreparedArchive{}, err
+		}
+
+		info, err := os.Stat(resolved)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return PreparedArchive{}, apierror.New("NOT_FOUND", "path not found", apiPath, http.StatusNotFound)
+			}
+			return PreparedArchive{}, err
+		}
+
+		if apiPath == "/" {
+			collected, err := s.collectDirectory(resolved, "")
+			if err != nil {
+				return PreparedArchive{}, err
+			}
+			for _, entry := range collected {
+				top := strings.SplitN(entry.Name, "/", 2)[0]
+				usedNames[strings.ToLower(top)] = true
+			}
+			entries = append(entries, collected...)
+			continue
+		}
+
+		topName := uniqueArchiveName(filepath.Base(resolved), info.IsDir(), usedNames)
+		if !info.IsDir() {
+			entries = append(entries, util.ArchiveEntry{
+				Name:    topName,
+				Source:  resolved,
+				Size:    info.Size(),
+				Mode:    info.Mode(),
+				ModTime: info.ModTime(),
+			})
+			continue
+		}
+
+		entries = append(entries, util.ArchiveEntry{Name: topName, IsDir: true, ModTime: info.ModTime()})
+		collected, err := s.collectDirectory(resolved, topName)
+		if err != nil {
+			return PreparedArchive{}, err
+		}
+		entries = append(entries, collected...)
+	}
+
+	prepared := PreparedArchive{Name: safeName + "." + format, Format: format, Entries: entries}
+	if format == ArchiveFormatTar {
+		prepared.ContentType = "application/x-tar"
+	} else {
+		prepared.ContentType = "application/zip"
+	}
+	return prepared, nil
+}
+
+// Write streams a prepared archive to writer.
+func (s *ArchiveService) Write(ctx context.Context, writer io.Writer, prepared PreparedArchive) error {
+	if prepared.Format == ArchiveFormatTar {
+		return util.WriteTarEntries(ctx, writer, prepared.Entries)
+	}
+	return util.WriteZipEntries(ctx, writer, prepared.Entries)
+}
+
+// LogDownload audits a direct archive download of request by actor.
+func (s *ArchiveService) LogDownload(request model.ArchiveRequest, actor model.AuditActor) {
+	s.audit.Log("archive_download", actor, "success", strings.Join(request.Paths, ","), map[string]any{"paths": request.Paths, "format": request.Format}, nil, "")
+}
+
+// PrepareResumable snapshots a directory into a deterministic uncompressed zip
+// layout whose size and ETag are known before any byte is sent. The caller
+// must Close the layout once the response is written.
+func (s *ArchiveService) PrepareResumable(path string) (*util.StoreZipLayout, string, error) {
+	apiPath := normalizeAPIPath(path)
+	if isInternalStoragePath(apiPath) {
+		return nil, "", apierror.New("BAD_REQUEST", "path is not downloadable", apiPath, http.StatusBadRequest)
+	}
+
+	resolved, err := s.store.Resolve(apiPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", apierror.New("NOT_FOUND", "directory not found", apiPath, http.StatusNotFound)
+		}
+		return nil, "", err
+	}
+	if !info.IsDir() {
+		return nil, "", apierror.New("BAD_REQUEST", "archive download requires a directory path", apiPath, http.StatusBadRequest)
+	}
+
+	entries, err := s.collectDirectory(resolved, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := strings.TrimSpace(filepath.Base(resolved))
+	if apiPath == "/" || name == "" || name == "." {
+		name = "archive"
+	}
+
+	return util.NewStoreZipLayout(entries, s.crcs), name + ".zip", nil
+}
+
+// CreateTicket validates the selection up front and stores it under a random
+// token for actor. The ticket can be redeemed once, before it expires.
+func (s *ArchiveService) CreateTicket(request model.ArchiveRequest, actor model.AuditActor) (model.ArchiveTicket, error) {
+	if _, err := s.Prepare(request); err != nil {
+		return model.ArchiveTicket{}, err
+	}
+
+	token, err := generateUploadID()
+	if err != nil {
+		return model.ArchiveTicket{}, fmt.Errorf("generate archive ticket: %w", err)
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.ticketTTL)
+
+	s.mu.Lock()
+	s.pruneTicketsLocked(now)
+	s.tickets[token] = archiveTicket{request: request, actor: actor, expiresAt: expiresAt}
+	s.mu.Unlock()
+
+	return model.ArchiveTicket{
+		Ticket:      token,
+		DownloadURL: "/api/v1/files/archive/" + token,
+		ExpiresAt:   expiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// RedeemTicket returns the selection stored under token and deletes the
+// ticket, so a leaked download URL stops working once it has been used. The
+// download is audited as the ticket's creator; clientIP is who redeemed it.
+func (s *ArchiveService) RedeemTicket(token string, clientIP string) (model.ArchiveRequest, error) {
+	now := time.Now().UTC()
+	token = strings.TrimSpace(token)
+
+	s.mu.Lock()
+	s.pruneTicketsLocked(now)
+	ticket, exists := s.tickets[token]
+	delete(s.tickets, token)
+	s.mu.Unlock()
+
+	if !exists {
+		return model.ArchiveRequest{}, apierror.New("NOT_FOUND", "archive ticket not found or expired", "", http.StatusNotFound)
+	}
+
+	s.audit.Log("archive_ticket_download", ticket.actor, "success", strings.Join(ticket.request.Paths, ","), map[string]any{"paths": ticket.request.Paths, "format": ticket.request.Format}, map[string]any{"client_ip": clientIP}, "")
+	return ticket.request, nil
+}
+
+func (s *ArchiveService) pruneTicketsLocked(now time.Time) {
+	for token, ticket := range s.tickets {
+		if now.After(ticket.expiresAt) {
+			delete(s.tickets, token)
+		}
+	}
+}
+
+// collectDirectory lists the contents of dir below prefix in walk order,
+// skipping symlinks and internal storage directories.
+func (s *ArchiveService) collectDirectory(dir string, prefix string) ([]util.ArchiveEntry, error) {
+	rootAbs := s.store.RootAbs()
+	entries := make([]util.ArchiveEntry, 0)
+
+	err := filepath.WalkDir(dir, func(current string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if current == dir {
+			return nil
+		}
+		if d.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if isInternalStoragePath(toAPIPath(current, rootAbs)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, current)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if prefix != "" {
+			name = prefix + "/" + name
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			entries = append(entries, util.ArchiveEntry{Name: name, IsDir: true, ModTime: info.ModTime()})
+			return nil
+		}
+
+		entries = append(entries, util.ArchiveEntry{
+			Name:    name,
+			Source:  current,
+			Size:    info.Size(),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// uniqueArchiveName returns name, or "name (n).ext" when an entry with the same
+// name (case-insensitively) is already at the top level of the archive.
+func uniqueArchiveName(name string, isDir bool, used map[string]bool) string {
+	candidate := name
+	ext := ""
+	if !isDir {
+		ext = filepath.Ext(name)
+	}
+	base := strings.TrimSuffix(name, ext)
+
+	for index := 1; used[strings.ToLower(candidate)]; index++ {
+		candidate = fmt.Sprintf("%s (%d)%s", base, index, ext)
+	}
+
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
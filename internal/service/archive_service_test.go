@@ -0,0 +1,81 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/storage"
+)
+
+func TestArchiveService_PrepareAndWrite(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.New(root)
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "a", "nested"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "b"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".trash"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a", "report.pdf"), []byte("first"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a", "nested", "deep.txt"), []byte("deep"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b", "report.pdf"), []byte("second"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".trash", "hidden.txt"), []byte("x"), 0o644))
+
+	svc := NewArchiveService(store, time.Minute)
+
+	t.Run("renames clashing names and folds nested selections", func(t *testing.T) {
+		prepared, err := svc.Prepare(model.ArchiveRequest{Paths: []string{"/a/report.pdf", "/b/report.pdf", "/a/nested", "/a/nested/deep.txt"}})
+		require.NoError(t, err)
+		require.Equal(t, "download.zip", prepared.Name)
+
+		var buf bytes.Buffer
+		require.NoError(t, svc.Write(context.Background(), &buf, prepared))
+
+		reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+
+		names := make([]string, 0, len(reader.File))
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+		}
+		require.ElementsMatch(t, []string{"nested/", "nested/deep.txt", "report.pdf", "report (1).pdf"}, names)
+	})
+
+	t.Run("skips internal storage entries when archiving the root", func(t *testing.T) {
+		prepared, err := svc.Prepare(model.ArchiveRequest{Paths: []string{"/"}, Format: "tar"})
+		require.NoError(t, err)
+
+		for _, entry := range prepared.Entries {
+			require.NotContains(t, entry.Name, ".trash")
+		}
+	})
+
+	t.Run("rejects internal paths and unknown formats", func(t *testing.T) {
+		_, err := svc.Prepare(model.ArchiveRequest{Paths: []string{"/.trash"}})
+		require.Error(t, err)
+
+		_, err = svc.Prepare(model.ArchiveRequest{Paths: []string{"/a"}, Format: "rar"})
+		require.Error(t, err)
+	})
+
+	t.Run("tickets resolve to the stored selection once", func(t *testing.T) {
+		ticket, err := svc.CreateTicket(model.ArchiveRequest{Paths: []string{"/a"}}, model.AuditActor{UserID: "u1", Username: "alice"})
+		require.NoError(t, err)
+		require.Contains(t, ticket.DownloadURL, ticket.Ticket)
+		require.Equal(t, "alice", svc.tickets[ticket.Ticket].actor.Username)
+
+		request, err := svc.RedeemTicket(ticket.Ticket, "203.0.113.7")
+		require.NoError(t, err)
+		require.Equal(t, []string{"/a"}, request.Paths)
+
+		_, err = svc.RedeemTicket(ticket.Ticket, "203.0.113.7")
+		require.Error(t, err)
+	})
+}
@@ -30,6 +30,8 @@ type uploadSession struct {
 	fileName       string
 	destination    string
 	conflictPolicy string
+	uploadedBy     string
+	uploaderID     string
 	totalChunks    int
 	chunkSize      int64
 	fileSize       int64
@@ -47,6 +49,7 @@ type ChunkedUploadService struct {
 	tempDir          string
 	allowedMIMETypes map[string]struct{}
 	bus              event.Bus
+	quick            *QuickAccessService
 
 	mu       sync.RWMutex
 	sessions map[string]*uploadSession
@@ -83,9 +86,14 @@ func NewChunkedUploadService(store storage.Storage, tempDir string, allowedMIMET
 	}, nil
 }
 
+// UseQuickAccess records completed uploads as recent files of the uploader.
+func (s *ChunkedUploadService) UseQuickAccess(quick *QuickAccessService) {
+	s.quick = quick
+}
+
 // ── Init ─────────────────────────────────────────────────────────
 
-func (s *ChunkedUploadService) InitUpload(_ context.Context, req model.ChunkedUploadInitRequest) (model.ChunkedUploadInitResponse, error) {
+func (s *ChunkedUploadService) InitUpload(_ context.Context, req model.ChunkedUploadInitRequest, actor model.AuditActor) (model.ChunkedUploadInitResponse, error) {
 	if strings.TrimSpace(req.FileName) == "" {
 		return model.ChunkedUploadInitResponse{}, apierror.New("BAD_REQUEST", "file_name is required", "", http.StatusBadRequest)
 	}
@@ -128,6 +136,8 @@ func (s *ChunkedUploadService) InitUpload(_ context.Context, req model.ChunkedUp
 		uploadID:       uploadID,
 		fileName:       safeName,
 		destination:    destination,
+		uploadedBy:     actor.Username,
+		uploaderID:     actor.UserID,
 		conflictPolicy: req.ConflictPolicy,
 		totalChunks:    totalChunks,
 		chunkSize:      req.ChunkSize,
@@ -214,7 +224,7 @@ func (s *ChunkedUploadService) WriteChunk(_ context.Context, uploadID string, ch
 
 // ── Complete ─────────────────────────────────────────────────────
 
-func (s *ChunkedUploadService) CompleteUpload(_ context.Context, uploadID string) (model.UploadItem, error) {
+func (s *ChunkedUploadService) CompleteUpload(ctx context.Context, uploadID string) (model.UploadItem, error) {
 	s.mu.RLock()
 	sess, ok := s.sessions[uploadID]
 	s.mu.RUnlock()
@@ -337,11 +347,13 @@ func (s *ChunkedUploadService) CompleteUpload(_ context.Context, uploadID string
 	)
 
 	item := model.UploadItem{
-		Name:     sess.fileName,
-		Path:     targetPath,
-		Size:     info.Size(),
-		MimeType: detectedMIME,
+		Name:       sess.fileName,
+		Path:       targetPath,
+		Size:       info.Size(),
+		MimeType:   detectedMIME,
+		UploadedBy: sess.uploadedBy,
 	}
+	s.quick.touched(ctx, targetPath, recentActionUpload, model.AuditActor{UserID: sess.uploaderID, Username: sess.uploadedBy})
 
 	if s.bus != nil {
 		s.bus.Publish(event.Event{
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/pkg/apierror"
+)
+
+// cursorToken is the encoded form of a cursor. Scope fingerprints the query
+// that produced it, so a cursor cannot be replayed against another query.
+type cursorToken struct {
+	Scope    string       `json:"q"`
+	Position model.Cursor `json:"c"`
+}
+
+// cursorScope fingerprints the parts of a query that decide its results and
+// their order.
+func cursorScope(parts ...any) string {
+	encoded, _ := json.Marshal(parts)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeCursor(scope string, position model.Cursor) string {
+	encoded, _ := json.Marshal(cursorToken{Scope: scope, Position: position})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeCursor returns the position of token, or nil when token is empty.
+func decodeCursor(token string, scope string) (*model.Cursor, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, nil
+	}
+
+	invalid := apierror.New("BAD_REQUEST", "invalid cursor", "cursor", http.StatusBadRequest)
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	var decoded cursorToken
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, invalid
+	}
+	if decoded.Scope != scope {
+		return nil, apierror.New("BAD_REQUEST", "cursor does not belong to this query; start again without it", "cursor", http.StatusBadRequest)
+	}
+	return &decoded.Position, nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	scope := cursorScope("files", "/docs", "name", true)
+	position := model.Cursor{Path: "/docs/b.txt", Name: "b.txt", Size: 42, Time: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC), Offset: 20}
+
+	decoded, err := decodeCursor(encodeCursor(scope, position), scope)
+	require.NoError(t, err)
+	require.Equal(t, position, *decoded)
+
+	decoded, err = decodeCursor("", scope)
+	require.NoError(t, err)
+	require.Nil(t, decoded)
+}
+
+func TestCursorRejectsForeignOrMalformedTokens(t *testing.T) {
+	token := encodeCursor(cursorScope("files", "/docs", "name", true), model.Cursor{Name: "b.txt"})
+
+	_, err := decodeCursor(token, cursorScope("files", "/docs", "size", true))
+	require.ErrorContains(t, err, "cursor does not belong to this query")
+
+	_, err = decodeCursor("not a cursor!", cursorScope("files", "/docs", "name", true))
+	require.ErrorContains(t, err, "invalid cursor")
+}
@@ -21,15 +21,32 @@ import (
 )
 
 type DirectoryService struct {
-	store storage.Storage
-	bus   event.Bus
+	store  storage.Storage
+	bus    event.Bus
+	tags   *TagService
+	images *ImageMetadataService
 }
 
 func NewDirectoryService(store storage.Storage, bus event.Bus) *DirectoryService {
 	return &DirectoryService{store: store, bus: bus}
 }
 
-func (s *DirectoryService) List(_ context.Context, requestedPath string, page int, limit int, sortBy string, order string) (model.DirectoryListData, model.Meta, error) {
+// UseTags makes listings carry the tags of their entries and filterable by
+// tag.
+func (s *DirectoryService) UseTags(tags *TagService) {
+	s.tags = tags
+}
+
+// UseImageMetadata lets listings carry the dimensions of their images.
+func (s *DirectoryService) UseImageMetadata(images *ImageMetadataService) {
+	s.images = images
+}
+
+// List returns one page of a directory, keeping only the entries tagged tag
+// when it is set. The page starts after cursor when it is set, otherwise at
+// page. Child counts, and image dimensions when dimensions is set, are read
+// only for the entries on the page.
+func (s *DirectoryService) List(ctx context.Context, requestedPath string, page int, limit int, sortBy string, order string, cursor string, tag string, dimensions bool) (model.DirectoryListData, model.Meta, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -82,11 +99,6 @@ func (s *DirectoryService) List(_ context.Context, requestedPath string, page in
 		if entry.IsDir() {
 			item.Type = "directory"
 			item.Size = 0
-			children, childrenErr := os.ReadDir(filepath.Join(resolved, entry.Name()))
-			if childrenErr == nil {
-				count := len(children)
-				item.ItemCount = &count
-			}
 		} else {
 			item.Type = "file"
 			item.Size = info.Size()
@@ -107,18 +119,6 @@ func (s *DirectoryService) List(_ context.Context, requestedPath string, page in
 		items = append(items, item)
 	}
 
-	sortItems(items, sortBy, order)
-
-	total := len(items)
-	start := (page - 1) * limit
-	if start > total {
-		start = total
-	}
-	end := start + limit
-	if end > total {
-		end = total
-	}
-
 	currentPath := requestedPath
 	if strings.TrimSpace(currentPath) == "" {
 		currentPath = "/"
@@ -126,6 +126,33 @@ func (s *DirectoryService) List(_ context.Context, requestedPath string, page in
 
 	currentPath = normalizeAPIPath(currentPath)
 
+	if strings.TrimSpace(tag) != "" {
+		items, err = s.filterTagged(ctx, items, tag)
+		if err != nil {
+			return model.DirectoryListData{}, model.Meta{}, err
+		}
+	}
+
+	pageItems, meta, err := paginateItems(items, currentPath, page, limit, sortBy, order, cursor)
+	if err != nil {
+		return model.DirectoryListData{}, model.Meta{}, err
+	}
+	if err := s.tags.Annotate(ctx, pageItems); err != nil {
+		return model.DirectoryListData{}, model.Meta{}, err
+	}
+
+	for i := range pageItems {
+		if pageItems[i].Type == "directory" {
+			children, childrenErr := os.ReadDir(filepath.Join(resolved, pageItems[i].Name))
+			if childrenErr == nil {
+				count := len(children)
+				pageItems[i].ItemCount = &count
+			}
+		} else if dimensions && pageItems[i].IsImage {
+			pageItems[i].ImageMetadata = s.images.Dimensions(filepath.Join(resolved, pageItems[i].Name), pageItems[i].Size, pageItems[i].ModifiedAt)
+		}
+	}
+
 	parentPath := "/"
 	if currentPath != "/" {
 		parentPath = normalizeAPIPath(filepath.Dir(currentPath))
@@ -134,7 +161,66 @@ func (s *DirectoryService) List(_ context.Context, requestedPath string, page in
 	data := model.DirectoryListData{
 		CurrentPath: currentPath,
 		ParentPath:  parentPath,
-		Items:       items[start:end],
+		Items:       pageItems,
+	}
+
+	return data, meta, nil
+}
+
+// filterTagged keeps the items tagged tag.
+func (s *DirectoryService) filterTagged(ctx context.Context, items []model.FileItem, tag string) ([]model.FileItem, error) {
+	tags, err := normalizeTags([]string{tag})
+	if err != nil {
+		return nil, err
+	}
+	if s.tags == nil {
+		return []model.FileItem{}, nil
+	}
+
+	paths := make([]string, 0, len(items))
+	for _, item := range items {
+		paths = append(paths, item.Path)
+	}
+	tagged, err := s.tags.Tagged(ctx, paths, tags[0])
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]model.FileItem, 0, len(tagged))
+	for _, item := range items {
+		if tagged[item.Path] {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+// paginateItems sorts the entries of the listing at currentPath and returns
+// the page that starts after cursor when it is set, otherwise at page.
+func paginateItems(items []model.FileItem, currentPath string, page int, limit int, sortBy string, order string, cursor string) ([]model.FileItem, model.Meta, error) {
+	sortItems(items, sortBy, order)
+
+	field, ascending := itemSortOrder(sortBy, order)
+	scope := cursorScope("files", currentPath, field, ascending)
+	after, err := decodeCursor(cursor, scope)
+	if err != nil {
+		return nil, model.Meta{}, err
+	}
+
+	total := len(items)
+	start := (page - 1) * limit
+	if after != nil {
+		position := model.FileItem{Name: after.Name, Type: after.Type, Size: after.Size, ModifiedAt: after.Time}
+		start = sort.Search(total, func(i int) bool {
+			return itemAfter(items[i], position, field, ascending)
+		})
+	}
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
 	}
 
 	totalPages := 0
@@ -148,8 +234,15 @@ func (s *DirectoryService) List(_ context.Context, requestedPath string, page in
 		Total:      total,
 		TotalPages: totalPages,
 	}
+	if after != nil {
+		meta.Page = 0
+	}
+	if end < total && end > start {
+		last := items[end-1]
+		meta.NextCursor = encodeCursor(scope, model.Cursor{Name: last.Name, Type: last.Type, Size: last.Size, Time: last.ModifiedAt})
+	}
 
-	return data, meta, nil
+	return items[start:end], meta, nil
 }
 
 func (s *DirectoryService) Create(_ context.Context, basePath string, name string) (model.DirectoryCreateData, error) {
@@ -163,6 +256,10 @@ func (s *DirectoryService) Create(_ context.Context, basePath string, name strin
 	}
 
 	fullPath := normalizeAPIPath(filepath.Join(basePath, safeName))
+	if IsVirtualPath(fullPath) {
+		return model.DirectoryCreateData{}, apierror.New("FORBIDDEN", "saved search folders are read-only", fullPath, http.StatusForbidden)
+	}
+
 	resolved, err := s.store.Resolve(fullPath)
 	if err != nil {
 		return model.DirectoryCreateData{}, err
@@ -350,53 +447,50 @@ func (s *DirectoryService) buildTreeNode(absPath string, apiPath string, entry o
 }
 
 func sortItems(items []model.FileItem, sortBy string, order string) {
+	field, ascending := itemSortOrder(sortBy, order)
+
+	sort.SliceStable(items, func(i int, j int) bool {
+		cmp := compareItems(items[i], items[j], field)
+		if cmp == 0 {
+			return false
+		}
+		if ascending {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+}
+
+func itemSortOrder(sortBy string, order string) (string, bool) {
 	field := strings.ToLower(strings.TrimSpace(sortBy))
 	if field == "" {
 		field = "name"
 	}
+	return field, strings.ToLower(strings.TrimSpace(order)) != "desc"
+}
 
-	ascending := strings.ToLower(strings.TrimSpace(order)) != "desc"
-
-	compare := func(i int, j int) int {
-		switch field {
-		case "size":
-			if items[i].Size < items[j].Size {
-				return -1
-			}
-			if items[i].Size > items[j].Size {
-				return 1
-			}
-			return 0
-		case "modified_at":
-			if items[i].ModifiedAt.Before(items[j].ModifiedAt) {
-				return -1
-			}
-			if items[i].ModifiedAt.After(items[j].ModifiedAt) {
-				return 1
-			}
-			return 0
-		case "type":
-			if items[i].Type == items[j].Type {
-				left := strings.ToLower(items[i].Name)
-				right := strings.ToLower(items[j].Name)
-				if left < right {
-					return -1
-				}
-				if left > right {
-					return 1
-				}
-				return 0
-			}
-			if items[i].Type < items[j].Type {
-				return -1
-			}
-			if items[i].Type > items[j].Type {
-				return 1
-			}
-			return 0
-		default:
-			left := strings.ToLower(items[i].Name)
-			right := strings.ToLower(items[j].Name)
+func compareItems(a model.FileItem, b model.FileItem, field string) int {
+	switch field {
+	case "size":
+		if a.Size < b.Size {
+			return -1
+		}
+		if a.Size > b.Size {
+			return 1
+		}
+		return 0
+	case "modified_at":
+		if a.ModifiedAt.Before(b.ModifiedAt) {
+			return -1
+		}
+		if a.ModifiedAt.After(b.ModifiedAt) {
+			return 1
+		}
+		return 0
+	case "type":
+		if a.Type == b.Type {
+			left := strings.ToLower(a.Name)
+			right := strings.ToLower(b.Name)
 			if left < right {
 				return -1
 			}
@@ -405,18 +499,37 @@ func sortItems(items []model.FileItem, sortBy string, order string) {
 			}
 			return 0
 		}
-	}
-
-	sort.SliceStable(items, func(i int, j int) bool {
-		cmp := compare(i, j)
-		if cmp == 0 {
-			return false
+		if a.Type < b.Type {
+			return -1
 		}
-		if ascending {
-			return cmp < 0
+		if a.Type > b.Type {
+			return 1
+		}
+		return 0
+	default:
+		left := strings.ToLower(a.Name)
+		right := strings.ToLower(b.Name)
+		if left < right {
+			return -1
 		}
+		if left > right {
+			return 1
+		}
+		return 0
+	}
+}
+
+// itemAfter reports whether item sorts after position. sortItems is stable
+// over names in directory order, so ties fall back to the exact name.
+func itemAfter(item model.FileItem, position model.FileItem, field string, ascending bool) bool {
+	cmp := compareItems(item, position, field)
+	if !ascending {
+		cmp = -cmp
+	}
+	if cmp != 0 {
 		return cmp > 0
-	})
+	}
+	return item.Name > position.Name
 }
 
 func humanizeSize(size int64) string {
@@ -466,7 +579,7 @@ func normalizeAPIPath(path string) string {
 func isInternalStorageEntry(name string) bool {
 	trimmed := strings.TrimSpace(name)
 	switch trimmed {
-	case ".trash", ".thumbnails", ".chunks":
+	case ".trash", ".thumbnails", ".chunks", ".searches":
 		return true
 	default:
 		return false
@@ -475,9 +588,9 @@ func isInternalStorageEntry(name string) bool {
 
 func isInternalStoragePath(raw string) bool {
 	normalized := normalizeAPIPath(raw)
-	if normalized == "/.trash" || normalized == "/.thumbnails" || normalized == "/.chunks" {
+	if normalized == "/.trash" || normalized == "/.thumbnails" || normalized == "/.chunks" || normalized == savedSearchRoot {
 		return true
 	}
 
-	return strings.HasPrefix(normalized, "/.trash/") || strings.HasPrefix(normalized, "/.thumbnails/") || strings.HasPrefix(normalized, "/.chunks/")
+	return strings.HasPrefix(normalized, "/.trash/") || strings.HasPrefix(normalized, "/.thumbnails/") || strings.HasPrefix(normalized, "/.chunks/") || strings.HasPrefix(normalized, savedSearchRoot+"/")
 }
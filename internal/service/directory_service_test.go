@@ -1,12 +1,14 @@
 package service
 
 import (
+	"context"
 	"testing"
 	time "time"
 
 	"github.com/stretchr/testify/require"
 
 	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/storage"
 )
 
 func TestSortItems(t *testing.T) {
@@ -41,3 +43,44 @@ func TestSortItems(t *testing.T) {
 		require.Equal(t, "c.txt", items[2].Name)
 	})
 }
+
+func TestDirectoryListCursor(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+	for _, name := range []string{"e.txt", "B.txt", "a.txt", "d.txt", "c.txt"} {
+		file, err := store.OpenForWrite("/" + name)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+	require.NoError(t, store.MkdirAll("/a-dir/child", 0o755))
+
+	svc := NewDirectoryService(store, nil)
+	names := func(items []model.FileItem) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.Name)
+		}
+		return out
+	}
+
+	data, meta, err := svc.List(context.Background(), "/", 1, 4, "name", "asc", "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a-dir", "a.txt", "B.txt", "c.txt"}, names(data.Items))
+	require.NotNil(t, data.Items[0].ItemCount)
+	require.Equal(t, 1, *data.Items[0].ItemCount)
+	require.NotEmpty(t, meta.NextCursor)
+
+	data, meta, err = svc.List(context.Background(), "/", 1, 4, "name", "asc", meta.NextCursor, "", false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"d.txt", "e.txt"}, names(data.Items))
+	require.Equal(t, 0, meta.Page)
+	require.Equal(t, 6, meta.Total)
+	require.Empty(t, meta.NextCursor)
+
+	_, first, err := svc.List(context.Background(), "/", 1, 2, "name", "desc", "", "", false)
+	require.NoError(t, err)
+	_, _, err = svc.List(context.Background(), "/", 1, 2, "name", "asc", first.NextCursor, "", false)
+	require.ErrorContains(t, err, "cursor does not belong to this query")
+}
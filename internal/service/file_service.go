@@ -3,8 +3,6 @@ package service
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"image"
 	"io"
 	"math"
@@ -41,6 +39,11 @@ type FileService struct {
 	allowedMIMETypes map[string]struct{}
 	thumbnailRoot    string
 	bus              event.Bus
+	tags             *TagService
+	metadata         *MetadataService
+	quick            *QuickAccessService
+	thumbnails       *ThumbnailService
+	images           *ImageMetadataService
 }
 
 func NewFileService(store storage.Storage, allowedMIMETypes []string, thumbnailRoot string, bus event.Bus) *FileService {
@@ -60,7 +63,34 @@ func NewFileService(store storage.Storage, allowedMIMETypes []string, thumbnailR
 	return &FileService{store: store, allowedMIMETypes: allowed, thumbnailRoot: thumbnailRoot, bus: bus}
 }
 
-func (s *FileService) Upload(_ context.Context, destination string, filename string, conflictPolicy string, reader io.Reader) (model.UploadItem, error) {
+// UseTags makes file info carry the tags of the entry.
+func (s *FileService) UseTags(tags *TagService) {
+	s.tags = tags
+}
+
+// UseMetadata makes file info carry the custom metadata of the entry.
+func (s *FileService) UseMetadata(metadata *MetadataService) {
+	s.metadata = metadata
+}
+
+// UseImageMetadata makes file info of images carry their dimensions, EXIF
+// data and PNG text.
+func (s *FileService) UseImageMetadata(images *ImageMetadataService) {
+	s.images = images
+}
+
+// UseQuickAccess records uploads, downloads and previews as recent files.
+func (s *FileService) UseQuickAccess(quick *QuickAccessService) {
+	s.quick = quick
+}
+
+// UseThumbnails accounts generated and served thumbnails in the cache of
+// thumbnails, which evicts the least recently used ones.
+func (s *FileService) UseThumbnails(thumbnails *ThumbnailService) {
+	s.thumbnails = thumbnails
+}
+
+func (s *FileService) Upload(ctx context.Context, destination string, filename string, conflictPolicy string, reader io.Reader, actor model.AuditActor) (model.UploadItem, error) {
 	safeName, err := util.SanitizeFilename(filename, false)
 	if err != nil {
 		return model.UploadItem{}, err
@@ -108,11 +138,13 @@ func (s *FileService) Upload(_ context.Context, destination string, filename str
 	}
 
 	item := model.UploadItem{
-		Name:     safeName,
-		Path:     targetPath,
-		Size:     written,
-		MimeType: detectedMIME,
+		Name:       safeName,
+		Path:       targetPath,
+		Size:       written,
+		MimeType:   detectedMIME,
+		UploadedBy: actor.Username,
 	}
+	s.quick.touched(ctx, targetPath, recentActionUpload, actor)
 
 	if s.bus != nil {
 		s.bus.Publish(event.Event{
@@ -158,10 +190,25 @@ func (s *FileService) GetFile(path string) (*os.File, os.FileInfo, string, error
 	return file, info, mimeType, nil
 }
 
-func (s *FileService) GetThumbnail(path string, size int) (*os.File, os.FileInfo, error) {
+// Downloaded records a download of path by actor.
+func (s *FileService) Downloaded(ctx context.Context, path string, actor model.AuditActor) {
+	s.quick.touched(ctx, path, recentActionDownload, actor)
+}
+
+// Previewed records a preview of path by actor.
+func (s *FileService) Previewed(ctx context.Context, path string, actor model.AuditActor) {
+	s.quick.touched(ctx, path, recentActionPreview, actor)
+}
+
+// GetThumbnail returns the cached thumbnail of the image or video at path,
+// generating it in the given format when it is missing or stale.
+func (s *FileService) GetThumbnail(path string, size int, format ThumbnailFormat) (*os.File, os.FileInfo, error) {
 	if size <= 0 {
 		size = 256
 	}
+	if format.Extension == "" {
+		format = ThumbnailJPEG
+	}
 
 	resolved, err := s.store.Resolve(path)
 	if err != nil {
@@ -184,16 +231,21 @@ func (s *FileService) GetThumbnail(path string, size int) (*os.File, os.FileInfo
 		return nil, nil, err
 	}
 
-	thumbPath := s.thumbnailPath(resolved, size)
-	if thumbInfo, err := os.Stat(thumbPath); err == nil {
-		if !thumbInfo.ModTime().Before(info.ModTime()) {
-			thumbFile, openErr := os.Open(thumbPath)
-			if openErr == nil {
-				return thumbFile, thumbInfo, nil
-			}
+	thumbPath := filepath.Join(s.thumbnailRoot, thumbnailFileName(resolved, size, format))
+	if thumbInfo, ok := freshThumbnail(thumbPath, info); ok {
+		thumbFile, openErr := os.Open(thumbPath)
+		if openErr == nil {
+			s.thumbnails.used(thumbPath, thumbInfo)
+			return thumbFile, thumbInfo, nil
 		}
 	}
 
+	return s.generateThumbnail(resolved, thumbPath, size, format, info)
+}
+
+// generateThumbnail writes the thumbnail of the image or video at resolved to
+// thumbPath and returns it opened.
+func (s *FileService) generateThumbnail(resolved, thumbPath string, size int, format ThumbnailFormat, info os.FileInfo) (*os.File, os.FileInfo, error) {
 	// Detect whether the file is a video or an image.
 	file, err := os.Open(resolved)
 	if err != nil {
@@ -213,14 +265,15 @@ func (s *FileService) GetThumbnail(path string, size int) (*os.File, os.FileInfo
 	}
 
 	if isVideo {
-		return s.generateVideoThumbnail(resolved, thumbPath, size, info)
+		return s.generateVideoThumbnail(resolved, thumbPath, size, format, info)
 	}
 
-	return s.generateImageThumbnail(resolved, thumbPath, size, info)
+	return s.generateImageThumbnail(resolved, thumbPath, size, format, info)
 }
 
-// generateImageThumbnail decodes an image, scales it, and writes a JPEG thumbnail.
-func (s *FileService) generateImageThumbnail(resolved, thumbPath string, size int, info os.FileInfo) (*os.File, os.FileInfo, error) {
+// generateImageThumbnail decodes an image, turns it upright according to its
+// EXIF orientation, scales it, and writes the thumbnail.
+func (s *FileService) generateImageThumbnail(resolved, thumbPath string, size int, format ThumbnailFormat, info os.FileInfo) (*os.File, os.FileInfo, error) {
 	file, err := os.Open(resolved)
 	if err != nil {
 		return nil, nil, err
@@ -239,20 +292,20 @@ func (s *FileService) generateImageThumbnail(resolved, thumbPath string, size in
 		return nil, nil, apierror.New("UNSUPPORTED_TYPE", "invalid image dimensions", resolved, http.StatusUnsupportedMediaType)
 	}
 
-	return s.scaleAndSaveThumbnail(src, bounds, thumbPath, size, info)
+	return s.scaleAndSaveThumbnail(src, bounds, util.ImageOrientation(file), thumbPath, size, format, info)
 }
 
 // generateVideoThumbnail extracts a frame from a video using ffmpeg and saves
-// it as a scaled JPEG thumbnail. If ffmpeg is not installed the endpoint returns
+// it as a scaled thumbnail. If ffmpeg is not installed the endpoint returns
 // UNSUPPORTED_TYPE so the client can fall back gracefully.
-func (s *FileService) generateVideoThumbnail(resolved, thumbPath string, size int, info os.FileInfo) (*os.File, os.FileInfo, error) {
+func (s *FileService) generateVideoThumbnail(resolved, thumbPath string, size int, format ThumbnailFormat, info os.FileInfo) (*os.File, os.FileInfo, error) {
 	ffmpegPath, err := exec.LookPath("ffmpeg")
 	if err != nil {
 		return nil, nil, apierror.New("UNSUPPORTED_TYPE", "ffmpeg not available for video thumbnails", "", http.StatusUnsupportedMediaType)
 	}
 
 	// Extract a single frame at ~1 s into the video (or 0 s if it's shorter).
-	// Output raw JPEG to a temp file so we can decode → scale → save like images.
+	// Output raw JPEG to a temp file so we can decode → save like images.
 	tmpFile, err := os.CreateTemp(s.thumbnailRoot, "vtmp-*.jpg")
 	if err != nil {
 		return nil, nil, err
@@ -282,43 +335,26 @@ func (s *FileService) generateVideoThumbnail(resolved, thumbPath string, size in
 		return nil, nil, apierror.New("UNSUPPORTED_TYPE", "failed to extract video frame", err.Error(), http.StatusUnsupportedMediaType)
 	}
 
-	// ffmpeg already scaled the frame; just copy it to the final thumbnail path.
-	src, err := os.Open(tmpPath)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer src.Close()
-
-	dst, err := os.OpenFile(thumbPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if _, err := io.Copy(dst, src); err != nil {
-		_ = dst.Close()
-		return nil, nil, err
-	}
-	_ = dst.Close()
-
-	_ = os.Chtimes(thumbPath, time.Now().UTC(), info.ModTime())
-
-	thumbFile, err := os.Open(thumbPath)
+	// ffmpeg already scaled the frame; decode it so it is saved in the
+	// requested format.
+	frameFile, err := os.Open(tmpPath)
 	if err != nil {
 		return nil, nil, err
 	}
+	defer frameFile.Close()
 
-	thumbInfo, err := os.Stat(thumbPath)
+	frame, err := jpeg.Decode(frameFile)
 	if err != nil {
-		_ = thumbFile.Close()
-		return nil, nil, err
+		return nil, nil, apierror.New("UNSUPPORTED_TYPE", "failed to decode video frame", err.Error(), http.StatusUnsupportedMediaType)
 	}
 
-	return thumbFile, thumbInfo, nil
+	return s.scaleAndSaveThumbnail(frame, frame.Bounds(), 1, thumbPath, size, format, info)
 }
 
-// scaleAndSaveThumbnail scales a decoded image to the given size and saves it
-// as a JPEG file at thumbPath. It returns the opened thumbnail file and its info.
-func (s *FileService) scaleAndSaveThumbnail(src image.Image, bounds image.Rectangle, thumbPath string, size int, info os.FileInfo) (*os.File, os.FileInfo, error) {
+// scaleAndSaveThumbnail scales a decoded image to the given size, applies the
+// EXIF orientation and saves it in format at thumbPath. It returns the opened
+// thumbnail file and its info.
+func (s *FileService) scaleAndSaveThumbnail(src image.Image, bounds image.Rectangle, orientation int, thumbPath string, size int, format ThumbnailFormat, info os.FileInfo) (*os.File, os.FileInfo, error) {
 	width := bounds.Dx()
 	height := bounds.Dy()
 
@@ -341,24 +377,37 @@ func (s *FileService) scaleAndSaveThumbnail(src image.Image, bounds image.Rectan
 		targetHeight = 1
 	}
 
-	dst := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
-	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	dst := image.NewNRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	thumbnailScaler.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	dst = util.ApplyOrientation(dst, orientation)
 
-	thumbWriter, err := os.OpenFile(thumbPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	// Write to a temporary file and rename it into place, so a request and
+	// the pre-generation workers never see a half written thumbnail.
+	thumbWriter, err := os.CreateTemp(filepath.Dir(thumbPath), "tmp-*"+format.Extension)
 	if err != nil {
 		return nil, nil, err
 	}
+	tmpPath := thumbWriter.Name()
 
-	encodeErr := jpeg.Encode(thumbWriter, dst, &jpeg.Options{Quality: 95})
+	encodeErr := encodeThumbnail(thumbWriter, dst, format)
 	closeErr := thumbWriter.Close()
+	if encodeErr == nil {
+		encodeErr = closeErr
+	}
+	if encodeErr == nil {
+		// The modification time records the last use, for the cache's LRU
+		// eviction; it never goes below the source's so freshness checks hold.
+		stamp := time.Now().UTC()
+		if info.ModTime().After(stamp) {
+			stamp = info.ModTime()
+		}
+		_ = os.Chtimes(tmpPath, stamp, stamp)
+		encodeErr = os.Rename(tmpPath, thumbPath)
+	}
 	if encodeErr != nil {
+		_ = os.Remove(tmpPath)
 		return nil, nil, encodeErr
 	}
-	if closeErr != nil {
-		return nil, nil, closeErr
-	}
-
-	_ = os.Chtimes(thumbPath, time.Now().UTC(), info.ModTime())
 
 	thumbFile, err := os.Open(thumbPath)
 	if err != nil {
@@ -370,16 +419,11 @@ func (s *FileService) scaleAndSaveThumbnail(src image.Image, bounds image.Rectan
 		_ = thumbFile.Close()
 		return nil, nil, err
 	}
+	s.thumbnails.stored(thumbPath, thumbInfo)
 
 	return thumbFile, thumbInfo, nil
 }
 
-func (s *FileService) thumbnailPath(resolvedPath string, size int) string {
-	hash := sha256.Sum256([]byte(resolvedPath + "|" + strconv.Itoa(size)))
-	name := hex.EncodeToString(hash[:]) + ".jpg"
-	return filepath.Join(s.thumbnailRoot, name)
-}
-
 func (s *FileService) GetDirectoryForArchive(path string) (string, string, error) {
 	resolved, err := s.store.Resolve(path)
 	if err != nil {
@@ -406,7 +450,7 @@ func (s *FileService) GetDirectoryForArchive(path string) (string, string, error
 	return resolved, name, nil
 }
 
-func (s *FileService) GetInfo(path string) (model.FileItem, error) {
+func (s *FileService) GetInfo(ctx context.Context, path string) (model.FileItem, error) {
 	resolved, err := s.store.Resolve(path)
 	if err != nil {
 		return model.FileItem{}, err
@@ -436,7 +480,7 @@ func (s *FileService) GetInfo(path string) (model.FileItem, error) {
 			count := len(children)
 			item.ItemCount = &count
 		}
-		return item, nil
+		return s.annotate(ctx, item)
 	}
 
 	item.Type = "file"
@@ -463,6 +507,7 @@ func (s *FileService) GetInfo(path string) (model.FileItem, error) {
 			if isImage {
 				item.IsImage = true
 				item.PreviewURL = "/api/v1/files/preview?path=" + url.QueryEscape(item.Path)
+				item.ImageMetadata = s.images.Read(resolved, info.Size(), info.ModTime())
 				thumbnailSupported := util.IsThumbnailMIME(mimeType)
 				if !thumbnailSupported && strings.EqualFold(mimeType, "application/octet-stream") {
 					thumbnailSupported = util.IsThumbnailExtension(item.Extension)
@@ -479,7 +524,19 @@ func (s *FileService) GetInfo(path string) (model.FileItem, error) {
 		}
 	}
 
-	return item, nil
+	return s.annotate(ctx, item)
+}
+
+// annotate adds the tags and custom metadata of item.
+func (s *FileService) annotate(ctx context.Context, item model.FileItem) (model.FileItem, error) {
+	items := []model.FileItem{item}
+	if err := s.tags.Annotate(ctx, items); err != nil {
+		return model.FileItem{}, err
+	}
+	if err := s.metadata.Annotate(ctx, &items[0]); err != nil {
+		return model.FileItem{}, err
+	}
+	return items[0], nil
 }
 
 func (s *FileService) isAllowedMIME(mimeType string) bool {
@@ -1,17 +1,25 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
 
 	"go-file-explorer/internal/event"
+	"go-file-explorer/internal/model"
 	"go-file-explorer/internal/storage"
 )
 
@@ -43,7 +51,7 @@ func TestFileService_Upload(t *testing.T) {
 		mockStore.On("OpenForWrite", "/docs/test.txt").Return(mockFile, nil)
 
 		// Execute
-		item, err := svc.Upload(context.Background(), destination, filename, "rename", reader)
+		item, err := svc.Upload(context.Background(), destination, filename, "rename", reader, model.AuditActor{})
 
 		// Assert
 		assert.NoError(t, err)
@@ -73,7 +81,7 @@ func TestFileService_Upload(t *testing.T) {
 		mockStore.On("Stat", "/evil.exe").Return(nil, os.ErrNotExist)
 
 		// Execute
-		_, err := svc.Upload(context.Background(), destination, filename, "rename", reader)
+		_, err := svc.Upload(context.Background(), destination, filename, "rename", reader, model.AuditActor{})
 
 		// Assert
 		assert.Error(t, err)
@@ -101,7 +109,7 @@ func TestFileService_Upload(t *testing.T) {
 		mockStore.On("Stat", "/exists.txt").Return(mockInfo, nil)
 
 		// Execute
-		_, err := svc.Upload(context.Background(), destination, filename, "skip", reader)
+		_, err := svc.Upload(context.Background(), destination, filename, "skip", reader, model.AuditActor{})
 
 		// Assert
 		assert.Error(t, err)
@@ -111,6 +119,54 @@ func TestFileService_Upload(t *testing.T) {
 	})
 }
 
+func TestFileService_GetThumbnail(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.New(root)
+	require.NoError(t, err)
+	svc := NewFileService(store, nil, filepath.Join(t.TempDir(), "thumbnails"), nil)
+
+	// A landscape photo, red on the left half, taken with the camera turned
+	// clockwise: EXIF orientation 6 asks viewers to rotate it upright.
+	photo := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := range 200 {
+		for x := range 400 {
+			c := color.RGBA{B: 255, A: 255}
+			if x < 200 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			photo.Set(x, y, c)
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, photo, &jpeg.Options{Quality: 95}))
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	data := append([]byte{0xff, 0xd8, 0xff, 0xe1, 0x00, byte(len(exif) + 2)}, exif...)
+	data = append(data, encoded.Bytes()[2:]...)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "photo.jpg"), data, 0o644))
+
+	file, _, err := svc.GetThumbnail("/photo.jpg", 100, ThumbnailWebP)
+	require.NoError(t, err)
+	defer file.Close()
+	require.Equal(t, ".webp", filepath.Ext(file.Name()))
+
+	thumb, err := webp.Decode(file)
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 50, 100), thumb.Bounds())
+
+	// Rotated clockwise, the red half ends up on top.
+	top := color.NRGBAModel.Convert(thumb.At(25, 10)).(color.NRGBA)
+	bottom := color.NRGBAModel.Convert(thumb.At(25, 90)).(color.NRGBA)
+	require.Greater(t, top.R, uint8(200))
+	require.Greater(t, bottom.B, uint8(200))
+
+	jpegFile, _, err := svc.GetThumbnail("/photo.jpg", 100, ThumbnailJPEG)
+	require.NoError(t, err)
+	defer jpegFile.Close()
+	require.NotEqual(t, file.Name(), jpegFile.Name())
+	_, err = jpeg.Decode(jpegFile)
+	require.NoError(t, err)
+}
+
 // mockFileInfo implements fs.FileInfo
 type mockFileInfo struct {
 	name string
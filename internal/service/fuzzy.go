@@ -0,0 +1,330 @@
+package service
+
+import (
+	"html"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"go-file-explorer/internal/model"
+)
+
+// Score tiers of a fuzzy term match. A term that appears whole scores by
+// where it appears; otherwise its letters must appear in order, or the term
+// must be within a few typos of a word of the name.
+const (
+	fuzzyExactScore     = 1000
+	fuzzyStemScore      = 800
+	fuzzyPrefixScore    = 400
+	fuzzyBoundaryScore  = 200
+	fuzzySubstringScore = 100
+	fuzzyTypoScore      = 60
+
+	fuzzyCharScore        = 16
+	fuzzyBoundaryBonus    = 10
+	fuzzyConsecutiveBonus = 8
+	fuzzyGapStartPenalty  = 3
+	fuzzyGapPenalty       = 1
+)
+
+// fuzzyQuery matches names, or paths for terms that contain a slash, the way
+// editor file pickers do and ranks the matches.
+type fuzzyQuery struct {
+	terms []string
+}
+
+func newFuzzyQuery(terms []string) fuzzyQuery {
+	query := fuzzyQuery{}
+	for _, term := range terms {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			query.terms = append(query.terms, term)
+		}
+	}
+	return query
+}
+
+// rank scores entry against every term and returns false when a term does
+// not match. The score adds up the term scores, favours short names and
+// shallow paths and boosts entries modified recently. Snippet is set to the
+// name, or the path, with the matched letters in <mark> tags.
+func (q fuzzyQuery) rank(entry model.IndexEntry, now time.Time) (model.IndexEntry, bool) {
+	nameMarks := make([]bool, len([]rune(entry.Name)))
+	pathMarks := make([]bool, len([]rune(entry.Path)))
+	usesPath := false
+
+	total := 0
+	for _, term := range q.terms {
+		target, marks := entry.Name, nameMarks
+		if strings.Contains(term, "/") {
+			target, marks, usesPath = entry.Path, pathMarks, true
+		}
+		score, positions, ok := fuzzyMatch(term, target)
+		if !ok {
+			return entry, false
+		}
+		total += score
+		for _, position := range positions {
+			marks[position] = true
+		}
+	}
+
+	depth := strings.Count(strings.Trim(entry.Path, "/"), "/")
+	total -= min(depth*8, 80)
+	total -= min(max(len([]rune(entry.Name))-len([]rune(strings.Join(q.terms, ""))), 0)/4, 20)
+	if age := now.Sub(entry.ModifiedAt); age >= 0 {
+		switch {
+		case age < 24*time.Hour:
+			total += 40
+		case age < 7*24*time.Hour:
+			total += 25
+		case age < 30*24*time.Hour:
+			total += 10
+		}
+	}
+
+	entry.Score = total
+	if usesPath {
+		entry.Snippet = markRunes(entry.Path, pathMarks)
+	} else {
+		entry.Snippet = markRunes(entry.Name, nameMarks)
+	}
+	return entry, true
+}
+
+// fuzzyMatch scores term, in lower case, against target and returns the rune
+// positions of target it matched. Typo matches have no positions.
+func fuzzyMatch(term string, target string) (int, []int, bool) {
+	// Lowering rune by rune keeps positions valid in the original.
+	original := []rune(target)
+	lower := []rune(strings.Map(unicode.ToLower, target))
+	pattern := []rune(term)
+	if len(pattern) == 0 || len(pattern) > len(lower)+2 {
+		return 0, nil, false
+	}
+
+	lowerTarget := string(lower)
+	stem := strings.TrimSuffix(lowerTarget, path.Ext(lowerTarget))
+	if index := strings.Index(lowerTarget, term); index >= 0 {
+		start := len([]rune(lowerTarget[:index]))
+		positions := make([]int, len(pattern))
+		for i := range positions {
+			positions[i] = start + i
+		}
+
+		tier := fuzzySubstringScore
+		switch {
+		case lowerTarget == term:
+			tier = fuzzyExactScore
+		case stem == term:
+			tier = fuzzyStemScore
+		case start == 0:
+			tier = fuzzyPrefixScore
+		case isWordBoundary(original, start):
+			tier = fuzzyBoundaryScore
+		}
+		return tier + alignmentScore(original, positions), positions, true
+	}
+
+	if isSubsequence(lower, pattern) {
+		score, positions := alignSubsequence(original, lower, pattern)
+		return score, positions, true
+	}
+
+	maxTypos := 2
+	switch {
+	case len(pattern) < 3:
+		return 0, nil, false
+	case len(pattern) <= 5:
+		maxTypos = 1
+	}
+	distance := maxTypos + 1
+	candidates := append(nameWords(stem), stem)
+	if len([]rune(stem)) > len(pattern) {
+		candidates = append(candidates, string([]rune(stem)[:len(pattern)]))
+	}
+	for _, candidate := range candidates {
+		distance = min(distance, editDistance(pattern, []rune(candidate)))
+	}
+	if distance > maxTypos {
+		return 0, nil, false
+	}
+	return fuzzyTypoScore - 20*distance, nil, true
+}
+
+func isSubsequence(target []rune, pattern []rune) bool {
+	j := 0
+	for i := 0; i < len(target) && j < len(pattern); i++ {
+		if target[i] == pattern[j] {
+			j++
+		}
+	}
+	return j == len(pattern)
+}
+
+// alignSubsequence finds pattern in target as a subsequence and returns the
+// positions with the best score: matched letters earn points, more when they
+// start a word or follow the previous letter, and gaps between them cost
+// points by their length. It returns nil when pattern is not a subsequence.
+func alignSubsequence(original []rune, target []rune, pattern []rune) (int, []int) {
+	const unset = -1 << 30
+	scores := make([][]int, len(pattern))
+	from := make([][]int, len(pattern))
+	for i := range pattern {
+		scores[i] = make([]int, len(target))
+		from[i] = make([]int, len(target))
+		// best tracks the best previous letter at least two runes back,
+		// offset by its position so the gap penalty is linear in j.
+		best, bestAt := unset, -1
+		for j := range target {
+			scores[i][j] = unset
+			if i > 0 && j >= 2 && scores[i-1][j-2] != unset && scores[i-1][j-2]+fuzzyGapPenalty*(j-2) > best {
+				best, bestAt = scores[i-1][j-2]+fuzzyGapPenalty*(j-2), j-2
+			}
+			if target[j] != pattern[i] {
+				continue
+			}
+
+			gain := fuzzyCharScore
+			if isWordBoundary(original, j) {
+				if i == 0 {
+					gain += 2 * fuzzyBoundaryBonus
+				} else {
+					gain += fuzzyBoundaryBonus
+				}
+			}
+			if i == 0 {
+				scores[i][j], from[i][j] = gain, -1
+				continue
+			}
+			if j >= 1 && scores[i-1][j-1] != unset {
+				scores[i][j], from[i][j] = scores[i-1][j-1]+fuzzyConsecutiveBonus+gain, j-1
+			}
+			if best != unset {
+				gapped := best - (fuzzyGapStartPenalty - 2*fuzzyGapPenalty) - fuzzyGapPenalty*j + gain
+				if gapped > scores[i][j] {
+					scores[i][j], from[i][j] = gapped, bestAt
+				}
+			}
+		}
+	}
+
+	last := len(pattern) - 1
+	end := -1
+	for j := range target {
+		if scores[last][j] != unset && (end < 0 || scores[last][j] > scores[last][end]) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return 0, nil
+	}
+
+	positions := make([]int, len(pattern))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return scores[last][end], positions
+}
+
+// alignmentScore scores matched letters at positions the way
+// alignSubsequence does.
+func alignmentScore(target []rune, positions []int) int {
+	score := 0
+	for i, position := range positions {
+		score += fuzzyCharScore
+		if isWordBoundary(target, position) {
+			bonus := fuzzyBoundaryBonus
+			if i == 0 {
+				bonus *= 2
+			}
+			score += bonus
+		}
+		if i == 0 {
+			continue
+		}
+		if gap := position - positions[i-1] - 1; gap == 0 {
+			score += fuzzyConsecutiveBonus
+		} else {
+			score -= fuzzyGapStartPenalty + fuzzyGapPenalty*(gap-1)
+		}
+	}
+	return score
+}
+
+// isWordBoundary reports whether the rune at index starts a word: it is the
+// first rune, follows a separator, or starts a camelCase hump or a number.
+func isWordBoundary(target []rune, index int) bool {
+	if index == 0 {
+		return true
+	}
+	previous, current := target[index-1], target[index]
+	switch {
+	case strings.ContainsRune("/\\-_. ", previous):
+		return true
+	case unicode.IsLower(previous) && unicode.IsUpper(current):
+		return true
+	case !unicode.IsDigit(previous) && unicode.IsDigit(current):
+		return true
+	}
+	return false
+}
+
+func nameWords(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return strings.ContainsRune("/\\-_. ", r)
+	})
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and swaps of adjacent runes.
+func editDistance(a []rune, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+// markRunes HTML-escapes text and wraps each run of marked runes in <mark>
+// tags.
+func markRunes(text string, marks []bool) string {
+	if !slices.Contains(marks, true) {
+		return html.EscapeString(text)
+	}
+
+	var out strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marks[j] == marks[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if marks[i] {
+			segment = "<mark>" + segment + "</mark>"
+		}
+		out.WriteString(segment)
+		i = j
+	}
+	return out.String()
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+)
+
+func TestFuzzyMatchTiers(t *testing.T) {
+	score := func(term string, name string) int {
+		t.Helper()
+		value, _, ok := fuzzyMatch(term, name)
+		require.True(t, ok, "%q should match %q", term, name)
+		return value
+	}
+
+	exact := score("report.pdf", "report.pdf")
+	stem := score("report", "report.pdf")
+	prefix := score("rep", "report.pdf")
+	boundary := score("rep", "q1_report.pdf")
+	substring := score("port", "report.pdf")
+	subsequence := score("rpt", "report.pdf")
+	typo := score("reprot", "report.pdf")
+
+	require.Greater(t, exact, stem)
+	require.Greater(t, stem, prefix)
+	require.Greater(t, prefix, boundary)
+	require.Greater(t, boundary, substring)
+	require.Greater(t, substring, subsequence)
+	require.Greater(t, subsequence, typo)
+	require.Greater(t, typo, 0)
+
+	_, _, ok := fuzzyMatch("xyz", "report.pdf")
+	require.False(t, ok)
+	_, _, ok = fuzzyMatch("rp", "paper.txt")
+	require.False(t, ok, "short terms allow no typos")
+}
+
+func TestFuzzyMatchPrefersWordBoundaries(t *testing.T) {
+	boundaries, positions, ok := fuzzyMatch("qr", "QuarterlyReport.xlsx")
+	require.True(t, ok)
+	require.Equal(t, []int{0, 9}, positions)
+
+	scattered, _, ok := fuzzyMatch("qr", "aqueous_ember.txt")
+	require.True(t, ok)
+	require.Greater(t, boundaries, scattered)
+
+	// Letters next to each other beat a scattered match.
+	_, positions, ok = fuzzyMatch("ab", "a_xab")
+	require.True(t, ok)
+	require.Equal(t, []int{3, 4}, positions)
+
+	score, positions, ok := fuzzyMatch("fbr", "foo_bar.go")
+	require.True(t, ok)
+	require.Equal(t, []int{0, 4, 6}, positions)
+	require.Equal(t, alignmentScore([]rune("foo_bar.go"), positions), score)
+}
+
+func TestEditDistance(t *testing.T) {
+	require.Equal(t, 0, editDistance([]rune("report"), []rune("report")))
+	require.Equal(t, 1, editDistance([]rune("reprot"), []rune("report")))
+	require.Equal(t, 1, editDistance([]rune("repot"), []rune("report")))
+	require.Equal(t, 4, editDistance([]rune("raport"), []rune("rep")))
+}
+
+func TestFuzzyQueryRank(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	query := newFuzzyQuery([]string{"Rep"})
+
+	shallow, ok := query.rank(model.IndexEntry{Path: "/report.pdf", Name: "report.pdf", ModifiedAt: now.AddDate(-1, 0, 0)}, now)
+	require.True(t, ok)
+	require.Equal(t, "<mark>rep</mark>ort.pdf", shallow.Snippet)
+
+	deep, ok := query.rank(model.IndexEntry{Path: "/a/b/c/report.pdf", Name: "report.pdf", ModifiedAt: now.AddDate(-1, 0, 0)}, now)
+	require.True(t, ok)
+	require.Greater(t, shallow.Score, deep.Score)
+
+	recent, ok := query.rank(model.IndexEntry{Path: "/a/b/c/report.pdf", Name: "report.pdf", ModifiedAt: now.Add(-time.Hour)}, now)
+	require.True(t, ok)
+	require.Greater(t, recent.Score, deep.Score)
+
+	_, ok = query.rank(model.IndexEntry{Path: "/notes.txt", Name: "notes.txt"}, now)
+	require.False(t, ok)
+
+	pathQuery := newFuzzyQuery([]string{"docs/rep"})
+	match, ok := pathQuery.rank(model.IndexEntry{Path: "/docs/<b>/report.pdf", Name: "report.pdf"}, now)
+	require.True(t, ok)
+	require.Equal(t, "/<mark>docs</mark>/&lt;b&gt;<mark>/rep</mark>ort.pdf", match.Snippet)
+}
+
+func TestLessSearchEntryRelevance(t *testing.T) {
+	best := model.IndexEntry{Path: "/b", Name: "b", Score: 900}
+	worse := model.IndexEntry{Path: "/a", Name: "a", Score: 100}
+	require.True(t, lessSearchEntry("relevance", best, worse))
+	require.True(t, lessSearchEntry("-relevance", best, worse))
+	require.False(t, lessSearchEntry("relevance", worse, best))
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/util"
+)
+
+const defaultImageMetadataEntries = 10000
+
+// ImageMetadataService reads the dimensions, EXIF data and PNG text of image
+// files. Results, including files that turn out not to be readable images,
+// are cached by path, size and modification time so a changed file is read
+// again. A nil ImageMetadataService reads nothing.
+type ImageMetadataService struct {
+	mu         sync.Mutex
+	entries    map[string]*model.ImageMetadata
+	maxEntries int
+}
+
+func NewImageMetadataService(maxEntries int) *ImageMetadataService {
+	if maxEntries <= 0 {
+		maxEntries = defaultImageMetadataEntries
+	}
+	return &ImageMetadataService{entries: map[string]*model.ImageMetadata{}, maxEntries: maxEntries}
+}
+
+// Read returns the metadata of the image file at resolved, or nil when it
+// cannot be read as an image. The result is shared with the cache and must
+// not be modified.
+func (s *ImageMetadataService) Read(resolved string, size int64, modTime time.Time) *model.ImageMetadata {
+	if s == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s\x00%d\x00%d", resolved, size, modTime.UnixNano())
+
+	s.mu.Lock()
+	metadata, ok := s.entries[key]
+	s.mu.Unlock()
+	if ok {
+		return metadata
+	}
+
+	metadata = readImageMetadata(resolved, size)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) >= s.maxEntries {
+		// Dropping arbitrary entries is fine: a miss only costs a re-read.
+		for key := range s.entries {
+			delete(s.entries, key)
+			if len(s.entries) < s.maxEntries/2 {
+				break
+			}
+		}
+	}
+	s.entries[key] = metadata
+	return metadata
+}
+
+// Dimensions returns only the format and dimensions of the image file at
+// resolved, as directory listings show them.
+func (s *ImageMetadataService) Dimensions(resolved string, size int64, modTime time.Time) *model.ImageMetadata {
+	metadata := s.Read(resolved, size, modTime)
+	if metadata == nil {
+		return nil
+	}
+	return &model.ImageMetadata{Format: metadata.Format, Width: metadata.Width, Height: metadata.Height}
+}
+
+func readImageMetadata(resolved string, size int64) *model.ImageMetadata {
+	file, err := os.Open(resolved)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	read, err := util.ReadImageMetadata(file, size)
+	if err != nil {
+		return nil
+	}
+
+	metadata := &model.ImageMetadata{
+		Format: read.Format,
+		Width:  read.Width,
+		Height: read.Height,
+		Text:   read.Text,
+	}
+	if exif := read.EXIF; exif != nil {
+		metadata.Orientation = exif.Orientation
+		metadata.CameraMake = exif.Make
+		metadata.CameraModel = exif.Model
+		metadata.LensModel = exif.LensModel
+		metadata.Software = exif.Software
+		metadata.ISO = exif.ISO
+		metadata.ExposureTime = formatExposureTime(exif.ExposureTime)
+		metadata.FNumber = exif.FNumber
+		metadata.FocalLength = exif.FocalLength
+		if !exif.CapturedAt.IsZero() {
+			if exif.CapturedAtOffset {
+				metadata.CapturedAt = exif.CapturedAt.Format(time.RFC3339)
+			} else {
+				metadata.CapturedAt = exif.CapturedAt.Format("2006-01-02T15:04:05")
+			}
+		}
+		if exif.GPS != nil {
+			metadata.GPS = &model.GPSPosition{
+				Latitude:  exif.GPS.Latitude,
+				Longitude: exif.GPS.Longitude,
+				Altitude:  exif.GPS.Altitude,
+			}
+		}
+	}
+	return metadata
+}
+
+// formatExposureTime writes exposures below one second the way cameras show
+// them, as "1/250", and longer ones in seconds.
+func formatExposureTime(seconds float64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	if seconds < 1 {
+		denominator := math.Round(1 / seconds)
+		if math.Abs(1/denominator-seconds) <= seconds*0.01 {
+			return "1/" + strconv.FormatFloat(denominator, 'f', 0, 64)
+		}
+	}
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/storage"
+)
+
+func TestImageMetadataInFileInfoAndListings(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.New(root)
+	require.NoError(t, err)
+	writeTestPNG(t, filepath.Join(root, "photos", "a.png"))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "photos", "broken.png"), []byte("not a png"), 0o644))
+
+	images := NewImageMetadataService(0)
+	files := NewFileService(store, nil, filepath.Join(t.TempDir(), "thumbnails"), nil)
+	files.UseImageMetadata(images)
+	directories := NewDirectoryService(store, nil)
+	directories.UseImageMetadata(images)
+
+	item, err := files.GetInfo(context.Background(), "/photos/a.png")
+	require.NoError(t, err)
+	require.Equal(t, &model.ImageMetadata{Format: "png", Width: 64, Height: 48}, item.ImageMetadata)
+
+	data, _, err := directories.List(context.Background(), "/photos", 1, 50, "name", "asc", "", "", false)
+	require.NoError(t, err)
+	require.Nil(t, data.Items[0].ImageMetadata)
+
+	data, _, err = directories.List(context.Background(), "/photos", 1, 50, "name", "asc", "", "", true)
+	require.NoError(t, err)
+	require.Equal(t, "a.png", data.Items[0].Name)
+	require.Equal(t, &model.ImageMetadata{Format: "png", Width: 64, Height: 48}, data.Items[0].ImageMetadata)
+	require.Equal(t, "broken.png", data.Items[1].Name)
+	require.Nil(t, data.Items[1].ImageMetadata)
+}
+
+func TestImageMetadataServiceRereadsChangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.png")
+	writeTestPNG(t, path)
+	images := NewImageMetadataService(1)
+
+	read := func() *model.ImageMetadata {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return images.Read(path, info.Size(), info.ModTime())
+	}
+	first := read()
+	require.Equal(t, 64, first.Width)
+	require.Same(t, first, read())
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, image.NewGray(image.Rect(0, 0, 10, 20))))
+	require.NoError(t, file.Close())
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	second := read()
+	require.Equal(t, 10, second.Width)
+	require.Equal(t, 20, second.Height)
+	require.Len(t, images.entries, 1)
+
+	var nilService *ImageMetadataService
+	require.Nil(t, nilService.Read(path, 0, time.Time{}))
+}
+
+func TestFormatExposureTime(t *testing.T) {
+	cases := map[float64]string{
+		0:         "",
+		1.0 / 250: "1/250",
+		1.0 / 3:   "1/3",
+		0.4:       "0.4",
+		1:         "1",
+		2.5:       "2.5",
+	}
+	for seconds, want := range cases {
+		require.Equal(t, want, formatExposureTime(seconds), "%v", seconds)
+	}
+}
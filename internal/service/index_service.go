@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-file-explorer/internal/event"
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/repository"
+	"go-file-explorer/internal/storage"
+	"go-file-explorer/internal/util"
+)
+
+const (
+	defaultIndexInterval       = time.Hour
+	defaultIndexContentMaxSize = 20 * 1024 * 1024
+	indexBatchSize             = 500
+)
+
+// IndexOptions configures the search index. Files larger than ContentMaxSize
+// are indexed by name only.
+type IndexOptions struct {
+	Interval       time.Duration
+	ContentMaxSize int64
+}
+
+// IndexService keeps the file_index table in step with the storage tree. File
+// events update the affected paths as they happen; a reconciliation crawl at
+// start, every interval and on Reindex catches changes made outside the API
+// and events the bus dropped. The text of supported documents is extracted
+// for content search whenever their size or modification time changes.
+type IndexService struct {
+	store          storage.Storage
+	repo           *repository.IndexRepository
+	bus            event.Bus
+	interval       time.Duration
+	contentMaxSize int64
+	trigger        chan struct{}
+
+	mu     sync.Mutex
+	status model.IndexStatus
+}
+
+func NewIndexService(store storage.Storage, repo *repository.IndexRepository, bus event.Bus, opts IndexOptions) *IndexService {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultIndexInterval
+	}
+	if opts.ContentMaxSize <= 0 {
+		opts.ContentMaxSize = defaultIndexContentMaxSize
+	}
+
+	return &IndexService{
+		store:          store,
+		repo:           repo,
+		bus:            bus,
+		interval:       opts.Interval,
+		contentMaxSize: opts.ContentMaxSize,
+		trigger:        make(chan struct{}, 1),
+	}
+}
+
+// Run applies file events and runs reconciliation crawls until ctx is
+// cancelled.
+func (s *IndexService) Run(ctx context.Context) {
+	events, unsubscribe := s.bus.Subscribe()
+	defer unsubscribe()
+
+	go s.reconcileLoop(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			s.apply(ctx, e)
+		}
+	}
+}
+
+// Ready reports whether a full crawl has completed, so the index can answer
+// searches for the whole tree.
+func (s *IndexService) Ready() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status.Ready
+}
+
+// Reindex queues a reconciliation crawl and returns the current status.
+func (s *IndexService) Reindex(ctx context.Context) (model.IndexStatus, error) {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+
+	status, err := s.Status(ctx)
+	if err != nil {
+		return model.IndexStatus{}, err
+	}
+	status.Running = true
+	return status, nil
+}
+
+func (s *IndexService) Status(ctx context.Context) (model.IndexStatus, error) {
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		return model.IndexStatus{}, err
+	}
+
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+	status.Entries = count
+	return status, nil
+}
+
+// Search returns one page of the entries matching filter and whether more
+// follow it.
+func (s *IndexService) Search(ctx context.Context, filter model.SearchFilter) ([]model.IndexEntry, bool, error) {
+	return s.repo.Search(ctx, filter)
+}
+
+func (s *IndexService) FuzzyCandidates(ctx context.Context, filter model.SearchFilter, limit int) ([]model.IndexEntry, error) {
+	return s.repo.FuzzyCandidates(ctx, filter, limit)
+}
+
+func (s *IndexService) CountMatches(ctx context.Context, filter model.SearchFilter) (int, error) {
+	return s.repo.CountMatches(ctx, filter)
+}
+
+func (s *IndexService) reconcileLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.reconcile(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.trigger:
+		}
+		s.reconcile(ctx)
+	}
+}
+
+// reconcile crawls the whole tree, then removes the entries the crawl did not
+// see. Entries written by events during the crawl are newer than the crawl
+// start and survive the prune.
+func (s *IndexService) reconcile(ctx context.Context) {
+	started := time.Now().UTC()
+	s.mu.Lock()
+	s.status.Running = true
+	s.status.LastStartedAt = started.Format(time.RFC3339Nano)
+	s.mu.Unlock()
+
+	indexed, err := s.indexTree(ctx, "/", started)
+	var pruned int64
+	if err == nil {
+		pruned, err = s.repo.Prune(ctx, "/", started)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Running = false
+	if err != nil {
+		s.status.LastError = err.Error()
+		if ctx.Err() == nil {
+			slog.Warn("search index reconciliation failed", "error", err)
+		}
+		return
+	}
+	s.status.Ready = true
+	s.status.LastError = ""
+	s.status.LastFinishedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	slog.Info("search index reconciled", "indexed", indexed, "pruned", pruned, "duration", time.Since(started))
+}
+
+func (s *IndexService) apply(ctx context.Context, e event.Event) {
+	changes := indexEventChanges(e)
+	for _, apiPath := range changes.removed {
+		if err := s.repo.DeleteTree(ctx, normalizeAPIPath(apiPath)); err != nil {
+			slog.Warn("search index update failed", "event_type", e.Type, "path", apiPath, "error", err)
+		}
+	}
+	if changes.movedFrom != "" {
+		// Moving the rows keeps their owner, creation time and extracted
+		// text; the refresh below then picks up anything else that changed.
+		if err := s.repo.MoveTree(ctx, normalizeAPIPath(changes.movedFrom), normalizeAPIPath(changes.refreshed[0])); err != nil {
+			slog.Warn("search index update failed", "event_type", e.Type, "path", changes.movedFrom, "error", err)
+		}
+	}
+	for _, apiPath := range changes.refreshed {
+		if err := s.refresh(ctx, apiPath); err != nil {
+			slog.Warn("search index update failed", "event_type", e.Type, "path", apiPath, "error", err)
+			continue
+		}
+		if changes.owner != "" {
+			if err := s.repo.SetOwner(ctx, normalizeAPIPath(apiPath), changes.owner); err != nil {
+				slog.Warn("search index update failed", "event_type", e.Type, "path", apiPath, "error", err)
+			}
+		}
+	}
+}
+
+// indexChanges describes how an event changed the tree: paths that were
+// removed, a path that was moved to the first refreshed path, paths whose
+// entries must be re-read from disk and the user who uploaded them.
+type indexChanges struct {
+	removed   []string
+	movedFrom string
+	refreshed []string
+	owner     string
+}
+
+func indexEventChanges(e event.Event) indexChanges {
+	switch payload := e.Payload.(type) {
+	case model.UploadItem:
+		return indexChanges{refreshed: []string{payload.Path}, owner: payload.UploadedBy}
+	case model.DirectoryCreateData:
+		return indexChanges{refreshed: []string{payload.Path}}
+	case model.RenameResponse:
+		return indexChanges{movedFrom: payload.OldPath, refreshed: []string{payload.NewPath}}
+	case model.MoveCopyResult:
+		if e.Type == event.TypeFileMoved {
+			return indexChanges{movedFrom: payload.From, refreshed: []string{payload.To}}
+		}
+		return indexChanges{refreshed: []string{payload.To}}
+	case map[string]string:
+		if e.Type == event.TypeFileDeleted && payload["path"] != "" {
+			return indexChanges{removed: []string{payload["path"]}}
+		}
+		if e.Type == event.TypeFileRestored && payload["path"] != "" {
+			return indexChanges{refreshed: []string{payload["path"]}}
+		}
+	case model.CompressResponse:
+		return indexChanges{refreshed: []string{payload.Path}}
+	case model.DecompressResponse:
+		return indexChanges{refreshed: []string{payload.Destination}}
+	case model.FileTags:
+		return indexChanges{refreshed: []string{payload.Path}}
+	case model.FileMetadata:
+		return indexChanges{refreshed: []string{payload.Path}}
+	}
+	return indexChanges{}
+}
+
+// refresh re-reads apiPath from disk: a missing path is removed from the
+// index, a file is upserted and a directory is re-crawled.
+func (s *IndexService) refresh(ctx context.Context, apiPath string) error {
+	apiPath = normalizeAPIPath(apiPath)
+	if isInternalStoragePath(apiPath) {
+		return nil
+	}
+
+	resolved, err := s.store.Resolve(apiPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(resolved)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.Mode()&os.ModeSymlink != 0) {
+		return s.repo.DeleteTree(ctx, apiPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	stamp := time.Now().UTC()
+	if !info.IsDir() {
+		entries := []model.IndexEntry{indexEntry(apiPath, info)}
+		if err := s.repo.Upsert(ctx, entries, stamp); err != nil {
+			return err
+		}
+		return s.indexContent(ctx, entries)
+	}
+
+	if _, err := s.indexTree(ctx, apiPath, stamp); err != nil {
+		return err
+	}
+	_, err = s.repo.Prune(ctx, apiPath, stamp)
+	return err
+}
+
+// indexTree upserts every entry at and below apiPath, except the storage root
+// itself, and returns how many entries were written.
+func (s *IndexService) indexTree(ctx context.Context, apiPath string, stamp time.Time) (int, error) {
+	resolvedRoot, err := s.store.Resolve(apiPath)
+	if err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	batch := make([]model.IndexEntry, 0, indexBatchSize)
+	flush := func() error {
+		if err := s.repo.Upsert(ctx, batch, stamp); err != nil {
+			return err
+		}
+		if err := s.indexContent(ctx, batch); err != nil {
+			return err
+		}
+		indexed += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	walkErr := filepath.WalkDir(resolvedRoot, func(current string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if current == resolvedRoot {
+				return walkErr
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if entry.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if current != resolvedRoot && isInternalStorageEntry(entry.Name()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.store.RootAbs(), current)
+		if err != nil {
+			return nil
+		}
+		entryPath := normalizeAPIPath(filepath.ToSlash(rel))
+		if entryPath == "/" {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		batch = append(batch, indexEntry(entryPath, info))
+		if len(batch) >= indexBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return indexed, walkErr
+	}
+
+	if err := flush(); err != nil {
+		return indexed, err
+	}
+	return indexed, nil
+}
+
+// indexContent extracts and stores the text of the supported files among
+// entries whose stored text is missing or stale. Files that fail to extract
+// or exceed the size limit get empty text, so they are not retried until they
+// change.
+func (s *IndexService) indexContent(ctx context.Context, entries []model.IndexEntry) error {
+	candidates := make([]model.IndexEntry, 0)
+	paths := make([]string, 0)
+	for _, entry := range entries {
+		if entry.Type == "file" && util.IsTextExtractable(entry.Extension) {
+			candidates = append(candidates, entry)
+			paths = append(paths, entry.Path)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	stamps, err := s.repo.ContentStamps(ctx, paths)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range candidates {
+		current := model.ContentStamp{Size: entry.Size, ModifiedAt: entry.ModifiedAt}
+		if stored, ok := stamps[entry.Path]; ok && stored.Size == current.Size && stored.ModifiedAt.Equal(current.ModifiedAt) {
+			continue
+		}
+
+		text := ""
+		if entry.Size <= s.contentMaxSize {
+			resolved, err := s.store.Resolve(entry.Path)
+			if err != nil {
+				return err
+			}
+			text, err = util.ExtractText(resolved, util.MaxExtractedText)
+			if err != nil {
+				slog.Debug("content extraction failed", "path", entry.Path, "error", err)
+				text = ""
+			}
+		}
+
+		if err := s.repo.UpsertContent(ctx, entry.Path, current, text); err != nil {
+			slog.Warn("store extracted content failed", "path", entry.Path, "error", err)
+		}
+	}
+	return nil
+}
+
+func indexEntry(apiPath string, info fs.FileInfo) model.IndexEntry {
+	// PostgreSQL keeps microseconds, so the truncated time compares equal
+	// to the stored one.
+	entry := model.IndexEntry{
+		Path:        apiPath,
+		Parent:      path.Dir(apiPath),
+		Name:        path.Base(apiPath),
+		Type:        "directory",
+		Permissions: info.Mode().String(),
+		ModifiedAt:  info.ModTime().UTC().Truncate(time.Microsecond),
+	}
+	if info.IsDir() {
+		return entry
+	}
+
+	entry.Type = "file"
+	entry.Size = info.Size()
+	entry.Extension = strings.ToLower(filepath.Ext(entry.Name))
+	mimeType, _, _ := strings.Cut(mime.TypeByExtension(entry.Extension), ";")
+	entry.MimeType = strings.TrimSpace(mimeType)
+	entry.Category = fileCategory(entry.Extension, entry.MimeType)
+	return entry
+}
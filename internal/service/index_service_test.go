@@ -0,0 +1,67 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/event"
+	"go-file-explorer/internal/model"
+)
+
+func TestIndexEventChanges(t *testing.T) {
+	changes := indexEventChanges(event.Event{Type: event.TypeFileMoved, Payload: model.MoveCopyResult{From: "/a", To: "/b/a"}})
+	require.Empty(t, changes.removed)
+	require.Equal(t, "/a", changes.movedFrom)
+	require.Equal(t, []string{"/b/a"}, changes.refreshed)
+
+	changes = indexEventChanges(event.Event{Type: event.TypeFileCopied, Payload: model.MoveCopyResult{From: "/a", To: "/b/a"}})
+	require.Empty(t, changes.movedFrom)
+	require.Equal(t, []string{"/b/a"}, changes.refreshed)
+
+	changes = indexEventChanges(event.Event{Type: event.TypeFileMoved, Payload: model.RenameResponse{OldPath: "/x.txt", NewPath: "/y.txt"}})
+	require.Equal(t, "/x.txt", changes.movedFrom)
+	require.Equal(t, []string{"/y.txt"}, changes.refreshed)
+
+	changes = indexEventChanges(event.Event{Type: event.TypeFileUploaded, Payload: model.UploadItem{Path: "/docs/a.txt", UploadedBy: "alice"}})
+	require.Equal(t, []string{"/docs/a.txt"}, changes.refreshed)
+	require.Equal(t, "alice", changes.owner)
+
+	changes = indexEventChanges(event.Event{Type: event.TypeFileDeleted, Payload: map[string]string{"path": "/old"}})
+	require.Equal(t, []string{"/old"}, changes.removed)
+	require.Empty(t, changes.refreshed)
+
+	changes = indexEventChanges(event.Event{Type: event.TypeFileRestored, Payload: map[string]string{"path": "/old"}})
+	require.Equal(t, []string{"/old"}, changes.refreshed)
+	require.Empty(t, changes.removed)
+
+	changes = indexEventChanges(event.Event{Type: event.TypeFileDecompressed, Payload: model.DecompressResponse{Destination: "/out"}})
+	require.Equal(t, []string{"/out"}, changes.refreshed)
+
+	require.Equal(t, indexChanges{}, indexEventChanges(event.Event{Type: event.TypeJobProgress, Payload: JobUpdate{}}))
+}
+
+func TestIndexEntry(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Photo.JPG"), []byte("data"), 0o644))
+
+	info, err := os.Stat(filepath.Join(root, "Photo.JPG"))
+	require.NoError(t, err)
+	entry := indexEntry("/albums/Photo.JPG", info)
+	require.Equal(t, "/albums", entry.Parent)
+	require.Equal(t, "Photo.JPG", entry.Name)
+	require.Equal(t, "file", entry.Type)
+	require.Equal(t, int64(4), entry.Size)
+	require.Equal(t, ".jpg", entry.Extension)
+	require.Equal(t, "image/jpeg", entry.MimeType)
+
+	info, err = os.Stat(root)
+	require.NoError(t, err)
+	entry = indexEntry("/albums", info)
+	require.Equal(t, "/", entry.Parent)
+	require.Equal(t, "directory", entry.Type)
+	require.Zero(t, entry.Size)
+	require.Empty(t, entry.Extension)
+}
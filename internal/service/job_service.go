@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
@@ -13,11 +15,38 @@ import (
 	"go-file-explorer/internal/event"
 	"go-file-explorer/internal/model"
 	"go-file-explorer/internal/repository"
+	"go-file-explorer/internal/util"
 	"go-file-explorer/pkg/apierror"
 )
 
-type queuedOperationJob struct {
-	jobID string
+const (
+	// jobPollInterval bounds how long a queued job waits when it was not
+	// created by this process (another instance, a resume, a restart).
+	jobPollInterval = 2 * time.Second
+	// jobHeartbeatInterval is how often a running job refreshes heartbeat_at
+	// and checks for a cancel requested through another instance.
+	jobHeartbeatInterval = 10 * time.Second
+	// jobStaleAfter is how old a heartbeat may get before the job is
+	// considered abandoned and marked interrupted.
+	jobStaleAfter = 3 * jobHeartbeatInterval
+	// jobProgressInterval throttles byte-level progress updates.
+	jobProgressInterval = 500 * time.Millisecond
+
+	// JobLaneQuick holds deletes and moves, which are usually a rename, so
+	// they are not stuck behind long copies. JobLaneBulk holds the rest.
+	JobLaneQuick = "quick"
+	JobLaneBulk  = "bulk"
+)
+
+// JobWorkerOptions sizes the worker pool started by Run.
+type JobWorkerOptions struct {
+	// Workers serve the bulk lane and pick up quick jobs when idle.
+	Workers int
+	// QuickWorkers only serve the quick lane.
+	QuickWorkers int
+	// MaxPerUser caps the running jobs of one owner; 0 disables the cap.
+	MaxPerUser        int
+	ResumeInterrupted bool
 }
 
 type JobUpdate struct {
@@ -28,157 +57,639 @@ type JobUpdate struct {
 	TotalItems     int    `json:"total_items"`
 	SuccessItems   int    `json:"success_items"`
 	FailedItems    int    `json:"failed_items"`
+	// Byte-level progress, reported by copy, move, compress and decompress.
+	BytesDone   int64   `json:"bytes_done,omitempty"`
+	BytesTotal  int64   `json:"bytes_total,omitempty"`
+	CurrentItem string  `json:"current_item,omitempty"`
+	Throughput  float64 `json:"throughput,omitempty"`
+	ETASeconds  int64   `json:"eta_seconds,omitempty"`
 }
 
+// activeJob is a job currently executed by this process.
+type activeJob struct {
+	job    *model.JobData
+	cancel context.CancelFunc
+	// lost is set when the job stopped running in the database while this
+	// process was still working on it.
+	lost bool
+}
+
+// JobService runs file operations in the background. Postgres is the source
+// of truth for the queue: jobs and their requests are stored on creation and
+// workers claim them with FOR UPDATE SKIP LOCKED, so queued work survives a
+// restart. Only the jobs this process is running are kept in memory.
 type JobService struct {
 	operations  *OperationsService
 	mu          sync.RWMutex
-	jobs        map[string]*model.JobData
-	requests    map[string]model.JobOperationRequest
-	queue       chan queuedOperationJob
+	active      map[string]*activeJob
+	wake        map[string]chan struct{}
 	subsMu      sync.RWMutex
 	subscribers map[string][]chan JobUpdate
 
-	jobRepo *repository.JobRepository
-	bus     event.Bus
+	jobRepo    *repository.JobRepository
+	bus        event.Bus
+	options    JobWorkerOptions
+	thumbnails *ThumbnailService
+
+	// onFinished is called after a job reaches a final status.
+	onFinished func(jobID string)
 }
 
-func NewJobService(operations *OperationsService, jobRepo *repository.JobRepository, bus event.Bus) *JobService {
-	s := &JobService{
-		operations:  operations,
-		jobs:        map[string]*model.JobData{},
-		requests:    map[string]model.JobOperationRequest{},
-		queue:       make(chan queuedOperationJob, 256),
+func NewJobService(operations *OperationsService, jobRepo *repository.JobRepository, bus event.Bus, options JobWorkerOptions) *JobService {
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.QuickWorkers < 0 {
+		options.QuickWorkers = 0
+	}
+	if options.MaxPerUser < 0 {
+		options.MaxPerUser = 0
+	}
+
+	return &JobService{
+		operations: operations,
+		active:     map[string]*activeJob{},
+		wake: map[string]chan struct{}{
+			JobLaneQuick: make(chan struct{}, 1),
+			JobLaneBulk:  make(chan struct{}, 1),
+		},
 		subscribers: map[string][]chan JobUpdate{},
 		jobRepo:     jobRepo,
 		bus:         bus,
+		options:     options,
 	}
-
-	go s.workerLoop()
-	return s
 }
 
-func (s *JobService) CreateOperationJob(request model.JobOperationRequest, actor model.AuditActor) (model.JobData, error) {
-	_ = actor
-	operation := strings.ToLower(strings.TrimSpace(request.Operation))
-	if operation != "copy" && operation != "move" && operation != "delete" && operation != "compress" && operation != "decompress" {
-		return model.JobData{}, fmt.Errorf("%w: operation must be one of: copy|move|delete|compress|decompress", model.ErrInvalidInput)
-	}
+// Run starts the worker pool and blocks until ctx is cancelled. Running jobs
+// whose worker went away (crash, restart) are marked interrupted once their
+// heartbeat goes stale; with ResumeInterrupted they are put back in the queue
+// instead of waiting for POST /jobs/{id}/resume. Jobs running when ctx is
+// cancelled are left to that same recovery on the next start.
+func (s *JobService) Run(ctx context.Context) {
+	s.recoverJobs(ctx)
 
-	total := len(request.Sources)
-	if operation == "delete" || operation == "restore" {
-		total = len(request.Paths)
+	go s.reapLoop(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.workerLoop(ctx, JobLaneBulk, []string{JobLaneBulk, JobLaneQuick})
+		}()
 	}
-	if total == 0 {
-		return model.JobData{}, fmt.Errorf("%w: job requires at least one source/path", model.ErrInvalidInput)
+	for i := 0; i < s.options.QuickWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.workerLoop(ctx, JobLaneQuick, []string{JobLaneQuick})
+		}()
 	}
+	wg.Wait()
+}
 
-	if operation == "copy" || operation == "move" || operation == "compress" || operation == "decompress" {
-		if strings.TrimSpace(request.Destination) == "" {
-			return model.JobData{}, fmt.Errorf("%w: destination is required for copy/move/compress/decompress", model.ErrInvalidInput)
-		}
-	}
+// UseThumbnails enables the thumbnails operation, which pre-generates the
+// thumbnails of the images in a subtree.
+func (s *JobService) UseThumbnails(thumbnails *ThumbnailService) {
+	s.thumbnails = thumbnails
+}
 
-	policy := strings.TrimSpace(request.ConflictPolicy)
-	if operation == "copy" || operation == "move" || operation == "decompress" {
-		normalized, err := normalizeConflictPolicy(policy)
-		if err != nil {
-			return model.JobData{}, err
-		}
-		policy = normalized
+// OnJobFinished registers fn to be called with the ID of each job that
+// finishes in this process. It must be set before Run.
+func (s *JobService) OnJobFinished(fn func(jobID string)) {
+	s.onFinished = fn
+}
+
+func (s *JobService) CreateOperationJob(request model.JobOperationRequest, actor model.AuditActor) (model.JobData, error) {
+	return s.createJob(request, actor, "")
+}
+
+// createJob validates request and queues it, linked to parentJobID when the
+// job is a retry.
+func (s *JobService) createJob(request model.JobOperationRequest, actor model.AuditActor, parentJobID string) (model.JobData, error) {
+	request, total, err := validateJobRequest(request)
+	if err != nil {
+		return model.JobData{}, err
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339Nano)
-	job := &model.JobData{
+	job := model.JobData{
 		JobID:          uuid.NewString(),
-		Operation:      operation,
+		Operation:      request.Operation,
 		Status:         "queued",
-		ConflictPolicy: policy,
+		ConflictPolicy: request.ConflictPolicy,
 		TotalItems:     total,
 		ProcessedItems: 0,
 		SuccessItems:   0,
 		FailedItems:    0,
 		Progress:       0,
+		OwnerID:        actor.UserID,
+		OwnerUsername:  actor.Username,
+		OwnerRole:      actor.Role,
+		OwnerIP:        actor.IP,
+		Lane:           jobLane(request.Operation),
+		ParentJobID:    parentJobID,
 		CreatedAt:      now,
 	}
 
-	s.mu.Lock()
-	s.jobs[job.JobID] = job
-	s.requests[job.JobID] = request
-	s.mu.Unlock()
-
-	s.persistJobCreate(job)
-
-	s.queue <- queuedOperationJob{jobID: job.JobID}
+	if err := s.jobRepo.Create(context.Background(), job, request); err != nil {
+		return model.JobData{}, err
+	}
 
-	return cloneJob(job, false), nil
+	s.signal()
+	return job, nil
 }
 
+// GetJob returns a job; queued jobs also report their position in the lane.
+// The position follows creation order, while owners actually take turns, so
+// it is an estimate.
 func (s *JobService) GetJob(jobID string, actor model.AuditActor) (model.JobData, error) {
 	job, err := s.getAuthorizedJob(jobID, actor)
 	if err != nil {
 		return model.JobData{}, err
 	}
 
-	return cloneJob(job, false), nil
+	if job.Status == "queued" {
+		position, depth, err := s.jobRepo.QueuePosition(context.Background(), jobID)
+		if err != nil {
+			return model.JobData{}, err
+		}
+		job.QueuePosition = position
+		job.QueueDepth = depth
+	}
+
+	retries, err := s.jobRepo.RetryJobIDs(context.Background(), jobID)
+	if err != nil {
+		return model.JobData{}, err
+	}
+	if len(retries) > 0 {
+		job.RetryJobIDs = retries
+	}
+	return job, nil
+}
+
+// QueueStats reports the worker pool configuration and the queued and running
+// jobs per lane and per owner.
+func (s *JobService) QueueStats() (model.JobQueueStats, error) {
+	lanes, owners, err := s.jobRepo.QueueStats(context.Background())
+	if err != nil {
+		return model.JobQueueStats{}, err
+	}
+
+	byLane := map[string]model.JobLaneStats{}
+	for _, lane := range lanes {
+		byLane[lane.Lane] = lane
+	}
+
+	stats := model.JobQueueStats{MaxPerUser: s.options.MaxPerUser, Owners: owners}
+	for _, name := range []string{JobLaneQuick, JobLaneBulk} {
+		lane := byLane[name]
+		lane.Lane = name
+		lane.Workers = s.options.Workers
+		if name == JobLaneQuick {
+			lane.Workers = s.options.QuickWorkers
+		}
+		stats.Lanes = append(stats.Lanes, lane)
+	}
+	return stats, nil
+}
+
+// ListJobs lists jobs newest first. Admins see every owner's jobs and may
+// filter by owner; everyone else only sees their own. Jobs running in this
+// process report their live progress.
+func (s *JobService) ListJobs(query model.JobQuery, actor model.AuditActor) ([]model.JobData, model.Meta, error) {
+	if actor.Role != "admin" {
+		query.Owner = ""
+		query.OwnerID = actor.UserID
+	}
+	for _, bound := range []string{query.From, query.To} {
+		if bound == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, bound); err != nil {
+			return nil, model.Meta{}, apierror.New("BAD_REQUEST", "from and to must be RFC3339 timestamps", bound, http.StatusBadRequest)
+		}
+	}
+
+	jobs, meta, err := s.jobRepo.List(context.Background(), query)
+	if err != nil {
+		return nil, model.Meta{}, err
+	}
+
+	s.mu.RLock()
+	for index := range jobs {
+		if running, exists := s.active[jobs[index].JobID]; exists {
+			jobs[index] = cloneJob(running.job, false)
+		}
+	}
+	s.mu.RUnlock()
+
+	return jobs, meta, nil
+}
+
+// DeleteJobs removes finished jobs and their items. Non-admins can only
+// delete their own jobs. Requested ids that were not deleted (unknown, not
+// finished or owned by someone else) are reported as skipped.
+func (s *JobService) DeleteJobs(request model.JobDeleteRequest, actor model.AuditActor) (model.JobDeleteResponse, error) {
+	ids := make([]string, 0, len(request.JobIDs))
+	for _, id := range request.JobIDs {
+		if trimmed := strings.TrimSpace(id); trimmed != "" {
+			ids = append(ids, trimmed)
+		}
+	}
+
+	var finishedBefore *time.Time
+	if raw := strings.TrimSpace(request.FinishedBefore); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return model.JobDeleteResponse{}, apierror.New("BAD_REQUEST", "finished_before must be an RFC3339 timestamp", raw, http.StatusBadRequest)
+		}
+		finishedBefore = &parsed
+	}
+
+	if len(ids) == 0 && finishedBefore == nil {
+		return model.JobDeleteResponse{}, apierror.New("BAD_REQUEST", "job_ids or finished_before is required", "", http.StatusBadRequest)
+	}
+
+	ownerID := ""
+	if actor.Role != "admin" {
+		ownerID = actor.UserID
+	}
+
+	deleted, err := s.jobRepo.DeleteFinished(context.Background(), ids, finishedBefore, ownerID)
+	if err != nil {
+		return model.JobDeleteResponse{}, err
+	}
+
+	removed := make(map[string]bool, len(deleted))
+	for _, id := range deleted {
+		removed[id] = true
+	}
+	skipped := make([]string, 0)
+	for _, id := range ids {
+		if !removed[id] {
+			skipped = append(skipped, id)
+		}
+	}
+
+	return model.JobDeleteResponse{Deleted: deleted, Skipped: skipped}, nil
+}
+
+// PurgeFinished deletes finished jobs older than maxAge.
+func (s *JobService) PurgeFinished(maxAge time.Duration) {
+	purged, err := s.jobRepo.PurgeFinished(context.Background(), time.Now().UTC().Add(-maxAge))
+	if err != nil {
+		slog.Warn("purge finished jobs failed", "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.Info("purged finished jobs", "count", purged)
+	}
+}
+
+// StartRetentionTicker runs PurgeFinished on a regular interval until ctx is
+// cancelled. A non-positive maxAge keeps jobs forever.
+func (s *JobService) StartRetentionTicker(ctx context.Context, maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	s.PurgeFinished(maxAge)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.PurgeFinished(maxAge)
+		}
+	}
 }
 
 func (s *JobService) GetJobItems(jobID string, actor model.AuditActor, page int, limit int) (model.JobItemsData, model.Meta, error) {
-	job, err := s.getAuthorizedJob(jobID, actor)
+	if _, err := s.getAuthorizedJob(jobID, actor); err != nil {
+		return model.JobItemsData{}, model.Meta{}, err
+	}
+
+	// Items are written when the job finishes; until then the list is empty.
+	items, meta, err := s.jobRepo.GetItems(context.Background(), jobID, page, limit)
 	if err != nil {
 		return model.JobItemsData{}, model.Meta{}, err
 	}
 
-	if page < 1 {
-		page = 1
+	return model.JobItemsData{JobID: jobID, Items: items}, meta, nil
+}
+
+// CancelJob stops a job. Queued and interrupted jobs are cancelled right away;
+// a running job is flagged and settles as "cancelled" once the operation
+// notices, keeping whatever it finished before that. The flag reaches a
+// worker in another process through its heartbeat.
+func (s *JobService) CancelJob(jobID string, actor model.AuditActor) (model.JobData, error) {
+	if _, err := s.getAuthorizedJob(jobID, actor); err != nil {
+		return model.JobData{}, err
+	}
+
+	job, err := s.jobRepo.RequestCancel(context.Background(), jobID)
+	if errors.Is(err, model.ErrJobNotFound) {
+		return model.JobData{}, apierror.New("CONFLICT", "job has already finished", jobID, http.StatusConflict)
+	}
+	if err != nil {
+		return model.JobData{}, err
+	}
+
+	if job.Status == "cancelled" {
+		s.notifySubscribers(jobID, JobUpdate{JobID: jobID, Status: job.Status, Progress: job.Progress, TotalItems: job.TotalItems})
+		s.closeSubscribers(jobID)
+		s.jobFinished(jobID)
+		return job, nil
+	}
+
+	s.mu.Lock()
+	if running, exists := s.active[jobID]; exists {
+		running.cancel()
+		running.job.CancelRequested = true
+		job = cloneJob(running.job, false)
+	}
+	s.mu.Unlock()
+
+	return job, nil
+}
+
+// ResumeJob puts an interrupted job back in the queue. The operation starts
+// over from its original request, so sources that were already handled are
+// reported again according to the conflict policy.
+func (s *JobService) ResumeJob(jobID string, actor model.AuditActor) (model.JobData, error) {
+	if _, err := s.getAuthorizedJob(jobID, actor); err != nil {
+		return model.JobData{}, err
+	}
+
+	job, err := s.jobRepo.Requeue(context.Background(), jobID)
+	if errors.Is(err, model.ErrJobNotFound) {
+		return model.JobData{}, apierror.New("CONFLICT", "only interrupted jobs can be resumed", jobID, http.StatusConflict)
+	}
+	if err != nil {
+		return model.JobData{}, err
 	}
-	if limit <= 0 {
-		limit = 100
+
+	s.signal()
+	return job, nil
+}
+
+// RetryJob queues a new job, linked to a finished one, that re-runs the
+// items that did not succeed (failed, skipped or cancelled). The conflict
+// policy can be changed, e.g. to overwrite targets that were skipped. When a
+// failure is not tied to an item, and for compress, which produces a single
+// archive, the whole original request runs again.
+func (s *JobService) RetryJob(jobID string, retry model.JobRetryRequest, actor model.AuditActor) (model.JobData, error) {
+	parent, err := s.getAuthorizedJob(jobID, actor)
+	if err != nil {
+		return model.JobData{}, err
 	}
-	if limit > 500 {
-		limit = 500
+
+	switch parent.Status {
+	case "partial", "failed", "cancelled":
+	case "completed":
+		return model.JobData{}, apierror.New("CONFLICT", "job has no failed items to retry", jobID, http.StatusConflict)
+	default:
+		return model.JobData{}, apierror.New("CONFLICT", "only finished jobs can be retried", jobID, http.StatusConflict)
 	}
 
-	items := job.Items
-	total := len(items)
-	start := (page - 1) * limit
-	if start > total {
-		start = total
+	request, err := s.jobRepo.FindRequest(context.Background(), jobID)
+	if err != nil {
+		return model.JobData{}, err
+	}
+	if request.Operation == "" {
+		return model.JobData{}, apierror.New("CONFLICT", "job has no stored request to retry", jobID, http.StatusConflict)
 	}
-	end := start + limit
-	if end > total {
-		end = total
+
+	items, err := s.jobRepo.UnsuccessfulItems(context.Background(), jobID)
+	if err != nil {
+		return model.JobData{}, err
 	}
 
-	totalPages := 0
-	if total > 0 {
-		totalPages = (total + limit - 1) / limit
+	retryRequest, ok := retryRequestFor(parent.Status, request, items)
+	if !ok {
+		return model.JobData{}, apierror.New("CONFLICT", "job has no failed items to retry", jobID, http.StatusConflict)
+	}
+	if policy := strings.TrimSpace(retry.ConflictPolicy); policy != "" {
+		retryRequest.ConflictPolicy = policy
 	}
 
-	meta := model.Meta{Page: page, Limit: limit, Total: total, TotalPages: totalPages}
-	data := model.JobItemsData{JobID: jobID, Items: append([]model.JobItemResult(nil), items[start:end]...)}
-	return data, meta, nil
+	return s.createJob(retryRequest, actor, jobID)
 }
 
-func (s *JobService) workerLoop() {
-	for next := range s.queue {
-		s.process(next.jobID)
+// JobResult returns the request a job ran and the items it completed
+// successfully, so callers that chain jobs can use what an earlier one
+// produced.
+func (s *JobService) JobResult(ctx context.Context, jobID string) (model.JobOperationRequest, []model.JobItemResult, error) {
+	request, err := s.jobRepo.FindRequest(ctx, jobID)
+	if err != nil {
+		return model.JobOperationRequest{}, nil, err
+	}
+	items, err := s.jobRepo.SuccessfulItems(ctx, jobID)
+	if err != nil {
+		return model.JobOperationRequest{}, nil, err
 	}
+	return request, items, nil
 }
 
-func (s *JobService) process(jobID string) {
-	s.mu.Lock()
-	job, exists := s.jobs[jobID]
-	if !exists {
-		s.mu.Unlock()
+// retryRequestFor narrows request to the sources, paths or archive entries of
+// the unsuccessful items. It reports false when there is nothing to retry.
+func retryRequestFor(status string, request model.JobOperationRequest, items []model.JobItemResult) (model.JobOperationRequest, bool) {
+	whole := request.Operation == "compress" && len(items) > 0
+	// Entries a cancelled extraction never reached are not itemized.
+	if request.Operation == "decompress" && status == "cancelled" {
+		whole = true
+	}
+
+	seen := map[string]bool{}
+	targets := make([]string, 0, len(items))
+	for _, item := range items {
+		target := item.From
+		if request.Operation == "delete" || request.Operation == "thumbnails" {
+			target = item.Path
+		}
+		if target == "" {
+			whole = true
+			continue
+		}
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+
+	if whole {
+		return request, true
+	}
+	if len(targets) == 0 {
+		return model.JobOperationRequest{}, false
+	}
+
+	retry := request
+	switch request.Operation {
+	case "copy", "move":
+		retry.Sources = targets
+	case "delete", "thumbnails":
+		retry.Paths = targets
+	case "decompress":
+		// Entry names are matched as globs; escape them so they only match
+		// themselves.
+		retry.Entries = make([]string, 0, len(targets))
+		for _, target := range targets {
+			retry.Entries = append(retry.Entries, globEscaper.Replace(target))
+		}
+	default:
+		return model.JobOperationRequest{}, false
+	}
+	return retry, true
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+
+// signal wakes an idle worker of each lane without blocking.
+func (s *JobService) signal() {
+	for _, wake := range s.wake {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// workerLoop claims jobs from lanes until ctx is cancelled, sleeping on the
+// wake channel of home between polls.
+func (s *JobService) workerLoop(ctx context.Context, home string, lanes []string) {
+	timer := time.NewTimer(jobPollInterval)
+	defer timer.Stop()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, request, err := s.jobRepo.ClaimNext(ctx, lanes, s.options.MaxPerUser)
+		if err == nil {
+			// Pass the wake-up on so another idle worker checks for more work.
+			s.signal()
+			s.process(job, request)
+			continue
+		}
+		if !errors.Is(err, model.ErrJobNotFound) && ctx.Err() == nil {
+			slog.Warn("claim job failed", "error", err)
+		}
+
+		timer.Reset(jobPollInterval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake[home]:
+		case <-timer.C:
+		}
+	}
+}
+
+// recoverJobs marks jobs abandoned by a previous run as interrupted and, when
+// configured, queues them again.
+func (s *JobService) recoverJobs(ctx context.Context) {
+	interrupted, err := s.jobRepo.InterruptStale(ctx, time.Now().Add(-jobStaleAfter))
+	if err != nil {
+		slog.Warn("mark interrupted jobs failed", "error", err)
+	} else if interrupted > 0 {
+		slog.Info("marked abandoned jobs as interrupted", "count", interrupted)
+	}
+
+	if !s.options.ResumeInterrupted {
+		return
+	}
+
+	resumed, err := s.jobRepo.RequeueInterrupted(ctx)
+	if err != nil {
+		slog.Warn("requeue interrupted jobs failed", "error", err)
 		return
 	}
-	job.Status = "running"
-	job.Progress = 5
-	job.StartedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if resumed > 0 {
+		slog.Info("requeued interrupted jobs", "count", resumed)
+	}
+}
+
+func (s *JobService) reapLoop(ctx context.Context) {
+	ticker := time.NewTicker(jobHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.recoverJobs(ctx)
+		}
+	}
+}
+
+// heartbeat keeps a running job alive in the database and cancels it when a
+// cancel was requested elsewhere. When the job is no longer running in the
+// database it is marked lost and cancelled so its outcome is not written over
+// the new state. It returns when ctx is done.
+func (s *JobService) heartbeat(ctx context.Context, jobID string, cancel context.CancelFunc) {
+	ticker := time.NewTicker(jobHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.RLock()
+			running, exists := s.active[jobID]
+			var job model.JobData
+			if exists {
+				job = cloneJob(running.job, false)
+			}
+			s.mu.RUnlock()
+			if !exists {
+				return
+			}
+
+			cancelRequested, err := s.jobRepo.Heartbeat(context.Background(), job)
+			if errors.Is(err, model.ErrJobNotFound) {
+				slog.Warn("job no longer running, abandoning it", "job_id", jobID)
+				s.mu.Lock()
+				running.lost = true
+				s.mu.Unlock()
+				cancel()
+				return
+			}
+			if err != nil {
+				slog.Warn("job heartbeat failed", "job_id", jobID, "error", err)
+				continue
+			}
+			if cancelRequested {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *JobService) process(claimed model.JobData, request model.JobOperationRequest) {
+	jobID := claimed.JobID
+	job := &claimed
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.active[jobID] = &activeJob{job: job, cancel: cancel}
 	s.mu.Unlock()
 
-	s.persistJobUpdate(job)
+	ctx = util.WithProgress(ctx, util.NewProgress(jobProgressInterval, func(report util.ProgressReport) {
+		s.reportProgress(jobID, report)
+	}))
+
+	go s.heartbeat(ctx, jobID, cancel)
+	defer cancel()
 
 	s.notifySubscribers(jobID, JobUpdate{
 		JobID:          jobID,
@@ -190,14 +701,17 @@ func (s *JobService) process(jobID string) {
 		FailedItems:    0,
 	})
 
-	ctx := context.Background()
+	// Operations are audited as the user who created the job.
+	actor := model.AuditActor{UserID: job.OwnerID, Username: job.OwnerUsername, Role: job.OwnerRole, IP: job.OwnerIP}
+
 	items := make([]model.JobItemResult, 0, job.TotalItems)
+	var opErr error
 
 	switch job.Operation {
 	case "copy":
-		request := s.lookupRequest(jobID)
-		result, err := s.operations.Copy(ctx, request.Sources, request.Destination, request.ConflictPolicy, model.AuditActor{})
-		if err != nil {
+		result, err := s.operations.Copy(ctx, request.Sources, request.Destination, request.ConflictPolicy, actor)
+		opErr = err
+		if err != nil && !errors.Is(err, context.Canceled) {
 			items = append(items, model.JobItemResult{Status: "failed", Reason: err.Error()})
 		}
 		for _, copied := range result.Copied {
@@ -211,9 +725,9 @@ func (s *JobService) process(jobID string) {
 			items = append(items, model.JobItemResult{From: failed.From, Status: status, Reason: failed.Reason})
 		}
 	case "move":
-		request := s.lookupRequest(jobID)
-		result, err := s.operations.Move(ctx, request.Sources, request.Destination, request.ConflictPolicy, model.AuditActor{})
-		if err != nil {
+		result, err := s.operations.Move(ctx, request.Sources, request.Destination, request.ConflictPolicy, actor)
+		opErr = err
+		if err != nil && !errors.Is(err, context.Canceled) {
 			items = append(items, model.JobItemResult{Status: "failed", Reason: err.Error()})
 		}
 		for _, moved := range result.Moved {
@@ -227,9 +741,9 @@ func (s *JobService) process(jobID string) {
 			items = append(items, model.JobItemResult{From: failed.From, Status: status, Reason: failed.Reason})
 		}
 	case "delete":
-		request := s.lookupRequest(jobID)
-		result, err := s.operations.Delete(ctx, request.Paths, model.AuditActor{})
-		if err != nil {
+		result, err := s.operations.Delete(ctx, request.Paths, actor)
+		opErr = err
+		if err != nil && !errors.Is(err, context.Canceled) {
 			items = append(items, model.JobItemResult{Status: "failed", Reason: err.Error()})
 		}
 		for _, deleted := range result.Deleted {
@@ -239,56 +753,200 @@ func (s *JobService) process(jobID string) {
 			items = append(items, model.JobItemResult{Path: failed.Path, Status: "failed", Reason: failed.Reason})
 		}
 	case "compress":
-		request := s.lookupRequest(jobID)
 		// Compress treats sources as inputs
-		result, err := s.operations.Compress(ctx, request.Sources, request.Destination, request.Name, model.AuditActor{})
-		if err != nil {
+		result, err := s.operations.Compress(ctx, request.Sources, request.Destination, request.Name, actor)
+		opErr = err
+		if err != nil && !errors.Is(err, context.Canceled) {
 			items = append(items, model.JobItemResult{Status: "failed", Reason: err.Error()})
-		} else {
+		} else if err == nil {
 			// One item representing the zip file
 			items = append(items, model.JobItemResult{Path: result.Path, Status: "success"})
 		}
+	case "empty_trash":
+		olderThan, _ := util.ParseAge(request.OlderThan)
+		count, err := s.operations.EmptyTrash(ctx, olderThan, actor)
+		opErr = err
+		if err != nil {
+			items = append(items, model.JobItemResult{Status: "failed", Reason: err.Error()})
+		} else {
+			items = append(items, model.JobItemResult{Status: "success", Reason: fmt.Sprintf("%d trash items deleted", count)})
+		}
+	case "thumbnails":
+		if s.thumbnails == nil {
+			items = append(items, model.JobItemResult{Status: "failed", Reason: "thumbnail generation is not available"})
+			break
+		}
+		for _, path := range request.Paths {
+			path = normalizeAPIPath(path)
+			processed, failed, err := s.thumbnails.Generate(ctx, path)
+			if errors.Is(err, context.Canceled) {
+				opErr = err
+				break
+			}
+			if err != nil {
+				items = append(items, model.JobItemResult{Path: path, Status: "failed", Reason: err.Error()})
+				continue
+			}
+			items = append(items, model.JobItemResult{Path: path, Status: "success", Reason: fmt.Sprintf("%d images processed, %d failed", processed, failed)})
+		}
 	case "decompress":
-		request := s.lookupRequest(jobID)
 		// Decompress treats sources[0] as the zip file
 		if len(request.Sources) == 0 {
 			items = append(items, model.JobItemResult{Status: "failed", Reason: "no source file provided"})
 		} else {
-			result, err := s.operations.Decompress(ctx, request.Sources[0], request.Destination, request.ConflictPolicy, model.AuditActor{})
-			if err != nil {
+			result, err := s.operations.Decompress(ctx, model.DecompressRequest{
+				Source:          request.Sources[0],
+				Destination:     request.Destination,
+				ConflictPolicy:  request.ConflictPolicy,
+				Entries:         request.Entries,
+				StripComponents: request.StripComponents,
+				Flatten:         request.Flatten,
+			}, actor)
+			opErr = err
+			cancelled := errors.Is(err, context.Canceled)
+			if err != nil && !cancelled && len(result.Conflicts) > 0 {
+				for _, conflict := range result.Conflicts {
+					items = append(items, model.JobItemResult{From: conflict, Status: "failed", Reason: "conflict: target already exists"})
+				}
+			} else if err != nil && !cancelled {
 				items = append(items, model.JobItemResult{Status: "failed", Reason: err.Error()})
 			} else {
-				for _, file := range result.Files {
-					items = append(items, model.JobItemResult{Path: file, Status: "success"})
+				for _, entry := range result.Entries {
+					items = append(items, model.JobItemResult{From: entry.Entry, Path: entry.Path, Status: entry.Status, Reason: entry.Reason})
 				}
 			}
 		}
 	}
 
-	s.finalize(jobID, items)
+	// A cancel that lands after the operation already returned does not
+	// change the outcome.
+	cancelled := errors.Is(opErr, context.Canceled)
+	if cancelled {
+		items = append(items, cancelledItems(job.Operation, request, items)...)
+	}
+
+	finished := s.finalize(jobID, items, cancelled)
+
+	// A finished job may bring its owner back under the concurrency cap.
+	s.signal()
+	if finished {
+		s.jobFinished(jobID)
+	}
 }
 
-func (s *JobService) lookupRequest(jobID string) model.JobOperationRequest {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	request, exists := s.requests[jobID]
-	if !exists {
-		return model.JobOperationRequest{}
+func (s *JobService) jobFinished(jobID string) {
+	if s.onFinished != nil {
+		s.onFinished(jobID)
 	}
-	return request
 }
 
-func (s *JobService) finalize(jobID string, items []model.JobItemResult) {
+// validateJobRequest normalizes the operation and conflict policy of request
+// and returns it with the number of items the job will process.
+func validateJobRequest(request model.JobOperationRequest) (model.JobOperationRequest, int, error) {
+	operation := strings.ToLower(strings.TrimSpace(request.Operation))
+	switch operation {
+	case "copy", "move", "delete", "compress", "decompress", "empty_trash", "thumbnails":
+	default:
+		return request, 0, fmt.Errorf("%w: operation must be one of: copy|move|delete|compress|decompress|empty_trash|thumbnails", model.ErrInvalidInput)
+	}
+	request.Operation = operation
+
+	if operation == "empty_trash" {
+		if raw := strings.TrimSpace(request.OlderThan); raw != "" {
+			if _, err := util.ParseAge(raw); err != nil {
+				return request, 0, fmt.Errorf("%w: older_than must be a duration such as 30d or 12h", model.ErrInvalidInput)
+			}
+		}
+		return request, 1, nil
+	}
+
+	total := len(request.Sources)
+	if operation == "delete" || operation == "thumbnails" {
+		total = len(request.Paths)
+	}
+	if total == 0 {
+		return request, 0, fmt.Errorf("%w: job requires at least one source/path", model.ErrInvalidInput)
+	}
+
+	if operation == "copy" || operation == "move" || operation == "compress" || operation == "decompress" {
+		if strings.TrimSpace(request.Destination) == "" {
+			return request, 0, fmt.Errorf("%w: destination is required for copy/move/compress/decompress", model.ErrInvalidInput)
+		}
+	}
+
+	policy := strings.TrimSpace(request.ConflictPolicy)
+	if operation == "copy" || operation == "move" || operation == "decompress" {
+		normalized, err := normalizeConflictPolicy(policy)
+		if err != nil {
+			return request, 0, err
+		}
+		policy = normalized
+	}
+	request.ConflictPolicy = policy
+
+	return request, total, nil
+}
+
+// jobLane returns the lane an operation is queued in.
+func jobLane(operation string) string {
+	if operation == "delete" || operation == "move" {
+		return JobLaneQuick
+	}
+	return JobLaneBulk
+}
+
+// cancelledItems lists the sources or paths a cancelled operation never got
+// to. A cancelled compress leaves no archive behind, so it is reported under
+// the destination directory.
+func cancelledItems(operation string, request model.JobOperationRequest, done []model.JobItemResult) []model.JobItemResult {
+	reached := map[string]bool{}
+	for _, item := range done {
+		reached[item.From] = true
+		reached[item.Path] = true
+	}
+
+	pending := make([]model.JobItemResult, 0)
+	switch operation {
+	case "copy", "move":
+		for _, source := range request.Sources {
+			source = normalizeAPIPath(source)
+			if !reached[source] {
+				pending = append(pending, model.JobItemResult{From: source, Status: "cancelled", Reason: "cancelled"})
+			}
+		}
+	case "delete", "thumbnails":
+		for _, path := range request.Paths {
+			path = normalizeAPIPath(path)
+			if !reached[path] {
+				pending = append(pending, model.JobItemResult{Path: path, Status: "cancelled", Reason: "cancelled"})
+			}
+		}
+	case "compress":
+		if len(done) == 0 {
+			pending = append(pending, model.JobItemResult{Path: normalizeAPIPath(request.Destination), Status: "cancelled", Reason: "cancelled: partial archive removed"})
+		}
+	}
+	return pending
+}
+
+// finalize records the outcome of a job run by this process. It reports
+// false, writing nothing, when the job is not active here or was lost.
+func (s *JobService) finalize(jobID string, items []model.JobItemResult, cancelled bool) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	job, exists := s.jobs[jobID]
+	running, exists := s.active[jobID]
 	if !exists {
-		return
+		return false
 	}
-	delete(s.requests, jobID)
+	delete(s.active, jobID)
+	if running.lost {
+		// Whoever changed the job's status owns it now.
+		s.closeSubscribers(jobID)
+		return false
+	}
+	job := running.job
 
-	job.Items = items
 	job.ProcessedItems = len(items)
 	job.SuccessItems = 0
 	job.FailedItems = 0
@@ -301,13 +959,17 @@ func (s *JobService) finalize(jobID string, items []model.JobItemResult) {
 		job.FailedItems++
 	}
 
-	if job.TotalItems <= 0 {
+	if job.TotalItems < len(items) {
 		job.TotalItems = len(items)
 	}
 	job.Progress = 100
+	job.Throughput = 0
+	job.ETASeconds = 0
 	job.FinishedAt = time.Now().UTC().Format(time.RFC3339Nano)
 
 	switch {
+	case cancelled:
+		job.Status = "cancelled"
 	case job.SuccessItems == 0 && job.FailedItems > 0:
 		job.Status = "failed"
 	case job.SuccessItems > 0 && job.FailedItems > 0:
@@ -316,20 +978,61 @@ func (s *JobService) finalize(jobID string, items []model.JobItemResult) {
 		job.Status = "completed"
 	}
 
-	s.notifySubscribers(jobID, JobUpdate{
-		JobID:          jobID,
+	s.notifySubscribers(jobID, jobUpdateFrom(job))
+
+	s.persistJobUpdate(job)
+	s.persistJobItems(jobID, items)
+
+	s.closeSubscribers(jobID)
+	return true
+}
+
+// reportProgress folds a byte-level report into a running job and pushes it
+// to subscribers. With a known total, progress moves between the 5% set on
+// start and the 100% set by finalize.
+func (s *JobService) reportProgress(jobID string, report util.ProgressReport) {
+	s.mu.Lock()
+	running, exists := s.active[jobID]
+	if !exists {
+		s.mu.Unlock()
+		return
+	}
+
+	job := running.job
+	job.BytesDone = report.BytesDone
+	job.BytesTotal = report.BytesTotal
+	job.CurrentItem = report.CurrentItem
+	job.Throughput = report.Throughput
+	job.ETASeconds = report.ETASeconds
+	if report.BytesTotal > 0 {
+		done := min(report.BytesDone, report.BytesTotal)
+		job.Progress = max(job.Progress, 5+int(done*94/report.BytesTotal))
+	}
+	update := jobUpdateFrom(job)
+	s.mu.Unlock()
+
+	s.notifySubscribers(jobID, update)
+}
+
+func jobUpdateFrom(job *model.JobData) JobUpdate {
+	return JobUpdate{
+		JobID:          job.JobID,
 		Status:         job.Status,
 		Progress:       job.Progress,
 		ProcessedItems: job.ProcessedItems,
 		TotalItems:     job.TotalItems,
 		SuccessItems:   job.SuccessItems,
 		FailedItems:    job.FailedItems,
-	})
-
-	s.persistJobUpdate(job)
-	s.persistJobItems(jobID, items)
+		BytesDone:      job.BytesDone,
+		BytesTotal:     job.BytesTotal,
+		CurrentItem:    job.CurrentItem,
+		Throughput:     job.Throughput,
+		ETASeconds:     job.ETASeconds,
+	}
+}
 
-	// Close all subscriber channels for this job
+// closeSubscribers closes all subscriber channels for a finished job.
+func (s *JobService) closeSubscribers(jobID string) {
 	s.subsMu.Lock()
 	for _, ch := range s.subscribers[jobID] {
 		close(ch)
@@ -338,27 +1041,55 @@ func (s *JobService) finalize(jobID string, items []model.JobItemResult) {
 	s.subsMu.Unlock()
 }
 
-func (s *JobService) getAuthorizedJob(jobID string, actor model.AuditActor) (*model.JobData, error) {
+// getAuthorizedJob returns the live state of a job run by this process, or
+// the stored state otherwise. Only the job's owner and admins may access it;
+// anyone else gets the same not-found error as for a missing job.
+func (s *JobService) getAuthorizedJob(jobID string, actor model.AuditActor) (model.JobData, error) {
 	s.mu.RLock()
-	job, exists := s.jobs[jobID]
+	running, exists := s.active[jobID]
+	var job model.JobData
+	if exists {
+		job = cloneJob(running.job, false)
+	}
 	s.mu.RUnlock()
+
 	if !exists {
-		return nil, apierror.New("NOT_FOUND", "job not found", jobID, http.StatusNotFound)
+		var err error
+		job, err = s.jobRepo.FindByID(context.Background(), jobID)
+		if errors.Is(err, model.ErrJobNotFound) {
+			return model.JobData{}, apierror.New("NOT_FOUND", "job not found", jobID, http.StatusNotFound)
+		}
+		if err != nil {
+			return model.JobData{}, err
+		}
 	}
 
-	_ = actor
+	if !canAccessJob(job, actor) {
+		return model.JobData{}, apierror.New("NOT_FOUND", "job not found", jobID, http.StatusNotFound)
+	}
 	return job, nil
 }
 
-func (s *JobService) Subscribe(jobID string) (chan JobUpdate, error) {
-	s.mu.RLock()
-	_, exists := s.jobs[jobID]
-	s.mu.RUnlock()
-	if !exists {
-		return nil, apierror.New("NOT_FOUND", "job not found", jobID, http.StatusNotFound)
+// canAccessJob reports whether actor may read or control job.
+func canAccessJob(job model.JobData, actor model.AuditActor) bool {
+	return actor.Role == "admin" || (actor.UserID != "" && job.OwnerID == actor.UserID)
+}
+
+// Subscribe streams updates for a job to its owner or an admin. A job that
+// is no longer queued or running gets its current state once and a closed
+// channel.
+func (s *JobService) Subscribe(jobID string, actor model.AuditActor) (chan JobUpdate, error) {
+	job, err := s.getAuthorizedJob(jobID, actor)
+	if err != nil {
+		return nil, err
 	}
 
 	ch := make(chan JobUpdate, 64)
+	if job.Status != "queued" && job.Status != "running" {
+		ch <- jobUpdateFrom(&job)
+		close(ch)
+		return ch, nil
+	}
 
 	s.subsMu.Lock()
 	s.subscribers[jobID] = append(s.subscribers[jobID], ch)
@@ -394,12 +1125,14 @@ func (s *JobService) notifySubscribers(jobID string, update JobUpdate) {
 
 	if s.bus != nil {
 		eventType := event.TypeJobProgress
-		if update.Status == "running" && update.Progress <= 5 {
+		if update.Status == "running" && update.Progress <= 5 && update.BytesDone == 0 && update.CurrentItem == "" {
 			eventType = event.TypeJobStarted
 		} else if update.Status == "completed" {
 			eventType = event.TypeJobCompleted
 		} else if update.Status == "failed" {
 			eventType = event.TypeJobFailed
+		} else if update.Status == "cancelled" {
+			eventType = event.TypeJobCancelled
 		}
 
 		s.bus.Publish(event.Event{
@@ -421,20 +1154,16 @@ func cloneJob(value *model.JobData, includeItems bool) model.JobData {
 	return cloned
 }
 
-// persistJobCreate saves a newly created job to the DB.
-func (s *JobService) persistJobCreate(job *model.JobData) {
-	ctx := context.Background()
-	_ = s.jobRepo.Create(ctx, *job)
-}
-
 // persistJobUpdate saves job state changes to the DB.
 func (s *JobService) persistJobUpdate(job *model.JobData) {
-	ctx := context.Background()
-	_ = s.jobRepo.Update(ctx, *job)
+	if err := s.jobRepo.Update(context.Background(), *job); err != nil {
+		slog.Warn("persist job update failed", "job_id", job.JobID, "error", err)
+	}
 }
 
 // persistJobItems saves completed job items to the DB.
 func (s *JobService) persistJobItems(jobID string, items []model.JobItemResult) {
-	ctx := context.Background()
-	_ = s.jobRepo.SaveItems(ctx, jobID, items)
+	if err := s.jobRepo.SaveItems(context.Background(), jobID, items); err != nil {
+		slog.Warn("persist job items failed", "job_id", jobID, "error", err)
+	}
 }
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+)
+
+func TestRetryRequestFor(t *testing.T) {
+	copyRequest := model.JobOperationRequest{
+		Operation:      "copy",
+		Sources:        []string{"/a.txt", "/b.txt", "/c.txt"},
+		Destination:    "/backup",
+		ConflictPolicy: "skip",
+	}
+
+	retry, ok := retryRequestFor("partial", copyRequest, []model.JobItemResult{
+		{From: "/b.txt", Status: "failed", Reason: "permission denied"},
+		{From: "/c.txt", Status: "skipped", Reason: "skipped: target already exists"},
+	})
+	require.True(t, ok)
+	require.Equal(t, []string{"/b.txt", "/c.txt"}, retry.Sources)
+	require.Equal(t, "/backup", retry.Destination)
+	require.Equal(t, []string{"/a.txt", "/b.txt", "/c.txt"}, copyRequest.Sources)
+
+	// A failure without a source re-runs the whole request.
+	retry, ok = retryRequestFor("failed", copyRequest, []model.JobItemResult{{Status: "failed", Reason: "destination is invalid"}})
+	require.True(t, ok)
+	require.Equal(t, copyRequest.Sources, retry.Sources)
+
+	deleteRequest := model.JobOperationRequest{Operation: "delete", Paths: []string{"/x", "/y"}}
+	retry, ok = retryRequestFor("cancelled", deleteRequest, []model.JobItemResult{{Path: "/y", Status: "cancelled"}})
+	require.True(t, ok)
+	require.Equal(t, []string{"/y"}, retry.Paths)
+
+	thumbnailsRequest := model.JobOperationRequest{Operation: "thumbnails", Paths: []string{"/photos", "/scans"}}
+	retry, ok = retryRequestFor("partial", thumbnailsRequest, []model.JobItemResult{{Path: "/scans", Status: "failed", Reason: "file not found"}})
+	require.True(t, ok)
+	require.Equal(t, []string{"/scans"}, retry.Paths)
+
+	decompressRequest := model.JobOperationRequest{Operation: "decompress", Sources: []string{"/a.zip"}, Destination: "/out"}
+	retry, ok = retryRequestFor("partial", decompressRequest, []model.JobItemResult{{From: "docs/[draft]*.txt", Status: "skipped"}})
+	require.True(t, ok)
+	require.Len(t, retry.Entries, 1)
+	matched, err := path.Match(retry.Entries[0], "docs/[draft]*.txt")
+	require.NoError(t, err)
+	require.True(t, matched)
+	matched, _ = path.Match(retry.Entries[0], "docs/d*.txt")
+	require.False(t, matched)
+
+	_, ok = retryRequestFor("partial", copyRequest, nil)
+	require.False(t, ok)
+}
+
+func TestCanAccessJob(t *testing.T) {
+	job := model.JobData{JobID: "j1", OwnerID: "u1"}
+
+	require.True(t, canAccessJob(job, model.AuditActor{UserID: "u1", Role: "editor"}))
+	require.True(t, canAccessJob(job, model.AuditActor{UserID: "u2", Role: "admin"}))
+	require.False(t, canAccessJob(job, model.AuditActor{UserID: "u2", Role: "editor"}))
+	require.False(t, canAccessJob(model.JobData{JobID: "j2"}, model.AuditActor{Role: "editor"}))
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-file-explorer/internal/event"
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/repository"
+	"go-file-explorer/internal/storage"
+	"go-file-explorer/pkg/apierror"
+)
+
+const (
+	maxMetadataValuesPerRequest = 50
+	maxMetadataStringLength     = 1024
+	maxMetadataEnumOptions      = 100
+	maxMetadataOptionLength     = 255
+	maxMetadataDescription      = 1024
+	metadataDateLayout          = "2006-01-02"
+)
+
+var metadataFieldTypes = []string{"string", "number", "date", "enum"}
+
+// metadataFieldPattern is a valid field name: lower-case letters, digits and
+// underscores, starting with a letter, so names work as search keys.
+var metadataFieldPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// MetadataService manages custom metadata: typed fields defined by admins and
+// their values per path. Like tags, values follow entries that
+// OperationsService renames, moves or copies, are parked in the trash with
+// them and are removed when the trash is purged. A nil MetadataService
+// ignores those changes.
+type MetadataService struct {
+	repo  *repository.MetadataRepository
+	store storage.Storage
+	audit *AuditService
+	bus   event.Bus
+	quick *QuickAccessService
+}
+
+func NewMetadataService(repo *repository.MetadataRepository, store storage.Storage, audit *AuditService, bus event.Bus) *MetadataService {
+	return &MetadataService{repo: repo, store: store, audit: audit, bus: bus}
+}
+
+func (s *MetadataService) Fields(ctx context.Context) (model.MetadataFieldListData, error) {
+	fields, err := s.repo.Fields(ctx)
+	if err != nil {
+		return model.MetadataFieldListData{}, err
+	}
+	return model.MetadataFieldListData{Items: fields}, nil
+}
+
+func (s *MetadataService) CreateField(ctx context.Context, request model.MetadataFieldRequest, actor model.AuditActor) (model.MetadataField, error) {
+	field := model.MetadataField{
+		Name:        strings.TrimSpace(request.Name),
+		Type:        strings.ToLower(strings.TrimSpace(request.Type)),
+		Description: strings.TrimSpace(request.Description),
+		CreatedBy:   actor.Username,
+	}
+	if !metadataFieldPattern.MatchString(field.Name) {
+		return model.MetadataField{}, apierror.New("BAD_REQUEST", "name must be 1 to 63 lower-case letters, digits or underscores, starting with a letter", "name", http.StatusBadRequest)
+	}
+	if !slices.Contains(metadataFieldTypes, field.Type) {
+		return model.MetadataField{}, apierror.New("BAD_REQUEST", "type must be one of "+strings.Join(metadataFieldTypes, ", "), "type", http.StatusBadRequest)
+	}
+	if err := validateMetadataDescription(field.Description); err != nil {
+		return model.MetadataField{}, err
+	}
+
+	options, err := normalizeMetadataOptions(field.Type, request.Options)
+	if err != nil {
+		return model.MetadataField{}, err
+	}
+	field.Options = options
+
+	created, err := s.repo.CreateField(ctx, field)
+	if err != nil {
+		return model.MetadataField{}, err
+	}
+	if !created {
+		return model.MetadataField{}, apierror.New("CONFLICT", "a metadata field with this name already exists", field.Name, http.StatusConflict)
+	}
+
+	field, err = s.repo.FindField(ctx, field.Name)
+	if err != nil {
+		return model.MetadataField{}, err
+	}
+	s.audit.Log("metadata_field", actor, "success", field.Name, nil, field, "")
+	return field, nil
+}
+
+// UpdateField changes the description of a field or adds enum options.
+// Options cannot be removed since values may use them.
+func (s *MetadataService) UpdateField(ctx context.Context, name string, request model.MetadataFieldUpdateRequest, actor model.AuditActor) (model.MetadataField, error) {
+	before, err := s.repo.FindField(ctx, strings.TrimSpace(name))
+	if err != nil {
+		return model.MetadataField{}, err
+	}
+
+	field := before
+	if request.Description != nil {
+		field.Description = strings.TrimSpace(*request.Description)
+		if err := validateMetadataDescription(field.Description); err != nil {
+			return model.MetadataField{}, err
+		}
+	}
+	if request.Options != nil {
+		options, err := normalizeMetadataOptions(field.Type, request.Options)
+		if err != nil {
+			return model.MetadataField{}, err
+		}
+		for _, option := range before.Options {
+			if !slices.Contains(options, option) {
+				return model.MetadataField{}, apierror.New("BAD_REQUEST", "enum options can only be added", option, http.StatusBadRequest)
+			}
+		}
+		field.Options = options
+	}
+
+	if err := s.repo.UpdateField(ctx, field); err != nil {
+		return model.MetadataField{}, err
+	}
+	field, err = s.repo.FindField(ctx, field.Name)
+	if err != nil {
+		return model.MetadataField{}, err
+	}
+	s.audit.Log("metadata_field", actor, "success", field.Name, before, field, "")
+	return field, nil
+}
+
+// DeleteField removes a field with all its values.
+func (s *MetadataService) DeleteField(ctx context.Context, name string, actor model.AuditActor) error {
+	before, err := s.repo.FindField(ctx, strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteField(ctx, before.Name); err != nil {
+		return err
+	}
+	s.audit.Log("metadata_field", actor, "success", before.Name, before, nil, "")
+	return nil
+}
+
+// UseQuickAccess records metadata changes as recent edits.
+func (s *MetadataService) UseQuickAccess(quick *QuickAccessService) {
+	s.quick = quick
+}
+
+// Get returns the metadata values of apiPath.
+func (s *MetadataService) Get(ctx context.Context, apiPath string) (model.FileMetadata, error) {
+	apiPath, err := existingEntryPath(s.store, apiPath, "root path cannot have metadata")
+	if err != nil {
+		return model.FileMetadata{}, err
+	}
+	return s.current(ctx, apiPath)
+}
+
+// Patch sets the values of request and removes the fields set to null. Every
+// value is checked against its field before anything changes.
+func (s *MetadataService) Patch(ctx context.Context, request model.MetadataPatchRequest, actor model.AuditActor) (model.FileMetadata, error) {
+	if len(request.Values) == 0 {
+		return model.FileMetadata{}, apierror.New("BAD_REQUEST", "values are required", "values", http.StatusBadRequest)
+	}
+	if len(request.Values) > maxMetadataValuesPerRequest {
+		return model.FileMetadata{}, apierror.New("BAD_REQUEST", "too many values", "values", http.StatusBadRequest)
+	}
+	apiPath, err := existingEntryPath(s.store, request.Path, "root path cannot have metadata")
+	if err != nil {
+		return model.FileMetadata{}, err
+	}
+
+	fields, err := s.fieldsByName(ctx)
+	if err != nil {
+		return model.FileMetadata{}, err
+	}
+	set := make([]model.MetadataValue, 0, len(request.Values))
+	remove := make([]string, 0)
+	for _, name := range slices.Sorted(maps.Keys(request.Values)) {
+		field, ok := fields[name]
+		if !ok {
+			return model.FileMetadata{}, apierror.New("BAD_REQUEST", "unknown metadata field", name, http.StatusBadRequest)
+		}
+		raw := request.Values[name]
+		if raw == nil {
+			remove = append(remove, name)
+			continue
+		}
+		value, err := metadataValue(field, raw)
+		if err != nil {
+			return model.FileMetadata{}, err
+		}
+		set = append(set, value)
+	}
+
+	before, err := s.current(ctx, apiPath)
+	if err != nil {
+		return model.FileMetadata{}, err
+	}
+	if err := s.repo.Apply(ctx, apiPath, set, remove, actor.Username); err != nil {
+		s.audit.Log("metadata", actor, "failed", apiPath, before.Values, nil, err.Error())
+		return model.FileMetadata{}, err
+	}
+	after, err := s.current(ctx, apiPath)
+	if err != nil {
+		return model.FileMetadata{}, err
+	}
+	if maps.Equal(before.Values, after.Values) {
+		return after, nil
+	}
+
+	s.audit.Log("metadata", actor, "success", apiPath, before.Values, after.Values, "")
+	s.quick.touched(ctx, apiPath, recentActionEdit, actor)
+	if s.bus != nil {
+		s.bus.Publish(event.Event{
+			ID:        uuid.NewString(),
+			Type:      event.TypeFileMetadataChanged,
+			Payload:   after,
+			Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+			ActorID:   actor.Username,
+		})
+	}
+	return after, nil
+}
+
+// Annotate sets the metadata values of item.
+func (s *MetadataService) Annotate(ctx context.Context, item *model.FileItem) error {
+	if s == nil {
+		return nil
+	}
+	current, err := s.current(ctx, item.Path)
+	if err != nil {
+		return err
+	}
+	if len(current.Values) > 0 {
+		item.Metadata = current.Values
+	}
+	return nil
+}
+
+// resolveFilters checks search filters against the field definitions and
+// sets their types.
+func (s *MetadataService) resolveFilters(ctx context.Context, filters []model.MetadataFilter) ([]model.MetadataFilter, error) {
+	if len(filters) == 0 {
+		return filters, nil
+	}
+	if s == nil {
+		return nil, apierror.New("BAD_REQUEST", "metadata filters are not available", "q", http.StatusBadRequest)
+	}
+
+	fields, err := s.fieldsByName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make([]model.MetadataFilter, 0, len(filters))
+	for _, filter := range filters {
+		field, ok := fields[filter.Field]
+		if !ok {
+			return nil, apierror.New("BAD_REQUEST", "unknown metadata field", filter.Field, http.StatusBadRequest)
+		}
+		checked, err := resolveMetadataFilter(field, filter)
+		if err != nil {
+			return nil, apierror.New("BAD_REQUEST", "invalid metadata filter: "+err.Error(), filter.Field, http.StatusBadRequest)
+		}
+		resolved = append(resolved, checked)
+	}
+	return resolved, nil
+}
+
+func (s *MetadataService) fieldsByName(ctx context.Context) (map[string]model.MetadataField, error) {
+	fields, err := s.repo.Fields(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]model.MetadataField, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	return byName, nil
+}
+
+func (s *MetadataService) current(ctx context.Context, apiPath string) (model.FileMetadata, error) {
+	values, err := s.repo.Values(ctx, apiPath)
+	if err != nil {
+		return model.FileMetadata{}, err
+	}
+	current := model.FileMetadata{Path: apiPath, Values: make(map[string]any, len(values))}
+	for _, value := range values {
+		if value.Number != nil {
+			current.Values[value.Field] = *value.Number
+		} else {
+			current.Values[value.Field] = value.Text
+		}
+	}
+	return current, nil
+}
+
+// moved makes the values of from and everything below it follow the entry to
+// to.
+func (s *MetadataService) moved(ctx context.Context, from string, to string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.MoveTree(context.WithoutCancel(ctx), normalizeAPIPath(from), normalizeAPIPath(to)); err != nil {
+		slog.Warn("failed to move file metadata", "from", from, "to", to, "error", err)
+	}
+}
+
+// copied gives the copy at to of from, and everything below it, the values
+// of the originals.
+func (s *MetadataService) copied(ctx context.Context, from string, to string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.CopyTree(context.WithoutCancel(ctx), normalizeAPIPath(from), normalizeAPIPath(to)); err != nil {
+		slog.Warn("failed to copy file metadata", "from", from, "to", to, "error", err)
+	}
+}
+
+// trashed parks the values of apiPath and everything below it with the trash
+// record trashID.
+func (s *MetadataService) trashed(ctx context.Context, apiPath string, trashID string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.Trash(context.WithoutCancel(ctx), normalizeAPIPath(apiPath), trashID); err != nil {
+		slog.Warn("failed to trash file metadata", "path", apiPath, "trash_id", trashID, "error", err)
+	}
+}
+
+// restored brings back the values parked with the trash record trashID.
+func (s *MetadataService) restored(ctx context.Context, trashID string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.Restore(context.WithoutCancel(ctx), trashID); err != nil {
+		slog.Warn("failed to restore file metadata", "trash_id", trashID, "error", err)
+	}
+}
+
+// purged removes the values parked with the trash record trashID, or with
+// every trash record when trashID is empty.
+func (s *MetadataService) purged(ctx context.Context, trashID string) {
+	if s == nil {
+		return
+	}
+	var err error
+	if trashID == "" {
+		err = s.repo.PurgeTrashed(context.WithoutCancel(ctx))
+	} else {
+		err = s.repo.Purge(context.WithoutCancel(ctx), trashID)
+	}
+	if err != nil {
+		slog.Warn("failed to purge file metadata", "trash_id", trashID, "error", err)
+	}
+}
+
+func validateMetadataDescription(description string) error {
+	if len(description) > maxMetadataDescription {
+		return apierror.New("BAD_REQUEST", fmt.Sprintf("description must be at most %d characters", maxMetadataDescription), "description", http.StatusBadRequest)
+	}
+	return nil
+}
+
+// normalizeMetadataOptions validates the options of a field of fieldType:
+// enums need at least one, other types none.
+func normalizeMetadataOptions(fieldType string, options []string) ([]string, error) {
+	if fieldType != "enum" {
+		if len(options) > 0 {
+			return nil, apierror.New("BAD_REQUEST", "only enum fields have options", "options", http.StatusBadRequest)
+		}
+		return []string{}, nil
+	}
+	if len(options) == 0 {
+		return nil, apierror.New("BAD_REQUEST", "enum fields need at least one option", "options", http.StatusBadRequest)
+	}
+	if len(options) > maxMetadataEnumOptions {
+		return nil, apierror.New("BAD_REQUEST", "too many options", "options", http.StatusBadRequest)
+	}
+
+	normalized := make([]string, 0, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" || len(option) > maxMetadataOptionLength {
+			return nil, apierror.New("BAD_REQUEST", fmt.Sprintf("options must be 1 to %d characters", maxMetadataOptionLength), "options", http.StatusBadRequest)
+		}
+		if slices.Contains(normalized, option) {
+			return nil, apierror.New("BAD_REQUEST", "options must be unique", option, http.StatusBadRequest)
+		}
+		normalized = append(normalized, option)
+	}
+	return normalized, nil
+}
+
+// metadataValue checks a JSON value against field and returns it in its
+// stored form.
+func metadataValue(field model.MetadataField, raw any) (model.MetadataValue, error) {
+	value := model.MetadataValue{Field: field.Name, Type: field.Type}
+	invalid := func(expected string) (model.MetadataValue, error) {
+		return model.MetadataValue{}, apierror.New("BAD_REQUEST", field.Name+" must be "+expected, field.Name, http.StatusBadRequest)
+	}
+
+	switch field.Type {
+	case "number":
+		number, ok := raw.(float64)
+		if !ok || math.IsInf(number, 0) || math.IsNaN(number) {
+			return invalid("a number")
+		}
+		value.Number = &number
+		value.Text = strconv.FormatFloat(number, 'g', -1, 64)
+	case "date":
+		text, ok := raw.(string)
+		if !ok {
+			return invalid("a date (YYYY-MM-DD)")
+		}
+		date, err := time.Parse(metadataDateLayout, strings.TrimSpace(text))
+		if err != nil {
+			return invalid("a date (YYYY-MM-DD)")
+		}
+		value.Text = date.Format(metadataDateLayout)
+	case "enum":
+		text, ok := raw.(string)
+		if !ok || !slices.Contains(field.Options, text) {
+			return invalid("one of " + strings.Join(field.Options, ", "))
+		}
+		value.Text = text
+	default:
+		text, ok := raw.(string)
+		if !ok || strings.TrimSpace(text) == "" || len(text) > maxMetadataStringLength {
+			return invalid(fmt.Sprintf("a string of 1 to %d characters", maxMetadataStringLength))
+		}
+		value.Text = text
+	}
+	return value, nil
+}
+
+// resolveMetadataFilter checks that filter compares field with a valid value
+// and operator. Strings and enums only compare for equality.
+func resolveMetadataFilter(field model.MetadataField, filter model.MetadataFilter) (model.MetadataFilter, error) {
+	filter.Type = field.Type
+	switch field.Type {
+	case "number":
+		number, err := strconv.ParseFloat(filter.Value, 64)
+		if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+			return model.MetadataFilter{}, fmt.Errorf("%s must be a number", field.Name)
+		}
+		filter.Value = strconv.FormatFloat(number, 'g', -1, 64)
+	case "date":
+		date, err := time.Parse(metadataDateLayout, filter.Value)
+		if err != nil {
+			return model.MetadataFilter{}, fmt.Errorf("%s must be a date (YYYY-MM-DD)", field.Name)
+		}
+		filter.Value = date.Format(metadataDateLayout)
+	default:
+		if filter.Op != "=" && filter.Op != "!=" {
+			return model.MetadataFilter{}, fmt.Errorf("%s only supports = and !=", field.Name)
+		}
+		if field.Type == "enum" && !slices.Contains(field.Options, filter.Value) {
+			return model.MetadataFilter{}, fmt.Errorf("%s must be one of %s", field.Name, strings.Join(field.Options, ", "))
+		}
+	}
+	return filter, nil
+}
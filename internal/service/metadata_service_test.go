@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+)
+
+func TestNormalizeMetadataOptions(t *testing.T) {
+	options, err := normalizeMetadataOptions("enum", []string{" draft ", "final"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"draft", "final"}, options)
+
+	options, err = normalizeMetadataOptions("string", nil)
+	require.NoError(t, err)
+	require.Empty(t, options)
+
+	_, err = normalizeMetadataOptions("enum", nil)
+	require.ErrorContains(t, err, "at least one option")
+	_, err = normalizeMetadataOptions("enum", []string{"draft", "draft "})
+	require.ErrorContains(t, err, "unique")
+	_, err = normalizeMetadataOptions("number", []string{"1"})
+	require.ErrorContains(t, err, "only enum fields")
+}
+
+func TestMetadataValue(t *testing.T) {
+	status := model.MetadataField{Name: "status", Type: "enum", Options: []string{"draft", "final"}}
+	value, err := metadataValue(status, "final")
+	require.NoError(t, err)
+	require.Equal(t, "final", value.Text)
+
+	amount := model.MetadataField{Name: "amount", Type: "number"}
+	value, err = metadataValue(amount, 12.5)
+	require.NoError(t, err)
+	require.Equal(t, 12.5, *value.Number)
+	require.Equal(t, "12.5", value.Text)
+
+	due := model.MetadataField{Name: "due", Type: "date"}
+	value, err = metadataValue(due, " 2026-07-01 ")
+	require.NoError(t, err)
+	require.Equal(t, "2026-07-01", value.Text)
+
+	client := model.MetadataField{Name: "client", Type: "string"}
+	cases := []struct {
+		field model.MetadataField
+		raw   any
+	}{
+		{status, "archived"},
+		{amount, "12"},
+		{due, "01/07/2026"},
+		{due, 20260701.0},
+		{client, ""},
+		{client, true},
+	}
+	for _, tc := range cases {
+		_, err := metadataValue(tc.field, tc.raw)
+		require.ErrorContains(t, err, tc.field.Name+" must be", tc.raw)
+	}
+}
+
+func TestResolveMetadataFilter(t *testing.T) {
+	amount := model.MetadataField{Name: "amount", Type: "number"}
+	filter, err := resolveMetadataFilter(amount, model.MetadataFilter{Field: "amount", Op: ">=", Value: "1e3"})
+	require.NoError(t, err)
+	require.Equal(t, model.MetadataFilter{Field: "amount", Op: ">=", Value: "1000", Type: "number"}, filter)
+
+	_, err = resolveMetadataFilter(amount, model.MetadataFilter{Field: "amount", Op: ">", Value: "lots"})
+	require.ErrorContains(t, err, "amount must be a number")
+
+	client := model.MetadataField{Name: "client", Type: "string"}
+	_, err = resolveMetadataFilter(client, model.MetadataFilter{Field: "client", Op: ">", Value: "acme"})
+	require.ErrorContains(t, err, "only supports = and !=")
+
+	status := model.MetadataField{Name: "status", Type: "enum", Options: []string{"draft", "final"}}
+	_, err = resolveMetadataFilter(status, model.MetadataFilter{Field: "status", Op: "=", Value: "archived"})
+	require.ErrorContains(t, err, "status must be one of draft, final")
+}
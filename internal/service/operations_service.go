@@ -2,10 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -20,17 +21,37 @@ import (
 )
 
 type OperationsService struct {
-	store storage.Storage
-	trash *TrashService
-	audit *AuditService
-	bus   event.Bus
+	store    storage.Storage
+	trash    *TrashService
+	audit    *AuditService
+	bus      event.Bus
+	tags     *TagService
+	metadata *MetadataService
+	quick    *QuickAccessService
 }
 
 func NewOperationsService(store storage.Storage, trash *TrashService, audit *AuditService, bus event.Bus) *OperationsService {
 	return &OperationsService{store: store, trash: trash, audit: audit, bus: bus}
 }
 
-func (s *OperationsService) Rename(_ context.Context, oldPath string, newName string, actor model.AuditActor) (model.RenameResponse, error) {
+// UseTags makes renamed and moved entries keep their tags.
+func (s *OperationsService) UseTags(tags *TagService) {
+	s.tags = tags
+}
+
+// UseMetadata makes renamed, moved and copied entries keep their custom
+// metadata.
+func (s *OperationsService) UseMetadata(metadata *MetadataService) {
+	s.metadata = metadata
+}
+
+// UseQuickAccess makes favorites and recent files follow renamed and moved
+// entries, and records renames as recent edits.
+func (s *OperationsService) UseQuickAccess(quick *QuickAccessService) {
+	s.quick = quick
+}
+
+func (s *OperationsService) Rename(ctx context.Context, oldPath string, newName string, actor model.AuditActor) (model.RenameResponse, error) {
 	if strings.TrimSpace(oldPath) == "" {
 		s.audit.Log("rename", actor, "failed", oldPath, map[string]any{"path": oldPath}, nil, "path is required")
 		return model.RenameResponse{}, fmt.Errorf("%w: path is required", model.ErrInvalidInput)
@@ -75,6 +96,10 @@ func (s *OperationsService) Rename(_ context.Context, oldPath string, newName st
 	}
 
 	result := model.RenameResponse{OldPath: normalizeAPIPath(oldPath), NewPath: newAPIPath, Name: safeName}
+	s.tags.moved(ctx, result.OldPath, result.NewPath)
+	s.metadata.moved(ctx, result.OldPath, result.NewPath)
+	s.quick.moved(ctx, result.OldPath, result.NewPath)
+	s.quick.touched(ctx, result.NewPath, recentActionEdit, actor)
 	s.audit.Log("rename", actor, "success", normalizeAPIPath(oldPath), map[string]any{"path": normalizeAPIPath(oldPath)}, map[string]any{"path": newAPIPath}, "")
 
 	if s.bus != nil {
@@ -90,7 +115,7 @@ func (s *OperationsService) Rename(_ context.Context, oldPath string, newName st
 	return result, nil
 }
 
-func (s *OperationsService) Move(_ context.Context, sources []string, destination string, conflictPolicy string, actor model.AuditActor) (model.MoveResponse, error) {
+func (s *OperationsService) Move(ctx context.Context, sources []string, destination string, conflictPolicy string, actor model.AuditActor) (model.MoveResponse, error) {
 	if len(sources) == 0 {
 		s.audit.Log("move", actor, "failed", destination, map[string]any{"sources": sources, "destination": destination}, nil, "sources are required")
 		return model.MoveResponse{}, fmt.Errorf("%w: sources are required", model.ErrInvalidInput)
@@ -116,7 +141,12 @@ func (s *OperationsService) Move(_ context.Context, sources []string, destinatio
 	result := model.MoveResponse{Moved: []model.MoveCopyResult{}, Failed: []model.MoveCopyFailure{}}
 
 	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		source = normalizeAPIPath(source)
+		util.ProgressFromContext(ctx).SetItem(source)
 		if source == "/" {
 			result.Failed = append(result.Failed, model.MoveCopyFailure{From: source, Reason: "root path cannot be moved"})
 			s.audit.Log("move", actor, "failed", source, map[string]any{"from": source}, nil, "root path cannot be moved")
@@ -149,6 +179,9 @@ func (s *OperationsService) Move(_ context.Context, sources []string, destinatio
 		}
 
 		result.Moved = append(result.Moved, model.MoveCopyResult{From: source, To: resolvedTarget})
+		s.tags.moved(ctx, source, resolvedTarget)
+		s.metadata.moved(ctx, source, resolvedTarget)
+		s.quick.moved(ctx, source, resolvedTarget)
 		s.audit.Log("move", actor, "success", source, map[string]any{"from": source}, map[string]any{"to": resolvedTarget}, "")
 
 		if s.bus != nil {
@@ -165,7 +198,7 @@ func (s *OperationsService) Move(_ context.Context, sources []string, destinatio
 	return result, nil
 }
 
-func (s *OperationsService) Copy(_ context.Context, sources []string, destination string, conflictPolicy string, actor model.AuditActor) (model.CopyResponse, error) {
+func (s *OperationsService) Copy(ctx context.Context, sources []string, destination string, conflictPolicy string, actor model.AuditActor) (model.CopyResponse, error) {
 	if len(sources) == 0 {
 		s.audit.Log("copy", actor, "failed", destination, map[string]any{"sources": sources, "destination": destination}, nil, "sources are required")
 		return model.CopyResponse{}, apierror.New("BAD_REQUEST", "sources are required", "sources", http.StatusBadRequest)
@@ -190,8 +223,25 @@ func (s *OperationsService) Copy(_ context.Context, sources []string, destinatio
 
 	result := model.CopyResponse{Copied: []model.MoveCopyResult{}, Failed: []model.MoveCopyFailure{}}
 
+	// Size the whole selection up front so byte progress has a total.
+	progress := util.ProgressFromContext(ctx)
+	if progress != nil {
+		for _, source := range sources {
+			if resolved, err := s.store.Resolve(normalizeAPIPath(source)); err == nil {
+				if size, err := util.TreeSize(resolved); err == nil {
+					progress.AddTotal(size)
+				}
+			}
+		}
+	}
+
 	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		source = normalizeAPIPath(source)
+		progress.SetItem(source)
 		sourceResolved, err := s.store.Resolve(source)
 		if err != nil {
 			result.Failed = append(result.Failed, model.MoveCopyFailure{From: source, Reason: err.Error()})
@@ -226,13 +276,20 @@ func (s *OperationsService) Copy(_ context.Context, sources []string, destinatio
 			continue
 		}
 
-		if err := copyRecursive(sourceResolved, resolvedTargetAbs); err != nil {
+		if err := copyRecursive(ctx, sourceResolved, resolvedTargetAbs); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				// Drop the half-written copy; the source is left untouched.
+				_ = os.RemoveAll(resolvedTargetAbs)
+				s.audit.Log("copy", actor, "failed", source, map[string]any{"from": source}, nil, "cancelled")
+				return result, ctxErr
+			}
 			result.Failed = append(result.Failed, model.MoveCopyFailure{From: source, Reason: err.Error()})
 			s.audit.Log("copy", actor, "failed", source, map[string]any{"from": source}, nil, err.Error())
 			continue
 		}
 
 		result.Copied = append(result.Copied, model.MoveCopyResult{From: source, To: resolvedTarget})
+		s.metadata.copied(ctx, source, resolvedTarget)
 		s.audit.Log("copy", actor, "success", source, map[string]any{"from": source}, map[string]any{"to": resolvedTarget}, "")
 
 		if s.bus != nil {
@@ -249,7 +306,7 @@ func (s *OperationsService) Copy(_ context.Context, sources []string, destinatio
 	return result, nil
 }
 
-func (s *OperationsService) Delete(_ context.Context, paths []string, actor model.AuditActor) (model.DeleteResponse, error) {
+func (s *OperationsService) Delete(ctx context.Context, paths []string, actor model.AuditActor) (model.DeleteResponse, error) {
 	if len(paths) == 0 {
 		s.audit.Log("delete", actor, "failed", "", map[string]any{"paths": paths}, nil, "paths are required")
 		return model.DeleteResponse{}, apierror.New("BAD_REQUEST", "paths are required", "paths", http.StatusBadRequest)
@@ -258,15 +315,24 @@ func (s *OperationsService) Delete(_ context.Context, paths []string, actor mode
 	result := model.DeleteResponse{Deleted: []string{}, Failed: []model.DeleteFailure{}}
 
 	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		path = normalizeAPIPath(path)
+		util.ProgressFromContext(ctx).SetItem(path)
 		if path == "/" {
 			result.Failed = append(result.Failed, model.DeleteFailure{Path: path, Reason: "root path cannot be deleted"})
 			s.audit.Log("delete", actor, "failed", path, map[string]any{"path": path}, nil, "root path cannot be deleted")
 			continue
 		}
 
-		record, err := s.trash.SoftDelete(path, actor)
+		record, err := s.trash.SoftDelete(ctx, path, actor)
 		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				s.audit.Log("delete", actor, "failed", path, map[string]any{"path": path}, nil, "cancelled")
+				return result, ctxErr
+			}
 			result.Failed = append(result.Failed, model.DeleteFailure{Path: path, Reason: err.Error()})
 			s.audit.Log("delete", actor, "failed", path, map[string]any{"path": path}, nil, err.Error())
 			continue
@@ -318,6 +384,16 @@ func (s *OperationsService) Restore(_ context.Context, paths []string, actor mod
 
 		result.Restored = append(result.Restored, path)
 		s.audit.Log("restore", actor, "success", path, map[string]any{"trash_id": record.ID}, map[string]any{"path": path, "restored_at": record.RestoredAt}, "")
+
+		if s.bus != nil {
+			s.bus.Publish(event.Event{
+				ID:        uuid.NewString(),
+				Type:      event.TypeFileRestored,
+				Payload:   map[string]string{"path": path},
+				Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+				ActorID:   actor.Username,
+			})
+		}
 	}
 
 	return result, nil
@@ -337,18 +413,25 @@ func (s *OperationsService) PermanentDeleteTrash(_ context.Context, trashID stri
 	return nil
 }
 
-func (s *OperationsService) EmptyTrash(_ context.Context, actor model.AuditActor) (int, error) {
-	count, err := s.trash.EmptyTrash()
+// EmptyTrash permanently deletes the trash, or only the items trashed more
+// than olderThan ago when it is positive.
+func (s *OperationsService) EmptyTrash(_ context.Context, olderThan time.Duration, actor model.AuditActor) (int, error) {
+	var before map[string]any
+	if olderThan > 0 {
+		before = map[string]any{"older_than": olderThan.String()}
+	}
+
+	count, err := s.trash.EmptyTrash(olderThan)
 	if err != nil {
-		s.audit.Log("empty_trash", actor, "failed", "", nil, nil, err.Error())
+		s.audit.Log("empty_trash", actor, "failed", "", before, nil, err.Error())
 		return count, err
 	}
 
-	s.audit.Log("empty_trash", actor, "success", "", nil, map[string]any{"deleted_count": count}, "")
+	s.audit.Log("empty_trash", actor, "success", "", before, map[string]any{"deleted_count": count}, "")
 	return count, nil
 }
 
-func (s *OperationsService) Compress(_ context.Context, sources []string, destination string, name string, actor model.AuditActor) (model.CompressResponse, error) {
+func (s *OperationsService) Compress(ctx context.Context, sources []string, destination string, name string, actor model.AuditActor) (model.CompressResponse, error) {
 	if len(sources) == 0 {
 		s.audit.Log("compress", actor, "failed", destination, map[string]any{"sources": sources, "destination": destination}, nil, "sources are required")
 		return model.CompressResponse{}, apierror.New("BAD_REQUEST", "sources are required", "sources", http.StatusBadRequest)
@@ -396,7 +479,8 @@ func (s *OperationsService) Compress(_ context.Context, sources []string, destin
 		sourcePaths = append(sourcePaths, res)
 	}
 
-	if err := util.Compress(sourcePaths, zipPathResolved); err != nil {
+	// util.Compress removes the partial zip itself when it fails or is cancelled.
+	if err := util.Compress(ctx, sourcePaths, zipPathResolved); err != nil {
 		s.audit.Log("compress", actor, "failed", zipPathAPI, nil, nil, err.Error())
 		return model.CompressResponse{}, err
 	}
@@ -423,51 +507,98 @@ func (s *OperationsService) Compress(_ context.Context, sources []string, destin
 	return resp, nil
 }
 
-func (s *OperationsService) Decompress(_ context.Context, source string, destination string, conflictPolicy string, actor model.AuditActor) (model.DecompressResponse, error) {
-	source = normalizeAPIPath(source)
+func (s *OperationsService) Decompress(ctx context.Context, request model.DecompressRequest, actor model.AuditActor) (model.DecompressResponse, error) {
+	source := normalizeAPIPath(request.Source)
 	sourceResolved, err := s.store.Resolve(source)
 	if err != nil {
 		s.audit.Log("decompress", actor, "failed", source, nil, nil, err.Error())
 		return model.DecompressResponse{}, err
 	}
 
-	destination = normalizeAPIPath(destination)
+	destination := normalizeAPIPath(request.Destination)
 	destResolved, err := s.store.Resolve(destination)
 	if err != nil {
 		s.audit.Log("decompress", actor, "failed", destination, nil, nil, err.Error())
 		return model.DecompressResponse{}, err
 	}
 
+	if request.StripComponents < 0 {
+		return model.DecompressResponse{}, apierror.New("BAD_REQUEST", "strip_components must not be negative", "strip_components", http.StatusBadRequest)
+	}
+
+	conflictPolicy := strings.ToLower(strings.TrimSpace(request.ConflictPolicy))
+	if conflictPolicy != "" {
+		if conflictPolicy, err = normalizeConflictPolicy(conflictPolicy); err != nil {
+			return model.DecompressResponse{}, err
+		}
+	}
+
+	options := util.ExtractOptions{
+		Entries:         request.Entries,
+		StripComponents: request.StripComponents,
+		Flatten:         request.Flatten,
+	}
+	before := map[string]any{"source": source, "destination": destination}
+	if len(request.Entries) > 0 || request.StripComponents > 0 || request.Flatten {
+		before["entries"] = request.Entries
+		before["strip_components"] = request.StripComponents
+		before["flatten"] = request.Flatten
+	}
+
 	if err := s.store.MkdirAll(destination, 0o755); err != nil {
 		s.audit.Log("decompress", actor, "failed", destination, nil, nil, err.Error())
 		return model.DecompressResponse{}, err
 	}
 
-	if conflictPolicy != "overwrite" {
-		conflicts, err := util.CheckZipConflicts(sourceResolved, destResolved)
+	// Overwrite replaces existing files and skip leaves them alone; any other
+	// policy refuses to extract while conflicts exist.
+	if conflictPolicy != ConflictPolicyOverwrite && conflictPolicy != ConflictPolicySkip {
+		conflicts, err := util.CheckZipConflicts(sourceResolved, destResolved, options)
 		if err != nil {
-			s.audit.Log("decompress", actor, "failed", source, nil, nil, err.Error())
-			return model.DecompressResponse{}, err
+			s.audit.Log("decompress", actor, "failed", source, before, nil, err.Error())
+			return model.DecompressResponse{}, mapExtractError(err)
 		}
 		if len(conflicts) > 0 {
-			s.audit.Log("decompress", actor, "failed", source, map[string]any{"source": source, "destination": destination}, nil, "conflicting files found")
+			s.audit.Log("decompress", actor, "failed", source, before, nil, "conflicting files found")
 			// Return special conflict error/response
 			return model.DecompressResponse{Conflicts: conflicts}, apierror.New("CONFLICT", "conflicting files found", "conflicts", http.StatusConflict)
 		}
 	}
 
-	files, err := util.Decompress(sourceResolved, destResolved)
-	if err != nil {
-		s.audit.Log("decompress", actor, "failed", source, nil, nil, err.Error())
-		return model.DecompressResponse{}, err
+	results, err := util.Decompress(ctx, sourceResolved, destResolved, options, conflictPolicy == ConflictPolicyOverwrite)
+	if err != nil && ctx.Err() == nil {
+		s.audit.Log("decompress", actor, "failed", source, before, nil, err.Error())
+		return model.DecompressResponse{}, mapExtractError(err)
 	}
 
 	resp := model.DecompressResponse{
 		Destination: destination,
-		Files:       files,
+		Files:       []string{},
+		Entries:     make([]model.DecompressEntryResult, 0, len(results)),
+	}
+	for _, result := range results {
+		entry := model.DecompressEntryResult{Entry: result.Entry, Status: result.Status, Reason: result.Reason}
+		if result.Target != "" {
+			entry.Path = normalizeAPIPath(path.Join(destination, result.Target))
+		}
+		switch result.Status {
+		case "success":
+			resp.Files = append(resp.Files, result.Target)
+		case "skipped":
+			if result.Target != "" {
+				resp.Conflicts = append(resp.Conflicts, result.Entry)
+			}
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+
+	// A cancelled extraction keeps what was written so far and reports it.
+	if err != nil {
+		s.audit.Log("decompress", actor, "failed", source, before, map[string]any{"destination": destination, "files_count": len(resp.Files)}, "cancelled")
+		return resp, err
 	}
 
-	s.audit.Log("decompress", actor, "success", source, map[string]any{"source": source}, map[string]any{"destination": destination, "files_count": len(files)}, "")
+	s.audit.Log("decompress", actor, "success", source, before, map[string]any{"destination": destination, "files_count": len(resp.Files)}, "")
 
 	if s.bus != nil {
 		s.bus.Publish(event.Event{
@@ -482,7 +613,22 @@ func (s *OperationsService) Decompress(_ context.Context, source string, destina
 	return resp, nil
 }
 
-func copyRecursive(source string, target string) error {
+// mapExtractError turns archive selection errors into client errors.
+func mapExtractError(err error) error {
+	if errors.Is(err, util.ErrNoMatchingEntries) || errors.Is(err, util.ErrInvalidEntryPattern) {
+		return apierror.New("BAD_REQUEST", err.Error(), "entries", http.StatusBadRequest)
+	}
+	return err
+}
+
+// copyRecursive copies source to target, checking ctx between entries and
+// between chunks of each file. On cancellation ctx.Err() is returned and the
+// caller is responsible for removing the partial target.
+func copyRecursive(ctx context.Context, source string, target string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	info, err := os.Stat(source)
 	if err != nil {
 		return err
@@ -502,7 +648,7 @@ func copyRecursive(source string, target string) error {
 			if entry.Type()&os.ModeSymlink != 0 {
 				continue
 			}
-			if err := copyRecursive(filepath.Join(source, entry.Name()), filepath.Join(target, entry.Name())); err != nil {
+			if err := copyRecursive(ctx, filepath.Join(source, entry.Name()), filepath.Join(target, entry.Name())); err != nil {
 				return err
 			}
 		}
@@ -526,6 +672,6 @@ func copyRecursive(source string, target string) error {
 	}
 	defer targetFile.Close()
 
-	_, err = io.Copy(targetFile, sourceFile)
+	_, err = util.CopyWithContext(ctx, targetFile, sourceFile)
 	return err
 }
@@ -0,0 +1,496 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/repository"
+	"go-file-explorer/pkg/apierror"
+)
+
+const (
+	// PipelineStepStop ends the pipeline when a step does not complete;
+	// PipelineStepContinue moves on to the next step.
+	PipelineStepStop     = "stop"
+	PipelineStepContinue = "continue"
+
+	pipelineMaxSteps = 20
+	// pipelinePollInterval is how often running pipelines are checked for
+	// steps whose job finished in another process or was cancelled while
+	// queued.
+	pipelinePollInterval = 5 * time.Second
+)
+
+// pipelineRefPattern matches a reference to the results of an earlier step,
+// e.g. {steps.extract.destination} or {steps.0.outputs}.
+var pipelineRefPattern = regexp.MustCompile(`\{steps\.([A-Za-z0-9_-]+)\.([a-z_]+)\}`)
+
+var pipelineStepIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// pipelineRefFields are the results of a step that later steps can use:
+// destination, sources and paths of its request, the paths it produced
+// (outputs), and its single main result (output).
+var pipelineRefFields = map[string]bool{
+	"destination": true,
+	"sources":     true,
+	"paths":       true,
+	"outputs":     true,
+	"output":      true,
+}
+
+// pipelineStepResult is what a finished step exposes to later steps.
+type pipelineStepResult struct {
+	request model.JobOperationRequest
+	outputs []string
+}
+
+// PipelineService runs pipelines: job steps queued one after another, each
+// once the previous one has finished. The state lives in Postgres and each
+// transition is a conditional update, so any instance can move a pipeline
+// on and none does it twice.
+type PipelineService struct {
+	repo  *repository.PipelineRepository
+	users *repository.UserRepository
+	jobs  *JobService
+}
+
+func NewPipelineService(repo *repository.PipelineRepository, users *repository.UserRepository, jobs *JobService) *PipelineService {
+	return &PipelineService{repo: repo, users: users, jobs: jobs}
+}
+
+// Create stores a pipeline and queues its first step.
+func (s *PipelineService) Create(request model.PipelineRequest, actor model.AuditActor) (model.PipelineData, error) {
+	steps, err := validatePipelineSteps(request.Steps)
+	if err != nil {
+		return model.PipelineData{}, err
+	}
+
+	pipeline := model.PipelineData{
+		PipelineID:    uuid.NewString(),
+		Name:          strings.TrimSpace(request.Name),
+		Status:        "running",
+		OwnerID:       actor.UserID,
+		OwnerUsername: actor.Username,
+		OwnerRole:     actor.Role,
+		Steps:         steps,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	ctx := context.Background()
+	if err := s.repo.Create(ctx, pipeline); err != nil {
+		return model.PipelineData{}, err
+	}
+
+	if err := s.advance(ctx, pipeline.PipelineID); err != nil {
+		slog.Warn("start pipeline failed", "pipeline_id", pipeline.PipelineID, "error", err)
+	}
+	return s.repo.FindByID(ctx, pipeline.PipelineID)
+}
+
+// Get returns a pipeline to its owner or an admin.
+func (s *PipelineService) Get(id string, actor model.AuditActor) (model.PipelineData, error) {
+	pipeline, err := s.repo.FindByID(context.Background(), strings.TrimSpace(id))
+	if err != nil {
+		return model.PipelineData{}, err
+	}
+	if actor.Role != "admin" && pipeline.OwnerID != actor.UserID {
+		return model.PipelineData{}, model.ErrPipelineNotFound
+	}
+	return pipeline, nil
+}
+
+// JobFinished moves on the pipeline a finished job belongs to, if any. It is
+// registered with JobService.OnJobFinished.
+func (s *PipelineService) JobFinished(jobID string) {
+	go func() {
+		ctx := context.Background()
+		pipelineID, err := s.repo.PipelineForJob(ctx, jobID)
+		if err != nil {
+			slog.Warn("find pipeline for job failed", "job_id", jobID, "error", err)
+			return
+		}
+		if pipelineID == "" {
+			return
+		}
+		if err := s.advance(ctx, pipelineID); err != nil {
+			slog.Warn("advance pipeline failed", "pipeline_id", pipelineID, "error", err)
+		}
+	}()
+}
+
+// Run checks running pipelines until ctx is cancelled. It catches steps whose
+// job finished in another process, was cancelled while queued, or finished
+// while no instance was running.
+func (s *PipelineService) Run(ctx context.Context) {
+	ticker := time.NewTicker(pipelinePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ids, err := s.repo.Running(ctx, 100)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("load running pipelines failed", "error", err)
+				}
+				continue
+			}
+			for _, id := range ids {
+				if ctx.Err() != nil {
+					return
+				}
+				if err := s.advance(ctx, id); err != nil {
+					slog.Warn("advance pipeline failed", "pipeline_id", id, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// advance moves a running pipeline as far as it can go: it records the
+// outcome of a step whose job has finished, applies the step's failure
+// policy, and starts the next step. It returns once a step's job is still
+// queued or running, or the pipeline has finished.
+func (s *PipelineService) advance(ctx context.Context, id string) error {
+	for {
+		pipeline, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if pipeline.Status != "running" || pipeline.CurrentStep >= len(pipeline.Steps) {
+			return nil
+		}
+		index := pipeline.CurrentStep
+		step := pipeline.Steps[index]
+
+		switch step.Status {
+		case "pending":
+			claimed, err := s.repo.ClaimStep(ctx, id, index)
+			if err != nil || !claimed {
+				return err
+			}
+			actor, ownerErr := jobOwnerActor(ctx, s.users, pipeline.OwnerID)
+			if errors.Is(ownerErr, model.ErrUserNotFound) || errors.Is(ownerErr, model.ErrForbidden) {
+				// Nobody may run the remaining steps, whatever their
+				// failure policy.
+				reason := "pipeline owner no longer exists"
+				if errors.Is(ownerErr, model.ErrForbidden) {
+					reason = "pipeline owner no longer has the editor or admin role"
+				}
+				if _, err := s.repo.FinishStep(ctx, id, index, "failed", reason); err != nil {
+					return err
+				}
+				return s.repo.Finish(ctx, id, "failed")
+			}
+			if ownerErr != nil {
+				if _, err := s.repo.FinishStep(ctx, id, index, "failed", ownerErr.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			jobID, startErr := s.startStep(ctx, pipeline, step, actor)
+			if startErr != nil {
+				if _, err := s.repo.FinishStep(ctx, id, index, "failed", startErr.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			return s.repo.SetStepJob(ctx, id, index, jobID)
+
+		case "running":
+			status, reason, done := stepOutcome(step)
+			if !done {
+				return nil
+			}
+			finished, err := s.repo.FinishStep(ctx, id, index, status, reason)
+			if err != nil || !finished {
+				return err
+			}
+
+		default:
+			next, final := nextPipelineStatus(pipeline.Steps, index)
+			if final != "" {
+				return s.repo.Finish(ctx, id, final)
+			}
+			moved, err := s.repo.MoveTo(ctx, id, index, next)
+			if err != nil || !moved {
+				return err
+			}
+		}
+	}
+}
+
+// startStep resolves the references of a step and queues its job as actor,
+// the pipeline's owner as they are now.
+func (s *PipelineService) startStep(ctx context.Context, pipeline model.PipelineData, step model.PipelineStep, actor model.AuditActor) (string, error) {
+	results := make(map[string]pipelineStepResult, step.Index)
+	for _, earlier := range pipeline.Steps[:step.Index] {
+		if earlier.JobID == "" {
+			continue
+		}
+		result, err := s.stepResult(ctx, earlier)
+		if err != nil {
+			return "", err
+		}
+		results[earlier.ID] = result
+	}
+
+	request, err := resolvePipelineRequest(step.Request, results)
+	if err != nil {
+		return "", err
+	}
+
+	job, err := s.jobs.CreateOperationJob(request, actor)
+	if err != nil {
+		return "", err
+	}
+	return job.JobID, nil
+}
+
+// stepResult loads the request a step's job ran and the paths it produced.
+func (s *PipelineService) stepResult(ctx context.Context, step model.PipelineStep) (pipelineStepResult, error) {
+	request, items, err := s.jobs.JobResult(ctx, step.JobID)
+	if err != nil {
+		return pipelineStepResult{}, err
+	}
+
+	result := pipelineStepResult{request: request, outputs: []string{}}
+	switch request.Operation {
+	case "copy", "move", "compress", "decompress":
+		for _, item := range items {
+			if item.To != "" {
+				result.outputs = append(result.outputs, item.To)
+			} else if item.Path != "" {
+				result.outputs = append(result.outputs, item.Path)
+			}
+		}
+	}
+	return result, nil
+}
+
+// stepOutcome reports whether the job of a running step has finished, and
+// with which step status. A step whose job is missing fails once it has
+// been running long enough for the job to have been created.
+func stepOutcome(step model.PipelineStep) (string, string, bool) {
+	switch step.JobStatus {
+	case "completed", "partial", "failed", "cancelled":
+		return step.JobStatus, "", true
+	case "":
+		startedAt, err := time.Parse(time.RFC3339Nano, step.StartedAt)
+		if step.JobID == "" && err == nil && time.Since(startedAt) < jobStaleAfter {
+			return "", "", false
+		}
+		return "failed", "job no longer exists", true
+	default:
+		// queued, running, or interrupted and waiting to be resumed.
+		return "", "", false
+	}
+}
+
+// nextPipelineStatus returns the index of the step to run after step index,
+// or the final status of the pipeline when it ends there.
+func nextPipelineStatus(steps []model.PipelineStep, index int) (int, string) {
+	step := steps[index]
+	if step.Status == "cancelled" {
+		return 0, "cancelled"
+	}
+	if step.Status != "completed" && step.OnFailure != PipelineStepContinue {
+		return 0, "failed"
+	}
+	if index+1 < len(steps) {
+		return index + 1, ""
+	}
+
+	for _, done := range steps {
+		if done.Status != "completed" {
+			return 0, "partial"
+		}
+	}
+	return 0, "completed"
+}
+
+// validatePipelineSteps checks the steps of a new pipeline, including that
+// references only point at earlier steps, and returns them ready to store.
+func validatePipelineSteps(requests []model.PipelineStepRequest) ([]model.PipelineStep, error) {
+	if len(requests) == 0 {
+		return nil, apierror.New("BAD_REQUEST", "pipeline requires at least one step", "steps", http.StatusBadRequest)
+	}
+	if len(requests) > pipelineMaxSteps {
+		return nil, apierror.New("BAD_REQUEST", fmt.Sprintf("pipeline cannot have more than %d steps", pipelineMaxSteps), "steps", http.StatusBadRequest)
+	}
+
+	steps := make([]model.PipelineStep, 0, len(requests))
+	seen := make(map[string]bool, len(requests))
+	for index, stepRequest := range requests {
+		field := fmt.Sprintf("steps[%d]", index)
+
+		id := strings.TrimSpace(stepRequest.ID)
+		if id == "" {
+			id = strconv.Itoa(index)
+		}
+		if !pipelineStepIDPattern.MatchString(id) {
+			return nil, apierror.New("BAD_REQUEST", "step id may only contain letters, digits, '-' and '_'", field, http.StatusBadRequest)
+		}
+		if seen[id] {
+			return nil, apierror.New("BAD_REQUEST", "duplicate step id", field+": "+id, http.StatusBadRequest)
+		}
+
+		onFailure := strings.ToLower(strings.TrimSpace(stepRequest.OnFailure))
+		if onFailure == "" {
+			onFailure = PipelineStepStop
+		}
+		if onFailure != PipelineStepStop && onFailure != PipelineStepContinue {
+			return nil, apierror.New("BAD_REQUEST", "invalid on_failure (allowed: stop|continue)", field, http.StatusBadRequest)
+		}
+
+		request, _, err := validateJobRequest(stepRequest.Request)
+		if err != nil {
+			return nil, apierror.New("BAD_REQUEST", "invalid step request", field+": "+err.Error(), http.StatusBadRequest)
+		}
+
+		for _, value := range pipelineRequestValues(request) {
+			for _, match := range pipelineRefPattern.FindAllStringSubmatch(value, -1) {
+				if !seen[match[1]] {
+					return nil, apierror.New("BAD_REQUEST", "reference to a step that does not run earlier", field+": "+match[0], http.StatusBadRequest)
+				}
+				if !pipelineRefFields[match[2]] {
+					return nil, apierror.New("BAD_REQUEST", "unknown step field (allowed: destination|sources|paths|outputs|output)", field+": "+match[0], http.StatusBadRequest)
+				}
+			}
+		}
+
+		seen[id] = true
+		steps = append(steps, model.PipelineStep{
+			Index:     index,
+			ID:        id,
+			OnFailure: onFailure,
+			Request:   request,
+			Status:    "pending",
+		})
+	}
+	return steps, nil
+}
+
+// pipelineRequestValues returns the strings of request that may contain
+// references.
+func pipelineRequestValues(request model.JobOperationRequest) []string {
+	values := make([]string, 0, len(request.Sources)+len(request.Paths)+len(request.Entries)+2)
+	values = append(values, request.Sources...)
+	values = append(values, request.Paths...)
+	values = append(values, request.Entries...)
+	return append(values, request.Destination, request.Name)
+}
+
+// resolvePipelineRequest replaces the references in request with the results
+// of earlier steps. A list element that is exactly a reference to a list
+// (sources, paths, outputs) expands to all of its values; anywhere else a
+// reference must stand for a single value.
+func resolvePipelineRequest(request model.JobOperationRequest, results map[string]pipelineStepResult) (model.JobOperationRequest, error) {
+	var err error
+	if request.Sources, err = resolvePipelineList(request.Sources, results); err != nil {
+		return request, err
+	}
+	if request.Paths, err = resolvePipelineList(request.Paths, results); err != nil {
+		return request, err
+	}
+	if request.Entries, err = resolvePipelineList(request.Entries, results); err != nil {
+		return request, err
+	}
+	if request.Destination, err = resolvePipelineString(request.Destination, results); err != nil {
+		return request, err
+	}
+	if request.Name, err = resolvePipelineString(request.Name, results); err != nil {
+		return request, err
+	}
+	return request, nil
+}
+
+func resolvePipelineList(values []string, results map[string]pipelineStepResult) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+
+	resolved := make([]string, 0, len(values))
+	for _, value := range values {
+		if match := pipelineRefPattern.FindStringSubmatch(value); match != nil && match[0] == value {
+			all, err := pipelineRefValues(match[1], match[2], results)
+			if err != nil {
+				return nil, err
+			}
+			resolved = append(resolved, all...)
+			continue
+		}
+
+		single, err := resolvePipelineString(value, results)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, single)
+	}
+	return resolved, nil
+}
+
+func resolvePipelineString(value string, results map[string]pipelineStepResult) (string, error) {
+	var resolveErr error
+	resolved := pipelineRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		match := pipelineRefPattern.FindStringSubmatch(ref)
+		values, err := pipelineRefValues(match[1], match[2], results)
+		if err == nil && len(values) != 1 {
+			err = fmt.Errorf("%s has %d values where one is expected", ref, len(values))
+		}
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = err
+			}
+			return ref
+		}
+		return values[0]
+	})
+	return resolved, resolveErr
+}
+
+// pipelineRefValues returns the values a reference to field of step id
+// stands for.
+func pipelineRefValues(id string, field string, results map[string]pipelineStepResult) ([]string, error) {
+	result, ok := results[id]
+	if !ok {
+		return nil, fmt.Errorf("step %q has no results to reference", id)
+	}
+
+	switch field {
+	case "destination":
+		return []string{result.request.Destination}, nil
+	case "sources":
+		return result.request.Sources, nil
+	case "paths":
+		return result.request.Paths, nil
+	case "outputs":
+		return result.outputs, nil
+	case "output":
+		// The extracted folder of a decompress; the archive of a compress;
+		// the single copied or moved path otherwise.
+		if result.request.Operation == "decompress" {
+			return []string{result.request.Destination}, nil
+		}
+		if len(result.outputs) == 0 {
+			return nil, fmt.Errorf("step %q produced no output", id)
+		}
+		if len(result.outputs) > 1 {
+			return nil, fmt.Errorf("step %q produced %d outputs; use {steps.%s.outputs}", id, len(result.outputs), id)
+		}
+		return result.outputs, nil
+	}
+	return nil, fmt.Errorf("unknown step field %q", field)
+}
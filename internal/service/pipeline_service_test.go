@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+)
+
+func TestValidatePipelineSteps(t *testing.T) {
+	steps, err := validatePipelineSteps([]model.PipelineStepRequest{
+		{ID: "extract", Request: model.JobOperationRequest{Operation: "decompress", Sources: []string{"/uploads/a.zip"}, Destination: "/tmp/a"}},
+		{Request: model.JobOperationRequest{Operation: "move", Sources: []string{"{steps.extract.output}"}, Destination: "/projects"}},
+		{OnFailure: "continue", Request: model.JobOperationRequest{Operation: "delete", Paths: []string{"{steps.extract.sources}"}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, steps, 3)
+	require.Equal(t, "1", steps[1].ID)
+	require.Equal(t, PipelineStepStop, steps[1].OnFailure)
+	require.Equal(t, PipelineStepContinue, steps[2].OnFailure)
+	require.Equal(t, "rename", steps[1].Request.ConflictPolicy)
+
+	invalid := [][]model.PipelineStepRequest{
+		nil,
+		{{Request: model.JobOperationRequest{Operation: "delete", Paths: []string{"{steps.0.paths}"}}}},
+		{{Request: model.JobOperationRequest{Operation: "delete", Paths: []string{"/a"}}}, {Request: model.JobOperationRequest{Operation: "delete", Paths: []string{"{steps.0.size}"}}}},
+		{{ID: "x", Request: model.JobOperationRequest{Operation: "delete", Paths: []string{"/a"}}}, {ID: "x", Request: model.JobOperationRequest{Operation: "delete", Paths: []string{"/b"}}}},
+		{{OnFailure: "retry", Request: model.JobOperationRequest{Operation: "delete", Paths: []string{"/a"}}}},
+		{{Request: model.JobOperationRequest{Operation: "copy", Sources: []string{"/a"}}}},
+	}
+	for _, candidate := range invalid {
+		_, err := validatePipelineSteps(candidate)
+		require.Error(t, err, candidate)
+	}
+}
+
+func TestResolvePipelineRequest(t *testing.T) {
+	results := map[string]pipelineStepResult{
+		"extract": {
+			request: model.JobOperationRequest{Operation: "decompress", Sources: []string{"/uploads/a.zip"}, Destination: "/tmp/a"},
+			outputs: []string{"/tmp/a/x.txt", "/tmp/a/y.txt"},
+		},
+		"zip": {
+			request: model.JobOperationRequest{Operation: "compress", Sources: []string{"/docs"}, Destination: "/archives"},
+			outputs: []string{"/archives/docs.zip"},
+		},
+	}
+
+	resolved, err := resolvePipelineRequest(model.JobOperationRequest{
+		Operation:   "copy",
+		Sources:     []string{"{steps.extract.outputs}", "{steps.zip.output}"},
+		Destination: "{steps.extract.destination}/backup",
+	}, results)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/tmp/a/x.txt", "/tmp/a/y.txt", "/archives/docs.zip"}, resolved.Sources)
+	require.Equal(t, "/tmp/a/backup", resolved.Destination)
+
+	resolved, err = resolvePipelineRequest(model.JobOperationRequest{Operation: "move", Sources: []string{"{steps.extract.output}"}}, results)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/tmp/a"}, resolved.Sources)
+
+	_, err = resolvePipelineRequest(model.JobOperationRequest{Operation: "move", Destination: "{steps.extract.outputs}"}, results)
+	require.Error(t, err)
+
+	_, err = resolvePipelineRequest(model.JobOperationRequest{Operation: "delete", Paths: []string{"{steps.missing.sources}"}}, results)
+	require.Error(t, err)
+}
+
+func TestNextPipelineStatus(t *testing.T) {
+	steps := []model.PipelineStep{
+		{Status: "completed", OnFailure: PipelineStepStop},
+		{Status: "failed", OnFailure: PipelineStepContinue},
+		{Status: "pending", OnFailure: PipelineStepStop},
+	}
+
+	next, final := nextPipelineStatus(steps, 0)
+	require.Equal(t, 1, next)
+	require.Empty(t, final)
+
+	next, final = nextPipelineStatus(steps, 1)
+	require.Equal(t, 2, next)
+	require.Empty(t, final)
+
+	steps[2].Status = "completed"
+	_, final = nextPipelineStatus(steps, 2)
+	require.Equal(t, "partial", final)
+
+	steps[1].OnFailure = PipelineStepStop
+	_, final = nextPipelineStatus(steps, 1)
+	require.Equal(t, "failed", final)
+
+	steps[1].Status = "cancelled"
+	steps[1].OnFailure = PipelineStepContinue
+	_, final = nextPipelineStatus(steps, 1)
+	require.Equal(t, "cancelled", final)
+}
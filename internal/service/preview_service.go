@@ -0,0 +1,397 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/util"
+	"go-file-explorer/pkg/apierror"
+)
+
+const (
+	// maxPreviewBytes bounds how much of a file text and JSON previews read;
+	// maxTableScanBytes bounds how far table pages may reach into a file.
+	maxPreviewBytes   = 1 << 20
+	maxTableScanBytes = 64 << 20
+	maxPreviewCell    = 4096
+	previewSniffBytes = 8192
+
+	defaultPreviewLines = 200
+	maxPreviewLines     = 5000
+	defaultTableRows    = 100
+	maxTableRows        = 1000
+
+	previewKindText = "text"
+	previewKindCSV  = "csv"
+	previewKindJSON = "json"
+)
+
+var (
+	errPreviewFull = errors.New("preview full")
+	errNotJSON     = errors.New("not a JSON document")
+)
+
+// PreviewOptions select what a structured preview shows. Kind forces text,
+// csv or json instead of choosing by extension. Lines bounds text and JSON
+// previews; Page and Limit select the rows of a table.
+type PreviewOptions struct {
+	Kind  string
+	Lines int
+	Page  int
+	Limit int
+}
+
+// PreviewService builds structured previews of text, CSV and JSON files
+// that only read the start of the file, so large files stay cheap to show.
+type PreviewService struct {
+	files *FileService
+}
+
+func NewPreviewService(files *FileService) *PreviewService {
+	return &PreviewService{files: files}
+}
+
+// Preview returns a structured preview of the file at path and records it
+// as a preview by actor. JSON files that do not parse are shown as text.
+func (s *PreviewService) Preview(ctx context.Context, path string, opts PreviewOptions, actor model.AuditActor) (model.FilePreview, error) {
+	opts, err := normalizePreviewOptions(opts)
+	if err != nil {
+		return model.FilePreview{}, err
+	}
+
+	file, info, _, err := s.files.GetFile(path)
+	if err != nil {
+		return model.FilePreview{}, err
+	}
+	defer file.Close()
+
+	sniff := make([]byte, previewSniffBytes)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return model.FilePreview{}, err
+	}
+	sniff = sniff[:n]
+	encoding, ok := util.DetectTextEncoding(sniff)
+	if !ok {
+		return model.FilePreview{}, apierror.New("UNSUPPORTED_TYPE", "file is not a text file", path, http.StatusUnsupportedMediaType)
+	}
+
+	preview := model.FilePreview{
+		Path:     toAPIPath(file.Name(), s.files.store.RootAbs()),
+		Name:     info.Name(),
+		Kind:     opts.Kind,
+		Size:     info.Size(),
+		Encoding: encoding,
+	}
+	if preview.Kind == "" {
+		preview.Kind = previewKindForExtension(filepath.Ext(info.Name()))
+	}
+
+	switch preview.Kind {
+	case previewKindCSV:
+		err = previewTable(file, sniff, &preview, opts)
+	case previewKindJSON:
+		err = previewJSON(file, &preview, opts)
+		if errors.Is(err, errNotJSON) {
+			preview.Kind = previewKindText
+			err = previewText(file, &preview, opts)
+		}
+	default:
+		err = previewText(file, &preview, opts)
+	}
+	if err != nil {
+		return model.FilePreview{}, err
+	}
+
+	s.files.Previewed(ctx, path, actor)
+	return preview, nil
+}
+
+func normalizePreviewOptions(opts PreviewOptions) (PreviewOptions, error) {
+	opts.Kind = strings.ToLower(strings.TrimSpace(opts.Kind))
+	switch opts.Kind {
+	case "", previewKindText, previewKindCSV, previewKindJSON:
+	default:
+		return PreviewOptions{}, apierror.New("BAD_REQUEST", "kind must be text, csv or json", opts.Kind, http.StatusBadRequest)
+	}
+
+	if opts.Lines <= 0 {
+		opts.Lines = defaultPreviewLines
+	}
+	opts.Lines = min(opts.Lines, maxPreviewLines)
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = defaultTableRows
+	}
+	opts.Limit = min(opts.Limit, maxTableRows)
+	return opts, nil
+}
+
+func previewKindForExtension(extension string) string {
+	switch strings.ToLower(extension) {
+	case ".csv", ".tsv":
+		return previewKindCSV
+	case ".json", ".geojson":
+		return previewKindJSON
+	default:
+		return previewKindText
+	}
+}
+
+// previewReader returns the text of file from its start, at most limit
+// bytes of it, and whether the file is longer than that.
+func previewReader(file *os.File, preview *model.FilePreview, limit int64) (io.Reader, bool, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	return util.NewTextReader(io.LimitReader(file, limit), preview.Encoding), preview.Size > limit, nil
+}
+
+func previewText(file *os.File, preview *model.FilePreview, opts PreviewOptions) error {
+	reader, cut, err := previewReader(file, preview, maxPreviewBytes)
+	if err != nil {
+		return err
+	}
+
+	lines := bufio.NewReader(reader)
+	text := &model.TextPreview{Lines: []string{}}
+	for len(text.Lines) < opts.Lines {
+		line, err := lines.ReadString('\n')
+		// Drop a last line cut off by the read limit, unless it is all
+		// there is.
+		if line != "" && (err == nil || !cut || len(text.Lines) == 0) {
+			text.Lines = append(text.Lines, strings.TrimRight(line, "\r\n"))
+		}
+		if err != nil {
+			preview.Truncated = cut
+			break
+		}
+	}
+	if len(text.Lines) == opts.Lines {
+		_, err := lines.Peek(1)
+		preview.Truncated = err == nil || cut
+	}
+
+	firstLine := ""
+	if len(text.Lines) > 0 {
+		firstLine = text.Lines[0]
+	}
+	text.Language = util.DetectLanguage(preview.Name, firstLine)
+	preview.Text = text
+	return nil
+}
+
+func previewTable(file *os.File, sniff []byte, preview *model.FilePreview, opts PreviewOptions) error {
+	delimiter := '\t'
+	if strings.ToLower(filepath.Ext(preview.Name)) != ".tsv" {
+		sample, err := io.ReadAll(util.NewTextReader(bytes.NewReader(sniff), preview.Encoding))
+		if err != nil {
+			return err
+		}
+		delimiter = util.DetectDelimiter(string(sample))
+	}
+
+	reader, cut, err := previewReader(file, preview, maxTableScanBytes)
+	if err != nil {
+		return err
+	}
+	records := csv.NewReader(reader)
+	records.Comma = delimiter
+	records.FieldsPerRecord = -1
+	records.LazyQuotes = true
+
+	table := &model.TablePreview{
+		Delimiter: string(delimiter),
+		Header:    []string{},
+		Rows:      [][]string{},
+		Page:      opts.Page,
+		Limit:     opts.Limit,
+	}
+	preview.Table = table
+
+	// read returns the next record; false means the rows ended, either with
+	// the file or, when truncated, early.
+	read := func() ([]string, bool) {
+		record, err := records.Read()
+		if err != nil {
+			preview.Truncated = preview.Truncated || cut || !errors.Is(err, io.EOF)
+			return nil, false
+		}
+		return record, true
+	}
+	// clip shortens the cells of a record that is shown.
+	clip := func(record []string) []string {
+		for i, cell := range record {
+			if len(cell) > maxPreviewCell {
+				record[i] = truncateUTF8(cell, maxPreviewCell)
+				preview.Truncated = true
+			}
+		}
+		return record
+	}
+
+	header, ok := read()
+	if !ok {
+		return nil
+	}
+	table.Header = clip(header)
+	for range (opts.Page - 1) * opts.Limit {
+		if _, ok := read(); !ok {
+			return nil
+		}
+	}
+	for len(table.Rows) < opts.Limit {
+		row, ok := read()
+		if !ok {
+			return nil
+		}
+		table.Rows = append(table.Rows, clip(row))
+	}
+	if _, ok := read(); ok {
+		table.HasMore = true
+		preview.Truncated = true
+	}
+	return nil
+}
+
+func truncateUTF8(value string, limit int) string {
+	for limit > 0 && !utf8.RuneStart(value[limit]) {
+		limit--
+	}
+	return value[:limit]
+}
+
+func previewJSON(file *os.File, preview *model.FilePreview, opts PreviewOptions) error {
+	reader, cut, err := previewReader(file, preview, maxPreviewBytes)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	printer := &jsonPrinter{decoder: decoder, maxLines: opts.Lines, lines: 1}
+	err = printer.value(0)
+	if err == nil {
+		// A document is one value; anything after it is not JSON.
+		if _, trailing := decoder.Token(); !errors.Is(trailing, io.EOF) {
+			err = trailing
+			if err == nil {
+				err = errNotJSON
+			}
+		}
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, errPreviewFull):
+		preview.Truncated = true
+	case cut && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)):
+		preview.Truncated = true
+	default:
+		return errNotJSON
+	}
+
+	preview.JSON = &model.JSONPreview{Content: printer.out.String(), Lines: printer.lines}
+	return nil
+}
+
+// jsonPrinter indents a JSON document token by token, so the start of a
+// document cut off by the read limit can still be shown. It stops with
+// errPreviewFull after maxLines lines.
+type jsonPrinter struct {
+	decoder  *json.Decoder
+	out      strings.Builder
+	lines    int
+	maxLines int
+}
+
+func (p *jsonPrinter) value(depth int) error {
+	token, err := p.decoder.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return p.scalar(token)
+	}
+
+	p.out.WriteRune(rune(delim))
+	first := true
+	for p.decoder.More() {
+		if !first {
+			p.out.WriteByte(',')
+		}
+		first = false
+		if err := p.newline(depth + 1); err != nil {
+			return err
+		}
+		if delim == '{' {
+			key, err := p.decoder.Token()
+			if err != nil {
+				return err
+			}
+			if err := p.scalar(key); err != nil {
+				return err
+			}
+			p.out.WriteString(": ")
+		}
+		if err := p.value(depth + 1); err != nil {
+			return err
+		}
+	}
+
+	closing, err := p.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if !first {
+		if err := p.newline(depth); err != nil {
+			return err
+		}
+	}
+	p.out.WriteRune(rune(closing.(json.Delim)))
+	return nil
+}
+
+func (p *jsonPrinter) newline(depth int) error {
+	if p.lines >= p.maxLines {
+		return errPreviewFull
+	}
+	p.lines++
+	p.out.WriteByte('\n')
+	p.out.WriteString(strings.Repeat("  ", depth))
+	return nil
+}
+
+func (p *jsonPrinter) scalar(token json.Token) error {
+	switch value := token.(type) {
+	case string:
+		var encoded bytes.Buffer
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		p.out.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+	case json.Number:
+		p.out.WriteString(value.String())
+	case bool:
+		p.out.WriteString(strconv.FormatBool(value))
+	case nil:
+		p.out.WriteString("null")
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/storage"
+	"go-file-explorer/pkg/apierror"
+)
+
+func newPreviewTestService(t *testing.T, files map[string]string) *PreviewService {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+	}
+	store, err := storage.New(root)
+	require.NoError(t, err)
+	return NewPreviewService(NewFileService(store, nil, filepath.Join(t.TempDir(), "thumbnails"), nil))
+}
+
+func TestPreviewServiceText(t *testing.T) {
+	var log strings.Builder
+	for i := range 10 {
+		fmt.Fprintf(&log, "line %d\r\n", i)
+	}
+	s := newPreviewTestService(t, map[string]string{
+		"app.log":  log.String(),
+		"main.go":  "package main\n\nfunc main() {}\n",
+		"run":      "#!/bin/sh\necho hi",
+		"data.bin": "\x00\x01\x02\x03binary\x00",
+	})
+
+	preview, err := s.Preview(context.Background(), "/app.log", PreviewOptions{Lines: 3}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "text", preview.Kind)
+	require.Equal(t, "utf-8", preview.Encoding)
+	require.True(t, preview.Truncated)
+	require.Equal(t, []string{"line 0", "line 1", "line 2"}, preview.Text.Lines)
+	require.Equal(t, "log", preview.Text.Language)
+
+	preview, err = s.Preview(context.Background(), "/app.log", PreviewOptions{Lines: 10}, model.AuditActor{})
+	require.NoError(t, err)
+	require.False(t, preview.Truncated)
+	require.Len(t, preview.Text.Lines, 10)
+
+	preview, err = s.Preview(context.Background(), "/main.go", PreviewOptions{}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "go", preview.Text.Language)
+	require.Equal(t, []string{"package main", "", "func main() {}"}, preview.Text.Lines)
+	require.False(t, preview.Truncated)
+
+	preview, err = s.Preview(context.Background(), "/run", PreviewOptions{}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "shell", preview.Text.Language)
+	require.Equal(t, []string{"#!/bin/sh", "echo hi"}, preview.Text.Lines)
+
+	_, err = s.Preview(context.Background(), "/data.bin", PreviewOptions{}, model.AuditActor{})
+	var apiErr *apierror.APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, "UNSUPPORTED_TYPE", apiErr.Code)
+
+	_, err = s.Preview(context.Background(), "/main.go", PreviewOptions{Kind: "xml"}, model.AuditActor{})
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, "BAD_REQUEST", apiErr.Code)
+}
+
+func TestPreviewServiceReadsOnlyTheStartOfLargeFiles(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	s := newPreviewTestService(t, map[string]string{
+		"big.txt": strings.Repeat(line, maxPreviewBytes/len(line)+10),
+	})
+
+	preview, err := s.Preview(context.Background(), "/big.txt", PreviewOptions{Lines: maxPreviewLines}, model.AuditActor{})
+	require.NoError(t, err)
+	require.True(t, preview.Truncated)
+	require.Len(t, preview.Text.Lines, maxPreviewLines)
+
+	// Within the read limit, the line cut off by it is dropped.
+	s = newPreviewTestService(t, map[string]string{
+		"big.txt": strings.Repeat(strings.Repeat("y", 1000)+"\n", maxPreviewBytes/1000+1),
+	})
+	preview, err = s.Preview(context.Background(), "/big.txt", PreviewOptions{Lines: maxPreviewLines}, model.AuditActor{})
+	require.NoError(t, err)
+	require.True(t, preview.Truncated)
+	require.Len(t, preview.Text.Lines, maxPreviewBytes/1001)
+	for _, line := range preview.Text.Lines {
+		require.Len(t, line, 1000)
+	}
+}
+
+func TestPreviewServiceTable(t *testing.T) {
+	var rows strings.Builder
+	rows.WriteString("\xef\xbb\xbfname;amount;note\n")
+	for i := range 5 {
+		fmt.Fprintf(&rows, "item %d;%d,50;\"a; b\"\n", i, i)
+	}
+	s := newPreviewTestService(t, map[string]string{
+		"sales.csv":  rows.String(),
+		"people.tsv": "name\tage\nAna\t31\n",
+	})
+
+	preview, err := s.Preview(context.Background(), "/sales.csv", PreviewOptions{Page: 2, Limit: 2}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "csv", preview.Kind)
+	require.Equal(t, ";", preview.Table.Delimiter)
+	require.Equal(t, []string{"name", "amount", "note"}, preview.Table.Header)
+	require.Equal(t, [][]string{{"item 2", "2,50", "a; b"}, {"item 3", "3,50", "a; b"}}, preview.Table.Rows)
+	require.True(t, preview.Table.HasMore)
+	require.True(t, preview.Truncated)
+
+	preview, err = s.Preview(context.Background(), "/sales.csv", PreviewOptions{Page: 3, Limit: 2}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Len(t, preview.Table.Rows, 1)
+	require.False(t, preview.Table.HasMore)
+	require.False(t, preview.Truncated)
+
+	preview, err = s.Preview(context.Background(), "/people.tsv", PreviewOptions{}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "\t", preview.Table.Delimiter)
+	require.Equal(t, [][]string{{"Ana", "31"}}, preview.Table.Rows)
+	require.Equal(t, 1, preview.Table.Page)
+	require.Equal(t, defaultTableRows, preview.Table.Limit)
+}
+
+func TestPreviewServiceJSON(t *testing.T) {
+	s := newPreviewTestService(t, map[string]string{
+		"config.json": `{"name":"<app>","tags":[],"nested":{"on":true,"ratio":1.50,"none":null},"list":[1,2]}`,
+		"broken.json": `{"name": oops}`,
+		"data.txt":    `[1,2,3]`,
+		"large.json":  "[" + strings.Repeat(`"`+strings.Repeat("z", 1000)+`",`, maxPreviewBytes/1000) + `"end"]`,
+	})
+
+	preview, err := s.Preview(context.Background(), "/config.json", PreviewOptions{}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "json", preview.Kind)
+	require.False(t, preview.Truncated)
+	require.Equal(t, `{
+  "name": "<app>",
+  "tags": [],
+  "nested": {
+    "on": true,
+    "ratio": 1.50,
+    "none": null
+  },
+  "list": [
+    1,
+    2
+  ]
+}`, preview.JSON.Content)
+	require.Equal(t, 13, preview.JSON.Lines)
+
+	preview, err = s.Preview(context.Background(), "/config.json", PreviewOptions{Lines: 4}, model.AuditActor{})
+	require.NoError(t, err)
+	require.True(t, preview.Truncated)
+	require.Equal(t, 4, preview.JSON.Lines)
+	require.Equal(t, "{\n  \"name\": \"<app>\",\n  \"tags\": [],\n  \"nested\": {", preview.JSON.Content)
+
+	preview, err = s.Preview(context.Background(), "/broken.json", PreviewOptions{}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "text", preview.Kind)
+	require.Equal(t, "json", preview.Text.Language)
+	require.Nil(t, preview.JSON)
+
+	preview, err = s.Preview(context.Background(), "/data.txt", PreviewOptions{Kind: "json"}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "json", preview.Kind)
+	require.Equal(t, "[\n  1,\n  2,\n  3\n]", preview.JSON.Content)
+
+	// Cut off by the read limit before the line limit.
+	preview, err = s.Preview(context.Background(), "/large.json", PreviewOptions{Lines: maxPreviewLines}, model.AuditActor{})
+	require.NoError(t, err)
+	require.Equal(t, "json", preview.Kind)
+	require.True(t, preview.Truncated)
+	require.Less(t, preview.JSON.Lines, maxPreviewBytes/1000)
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/repository"
+	"go-file-explorer/internal/storage"
+	"go-file-explorer/pkg/apierror"
+)
+
+const (
+	maxFavorites         = 100
+	maxRecentFiles       = 50
+	defaultRecentFiles   = 20
+	recentActionDownload = "download"
+	recentActionPreview  = "preview"
+	recentActionUpload   = "upload"
+	recentActionEdit     = "edit"
+)
+
+// QuickAccessService keeps the favorites and recent files of each user.
+// Favorites are pinned by hand; recent files are recorded as users download,
+// preview, upload and edit files. Both are keyed by path: they follow
+// entries that OperationsService renames or moves. Trashing an entry parks
+// its favorites until it is restored or purged and drops its recent files;
+// entries found missing when listed are dropped as well. Those updates only
+// log failures, and a nil QuickAccessService ignores them.
+type QuickAccessService struct {
+	repo  *repository.QuickAccessRepository
+	store storage.Storage
+	files *FileService
+}
+
+func NewQuickAccessService(repo *repository.QuickAccessRepository, store storage.Storage, files *FileService) *QuickAccessService {
+	return &QuickAccessService{repo: repo, store: store, files: files}
+}
+
+// Favorites returns the favorites of actor in their order.
+func (s *QuickAccessService) Favorites(ctx context.Context, actor model.AuditActor) (model.FavoriteListData, error) {
+	paths, err := s.repo.Favorites(ctx, actor.UserID)
+	if err != nil {
+		return model.FavoriteListData{}, err
+	}
+
+	items := make([]model.FileItem, 0, len(paths))
+	for _, apiPath := range paths {
+		item, ok, err := s.item(ctx, apiPath)
+		if err != nil {
+			return model.FavoriteListData{}, err
+		}
+		if !ok {
+			if err := s.repo.RemoveFavorite(ctx, actor.UserID, apiPath); err != nil {
+				return model.FavoriteListData{}, err
+			}
+			continue
+		}
+		items = append(items, item)
+	}
+	return model.FavoriteListData{Items: items}, nil
+}
+
+// Pin adds a file or folder to the end of the favorites of actor. Pinning a
+// favorite again keeps its place.
+func (s *QuickAccessService) Pin(ctx context.Context, request model.FavoriteRequest, actor model.AuditActor) (model.FavoriteListData, error) {
+	apiPath, err := existingEntryPath(s.store, request.Path, "root path cannot be pinned")
+	if err != nil {
+		return model.FavoriteListData{}, err
+	}
+
+	paths, err := s.repo.Favorites(ctx, actor.UserID)
+	if err != nil {
+		return model.FavoriteListData{}, err
+	}
+	if !slices.Contains(paths, apiPath) {
+		if len(paths) >= maxFavorites {
+			return model.FavoriteListData{}, apierror.New("BAD_REQUEST", fmt.Sprintf("at most %d favorites are allowed", maxFavorites), "path", http.StatusBadRequest)
+		}
+		if err := s.repo.AddFavorite(ctx, actor.UserID, apiPath); err != nil {
+			return model.FavoriteListData{}, err
+		}
+	}
+	return s.Favorites(ctx, actor)
+}
+
+// Unpin removes a path from the favorites of actor. The path does not need
+// to exist any more.
+func (s *QuickAccessService) Unpin(ctx context.Context, request model.FavoriteRequest, actor model.AuditActor) (model.FavoriteListData, error) {
+	if strings.TrimSpace(request.Path) == "" {
+		return model.FavoriteListData{}, apierror.New("BAD_REQUEST", "path is required", "path", http.StatusBadRequest)
+	}
+	if err := s.repo.RemoveFavorite(ctx, actor.UserID, normalizeAPIPath(request.Path)); err != nil {
+		return model.FavoriteListData{}, err
+	}
+	return s.Favorites(ctx, actor)
+}
+
+// Reorder puts the favorites of actor in the order of request.Paths, which
+// must list each of them once.
+func (s *QuickAccessService) Reorder(ctx context.Context, request model.FavoriteOrderRequest, actor model.AuditActor) (model.FavoriteListData, error) {
+	current, err := s.repo.Favorites(ctx, actor.UserID)
+	if err != nil {
+		return model.FavoriteListData{}, err
+	}
+	ordered, err := orderedFavorites(current, request.Paths)
+	if err != nil {
+		return model.FavoriteListData{}, err
+	}
+	if err := s.repo.ReorderFavorites(ctx, actor.UserID, ordered); err != nil {
+		return model.FavoriteListData{}, err
+	}
+	return s.Favorites(ctx, actor)
+}
+
+// Recent returns up to limit recent files of actor, most recent first.
+func (s *QuickAccessService) Recent(ctx context.Context, limit int, actor model.AuditActor) (model.RecentListData, error) {
+	if limit <= 0 {
+		limit = defaultRecentFiles
+	}
+	if limit > maxRecentFiles {
+		limit = maxRecentFiles
+	}
+
+	entries, err := s.repo.Recent(ctx, actor.UserID, limit)
+	if err != nil {
+		return model.RecentListData{}, err
+	}
+
+	items := make([]model.RecentFile, 0, len(entries))
+	for _, entry := range entries {
+		item, ok, err := s.item(ctx, entry.Path)
+		if err != nil {
+			return model.RecentListData{}, err
+		}
+		if !ok {
+			if err := s.repo.RemoveRecent(ctx, actor.UserID, entry.Path); err != nil {
+				return model.RecentListData{}, err
+			}
+			continue
+		}
+		items = append(items, model.RecentFile{FileItem: item, Action: entry.Action, AccessedAt: entry.AccessedAt})
+	}
+	return model.RecentListData{Items: items}, nil
+}
+
+// item returns the file info of apiPath, or false when it no longer exists.
+func (s *QuickAccessService) item(ctx context.Context, apiPath string) (model.FileItem, bool, error) {
+	resolved, err := s.store.Resolve(apiPath)
+	if err != nil {
+		return model.FileItem{}, false, nil
+	}
+	if _, err := os.Stat(resolved); err != nil {
+		if os.IsNotExist(err) {
+			return model.FileItem{}, false, nil
+		}
+		return model.FileItem{}, false, err
+	}
+
+	item, err := s.files.GetInfo(ctx, apiPath)
+	if err != nil {
+		return model.FileItem{}, false, err
+	}
+	return item, true, nil
+}
+
+// touched records that actor used the file at apiPath. Folders and actions
+// without a user are ignored.
+func (s *QuickAccessService) touched(ctx context.Context, apiPath string, action string, actor model.AuditActor) {
+	if s == nil || actor.UserID == "" {
+		return
+	}
+	apiPath = normalizeAPIPath(apiPath)
+	resolved, err := s.store.Resolve(apiPath)
+	if err != nil {
+		return
+	}
+	if info, err := os.Stat(resolved); err != nil || info.IsDir() {
+		return
+	}
+	if err := s.repo.Touch(context.WithoutCancel(ctx), actor.UserID, apiPath, action, maxRecentFiles); err != nil {
+		slog.Warn("failed to record recent file", "path", apiPath, "user_id", actor.UserID, "error", err)
+	}
+}
+
+// moved makes the favorites and recent files at from and below it follow
+// the entry to to.
+func (s *QuickAccessService) moved(ctx context.Context, from string, to string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.MoveTree(context.WithoutCancel(ctx), normalizeAPIPath(from), normalizeAPIPath(to)); err != nil {
+		slog.Warn("failed to move favorites and recent files", "from", from, "to", to, "error", err)
+	}
+}
+
+// trashed parks the favorites at apiPath and below it with the trash record
+// trashID and drops the recent files there.
+func (s *QuickAccessService) trashed(ctx context.Context, apiPath string, trashID string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.Trash(context.WithoutCancel(ctx), normalizeAPIPath(apiPath), trashID); err != nil {
+		slog.Warn("failed to trash favorites and recent files", "path", apiPath, "trash_id", trashID, "error", err)
+	}
+}
+
+// restored brings back the favorites parked with the trash record trashID.
+func (s *QuickAccessService) restored(ctx context.Context, trashID string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.RestoreFavorites(context.WithoutCancel(ctx), trashID); err != nil {
+		slog.Warn("failed to restore favorites", "trash_id", trashID, "error", err)
+	}
+}
+
+// purged removes the favorites parked with the trash record trashID, or with
+// every trash record when trashID is empty.
+func (s *QuickAccessService) purged(ctx context.Context, trashID string) {
+	if s == nil {
+		return
+	}
+	var err error
+	if trashID == "" {
+		err = s.repo.PurgeTrashedFavorites(context.WithoutCancel(ctx))
+	} else {
+		err = s.repo.PurgeFavorites(context.WithoutCancel(ctx), trashID)
+	}
+	if err != nil {
+		slog.Warn("failed to purge favorites", "trash_id", trashID, "error", err)
+	}
+}
+
+// orderedFavorites checks that requested lists every path of current once
+// and returns it normalized.
+func orderedFavorites(current []string, requested []string) ([]string, error) {
+	if len(requested) != len(current) {
+		return nil, apierror.New("BAD_REQUEST", "paths must list every favorite once", "paths", http.StatusBadRequest)
+	}
+
+	ordered := make([]string, 0, len(requested))
+	for _, apiPath := range requested {
+		apiPath = normalizeAPIPath(apiPath)
+		if !slices.Contains(current, apiPath) {
+			return nil, apierror.New("BAD_REQUEST", "path is not a favorite", apiPath, http.StatusBadRequest)
+		}
+		if slices.Contains(ordered, apiPath) {
+			return nil, apierror.New("BAD_REQUEST", "paths must list every favorite once", apiPath, http.StatusBadRequest)
+		}
+		ordered = append(ordered, apiPath)
+	}
+	return ordered, nil
+}
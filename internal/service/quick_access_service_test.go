@@ -0,0 +1,22 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedFavorites(t *testing.T) {
+	current := []string{"/docs", "/photos/2026", "/notes.md"}
+
+	ordered, err := orderedFavorites(current, []string{"notes.md", "/photos/2026/", "/docs"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/notes.md", "/photos/2026", "/docs"}, ordered)
+
+	_, err = orderedFavorites(current, []string{"/docs", "/notes.md"})
+	require.ErrorContains(t, err, "every favorite once")
+	_, err = orderedFavorites(current, []string{"/docs", "/docs", "/notes.md"})
+	require.ErrorContains(t, err, "every favorite once")
+	_, err = orderedFavorites(current, []string{"/docs", "/photos", "/notes.md"})
+	require.ErrorContains(t, err, "not a favorite")
+}
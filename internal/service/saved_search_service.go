@@ -0,0 +1,528 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-file-explorer/internal/event"
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/repository"
+	"go-file-explorer/pkg/apierror"
+)
+
+const (
+	// savedSearchRoot is the virtual directory that holds one read-only
+	// folder per saved search, named after its ID.
+	savedSearchRoot = "/.searches"
+
+	// savedSearchNotifyInterval is how long file events are collected before
+	// the affected saved searches are notified, so a burst of changes sends
+	// one search.updated event per search.
+	savedSearchNotifyInterval = time.Second
+)
+
+// savedSearchSortFields maps the sort fields of directory listings to search
+// sort fields.
+var savedSearchSortFields = map[string]string{
+	"name":        "name",
+	"size":        "size",
+	"modified_at": "modified",
+	"type":        "type",
+}
+
+// SavedSearchService stores named searches and exposes them as virtual
+// folders below /.searches whose contents are the current results of each
+// search. Users see their own searches and the shared ones; admins can also
+// read and edit everyone else's. File events publish search.updated to the
+// users of every search whose results they may have changed.
+type SavedSearchService struct {
+	repo   *repository.SavedSearchRepository
+	search *SearchService
+	bus    event.Bus
+}
+
+func NewSavedSearchService(repo *repository.SavedSearchRepository, search *SearchService, bus event.Bus) *SavedSearchService {
+	return &SavedSearchService{repo: repo, search: search, bus: bus}
+}
+
+// IsVirtualPath reports whether apiPath is /.searches or below it.
+func IsVirtualPath(apiPath string) bool {
+	normalized := normalizeAPIPath(apiPath)
+	return normalized == savedSearchRoot || strings.HasPrefix(normalized, savedSearchRoot+"/")
+}
+
+func (s *SavedSearchService) Create(ctx context.Context, request model.SavedSearchRequest, actor model.AuditActor) (model.SavedSearch, error) {
+	now := time.Now().UTC()
+	search := model.SavedSearch{
+		ID:            uuid.NewString(),
+		Name:          request.Name,
+		Query:         request.Query,
+		Shared:        request.Shared,
+		OwnerID:       actor.UserID,
+		OwnerUsername: actor.Username,
+		CreatedAt:     now.Format(time.RFC3339Nano),
+		UpdatedAt:     now.Format(time.RFC3339Nano),
+	}
+
+	if err := s.prepare(ctx, &search); err != nil {
+		return model.SavedSearch{}, err
+	}
+
+	if err := s.repo.Create(ctx, search); err != nil {
+		return model.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+// List returns the caller's searches and the shared ones.
+func (s *SavedSearchService) List(ctx context.Context, actor model.AuditActor, page int, limit int) ([]model.SavedSearch, model.Meta, error) {
+	searches, meta, err := s.repo.List(ctx, actor.UserID, page, limit)
+	if err != nil {
+		return nil, model.Meta{}, err
+	}
+	for i := range searches {
+		searches[i].Path = savedSearchPath(searches[i].ID)
+	}
+	return searches, meta, nil
+}
+
+func (s *SavedSearchService) Get(ctx context.Context, id string, actor model.AuditActor) (model.SavedSearch, error) {
+	return s.getVisibleSearch(ctx, id, actor)
+}
+
+// Update changes the given fields. Only the owner and admins can edit a
+// search, shared or not.
+func (s *SavedSearchService) Update(ctx context.Context, id string, request model.SavedSearchUpdateRequest, actor model.AuditActor) (model.SavedSearch, error) {
+	search, err := s.getEditableSearch(ctx, id, actor)
+	if err != nil {
+		return model.SavedSearch{}, err
+	}
+
+	if request.Name != nil {
+		search.Name = *request.Name
+	}
+	if request.Query != nil {
+		search.Query = *request.Query
+	}
+	if request.Shared != nil {
+		search.Shared = *request.Shared
+	}
+
+	if err := s.prepare(ctx, &search); err != nil {
+		return model.SavedSearch{}, err
+	}
+
+	if err := s.repo.Update(ctx, search); err != nil {
+		return model.SavedSearch{}, err
+	}
+	search.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	return search, nil
+}
+
+func (s *SavedSearchService) Delete(ctx context.Context, id string, actor model.AuditActor) error {
+	if _, err := s.getEditableSearch(ctx, id, actor); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, strings.TrimSpace(id))
+}
+
+// ListFolder lists a virtual path: /.searches lists the visible saved
+// searches as folders and /.searches/<id> the results of one search. Sorting
+// uses the fields of directory listings; a search folder listed without a
+// sort field keeps the order saved with the search.
+func (s *SavedSearchService) ListFolder(ctx context.Context, requestedPath string, page int, limit int, sortBy string, order string, cursor string, tag string, actor model.AuditActor) (model.DirectoryListData, model.Meta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	currentPath := normalizeAPIPath(requestedPath)
+	if currentPath == savedSearchRoot {
+		searches, err := s.repo.Visible(ctx, actor.UserID)
+		if err != nil {
+			return model.DirectoryListData{}, model.Meta{}, err
+		}
+
+		items := make([]model.FileItem, 0, len(searches))
+		for _, search := range searches {
+			items = append(items, savedSearchItem(search))
+		}
+
+		pageItems, meta, err := paginateItems(items, currentPath, page, limit, sortBy, order, cursor)
+		if err != nil {
+			return model.DirectoryListData{}, model.Meta{}, err
+		}
+		return model.DirectoryListData{CurrentPath: currentPath, ParentPath: "/", Items: pageItems}, meta, nil
+	}
+
+	search, err := s.folderSearch(ctx, currentPath, actor)
+	if err != nil {
+		return model.DirectoryListData{}, model.Meta{}, err
+	}
+
+	query := search.Query
+	query.Sort = savedSearchSort(search.Query.Sort, sortBy, order)
+	query.Page, query.Limit, query.Cursor = page, limit, cursor
+	if strings.TrimSpace(tag) != "" {
+		query.Tag = strings.Join([]string{query.Tag, tag}, ",")
+	}
+
+	results, err := s.search.page(ctx, query)
+	if err != nil {
+		return model.DirectoryListData{}, model.Meta{}, err
+	}
+	for i := range results.items {
+		if results.items[i].Type == "file" {
+			results.items[i].SizeHuman = humanizeSize(results.items[i].Size)
+		}
+	}
+
+	data := model.DirectoryListData{CurrentPath: savedSearchPath(search.ID), ParentPath: savedSearchRoot, Items: results.items}
+	return data, results.meta, nil
+}
+
+// TreeFolder returns the tree nodes of a virtual path: the saved searches
+// below /.searches, and the results of a search below its folder. Result
+// nodes are leaves; clients expand directories through their real paths.
+func (s *SavedSearchService) TreeFolder(ctx context.Context, requestedPath string, page int, limit int, actor model.AuditActor) (model.TreeData, model.Meta, error) {
+	currentPath := normalizeAPIPath(requestedPath)
+	if currentPath == savedSearchRoot {
+		searches, meta, err := s.repo.List(ctx, actor.UserID, page, limit)
+		if err != nil {
+			return model.TreeData{}, model.Meta{}, err
+		}
+
+		nodes := make([]model.TreeNode, 0, len(searches))
+		for _, search := range searches {
+			item := savedSearchItem(search)
+			nodes = append(nodes, model.TreeNode{
+				Name:        item.Name,
+				Path:        item.Path,
+				Type:        item.Type,
+				HasChildren: true,
+				ModifiedAt:  item.ModifiedAt,
+				Virtual:     true,
+			})
+		}
+		return model.TreeData{Path: currentPath, Nodes: nodes}, meta, nil
+	}
+
+	search, err := s.folderSearch(ctx, currentPath, actor)
+	if err != nil {
+		return model.TreeData{}, model.Meta{}, err
+	}
+
+	query := search.Query
+	query.Page, query.Limit = page, limit
+	results, err := s.search.page(ctx, query)
+	if err != nil {
+		return model.TreeData{}, model.Meta{}, err
+	}
+
+	nodes := make([]model.TreeNode, 0, len(results.items))
+	for _, item := range results.items {
+		node := model.TreeNode{Name: item.Name, Path: item.Path, Type: item.Type, ModifiedAt: item.ModifiedAt}
+		if item.Type == "directory" {
+			if resolved, err := s.search.store.Resolve(item.Path); err == nil {
+				children, _ := os.ReadDir(resolved)
+				node.HasChildren = len(children) > 0
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return model.TreeData{Path: savedSearchPath(search.ID), Nodes: nodes}, results.meta, nil
+}
+
+// RootNode returns the /.searches node shown in the tree of the storage
+// root, or nil when the caller has no saved searches.
+func (s *SavedSearchService) RootNode(ctx context.Context, actor model.AuditActor) (*model.TreeNode, error) {
+	_, meta, err := s.repo.List(ctx, actor.UserID, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Total == 0 {
+		return nil, nil
+	}
+
+	count := meta.Total
+	return &model.TreeNode{
+		Name:        "Saved searches",
+		Path:        savedSearchRoot,
+		Type:        "directory",
+		HasChildren: true,
+		ItemCount:   &count,
+		Virtual:     true,
+	}, nil
+}
+
+// Run publishes search.updated for the saved searches affected by file
+// events until ctx is cancelled.
+func (s *SavedSearchService) Run(ctx context.Context) {
+	events, unsubscribe := s.bus.Subscribe()
+	defer unsubscribe()
+
+	ticker := time.NewTicker(savedSearchNotifyInterval)
+	defer ticker.Stop()
+
+	pending := make([]indexChanges, 0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if changes := indexEventChanges(e); len(changes.removed) > 0 || changes.movedFrom != "" || len(changes.refreshed) > 0 {
+				pending = append(pending, changes)
+			}
+		case <-ticker.C:
+			if len(pending) == 0 {
+				continue
+			}
+			s.notify(ctx, pending)
+			pending = pending[:0]
+		}
+	}
+}
+
+func (s *SavedSearchService) notify(ctx context.Context, changes []indexChanges) {
+	searches, err := s.repo.Visible(ctx, "")
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("load saved searches failed", "error", err)
+		}
+		return
+	}
+	if len(searches) == 0 {
+		return
+	}
+
+	changed := s.changedEntries(changes)
+	for _, search := range searches {
+		filter, err := buildSearchFilter(search.Query)
+		if err != nil {
+			continue
+		}
+		affected, err := savedSearchAffected(filter, changed)
+		if err != nil || !affected {
+			continue
+		}
+
+		e := event.Event{
+			ID:        uuid.NewString(),
+			Type:      event.TypeSearchUpdated,
+			Payload:   model.SavedSearchUpdate{ID: search.ID, Name: search.Name, Path: savedSearchPath(search.ID)},
+			Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		}
+		if !search.Shared {
+			e.Audience = []string{search.OwnerID}
+		}
+		s.bus.Publish(e)
+	}
+}
+
+// changedEntries describes the paths named by changes. Paths that were
+// removed or moved away, and refreshed directories, have an empty Type: what
+// they contained is unknown, so they affect every search whose scope they
+// overlap. Refreshed files are read from disk.
+func (s *SavedSearchService) changedEntries(changes []indexChanges) []model.IndexEntry {
+	entries := make([]model.IndexEntry, 0)
+	for _, change := range changes {
+		for _, apiPath := range change.removed {
+			entries = append(entries, model.IndexEntry{Path: normalizeAPIPath(apiPath)})
+		}
+		if change.movedFrom != "" {
+			entries = append(entries, model.IndexEntry{Path: normalizeAPIPath(change.movedFrom)})
+		}
+		for _, apiPath := range change.refreshed {
+			apiPath = normalizeAPIPath(apiPath)
+			entry := model.IndexEntry{Path: apiPath}
+			if resolved, err := s.search.store.Resolve(apiPath); err == nil {
+				if info, err := os.Stat(resolved); err == nil && !info.IsDir() {
+					entry = indexEntry(apiPath, info)
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// savedSearchAffected reports whether the results of filter may have changed
+// with the changed entries. A file counts when it is in scope and its name
+// and type match the search; its size and dates may just have stopped
+// matching, so those are not checked. Fuzzy terms are not checked either.
+func savedSearchAffected(filter model.SearchFilter, changed []model.IndexEntry) (bool, error) {
+	scope := normalizeAPIPath(filter.Path)
+	terms := filter.Terms
+	if filter.Fuzzy {
+		terms = nil
+	}
+	matcher, err := newSearchMatcher(model.SearchFilter{
+		Terms:      terms,
+		Exclude:    filter.Exclude,
+		Type:       filter.Type,
+		Extensions: filter.Extensions,
+		MimeType:   filter.MimeType,
+		Category:   filter.Category,
+		NameGlob:   filter.NameGlob,
+		NameRegex:  filter.NameRegex,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range changed {
+		if !pathWithin(entry.Path, scope) && !pathWithin(scope, entry.Path) {
+			continue
+		}
+		excluded := false
+		for _, prefix := range filter.Exclude {
+			if pathWithin(entry.Path, normalizeAPIPath(prefix)) {
+				excluded = true
+				break
+			}
+		}
+		if excluded {
+			continue
+		}
+		if entry.Type == "" || matcher.matches(entry) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// pathWithin reports whether apiPath is root or below it.
+func pathWithin(apiPath string, root string) bool {
+	return root == "/" || apiPath == root || strings.HasPrefix(apiPath, root+"/")
+}
+
+// folderSearch returns the visible search whose folder is currentPath.
+func (s *SavedSearchService) folderSearch(ctx context.Context, currentPath string, actor model.AuditActor) (model.SavedSearch, error) {
+	id, ok := strings.CutPrefix(currentPath, savedSearchRoot+"/")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return model.SavedSearch{}, apierror.New("NOT_FOUND", "directory not found", currentPath, http.StatusNotFound)
+	}
+	return s.getVisibleSearch(ctx, id, actor)
+}
+
+func (s *SavedSearchService) getVisibleSearch(ctx context.Context, id string, actor model.AuditActor) (model.SavedSearch, error) {
+	search, err := s.repo.FindByID(ctx, strings.TrimSpace(id))
+	if err != nil {
+		return model.SavedSearch{}, err
+	}
+	// Other users' private searches are reported as missing rather than
+	// forbidden.
+	if !search.Shared && actor.Role != "admin" && search.OwnerID != actor.UserID {
+		return model.SavedSearch{}, model.ErrSavedSearchNotFound
+	}
+	search.Path = savedSearchPath(search.ID)
+	return search, nil
+}
+
+func (s *SavedSearchService) getEditableSearch(ctx context.Context, id string, actor model.AuditActor) (model.SavedSearch, error) {
+	search, err := s.getVisibleSearch(ctx, id, actor)
+	if err != nil {
+		return model.SavedSearch{}, err
+	}
+	if actor.Role != "admin" && search.OwnerID != actor.UserID {
+		return model.SavedSearch{}, apierror.New("FORBIDDEN", "only the owner can change a shared search", search.ID, http.StatusForbidden)
+	}
+	return search, nil
+}
+
+// prepare validates and normalizes search. The query is checked the way
+// /search checks it and stored as given, so relative dates keep following
+// the calendar.
+func (s *SavedSearchService) prepare(ctx context.Context, search *model.SavedSearch) error {
+	search.Name = strings.TrimSpace(search.Name)
+	if search.Name == "" {
+		return apierror.New("BAD_REQUEST", "name is required", "name", http.StatusBadRequest)
+	}
+	if len(search.Name) > 255 {
+		return apierror.New("BAD_REQUEST", "name must be at most 255 characters", "name", http.StatusBadRequest)
+	}
+
+	filter, err := buildSearchFilter(search.Query)
+	if err != nil {
+		return apierror.New("BAD_REQUEST", err.Error(), "query", http.StatusBadRequest)
+	}
+	if !hasSearchCriteria(filter) {
+		return apierror.New("BAD_REQUEST", "query needs at least one filter: q, content, type, ext or another filter", "query", http.StatusBadRequest)
+	}
+	if filter.Path != "" && isInternalStoragePath(filter.Path) {
+		return apierror.New("BAD_REQUEST", "query path cannot be an internal or virtual folder", "query", http.StatusBadRequest)
+	}
+	search.Query.Page, search.Query.Limit, search.Query.Cursor = 0, 0, ""
+
+	taken, err := s.repo.NameTaken(ctx, search.OwnerID, search.Name, search.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return apierror.New("CONFLICT", "a saved search with this name already exists", search.Name, http.StatusConflict)
+	}
+
+	search.Path = savedSearchPath(search.ID)
+	return nil
+}
+
+func savedSearchPath(id string) string {
+	return savedSearchRoot + "/" + id
+}
+
+// savedSearchItem is the read-only folder of a saved search.
+func savedSearchItem(search model.SavedSearch) model.FileItem {
+	createdAt, _ := time.Parse(time.RFC3339Nano, search.CreatedAt)
+	updatedAt, _ := time.Parse(time.RFC3339Nano, search.UpdatedAt)
+	return model.FileItem{
+		Name:        search.Name,
+		Path:        savedSearchPath(search.ID),
+		Type:        "directory",
+		ModifiedAt:  updatedAt.UTC(),
+		CreatedAt:   createdAt.UTC(),
+		Permissions: "dr-xr-xr-x",
+		Virtual:     true,
+	}
+}
+
+// savedSearchSort is the search sort for a folder listing sorted by sortBy
+// and order, falling back to the saved sort when sortBy is empty.
+// Unknown fields sort by name, as in directory listings.
+func savedSearchSort(saved string, sortBy string, order string) string {
+	field, descending := strings.CutPrefix(saved, "-")
+	if sortBy = strings.ToLower(strings.TrimSpace(sortBy)); sortBy != "" {
+		field = savedSearchSortFields[sortBy]
+		if field == "" {
+			field = "name"
+		}
+		descending = false
+	}
+	if order = strings.ToLower(strings.TrimSpace(order)); order != "" {
+		descending = order == "desc"
+	}
+
+	if field == "" {
+		if !descending {
+			return ""
+		}
+		field = "name"
+	}
+	if descending {
+		return "-" + field
+	}
+	return field
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+)
+
+func TestSavedSearchAffected(t *testing.T) {
+	filter, err := buildSearchFilter(model.SearchQuery{Query: "ext:pdf report -path:/contracts/archive", Path: "/contracts"})
+	require.NoError(t, err)
+
+	file := func(apiPath string) model.IndexEntry {
+		entry := model.IndexEntry{Path: apiPath, Name: apiPath[len("/contracts/"):], Type: "file", ModifiedAt: time.Now()}
+		entry.Extension = ".pdf"
+		return entry
+	}
+
+	cases := map[string]struct {
+		changed  []model.IndexEntry
+		affected bool
+	}{
+		"matching file in scope":      {[]model.IndexEntry{file("/contracts/report-q1.pdf")}, true},
+		"other name in scope":         {[]model.IndexEntry{file("/contracts/invoice.pdf")}, false},
+		"matching file out of scope":  {[]model.IndexEntry{{Path: "/photos/report.pdf", Name: "report.pdf", Type: "file", Extension: ".pdf"}}, false},
+		"excluded subtree":            {[]model.IndexEntry{file("/contracts/archive/report.pdf")}, false},
+		"removed path in scope":       {[]model.IndexEntry{{Path: "/contracts/old"}}, true},
+		"removed parent of the scope": {[]model.IndexEntry{{Path: "/"}}, true},
+		"removed path out of scope":   {[]model.IndexEntry{{Path: "/contractsX"}}, false},
+		"nothing changed":             {nil, false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			affected, err := savedSearchAffected(filter, tc.changed)
+			require.NoError(t, err)
+			require.Equal(t, tc.affected, affected)
+		})
+	}
+}
+
+func TestSavedSearchSort(t *testing.T) {
+	require.Equal(t, "-size", savedSearchSort("-size", "", ""))
+	require.Equal(t, "size", savedSearchSort("-size", "", "asc"))
+	require.Equal(t, "-modified", savedSearchSort("name", "modified_at", "desc"))
+	require.Equal(t, "type", savedSearchSort("", "type", ""))
+	require.Equal(t, "name", savedSearchSort("-size", "owner", ""))
+	require.Equal(t, "", savedSearchSort("", "", ""))
+	require.Equal(t, "-name", savedSearchSort("", "", "desc"))
+}
+
+func TestIsVirtualPath(t *testing.T) {
+	require.True(t, IsVirtualPath("/.searches"))
+	require.True(t, IsVirtualPath(".searches/abc"))
+	require.False(t, IsVirtualPath("/.searchesX"))
+	require.False(t, IsVirtualPath("/docs/.searches"))
+	require.True(t, isInternalStoragePath("/.searches/abc"))
+}
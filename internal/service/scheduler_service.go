@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/repository"
+	"go-file-explorer/internal/util"
+	"go-file-explorer/pkg/apierror"
+)
+
+const (
+	// ScheduleMisfireRunOnce runs a schedule once after downtime, however many
+	// occurrences were missed. ScheduleMisfireSkip only records them.
+	ScheduleMisfireRunOnce = "run_once"
+	ScheduleMisfireSkip    = "skip"
+
+	// schedulerInterval is how often due schedules are checked.
+	schedulerInterval = 30 * time.Second
+	// scheduleMisfireGrace is how late an occurrence may be picked up before
+	// it counts as missed.
+	scheduleMisfireGrace = 2 * schedulerInterval
+	// scheduleMaxMissed caps how many missed occurrences are counted.
+	scheduleMaxMissed = 10000
+)
+
+// SchedulerService stores cron schedules and queues their jobs when they are
+// due. Schedules live in Postgres; each due occurrence is claimed with a
+// conditional update so that only one instance queues it.
+type SchedulerService struct {
+	repo  *repository.ScheduleRepository
+	users *repository.UserRepository
+	jobs  *JobService
+}
+
+func NewSchedulerService(repo *repository.ScheduleRepository, users *repository.UserRepository, jobs *JobService) *SchedulerService {
+	return &SchedulerService{repo: repo, users: users, jobs: jobs}
+}
+
+func (s *SchedulerService) Create(request model.ScheduleRequest, actor model.AuditActor) (model.Schedule, error) {
+	now := time.Now().UTC()
+	schedule := model.Schedule{
+		ID:            uuid.NewString(),
+		Name:          request.Name,
+		Cron:          request.Cron,
+		Timezone:      request.Timezone,
+		MisfirePolicy: request.MisfirePolicy,
+		Enabled:       true,
+		Request:       request.Request,
+		OwnerID:       actor.UserID,
+		OwnerUsername: actor.Username,
+		OwnerRole:     actor.Role,
+		CreatedAt:     now.Format(time.RFC3339Nano),
+		UpdatedAt:     now.Format(time.RFC3339Nano),
+	}
+	if request.Enabled != nil {
+		schedule.Enabled = *request.Enabled
+	}
+
+	if err := prepareSchedule(&schedule, now); err != nil {
+		return model.Schedule{}, err
+	}
+
+	if err := s.repo.Create(context.Background(), schedule); err != nil {
+		return model.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// List returns every schedule to admins and their own to everyone else.
+func (s *SchedulerService) List(actor model.AuditActor, page int, limit int) ([]model.Schedule, model.Meta, error) {
+	ownerID := actor.UserID
+	if actor.Role == "admin" {
+		ownerID = ""
+	}
+	return s.repo.List(context.Background(), ownerID, page, limit)
+}
+
+func (s *SchedulerService) Get(id string, actor model.AuditActor) (model.Schedule, error) {
+	return s.getAuthorizedSchedule(id, actor)
+}
+
+// Update changes the given fields. The next run is computed again from now,
+// so re-enabling a schedule does not replay the occurrences it skipped.
+func (s *SchedulerService) Update(id string, request model.ScheduleUpdateRequest, actor model.AuditActor) (model.Schedule, error) {
+	schedule, err := s.getAuthorizedSchedule(id, actor)
+	if err != nil {
+		return model.Schedule{}, err
+	}
+
+	if request.Name != nil {
+		schedule.Name = *request.Name
+	}
+	if request.Cron != nil {
+		schedule.Cron = *request.Cron
+	}
+	if request.Timezone != nil {
+		schedule.Timezone = *request.Timezone
+	}
+	if request.MisfirePolicy != nil {
+		schedule.MisfirePolicy = *request.MisfirePolicy
+	}
+	if request.Enabled != nil {
+		schedule.Enabled = *request.Enabled
+	}
+	if request.Request != nil {
+		schedule.Request = *request.Request
+	}
+
+	now := time.Now().UTC()
+	if err := prepareSchedule(&schedule, now); err != nil {
+		return model.Schedule{}, err
+	}
+
+	if err := s.repo.Update(context.Background(), schedule); err != nil {
+		return model.Schedule{}, err
+	}
+	schedule.UpdatedAt = now.Format(time.RFC3339Nano)
+	return schedule, nil
+}
+
+func (s *SchedulerService) Delete(id string, actor model.AuditActor) error {
+	if _, err := s.getAuthorizedSchedule(id, actor); err != nil {
+		return err
+	}
+	return s.repo.Delete(context.Background(), id)
+}
+
+func (s *SchedulerService) Runs(id string, actor model.AuditActor, page int, limit int) (model.ScheduleRunsData, model.Meta, error) {
+	schedule, err := s.getAuthorizedSchedule(id, actor)
+	if err != nil {
+		return model.ScheduleRunsData{}, model.Meta{}, err
+	}
+
+	runs, meta, err := s.repo.Runs(context.Background(), schedule.ID, page, limit)
+	if err != nil {
+		return model.ScheduleRunsData{}, model.Meta{}, err
+	}
+	return model.ScheduleRunsData{ScheduleID: schedule.ID, Items: runs}, meta, nil
+}
+
+// Run checks for due schedules until ctx is cancelled. Occurrences missed
+// while no instance was running are handled by each schedule's misfire
+// policy on the first check.
+func (s *SchedulerService) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	s.runDue(ctx, time.Now().UTC())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runDue(ctx, now.UTC())
+		}
+	}
+}
+
+func (s *SchedulerService) runDue(ctx context.Context, now time.Time) {
+	due, err := s.repo.Due(ctx, now, 100)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("load due schedules failed", "error", err)
+		}
+		return
+	}
+
+	for _, schedule := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.fire(ctx, schedule, now); err != nil {
+			slog.Warn("run schedule failed", "schedule_id", schedule.ID, "error", err)
+		}
+	}
+}
+
+// fire claims the due occurrence of schedule, moves it to its next run and
+// queues its job, or records the occurrence as missed.
+func (s *SchedulerService) fire(ctx context.Context, schedule model.Schedule, now time.Time) error {
+	cron, err := util.ParseCron(schedule.Cron)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return err
+	}
+	dueAt, err := time.Parse(time.RFC3339Nano, schedule.NextRunAt)
+	if err != nil {
+		return err
+	}
+
+	var next *time.Time
+	if upcoming := cron.Next(now.In(loc)); !upcoming.IsZero() {
+		upcoming = upcoming.UTC()
+		next = &upcoming
+	}
+
+	claimed, err := s.repo.Advance(ctx, schedule.ID, dueAt, next)
+	if err != nil || !claimed {
+		return err
+	}
+
+	run := model.ScheduleRun{ScheduleID: schedule.ID, ScheduledFor: dueAt.Format(time.RFC3339Nano)}
+
+	// Count the occurrences between the one that was due and now, which
+	// were missed while no instance was checking.
+	missed := 0
+	if now.Sub(dueAt) > scheduleMisfireGrace {
+		for at := dueAt; missed < scheduleMaxMissed; missed++ {
+			at = cron.Next(at.In(loc))
+			if at.IsZero() || at.After(now) {
+				break
+			}
+		}
+		if schedule.MisfirePolicy == ScheduleMisfireSkip {
+			run.Status = "missed"
+			run.MissedRuns = missed + 1
+			return s.repo.AddRun(ctx, run)
+		}
+	}
+	run.MissedRuns = missed
+
+	actor, err := jobOwnerActor(ctx, s.users, schedule.OwnerID)
+	if errors.Is(err, model.ErrUserNotFound) || errors.Is(err, model.ErrForbidden) {
+		// Nobody may run the schedule any more, so it stops firing.
+		run.Status = "failed"
+		run.Error = "schedule owner no longer exists; schedule disabled"
+		if errors.Is(err, model.ErrForbidden) {
+			run.Error = "schedule owner no longer has the editor or admin role; schedule disabled"
+		}
+		if err := s.repo.Disable(ctx, schedule.ID); err != nil {
+			return err
+		}
+		return s.repo.AddRun(ctx, run)
+	}
+	if err != nil {
+		run.Status = "failed"
+		run.Error = err.Error()
+		return s.repo.AddRun(ctx, run)
+	}
+
+	job, err := s.jobs.CreateOperationJob(expandSchedulePlaceholders(schedule.Request, dueAt.In(loc)), actor)
+	if err != nil {
+		run.Status = "failed"
+		run.Error = err.Error()
+	} else {
+		run.Status = "queued"
+		run.JobID = job.JobID
+	}
+	return s.repo.AddRun(ctx, run)
+}
+
+// jobOwnerActor returns the user ownerID as they are now, for jobs queued on
+// their behalf by schedules and pipelines, so those jobs never run with a
+// role the owner has since lost. model.ErrUserNotFound means the owner was
+// deleted and model.ErrForbidden that they may no longer run jobs.
+func jobOwnerActor(ctx context.Context, users *repository.UserRepository, ownerID string) (model.AuditActor, error) {
+	if ownerID == "" {
+		return model.AuditActor{}, model.ErrUserNotFound
+	}
+	owner, err := users.FindByID(ctx, ownerID)
+	if err != nil {
+		return model.AuditActor{}, err
+	}
+	if owner.Role != "editor" && owner.Role != "admin" {
+		return model.AuditActor{}, model.ErrForbidden
+	}
+	return model.AuditActor{UserID: owner.ID, Username: owner.Username, Role: owner.Role}, nil
+}
+
+func (s *SchedulerService) getAuthorizedSchedule(id string, actor model.AuditActor) (model.Schedule, error) {
+	schedule, err := s.repo.FindByID(context.Background(), strings.TrimSpace(id))
+	if err != nil {
+		return model.Schedule{}, err
+	}
+	// Other users' schedules are reported as missing rather than forbidden.
+	if actor.Role != "admin" && schedule.OwnerID != actor.UserID {
+		return model.Schedule{}, model.ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+// prepareSchedule validates and normalizes schedule and sets its next run.
+func prepareSchedule(schedule *model.Schedule, now time.Time) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	if schedule.Name == "" {
+		return apierror.New("BAD_REQUEST", "name is required", "name", http.StatusBadRequest)
+	}
+
+	schedule.Cron = strings.TrimSpace(schedule.Cron)
+	cron, err := util.ParseCron(schedule.Cron)
+	if err != nil {
+		return apierror.New("BAD_REQUEST", "invalid cron expression", err.Error(), http.StatusBadRequest)
+	}
+
+	schedule.Timezone = strings.TrimSpace(schedule.Timezone)
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return apierror.New("BAD_REQUEST", "invalid timezone", schedule.Timezone, http.StatusBadRequest)
+	}
+
+	schedule.MisfirePolicy = strings.ToLower(strings.TrimSpace(schedule.MisfirePolicy))
+	if schedule.MisfirePolicy == "" {
+		schedule.MisfirePolicy = ScheduleMisfireRunOnce
+	}
+	if schedule.MisfirePolicy != ScheduleMisfireRunOnce && schedule.MisfirePolicy != ScheduleMisfireSkip {
+		return apierror.New("BAD_REQUEST", "invalid misfire_policy (allowed: run_once|skip)", schedule.MisfirePolicy, http.StatusBadRequest)
+	}
+
+	request, _, err := validateJobRequest(schedule.Request)
+	if err != nil {
+		return err
+	}
+	schedule.Request = request
+
+	schedule.NextRunAt = ""
+	if next := cron.Next(now.In(loc)); !next.IsZero() {
+		schedule.NextRunAt = next.UTC().Format(time.RFC3339Nano)
+	}
+	if schedule.NextRunAt == "" {
+		return apierror.New("BAD_REQUEST", "cron expression never fires", schedule.Cron, http.StatusBadRequest)
+	}
+	return nil
+}
+
+// expandSchedulePlaceholders fills the date placeholders {date}, {time},
+// {year}, {month}, {day}, {hour} and {minute} in the paths and names of
+// request with the scheduled time of the run.
+func expandSchedulePlaceholders(request model.JobOperationRequest, at time.Time) model.JobOperationRequest {
+	replacer := strings.NewReplacer(
+		"{date}", at.Format("2006-01-02"),
+		"{time}", at.Format("150405"),
+		"{year}", at.Format("2006"),
+		"{month}", at.Format("01"),
+		"{day}", at.Format("02"),
+		"{hour}", at.Format("15"),
+		"{minute}", at.Format("04"),
+	)
+
+	expand := func(values []string) []string {
+		if values == nil {
+			return nil
+		}
+		expanded := make([]string, len(values))
+		for index, value := range values {
+			expanded[index] = replacer.Replace(value)
+		}
+		return expanded
+	}
+
+	request.Sources = expand(request.Sources)
+	request.Paths = expand(request.Paths)
+	request.Entries = expand(request.Entries)
+	request.Destination = replacer.Replace(request.Destination)
+	request.Name = replacer.Replace(request.Name)
+	return request
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+)
+
+func TestExpandSchedulePlaceholders(t *testing.T) {
+	at := time.Date(2026, time.March, 7, 4, 5, 6, 0, time.UTC)
+	request := model.JobOperationRequest{
+		Operation:   "compress",
+		Sources:     []string{"/reports/{year}/{month}"},
+		Destination: "/backups/{date}",
+		Name:        "reports-{date}-{time}.zip",
+	}
+
+	expanded := expandSchedulePlaceholders(request, at)
+	require.Equal(t, []string{"/reports/2026/03"}, expanded.Sources)
+	require.Equal(t, "/backups/2026-03-07", expanded.Destination)
+	require.Equal(t, "reports-2026-03-07-040506.zip", expanded.Name)
+	require.Equal(t, []string{"/reports/{year}/{month}"}, request.Sources)
+	require.Nil(t, expanded.Paths)
+}
+
+func TestPrepareSchedule(t *testing.T) {
+	now := time.Date(2026, time.March, 7, 12, 0, 0, 0, time.UTC)
+	schedule := model.Schedule{
+		Name:     " weekly trash ",
+		Cron:     "0 3 * * sun",
+		Timezone: "America/Bogota",
+		Request:  model.JobOperationRequest{Operation: "EMPTY_TRASH", OlderThan: "30d"},
+	}
+
+	require.NoError(t, prepareSchedule(&schedule, now))
+	require.Equal(t, "weekly trash", schedule.Name)
+	require.Equal(t, ScheduleMisfireRunOnce, schedule.MisfirePolicy)
+	require.Equal(t, "empty_trash", schedule.Request.Operation)
+	// 03:00 in Bogota (UTC-5) on Sunday 8 March.
+	require.Equal(t, "2026-03-08T08:00:00Z", schedule.NextRunAt)
+
+	invalid := []model.Schedule{
+		{Name: "", Cron: "@daily", Request: schedule.Request},
+		{Name: "x", Cron: "61 * * * *", Request: schedule.Request},
+		{Name: "x", Cron: "@daily", Timezone: "Mars/Olympus", Request: schedule.Request},
+		{Name: "x", Cron: "@daily", MisfirePolicy: "later", Request: schedule.Request},
+		{Name: "x", Cron: "@daily", Request: model.JobOperationRequest{Operation: "copy"}},
+		{Name: "x", Cron: "0 0 31 2 *", Request: schedule.Request},
+	}
+	for _, candidate := range invalid {
+		require.Error(t, prepareSchedule(&candidate, now), candidate)
+	}
+}
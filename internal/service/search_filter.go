@@ -0,0 +1,667 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/util"
+)
+
+// searchSortFields are the fields results can be sorted by. Relevance only
+// applies to content and fuzzy searches.
+var searchSortFields = []string{"name", "path", "type", "size", "modified", "created", "relevance"}
+
+var searchModes = []string{"substring", "fuzzy"}
+
+var searchCategories = []string{"image", "video", "audio", "document", "archive", "text"}
+
+var documentExtensions = []string{".pdf", ".doc", ".docx", ".odt", ".rtf", ".xls", ".xlsx", ".ods", ".csv", ".ppt", ".pptx", ".odp", ".epub", ".md", ".txt"}
+
+var archiveExtensions = []string{".zip", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".7z", ".rar"}
+
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// fileCategory groups a file by its MIME type or extension.
+func fileCategory(ext string, mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/") || util.IsImageExtension(ext):
+		return "image"
+	case strings.HasPrefix(mimeType, "video/") || util.IsVideoExtension(ext):
+		return "video"
+	case strings.HasPrefix(mimeType, "audio/"):
+		return "audio"
+	case slices.Contains(documentExtensions, ext):
+		return "document"
+	case slices.Contains(archiveExtensions, ext):
+		return "archive"
+	case strings.HasPrefix(mimeType, "text/") || util.IsTextExtractable(ext):
+		return "text"
+	default:
+		return ""
+	}
+}
+
+// buildSearchFilter validates the request parameters and parses the search
+// syntax in Query. Filters in Query take precedence over parameters.
+func buildSearchFilter(request model.SearchQuery) (model.SearchFilter, error) {
+	filter := model.SearchFilter{
+		Content:  strings.TrimSpace(request.Content),
+		Path:     strings.TrimSpace(request.Path),
+		MimeType: strings.ToLower(strings.TrimSpace(request.MimeType)),
+		Owner:    strings.TrimSpace(request.Owner),
+		Page:     request.Page,
+		Limit:    request.Limit,
+	}
+
+	for _, excluded := range request.Exclude {
+		for _, part := range strings.Split(excluded, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				filter.Exclude = append(filter.Exclude, normalizeAPIPath(part))
+			}
+		}
+	}
+
+	for _, meta := range request.Meta {
+		if err := addMetadataFilter(&filter, meta); err != nil {
+			return model.SearchFilter{}, fmt.Errorf("invalid meta: %w", err)
+		}
+	}
+
+	params := []struct {
+		name  string
+		value string
+		apply func(string) error
+	}{
+		{"type", request.Type, func(v string) error { return setSearchType(&filter, v) }},
+		{"ext", request.Extension, func(v string) error { setSearchExtensions(&filter, v); return nil }},
+		{"category", request.Category, func(v string) error { return setSearchCategory(&filter, v) }},
+		{"min_size", request.MinSize, func(v string) error { return setByteSize(&filter.MinSize, v) }},
+		{"max_size", request.MaxSize, func(v string) error { return setByteSize(&filter.MaxSize, v) }},
+		{"modified_after", request.ModifiedAfter, func(v string) error { return setDateStart(&filter.ModifiedAfter, v) }},
+		{"modified_before", request.ModifiedBefore, func(v string) error { return setDateStart(&filter.ModifiedBefore, v) }},
+		{"created_after", request.CreatedAfter, func(v string) error { return setDateStart(&filter.CreatedAfter, v) }},
+		{"created_before", request.CreatedBefore, func(v string) error { return setDateStart(&filter.CreatedBefore, v) }},
+		{"name", request.Name, func(v string) error { filter.NameGlob = v; return nil }},
+		{"regex", request.Regex, func(v string) error { return setNameRegex(&filter, v) }},
+		{"sort", request.Sort, func(v string) error { return setSearchSort(&filter, v) }},
+		{"mode", request.Mode, func(v string) error { return setSearchMode(&filter, v) }},
+		{"tag", request.Tag, func(v string) error { return setSearchTags(&filter, v) }},
+	}
+	for _, param := range params {
+		value := strings.TrimSpace(param.value)
+		if value == "" {
+			continue
+		}
+		if err := param.apply(value); err != nil {
+			return model.SearchFilter{}, fmt.Errorf("invalid %s: %w", param.name, err)
+		}
+	}
+
+	if err := parseSearchSyntax(request.Query, &filter); err != nil {
+		return model.SearchFilter{}, fmt.Errorf("invalid search query: %w", err)
+	}
+
+	if filter.Fuzzy {
+		if len(filter.Terms) == 0 {
+			return model.SearchFilter{}, fmt.Errorf("fuzzy mode requires words in q to match")
+		}
+		if filter.Content != "" {
+			return model.SearchFilter{}, fmt.Errorf("fuzzy mode cannot be combined with a content query")
+		}
+	}
+	if filter.Fuzzy && filter.Sort == "" {
+		filter.Sort = "relevance"
+	}
+	if strings.TrimPrefix(filter.Sort, "-") == "relevance" && filter.Content == "" && !filter.Fuzzy {
+		return model.SearchFilter{}, fmt.Errorf("sort by relevance requires a content query or fuzzy mode")
+	}
+	return filter, nil
+}
+
+// hasSearchCriteria reports whether filter narrows results by more than their
+// location.
+func hasSearchCriteria(filter model.SearchFilter) bool {
+	return len(filter.Terms) > 0 || filter.Content != "" || filter.Type != "" || len(filter.Extensions) > 0 ||
+		filter.MimeType != "" || filter.Category != "" || filter.MinSize != nil || filter.MaxSize != nil ||
+		filter.ModifiedAfter != nil || filter.ModifiedBefore != nil || filter.CreatedAfter != nil || filter.CreatedBefore != nil ||
+		filter.NameGlob != "" || filter.NameRegex != "" || filter.Owner != "" || len(filter.Tags) > 0 ||
+		len(filter.Metadata) > 0
+}
+
+type searchToken struct {
+	raw     string
+	key     string
+	value   string
+	negated bool
+}
+
+var searchKeys = []string{"ext", "type", "size", "modified", "created", "mime", "category", "name", "regex", "path", "owner", "content", "sort", "mode", "tag", "meta"}
+
+// parseSearchSyntax applies the filters of a query such as
+// `ext:pdf size:>10MB modified:<2026-01-01 "quarterly report"` to filter.
+// Words and quoted phrases that are not key:value filters must appear in the
+// name. Only -path: may be negated, to exclude a subtree.
+func parseSearchSyntax(input string, filter *model.SearchFilter) error {
+	tokens, err := tokenizeSearchQuery(input)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.key == "" {
+			if token.value != "" {
+				filter.Terms = append(filter.Terms, token.value)
+			}
+			continue
+		}
+		if token.negated && token.key != "path" {
+			return fmt.Errorf("%s: only path: can be negated", token.raw)
+		}
+		if token.value == "" {
+			return fmt.Errorf("%s: missing value", token.raw)
+		}
+
+		if err := applySearchToken(filter, token); err != nil {
+			return fmt.Errorf("%s: %w", token.raw, err)
+		}
+	}
+	return nil
+}
+
+func applySearchToken(filter *model.SearchFilter, token searchToken) error {
+	switch token.key {
+	case "ext":
+		setSearchExtensions(filter, token.value)
+	case "type":
+		return setSearchType(filter, token.value)
+	case "size":
+		return setSizeRange(filter, token.value)
+	case "modified":
+		return setDateRange(&filter.ModifiedAfter, &filter.ModifiedBefore, token.value)
+	case "created":
+		return setDateRange(&filter.CreatedAfter, &filter.CreatedBefore, token.value)
+	case "mime":
+		filter.MimeType = strings.ToLower(token.value)
+	case "category":
+		return setSearchCategory(filter, token.value)
+	case "name":
+		filter.NameGlob = token.value
+	case "regex":
+		return setNameRegex(filter, token.value)
+	case "path":
+		if token.negated {
+			filter.Exclude = append(filter.Exclude, normalizeAPIPath(token.value))
+		} else {
+			filter.Path = token.value
+		}
+	case "owner":
+		filter.Owner = token.value
+	case "content":
+		filter.Content = token.value
+	case "sort":
+		return setSearchSort(filter, token.value)
+	case "mode":
+		return setSearchMode(filter, token.value)
+	case "tag":
+		return setSearchTags(filter, token.value)
+	case "meta":
+		return addMetadataFilter(filter, token.value)
+	}
+	return nil
+}
+
+// tokenizeSearchQuery splits input on whitespace outside double quotes. A
+// word is a key:value filter when its key is known and not quoted, so
+// `"10:30"` stays plain text.
+func tokenizeSearchQuery(input string) ([]searchToken, error) {
+	tokens := make([]searchToken, 0)
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		var word strings.Builder
+		firstQuote := -1
+		inQuote := false
+		for ; i < len(runes) && (inQuote || !unicode.IsSpace(runes[i])); i++ {
+			if runes[i] == '"' {
+				if firstQuote < 0 {
+					firstQuote = word.Len()
+				}
+				inQuote = !inQuote
+				continue
+			}
+			word.WriteRune(runes[i])
+		}
+		raw := string(runes[start:i])
+		if inQuote {
+			return nil, fmt.Errorf("%s: unterminated quote", raw)
+		}
+
+		token := searchToken{raw: raw, value: word.String()}
+		if key, value, ok := strings.Cut(token.value, ":"); ok && (firstQuote < 0 || firstQuote > len(key)) {
+			name := strings.ToLower(strings.TrimPrefix(key, "-"))
+			if slices.Contains(searchKeys, name) {
+				token.key = name
+				token.value = value
+				token.negated = strings.HasPrefix(key, "-")
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+func setSearchType(filter *model.SearchFilter, value string) error {
+	switch strings.ToLower(value) {
+	case "file":
+		filter.Type = "file"
+	case "dir", "directory":
+		filter.Type = "directory"
+	default:
+		return fmt.Errorf("type must be file or dir")
+	}
+	return nil
+}
+
+// setSearchExtensions accepts a comma-separated list, with or without dots.
+func setSearchExtensions(filter *model.SearchFilter, value string) {
+	filter.Extensions = nil
+	for _, ext := range strings.Split(strings.ToLower(value), ",") {
+		if ext = strings.TrimSpace(ext); ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		filter.Extensions = append(filter.Extensions, ext)
+	}
+}
+
+func setSearchCategory(filter *model.SearchFilter, value string) error {
+	category := strings.ToLower(value)
+	if !slices.Contains(searchCategories, category) {
+		return fmt.Errorf("category must be one of %s", strings.Join(searchCategories, ", "))
+	}
+	filter.Category = category
+	return nil
+}
+
+func setNameRegex(filter *model.SearchFilter, value string) error {
+	if _, err := regexp.Compile(value); err != nil {
+		return fmt.Errorf("invalid regular expression: %w", err)
+	}
+	// The index matches the pattern in PostgreSQL, so it must translate too.
+	if _, err := util.PostgresRegex(value); err != nil {
+		return fmt.Errorf("invalid regular expression: %w", err)
+	}
+	filter.NameRegex = value
+	return nil
+}
+
+func setSearchSort(filter *model.SearchFilter, value string) error {
+	field := strings.ToLower(strings.TrimPrefix(value, "-"))
+	if !slices.Contains(searchSortFields, field) {
+		return fmt.Errorf("sort must be one of %s, optionally prefixed with - for descending order", strings.Join(searchSortFields, ", "))
+	}
+	filter.Sort = strings.ToLower(value)
+	return nil
+}
+
+func setSearchMode(filter *model.SearchFilter, value string) error {
+	mode := strings.ToLower(value)
+	if !slices.Contains(searchModes, mode) {
+		return fmt.Errorf("mode must be one of %s", strings.Join(searchModes, ", "))
+	}
+	filter.Fuzzy = mode == "fuzzy"
+	return nil
+}
+
+// setSearchTags adds a comma-separated list of tags the results must all
+// carry.
+func setSearchTags(filter *model.SearchFilter, value string) error {
+	parts := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+	}
+	tags, err := normalizeTags(parts)
+	if err != nil {
+		return fmt.Errorf("tags must be 1 to 64 letters, digits, spaces, dots, dashes or underscores")
+	}
+	for _, tag := range tags {
+		if !slices.Contains(filter.Tags, tag) {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+	return nil
+}
+
+// metadataFilterPattern is a custom metadata filter such as due_date<2026-07-01.
+var metadataFilterPattern = regexp.MustCompile(`^([a-z][a-z0-9_]*)(>=|<=|!=|=|>|<)(.*)$`)
+
+// addMetadataFilter adds a comparison of a custom metadata field. Fields and
+// values are checked against the field definitions when the search runs.
+func addMetadataFilter(filter *model.SearchFilter, value string) error {
+	match := metadataFilterPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return fmt.Errorf("metadata filters look like field=value, with =, !=, >, >=, < or <=")
+	}
+	if strings.TrimSpace(match[3]) == "" {
+		return fmt.Errorf("%s: missing value", match[1])
+	}
+	filter.Metadata = append(filter.Metadata, model.MetadataFilter{Field: match[1], Op: match[2], Value: strings.TrimSpace(match[3])})
+	return nil
+}
+
+func setByteSize(target **int64, value string) error {
+	size, err := parseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*target = &size
+	return nil
+}
+
+func setDateStart(target **time.Time, value string) error {
+	start, _, err := parseDateBound(value)
+	if err != nil {
+		return err
+	}
+	*target = &start
+	return nil
+}
+
+// setSizeRange parses >N, >=N, <N, <=N, =N, N or A..B, with sizes such as
+// 10MB or 1.5GiB.
+func setSizeRange(filter *model.SearchFilter, value string) error {
+	if low, high, ok := strings.Cut(value, ".."); ok {
+		minSize, err := parseByteSize(low)
+		if err != nil {
+			return err
+		}
+		maxSize, err := parseByteSize(high)
+		if err != nil {
+			return err
+		}
+		if minSize > maxSize {
+			return fmt.Errorf("size range %s is empty", value)
+		}
+		filter.MinSize, filter.MaxSize = &minSize, &maxSize
+		return nil
+	}
+
+	op, operand := splitComparison(value)
+	size, err := parseByteSize(operand)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case ">":
+		size++
+		filter.MinSize = &size
+	case ">=":
+		filter.MinSize = &size
+	case "<":
+		size--
+		filter.MaxSize = &size
+	case "<=":
+		filter.MaxSize = &size
+	default:
+		filter.MinSize, filter.MaxSize = &size, &size
+	}
+	return nil
+}
+
+// setDateRange parses >D, >=D, <D, <=D, =D, D or A..B. A date without a time
+// covers the whole UTC day, so modified:2026-01-01 matches that day and
+// modified:>2026-01-01 starts the day after.
+func setDateRange(after **time.Time, before **time.Time, value string) error {
+	if low, high, ok := strings.Cut(value, ".."); ok {
+		start, _, err := parseDateBound(low)
+		if err != nil {
+			return err
+		}
+		_, end, err := parseDateBound(high)
+		if err != nil {
+			return err
+		}
+		if !start.Before(end) {
+			return fmt.Errorf("date range %s is empty", value)
+		}
+		*after, *before = &start, &end
+		return nil
+	}
+
+	op, operand := splitComparison(value)
+	start, end, err := parseDateBound(operand)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case ">":
+		*after = &end
+	case ">=":
+		*after = &start
+	case "<":
+		*before = &start
+	case "<=":
+		*before = &end
+	default:
+		*after, *before = &start, &end
+	}
+	return nil
+}
+
+func splitComparison(value string) (string, string) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if operand, ok := strings.CutPrefix(value, op); ok {
+			return op, strings.TrimSpace(operand)
+		}
+	}
+	return "", value
+}
+
+// parseByteSize parses a size such as 512, 10KB, 1.5GiB or 2m. Units are
+// binary, so 1KB is 1024 bytes.
+func parseByteSize(value string) (int64, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	split := strings.IndexFunc(trimmed, func(r rune) bool { return r != '.' && !unicode.IsDigit(r) })
+	if split < 0 {
+		split = len(trimmed)
+	}
+
+	number, err := strconv.ParseFloat(trimmed[:split], 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a number with an optional unit such as 10MB", value)
+	}
+	multiplier, ok := byteSizeUnits[strings.TrimSpace(trimmed[split:])]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit in %q, expected B, KB, MB, GB or TB", value)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// searchNow is the clock relative dates are resolved against.
+var searchNow = time.Now
+
+// parseDateBound parses a date (2006-01-02), an RFC 3339 timestamp or a
+// relative period (today, yesterday, this-week, last-week, this-month,
+// last-month, this-year, last-year) and returns the instant it starts and
+// the instant after it ends. Relative periods are UTC days, weeks starting
+// on Monday, months and years, resolved when the search runs, so a saved
+// search keeps following them.
+func parseDateBound(value string) (time.Time, time.Time, error) {
+	value = strings.TrimSpace(value)
+	if start, end, ok := relativeDateBound(strings.ToLower(value)); ok {
+		return start, end, nil
+	}
+	if day, err := time.Parse(time.DateOnly, value); err == nil {
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	if instant, err := time.Parse(time.RFC3339, value); err == nil {
+		instant = instant.UTC()
+		return instant, instant.Add(time.Microsecond), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD, an RFC 3339 timestamp or a period such as today or this-week", value)
+}
+
+func relativeDateBound(value string) (time.Time, time.Time, bool) {
+	now := searchNow().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	year := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	switch value {
+	case "today":
+		return today, today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, true
+	case "this-week":
+		return monday, monday.AddDate(0, 0, 7), true
+	case "last-week":
+		return monday.AddDate(0, 0, -7), monday, true
+	case "this-month":
+		return month, month.AddDate(0, 1, 0), true
+	case "last-month":
+		return month.AddDate(0, -1, 0), month, true
+	case "this-year":
+		return year, year.AddDate(1, 0, 0), true
+	case "last-year":
+		return year.AddDate(-1, 0, 0), year, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// searchMatcher evaluates a SearchFilter against files found by walking the
+// tree, for searches that cannot use the index.
+type searchMatcher struct {
+	filter model.SearchFilter
+	glob   *regexp.Regexp
+	regex  *regexp.Regexp
+}
+
+func newSearchMatcher(filter model.SearchFilter) (*searchMatcher, error) {
+	matcher := &searchMatcher{filter: filter}
+	if filter.NameGlob != "" {
+		pattern := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(filter.NameGlob))
+		matcher.glob = regexp.MustCompile("(?is)^" + pattern + "$")
+	}
+	if filter.NameRegex != "" {
+		regex, err := regexp.Compile(filter.NameRegex)
+		if err != nil {
+			return nil, err
+		}
+		matcher.regex = regex
+	}
+	return matcher, nil
+}
+
+func (m *searchMatcher) matches(entry model.IndexEntry) bool {
+	filter := m.filter
+	nameLower := strings.ToLower(entry.Name)
+	for _, term := range filter.Terms {
+		if !strings.Contains(nameLower, strings.ToLower(term)) {
+			return false
+		}
+	}
+	for _, excluded := range filter.Exclude {
+		if entry.Path == excluded || strings.HasPrefix(entry.Path, strings.TrimSuffix(excluded, "/")+"/") {
+			return false
+		}
+	}
+	if filter.Type != "" && entry.Type != filter.Type {
+		return false
+	}
+	if len(filter.Extensions) > 0 && !slices.Contains(filter.Extensions, entry.Extension) {
+		return false
+	}
+	if filter.MimeType != "" {
+		if prefix, ok := strings.CutSuffix(filter.MimeType, "/*"); ok {
+			if !strings.HasPrefix(entry.MimeType, prefix+"/") {
+				return false
+			}
+		} else if entry.MimeType != filter.MimeType {
+			return false
+		}
+	}
+	if filter.Category != "" && entry.Category != filter.Category {
+		return false
+	}
+	if (filter.MinSize != nil && entry.Size < *filter.MinSize) || (filter.MaxSize != nil && entry.Size > *filter.MaxSize) {
+		return false
+	}
+	if (filter.ModifiedAfter != nil && entry.ModifiedAt.Before(*filter.ModifiedAfter)) ||
+		(filter.ModifiedBefore != nil && !entry.ModifiedAt.Before(*filter.ModifiedBefore)) {
+		return false
+	}
+	if (filter.CreatedAfter != nil && entry.CreatedAt.Before(*filter.CreatedAfter)) ||
+		(filter.CreatedBefore != nil && !entry.CreatedAt.Before(*filter.CreatedBefore)) {
+		return false
+	}
+	if m.glob != nil && !m.glob.MatchString(entry.Name) {
+		return false
+	}
+	if m.regex != nil && !m.regex.MatchString(entry.Name) {
+		return false
+	}
+	return true
+}
+
+// lessSearchEntry orders walk results like the index orders its results.
+func lessSearchEntry(sort string, a model.IndexEntry, b model.IndexEntry) bool {
+	field, descending := strings.CutPrefix(sort, "-")
+	compare := 0
+	switch field {
+	case "path":
+		compare = strings.Compare(a.Path, b.Path)
+	case "type":
+		compare = strings.Compare(a.Type, b.Type)
+	case "size":
+		compare = cmp.Compare(a.Size, b.Size)
+	case "modified":
+		compare = a.ModifiedAt.Compare(b.ModifiedAt)
+	case "created":
+		compare = a.CreatedAt.Compare(b.CreatedAt)
+	case "relevance":
+		// Best matches come first either way, as in content searches.
+		compare, descending = cmp.Compare(b.Score, a.Score), false
+	default:
+		compare = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+	if descending {
+		compare = -compare
+	}
+	if compare == 0 {
+		return a.Path < b.Path
+	}
+	return compare < 0
+}
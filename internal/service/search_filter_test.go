@@ -0,0 +1,229 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+)
+
+func TestBuildSearchFilterParsesQuerySyntax(t *testing.T) {
+	filter, err := buildSearchFilter(model.SearchQuery{
+		Query: `ext:pdf,DOCX size:>10MB modified:<2026-01-01 "quarterly report" -path:/archive sort:-size`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"quarterly report"}, filter.Terms)
+	require.Equal(t, []string{".pdf", ".docx"}, filter.Extensions)
+	require.NotNil(t, filter.MinSize)
+	require.Equal(t, int64(10<<20)+1, *filter.MinSize)
+	require.Nil(t, filter.MaxSize)
+	require.NotNil(t, filter.ModifiedBefore)
+	require.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *filter.ModifiedBefore)
+	require.Equal(t, []string{"/archive"}, filter.Exclude)
+	require.Equal(t, "-size", filter.Sort)
+}
+
+func TestBuildSearchFilterQueryOverridesParams(t *testing.T) {
+	filter, err := buildSearchFilter(model.SearchQuery{
+		Query:     "type:dir name:*2025*",
+		Type:      "file",
+		Extension: "txt",
+		MinSize:   "1.5KB",
+		Exclude:   []string{"/tmp,/cache/"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "directory", filter.Type)
+	require.Equal(t, []string{".txt"}, filter.Extensions)
+	require.Equal(t, "*2025*", filter.NameGlob)
+	require.Equal(t, int64(1536), *filter.MinSize)
+	require.Equal(t, []string{"/tmp", "/cache"}, filter.Exclude)
+	require.Empty(t, filter.Terms)
+}
+
+func TestBuildSearchFilterDateRanges(t *testing.T) {
+	day := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	filter, err := buildSearchFilter(model.SearchQuery{Query: "modified:2026-03-15"})
+	require.NoError(t, err)
+	require.Equal(t, day, *filter.ModifiedAfter)
+	require.Equal(t, day.AddDate(0, 0, 1), *filter.ModifiedBefore)
+
+	filter, err = buildSearchFilter(model.SearchQuery{Query: "created:2026-03-15..2026-03-20"})
+	require.NoError(t, err)
+	require.Equal(t, day, *filter.CreatedAfter)
+	require.Equal(t, day.AddDate(0, 0, 6), *filter.CreatedBefore)
+
+	filter, err = buildSearchFilter(model.SearchQuery{Query: "modified:>2026-03-15"})
+	require.NoError(t, err)
+	require.Equal(t, day.AddDate(0, 0, 1), *filter.ModifiedAfter)
+	require.Nil(t, filter.ModifiedBefore)
+
+	filter, err = buildSearchFilter(model.SearchQuery{ModifiedAfter: "2026-03-15T10:00:00Z"})
+	require.NoError(t, err)
+	require.Equal(t, day.Add(10*time.Hour), *filter.ModifiedAfter)
+}
+
+func TestBuildSearchFilterRelativeDates(t *testing.T) {
+	original := searchNow
+	searchNow = func() time.Time { return time.Date(2026, 3, 19, 15, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { searchNow = original })
+
+	cases := map[string][2]time.Time{
+		"today":      {time.Date(2026, 3, 19, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)},
+		"yesterday":  {time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 19, 0, 0, 0, 0, time.UTC)},
+		"this-week":  {time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC)},
+		"last-week":  {time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		"this-month": {time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		"last-month": {time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		"this-year":  {time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		"last-year":  {time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for period, bounds := range cases {
+		t.Run(period, func(t *testing.T) {
+			filter, err := buildSearchFilter(model.SearchQuery{Query: "modified:" + period})
+			require.NoError(t, err)
+			require.Equal(t, bounds[0], *filter.ModifiedAfter)
+			require.Equal(t, bounds[1], *filter.ModifiedBefore)
+		})
+	}
+
+	filter, err := buildSearchFilter(model.SearchQuery{Query: "created:<This-Week"})
+	require.NoError(t, err)
+	require.Equal(t, cases["this-week"][0], *filter.CreatedBefore)
+}
+
+func TestBuildSearchFilterQuotedKeysAreText(t *testing.T) {
+	filter, err := buildSearchFilter(model.SearchQuery{Query: `"size:10" meeting 10:30`})
+	require.NoError(t, err)
+	require.Equal(t, []string{"size:10", "meeting", "10:30"}, filter.Terms)
+	require.Nil(t, filter.MinSize)
+}
+
+func TestBuildSearchFilterFuzzyMode(t *testing.T) {
+	filter, err := buildSearchFilter(model.SearchQuery{Query: "qrep ext:pdf", Mode: "Fuzzy"})
+	require.NoError(t, err)
+	require.True(t, filter.Fuzzy)
+	require.Equal(t, []string{"qrep"}, filter.Terms)
+	require.Equal(t, "relevance", filter.Sort)
+
+	filter, err = buildSearchFilter(model.SearchQuery{Query: "qrep mode:fuzzy sort:-modified"})
+	require.NoError(t, err)
+	require.True(t, filter.Fuzzy)
+	require.Equal(t, "-modified", filter.Sort)
+}
+
+func TestBuildSearchFilterTags(t *testing.T) {
+	filter, err := buildSearchFilter(model.SearchQuery{Query: "tag:Urgent tag:\"Q1  Review\" report", Tag: "urgent,draft"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"urgent", "draft", "q1 review"}, filter.Tags)
+	require.Equal(t, []string{"report"}, filter.Terms)
+
+	filter, err = buildSearchFilter(model.SearchQuery{Tag: ",archived,"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"archived"}, filter.Tags)
+	require.True(t, hasSearchCriteria(filter))
+}
+
+func TestBuildSearchFilterMetadata(t *testing.T) {
+	filter, err := buildSearchFilter(model.SearchQuery{Query: "meta:amount>=100 report", Meta: []string{"status=final"}})
+	require.NoError(t, err)
+	require.Equal(t, []model.MetadataFilter{
+		{Field: "status", Op: "=", Value: "final"},
+		{Field: "amount", Op: ">=", Value: "100"},
+	}, filter.Metadata)
+	require.Equal(t, []string{"report"}, filter.Terms)
+	require.True(t, hasSearchCriteria(filter))
+}
+
+func TestBuildSearchFilterErrors(t *testing.T) {
+	cases := map[string]struct {
+		request model.SearchQuery
+		message string
+	}{
+		"unknown unit":         {model.SearchQuery{Query: "size:>10XB"}, "invalid search query: size:>10XB: unknown size unit"},
+		"bad date":             {model.SearchQuery{Query: "modified:<someday"}, "invalid search query: modified:<someday: invalid date"},
+		"empty range":          {model.SearchQuery{Query: "size:10MB..1MB"}, "is empty"},
+		"unterminated quote":   {model.SearchQuery{Query: `"quarterly report`}, "unterminated quote"},
+		"negated filter":       {model.SearchQuery{Query: "-ext:pdf"}, "only path: can be negated"},
+		"missing value":        {model.SearchQuery{Query: "ext:"}, "missing value"},
+		"bad type":             {model.SearchQuery{Type: "link"}, "invalid type: type must be file or dir"},
+		"bad category":         {model.SearchQuery{Category: "spreadsheet"}, "invalid category"},
+		"bad regex":            {model.SearchQuery{Regex: "(unclosed"}, "invalid regex: invalid regular expression"},
+		"regex repeat too big": {model.SearchQuery{Regex: "a{300}"}, "invalid regex: invalid regular expression: repetition counts above 255"},
+		"bad sort":             {model.SearchQuery{Sort: "owner"}, "invalid sort"},
+		"relevance no content": {model.SearchQuery{Query: "report", Sort: "relevance"}, "requires a content query"},
+		"bad min size":         {model.SearchQuery{MinSize: "-4"}, "invalid min_size"},
+		"bad mode":             {model.SearchQuery{Query: "report", Mode: "exact"}, "invalid mode"},
+		"fuzzy without words":  {model.SearchQuery{Query: "ext:pdf", Mode: "fuzzy"}, "fuzzy mode requires words"},
+		"fuzzy with content":   {model.SearchQuery{Query: "rprt", Content: "budget", Mode: "fuzzy"}, "cannot be combined"},
+		"bad tag":              {model.SearchQuery{Tag: "-draft"}, "invalid tag: tags must be"},
+		"bad meta":             {model.SearchQuery{Meta: []string{"Status~final"}}, "invalid meta: metadata filters look like"},
+		"empty meta value":     {model.SearchQuery{Query: "meta:status="}, "status: missing value"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildSearchFilter(tc.request)
+			require.ErrorContains(t, err, tc.message)
+		})
+	}
+}
+
+func TestSearchMatcher(t *testing.T) {
+	modified := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	entry := model.IndexEntry{
+		Path:       "/reports/Q1 Report.PDF",
+		Name:       "Q1 Report.PDF",
+		Type:       "file",
+		Size:       20 << 20,
+		Extension:  ".pdf",
+		MimeType:   "application/pdf",
+		Category:   "document",
+		ModifiedAt: modified,
+		CreatedAt:  modified,
+	}
+
+	matches := func(query string) bool {
+		t.Helper()
+		filter, err := buildSearchFilter(model.SearchQuery{Query: query})
+		require.NoError(t, err)
+		matcher, err := newSearchMatcher(filter)
+		require.NoError(t, err)
+		return matcher.matches(entry)
+	}
+
+	require.True(t, matches(`ext:pdf size:>10MB modified:<2026-03-01 "q1 report"`))
+	require.True(t, matches("name:q1*.pdf category:document mime:application/*"))
+	require.True(t, matches(`regex:^Q\d`))
+	require.True(t, matches("modified:2026-02-10 created:>=2026-02-10"))
+	require.False(t, matches("size:<10MB"))
+	require.False(t, matches("type:dir"))
+	require.False(t, matches("mime:image/*"))
+	require.False(t, matches("report -path:/reports"))
+	require.False(t, matches("modified:>2026-02-10"))
+	require.False(t, matches("name:*.docx"))
+}
+
+func TestLessSearchEntry(t *testing.T) {
+	small := model.IndexEntry{Name: "b.txt", Size: 1, ModifiedAt: time.Unix(200, 0)}
+	large := model.IndexEntry{Name: "A.txt", Size: 9, ModifiedAt: time.Unix(100, 0)}
+
+	require.True(t, lessSearchEntry("", large, small))
+	require.True(t, lessSearchEntry("size", small, large))
+	require.True(t, lessSearchEntry("-size", large, small))
+	require.True(t, lessSearchEntry("modified", large, small))
+	require.False(t, lessSearchEntry("-modified", large, small))
+
+	directory := model.IndexEntry{Name: "z", Type: "directory"}
+	file := model.IndexEntry{Name: "a", Type: "file"}
+	require.True(t, lessSearchEntry("type", directory, file))
+}
+
+func TestFileCategory(t *testing.T) {
+	require.Equal(t, "image", fileCategory(".jpg", "image/jpeg"))
+	require.Equal(t, "document", fileCategory(".pdf", "application/pdf"))
+	require.Equal(t, "archive", fileCategory(".zip", "application/zip"))
+	require.Equal(t, "", fileCategory(".bin", ""))
+}
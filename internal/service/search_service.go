@@ -1,6 +1,7 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"io/fs"
@@ -23,6 +24,9 @@ type SearchService struct {
 	maxDepth   int
 	timeout    time.Duration
 	maxResults int
+	index      *IndexService
+	tags       *TagService
+	metadata   *MetadataService
 }
 
 func NewSearchService(store storage.Storage, maxDepth int, timeout time.Duration) *SearchService {
@@ -36,53 +40,502 @@ func NewSearchService(store storage.Storage, maxDepth int, timeout time.Duration
 	return &SearchService{store: store, maxDepth: maxDepth, timeout: timeout, maxResults: 1000}
 }
 
-func (s *SearchService) Search(ctx context.Context, query string, startPath string, itemType string, extension string, page int, limit int) (map[string]any, model.Meta, error) {
-	query = strings.TrimSpace(query)
-	normalizedType := strings.ToLower(strings.TrimSpace(itemType))
-	normalizedExt := strings.ToLower(strings.TrimSpace(extension))
-	if normalizedExt != "" && !strings.HasPrefix(normalizedExt, ".") {
-		normalizedExt = "." + normalizedExt
-	}
+// UseIndex makes searches query index once it has completed a full crawl.
+// Until then, and without an index, searches walk the tree.
+func (s *SearchService) UseIndex(index *IndexService) {
+	s.index = index
+}
 
-	if query == "" && normalizedType == "" && normalizedExt == "" {
-		return nil, model.Meta{}, apierror.New("BAD_REQUEST", "at least one filter is required: q, type, or ext", "q", http.StatusBadRequest)
-	}
+// UseTags makes search results carry their tags.
+func (s *SearchService) UseTags(tags *TagService) {
+	s.tags = tags
+}
+
+// UseMetadata makes searches filterable by custom metadata.
+func (s *SearchService) UseMetadata(metadata *MetadataService) {
+	s.metadata = metadata
+}
 
-	if page < 1 {
-		page = 1
+// Reindex starts a reconciliation crawl of the search index.
+func (s *SearchService) Reindex(ctx context.Context) (model.IndexStatus, error) {
+	if s.index == nil {
+		return model.IndexStatus{}, apierror.New("CONFLICT", "search index is not enabled", "", http.StatusConflict)
 	}
-	if limit <= 0 {
-		limit = 20
+	return s.index.Reindex(ctx)
+}
+
+// searchPlan is a validated search: its filter, the resolved start directory
+// and whether the index answers it.
+type searchPlan struct {
+	filter   model.SearchFilter
+	resolved string
+	indexed  bool
+}
+
+func (s *SearchService) plan(ctx context.Context, request model.SearchQuery) (searchPlan, error) {
+	filter, err := buildSearchFilter(request)
+	if err != nil {
+		return searchPlan{}, apierror.New("BAD_REQUEST", err.Error(), "q", http.StatusBadRequest)
 	}
-	if limit > 200 {
-		limit = 200
+
+	if !hasSearchCriteria(filter) {
+		return searchPlan{}, apierror.New("BAD_REQUEST", "at least one filter is required: q, content, type, ext or another filter", "q", http.StatusBadRequest)
 	}
 
-	if strings.TrimSpace(startPath) == "" {
+	startPath := filter.Path
+	if startPath == "" {
 		startPath = "/"
 	}
+	filter.Path = normalizeAPIPath(startPath)
 
 	if isInternalStoragePath(startPath) {
-		return nil, model.Meta{}, apierror.New("NOT_FOUND", "start path not found", startPath, http.StatusNotFound)
+		return searchPlan{}, apierror.New("NOT_FOUND", "start path not found", startPath, http.StatusNotFound)
 	}
 
 	resolvedStart, err := s.store.Resolve(startPath)
 	if err != nil {
-		return nil, model.Meta{}, err
+		return searchPlan{}, err
 	}
 
 	if _, err := os.Stat(resolvedStart); err != nil {
 		if os.IsNotExist(err) {
-			return nil, model.Meta{}, apierror.New("NOT_FOUND", "start path not found", startPath, http.StatusNotFound)
+			return searchPlan{}, apierror.New("NOT_FOUND", "start path not found", startPath, http.StatusNotFound)
 		}
+		return searchPlan{}, err
+	}
+
+	filter.Metadata, err = s.metadata.resolveFilters(ctx, filter.Metadata)
+	if err != nil {
+		return searchPlan{}, err
+	}
+
+	indexed := s.index != nil && s.index.Ready()
+	if !indexed && (filter.Content != "" || filter.Owner != "" || len(filter.Tags) > 0 || len(filter.Metadata) > 0) {
+		return searchPlan{}, apierror.New("INDEX_NOT_READY", "content, owner, tag and metadata filters are unavailable until the search index is built", "q", http.StatusServiceUnavailable)
+	}
+	return searchPlan{filter: filter, resolved: resolvedStart, indexed: indexed}, nil
+}
+
+// cursorScope binds cursors to the filter, the kind of request and whether
+// the index or a walk produced them, since each orders results differently.
+func (p searchPlan) cursorScope(kind string) string {
+	filter := p.filter
+	filter.Page, filter.Limit, filter.After = 0, 0, nil
+	return cursorScope("search", kind, p.indexed, filter)
+}
+
+func (s *SearchService) Search(ctx context.Context, request model.SearchQuery) (map[string]any, model.Meta, error) {
+	results, err := s.page(ctx, request)
+	if err != nil {
 		return nil, model.Meta{}, err
 	}
 
+	data := map[string]any{"query": strings.TrimSpace(request.Query), "items": results.items}
+	if results.capped {
+		data["truncated"] = results.truncated
+		data["timed_out"] = results.timedOut
+	}
+	if results.content != "" {
+		data["content"] = results.content
+	}
+	return data, results.meta, nil
+}
+
+// searchResults is one page of a search. Truncated and TimedOut only apply
+// when the matches were collected in memory up to a cap, by walking the tree
+// or ranking fuzzy matches.
+type searchResults struct {
+	items     []model.FileItem
+	meta      model.Meta
+	content   string
+	capped    bool
+	truncated bool
+	timedOut  bool
+}
+
+func (s *SearchService) page(ctx context.Context, request model.SearchQuery) (searchResults, error) {
+	plan, err := s.plan(ctx, request)
+	if err != nil {
+		return searchResults{}, err
+	}
+
+	filter := plan.filter
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 200 {
+		filter.Limit = 200
+	}
+
+	scope := plan.cursorScope("page")
+	filter.After, err = decodeCursor(request.Cursor, scope)
+	if err != nil {
+		return searchResults{}, err
+	}
+	offset := (filter.Page - 1) * filter.Limit
+	if filter.After != nil {
+		offset = filter.After.Offset
+	}
+
+	results := searchResults{content: filter.Content, capped: !plan.indexed || filter.Fuzzy}
+
+	var entries []model.IndexEntry
+	var total int
+	var more bool
+	if filter.Fuzzy {
+		var ranked fuzzyRanking
+		ranked, err = s.fuzzyRank(ctx, plan, s.maxResults)
+		total = len(ranked.entries)
+		start := min(offset, total)
+		end := min(start+filter.Limit, total)
+		entries, more = ranked.entries[start:end], end < total
+		results.truncated, results.timedOut = ranked.truncated, ranked.timedOut
+	} else if plan.indexed {
+		total, err = s.index.CountMatches(ctx, filter)
+		if err == nil {
+			entries, more, err = s.index.Search(ctx, filter)
+		}
+	} else {
+		var result walkPage
+		result, err = s.searchWalk(ctx, plan.resolved, filter)
+		entries, total, more = result.entries, result.total, result.more
+		results.truncated, results.timedOut = result.truncated, result.timedOut
+	}
+	if err != nil {
+		return searchResults{}, err
+	}
+
+	results.items = make([]model.FileItem, 0, len(entries))
+	for _, entry := range entries {
+		results.items = append(results.items, searchResultItem(entry))
+	}
+	if err := s.tags.Annotate(ctx, results.items); err != nil {
+		return searchResults{}, err
+	}
+
+	totalPages := 0
+	if total > 0 {
+		totalPages = (total + filter.Limit - 1) / filter.Limit
+	}
+	results.meta = model.Meta{Page: filter.Page, Limit: filter.Limit, Total: total, TotalPages: totalPages}
+	if filter.After != nil {
+		results.meta.Page = 0
+	}
+	if more && len(entries) > 0 {
+		results.meta.NextCursor = encodeCursor(scope, searchCursor(filter.Sort, entries[len(entries)-1], offset+len(entries)))
+	}
+	return results, nil
+}
+
+// Stream sends the matches of a search to emit as they are found, up to
+// request.Limit or maxResults, and returns a summary of the run. Index
+// searches stream in the requested order; until the index is ready the tree
+// is walked and matches arrive in directory order. Fuzzy searches stream
+// their ranked matches.
+func (s *SearchService) Stream(ctx context.Context, request model.SearchQuery, emit func(model.FileItem) error) (model.SearchSummary, error) {
+	plan, err := s.plan(ctx, request)
+	if err != nil {
+		return model.SearchSummary{}, err
+	}
+
+	filter := plan.filter
+	limit := filter.Limit
+	if limit <= 0 || limit > s.maxResults {
+		limit = s.maxResults
+	}
+
+	scope := plan.cursorScope("stream")
+	after, err := decodeCursor(request.Cursor, scope)
+	if err != nil {
+		return model.SearchSummary{}, err
+	}
+
+	if filter.Fuzzy {
+		return s.streamFuzzy(ctx, plan, after, limit, scope, emit)
+	}
+	if plan.indexed {
+		return s.streamIndex(ctx, filter, after, limit, scope, emit)
+	}
+	return s.streamWalk(ctx, plan.resolved, filter, after, limit, scope, emit)
+}
+
+func (s *SearchService) streamIndex(ctx context.Context, filter model.SearchFilter, after *model.Cursor, limit int, scope string, emit func(model.FileItem) error) (model.SearchSummary, error) {
+	summary := model.SearchSummary{}
+	offset := 0
+	if after != nil {
+		offset = after.Offset
+	}
+
+	filter.Page, filter.After = 1, after
+	for summary.Count < limit {
+		filter.Limit = min(limit-summary.Count, 200)
+		entries, more, err := s.index.Search(ctx, filter)
+		if err != nil {
+			return summary, err
+		}
+		items := make([]model.FileItem, 0, len(entries))
+		for _, entry := range entries {
+			items = append(items, searchResultItem(entry))
+		}
+		if err := s.tags.Annotate(ctx, items); err != nil {
+			return summary, err
+		}
+		for _, item := range items {
+			if err := emit(item); err != nil {
+				return summary, err
+			}
+			summary.Count++
+		}
+		offset += len(entries)
+		if !more || len(entries) == 0 {
+			return summary, nil
+		}
+
+		position := searchCursor(filter.Sort, entries[len(entries)-1], offset)
+		filter.After = &position
+		if summary.Count >= limit {
+			summary.Truncated = true
+			summary.NextCursor = encodeCursor(scope, position)
+		}
+	}
+	return summary, nil
+}
+
+func (s *SearchService) streamWalk(ctx context.Context, resolvedStart string, filter model.SearchFilter, after *model.Cursor, limit int, scope string, emit func(model.FileItem) error) (model.SearchSummary, error) {
+	summary := model.SearchSummary{}
+	lastPath := ""
+	if after != nil {
+		lastPath = after.Path
+	}
+
+	var emitErr error
+	timedOut, err := s.walk(ctx, resolvedStart, filter, lastPath, func(entry model.IndexEntry) bool {
+		if summary.Count >= limit {
+			summary.Truncated = true
+			return false
+		}
+		item := []model.FileItem{searchResultItem(entry)}
+		if emitErr = s.tags.Annotate(ctx, item); emitErr != nil {
+			return false
+		}
+		if emitErr = emit(item[0]); emitErr != nil {
+			return false
+		}
+		summary.Count++
+		lastPath = entry.Path
+		return true
+	})
+	if emitErr != nil {
+		return summary, emitErr
+	}
+	if err != nil {
+		return summary, err
+	}
+
+	summary.TimedOut = timedOut
+	if (summary.Truncated || summary.TimedOut) && lastPath != "" {
+		summary.NextCursor = encodeCursor(scope, model.Cursor{Path: lastPath})
+	}
+	return summary, nil
+}
+
+// streamFuzzy ranks every fuzzy match before sending them best first, so
+// its cursors carry an offset into the ranking.
+func (s *SearchService) streamFuzzy(ctx context.Context, plan searchPlan, after *model.Cursor, limit int, scope string, emit func(model.FileItem) error) (model.SearchSummary, error) {
+	ranked, err := s.fuzzyRank(ctx, plan, s.maxResults)
+	if err != nil {
+		return model.SearchSummary{}, err
+	}
+
+	summary := model.SearchSummary{TimedOut: ranked.timedOut}
+	offset := 0
+	if after != nil {
+		offset = min(after.Offset, len(ranked.entries))
+	}
+	entries := ranked.entries[offset:]
+	if len(entries) > limit {
+		entries = entries[:limit]
+		summary.Truncated = true
+	}
+	items := make([]model.FileItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, searchResultItem(entry))
+	}
+	if err := s.tags.Annotate(ctx, items); err != nil {
+		return summary, err
+	}
+	for _, item := range items {
+		if err := emit(item); err != nil {
+			return summary, err
+		}
+		summary.Count++
+	}
+	if summary.Count > 0 && (summary.Truncated || summary.TimedOut) {
+		summary.NextCursor = encodeCursor(scope, searchCursor(plan.filter.Sort, ranked.entries[offset+summary.Count-1], offset+summary.Count))
+	}
+	summary.Truncated = summary.Truncated || ranked.truncated
+	return summary, nil
+}
+
+// Quick answers as-you-type lookups with the best few fuzzy matches of
+// request.Query. It scores fewer candidates than a search and does not count
+// the matches beyond the ones returned.
+func (s *SearchService) Quick(ctx context.Context, request model.SearchQuery) (map[string]any, error) {
+	if strings.TrimSpace(request.Query) == "" {
+		return nil, apierror.New("BAD_REQUEST", "q is required", "q", http.StatusBadRequest)
+	}
+	limit := request.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	request.Mode = "fuzzy"
+	request.Page, request.Limit, request.Cursor = 0, 0, ""
+	plan, err := s.plan(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked, err := s.fuzzyRank(ctx, plan, quickOpenCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := ranked.entries[:min(limit, len(ranked.entries))]
+	items := make([]model.FileItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, searchResultItem(entry))
+	}
+	if err := s.tags.Annotate(ctx, items); err != nil {
+		return nil, err
+	}
+	return map[string]any{"query": strings.TrimSpace(request.Query), "items": items}, nil
+}
+
+// quickOpenCandidates caps the candidates a quick open lookup scores.
+const quickOpenCandidates = 200
+
+// fuzzyRanking is every fuzzy match of a search in result order. Truncated
+// means the candidates hit their cap and TimedOut that the walk hit
+// SEARCH_TIMEOUT, so matches may be missing.
+type fuzzyRanking struct {
+	entries   []model.IndexEntry
+	truncated bool
+	timedOut  bool
+}
+
+// fuzzyRank scores up to candidates entries that may match the fuzzy terms
+// of plan and sorts the matches. The index preselects candidates by
+// similarity; a walk scores entries as it finds them.
+func (s *SearchService) fuzzyRank(ctx context.Context, plan searchPlan, candidates int) (fuzzyRanking, error) {
+	filter := plan.filter
+	query := newFuzzyQuery(filter.Terms)
+	now := searchNow()
+
+	ranking := fuzzyRanking{entries: make([]model.IndexEntry, 0)}
+	if plan.indexed {
+		found, err := s.index.FuzzyCandidates(ctx, filter, candidates)
+		if err != nil {
+			return fuzzyRanking{}, err
+		}
+		ranking.truncated = len(found) >= candidates
+		for _, entry := range found {
+			if match, ok := query.rank(entry, now); ok {
+				ranking.entries = append(ranking.entries, match)
+			}
+		}
+	} else {
+		filter.Terms = nil
+		timedOut, err := s.walk(ctx, plan.resolved, filter, "", func(entry model.IndexEntry) bool {
+			if len(ranking.entries) >= candidates {
+				ranking.truncated = true
+				return false
+			}
+			if match, ok := query.rank(entry, now); ok {
+				ranking.entries = append(ranking.entries, match)
+			}
+			return true
+		})
+		if err != nil {
+			return fuzzyRanking{}, err
+		}
+		ranking.timedOut = timedOut
+	}
+
+	sort.Slice(ranking.entries, func(i int, j int) bool {
+		return lessSearchEntry(plan.filter.Sort, ranking.entries[i], ranking.entries[j])
+	})
+	return ranking, nil
+}
+
+// walkPage is one page of a search answered by walking the tree. Truncated
+// means the walk stopped at maxResults matches and TimedOut that it hit
+// SEARCH_TIMEOUT, so total counts only the matches found.
+type walkPage struct {
+	entries   []model.IndexEntry
+	total     int
+	more      bool
+	truncated bool
+	timedOut  bool
+}
+
+// searchWalk answers a paged search by walking the tree. Matches are sorted
+// in memory, so a walk keeps at most maxResults of them.
+func (s *SearchService) searchWalk(ctx context.Context, resolvedStart string, filter model.SearchFilter) (walkPage, error) {
+	page := walkPage{}
+	matches := make([]model.IndexEntry, 0)
+	timedOut, err := s.walk(ctx, resolvedStart, filter, "", func(entry model.IndexEntry) bool {
+		if len(matches) >= s.maxResults {
+			page.truncated = true
+			return false
+		}
+		matches = append(matches, entry)
+		return true
+	})
+	if err != nil {
+		return walkPage{}, err
+	}
+	page.timedOut = timedOut
+
+	sort.Slice(matches, func(i int, j int) bool {
+		return lessSearchEntry(filter.Sort, matches[i], matches[j])
+	})
+	page.total = len(matches)
+
+	start := (filter.Page - 1) * filter.Limit
+	if filter.After != nil {
+		position := model.IndexEntry{Path: filter.After.Path, Name: filter.After.Name, Type: filter.After.Type, Size: filter.After.Size, ModifiedAt: filter.After.Time, CreatedAt: filter.After.Time}
+		start = sort.Search(len(matches), func(i int) bool {
+			return lessSearchEntry(filter.Sort, position, matches[i])
+		})
+	}
+	start = min(start, page.total)
+	end := min(start+filter.Limit, page.total)
+	page.entries = matches[start:end]
+	page.more = end < page.total
+	return page, nil
+}
+
+// walk visits the entries below resolvedStart that match filter in directory
+// order, within SEARCH_MAX_DEPTH and SEARCH_TIMEOUT, until visit returns
+// false. Entries up to and including the API path after are skipped, along
+// with the directories that end before it. walk reports whether it timed
+// out.
+func (s *SearchService) walk(ctx context.Context, resolvedStart string, filter model.SearchFilter, after string, visit func(model.IndexEntry) bool) (bool, error) {
+	matcher, err := newSearchMatcher(filter)
+	if err != nil {
+		return false, err
+	}
+
 	searchCtx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	queryLower := strings.ToLower(query)
-	items := make([]model.FileItem, 0)
 	depthRoot := resolvedStart
 
 	walkErr := filepath.WalkDir(resolvedStart, func(path string, entry fs.DirEntry, walkErr error) error {
@@ -126,23 +579,15 @@ func (s *SearchService) Search(ctx context.Context, query string, startPath stri
 			return nil
 		}
 
-		if queryLower != "" {
-			nameLower := strings.ToLower(entry.Name())
-			if !strings.Contains(nameLower, queryLower) {
-				return nil
-			}
-		}
-
-		isDir := entry.IsDir()
-		if normalizedType == "file" && isDir {
-			return nil
-		}
-		if normalizedType == "dir" && !isDir {
+		relToRoot, relErr := filepath.Rel(s.store.RootAbs(), path)
+		if relErr != nil {
 			return nil
 		}
-
-		ext := strings.ToLower(filepath.Ext(entry.Name()))
-		if normalizedExt != "" && normalizedExt != ext {
+		apiPath := normalizeAPIPath(filepath.ToSlash(relToRoot))
+		if after != "" && compareWalkOrder(apiPath, after) <= 0 {
+			if entry.IsDir() && apiPath != after && !strings.HasPrefix(after, apiPath+"/") {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -151,72 +596,84 @@ func (s *SearchService) Search(ctx context.Context, query string, startPath stri
 			return nil
 		}
 
-		relToRoot, relErr := filepath.Rel(s.store.RootAbs(), path)
-		if relErr != nil {
+		candidate := indexEntry(apiPath, info)
+		candidate.CreatedAt = candidate.ModifiedAt
+		if !matcher.matches(candidate) {
 			return nil
 		}
-		apiPath := normalizeAPIPath(filepath.ToSlash(relToRoot))
-		item := model.FileItem{
-			Name:         entry.Name(),
-			Path:         apiPath,
-			Type:         map[bool]string{true: "directory", false: "file"}[isDir],
-			Size:         info.Size(),
-			Extension:    ext,
-			ModifiedAt:   info.ModTime().UTC(),
-			CreatedAt:    info.ModTime().UTC(),
-			Permissions:  info.Mode().String(),
-			MatchContext: entry.Name(),
-		}
-		if isDir {
-			item.Size = 0
-			item.Extension = ""
-		} else if util.IsImageExtension(ext) {
-			item.IsImage = true
-			item.PreviewURL = "/api/v1/files/preview?path=" + url.QueryEscape(apiPath)
-			if util.IsThumbnailExtension(ext) {
-				item.ThumbnailURL = "/api/v1/files/thumbnail?path=" + url.QueryEscape(apiPath) + "&size=256"
-			}
-		} else if util.IsVideoExtension(ext) {
-			item.IsVideo = true
-			item.PreviewURL = "/api/v1/files/preview?path=" + url.QueryEscape(apiPath)
-		}
 
-		items = append(items, item)
-		if len(items) >= s.maxResults {
-			return errors.New("max results reached")
+		if !visit(candidate) {
+			return errStopWalk
 		}
-
 		return nil
 	})
 
-	if walkErr != nil && !errors.Is(walkErr, context.DeadlineExceeded) && !errors.Is(walkErr, context.Canceled) && walkErr.Error() != "max results reached" {
-		return nil, model.Meta{}, walkErr
+	if errors.Is(walkErr, context.DeadlineExceeded) && ctx.Err() == nil {
+		return true, nil
 	}
+	if walkErr != nil && !errors.Is(walkErr, errStopWalk) {
+		return false, walkErr
+	}
+	return false, nil
+}
 
-	sort.Slice(items, func(i int, j int) bool {
-		return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name)
-	})
+var errStopWalk = errors.New("stop walk")
 
-	total := len(items)
-	start := (page - 1) * limit
-	if start > total {
-		start = total
-	}
-	end := start + limit
-	if end > total {
-		end = total
+// compareWalkOrder orders API paths the way filepath.WalkDir visits them: a
+// directory before its contents, siblings by name.
+func compareWalkOrder(a string, b string) int {
+	aParts := strings.Split(strings.Trim(a, "/"), "/")
+	bParts := strings.Split(strings.Trim(b, "/"), "/")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if compare := strings.Compare(aParts[i], bParts[i]); compare != 0 {
+			return compare
+		}
 	}
+	return cmp.Compare(len(aParts), len(bParts))
+}
 
-	totalPages := 0
-	if total > 0 {
-		totalPages = (total + limit - 1) / limit
+// searchCursor is the position after entry in a search sorted by sort;
+// offset is the number of results up to and including entry.
+func searchCursor(sort string, entry model.IndexEntry, offset int) model.Cursor {
+	position := model.Cursor{Path: entry.Path, Name: entry.Name, Type: entry.Type, Size: entry.Size, Time: entry.ModifiedAt, Offset: offset}
+	if strings.TrimPrefix(sort, "-") == "created" {
+		position.Time = entry.CreatedAt
 	}
+	return position
+}
 
-	meta := model.Meta{Page: page, Limit: limit, Total: total, TotalPages: totalPages}
-	data := map[string]any{
-		"query": query,
-		"items": items[start:end],
+// searchResultItem converts an index entry into a search result. Content
+// matches carry an HTML snippet with the matched terms in <mark> tags as
+// match context, and fuzzy matches their highlighted name and score.
+func searchResultItem(entry model.IndexEntry) model.FileItem {
+	item := model.FileItem{
+		Name:         entry.Name,
+		Path:         entry.Path,
+		Type:         entry.Type,
+		Size:         entry.Size,
+		MimeType:     entry.MimeType,
+		Extension:    entry.Extension,
+		ModifiedAt:   entry.ModifiedAt.UTC(),
+		CreatedAt:    entry.CreatedAt.UTC(),
+		Permissions:  entry.Permissions,
+		MatchContext: entry.Name,
+		Score:        entry.Score,
 	}
-
-	return data, meta, nil
+	if entry.Snippet != "" {
+		item.MatchContext = entry.Snippet
+	}
+	if entry.Type == "directory" {
+		item.Size = 0
+		item.Extension = ""
+	} else if util.IsImageExtension(entry.Extension) {
+		item.IsImage = true
+		item.PreviewURL = "/api/v1/files/preview?path=" + url.QueryEscape(entry.Path)
+		if util.IsThumbnailExtension(entry.Extension) {
+			item.ThumbnailURL = "/api/v1/files/thumbnail?path=" + url.QueryEscape(entry.Path) + "&size=256"
+		}
+	} else if util.IsVideoExtension(entry.Extension) {
+		item.IsVideo = true
+		item.PreviewURL = "/api/v1/files/preview?path=" + url.QueryEscape(entry.Path)
+	}
+	return item
 }
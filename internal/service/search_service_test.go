@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/storage"
+)
+
+func newSearchTestStore(t *testing.T, paths ...string) storage.Storage {
+	t.Helper()
+
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+	for _, filePath := range paths {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+	return store
+}
+
+func TestSearchStreamWalkResumesFromCursor(t *testing.T) {
+	store := newSearchTestStore(t, "/a/report-1.txt", "/a/b/report-2.txt", "/a-report-3.txt", "/c/report-4.txt", "/c/notes.txt")
+	svc := NewSearchService(store, 10, time.Minute)
+
+	stream := func(cursor string) ([]string, model.SearchSummary) {
+		paths := make([]string, 0)
+		summary, err := svc.Stream(context.Background(), model.SearchQuery{Query: "report", Limit: 2, Cursor: cursor}, func(item model.FileItem) error {
+			paths = append(paths, item.Path)
+			return nil
+		})
+		require.NoError(t, err)
+		return paths, summary
+	}
+
+	paths, summary := stream("")
+	require.Equal(t, []string{"/a/b/report-2.txt", "/a/report-1.txt"}, paths)
+	require.Equal(t, 2, summary.Count)
+	require.True(t, summary.Truncated)
+	require.False(t, summary.TimedOut)
+	require.NotEmpty(t, summary.NextCursor)
+
+	paths, summary = stream(summary.NextCursor)
+	require.Equal(t, []string{"/a-report-3.txt", "/c/report-4.txt"}, paths)
+	require.False(t, summary.Truncated)
+	require.Empty(t, summary.NextCursor)
+}
+
+func TestSearchWalkCursorPages(t *testing.T) {
+	store := newSearchTestStore(t, "/docs/d.txt", "/docs/b.txt", "/c.txt", "/a.txt", "/e.txt")
+	svc := NewSearchService(store, 10, time.Minute)
+
+	search := func(cursor string) ([]string, model.Meta, map[string]any) {
+		data, meta, err := svc.Search(context.Background(), model.SearchQuery{Extension: "txt", Limit: 2, Cursor: cursor})
+		require.NoError(t, err)
+		paths := make([]string, 0)
+		for _, item := range data["items"].([]model.FileItem) {
+			paths = append(paths, item.Path)
+		}
+		return paths, meta, data
+	}
+
+	paths, meta, data := search("")
+	require.Equal(t, []string{"/a.txt", "/docs/b.txt"}, paths)
+	require.Equal(t, 5, meta.Total)
+	require.Equal(t, false, data["truncated"])
+
+	paths, meta, _ = search(meta.NextCursor)
+	require.Equal(t, []string{"/c.txt", "/docs/d.txt"}, paths)
+	require.Equal(t, 0, meta.Page)
+
+	paths, meta, _ = search(meta.NextCursor)
+	require.Equal(t, []string{"/e.txt"}, paths)
+	require.Empty(t, meta.NextCursor)
+
+	_, _, err := svc.Search(context.Background(), model.SearchQuery{Extension: "pdf", Cursor: encodeCursor("other", model.Cursor{})})
+	require.ErrorContains(t, err, "cursor does not belong to this query")
+}
+
+func TestCompareWalkOrder(t *testing.T) {
+	require.Negative(t, compareWalkOrder("/a", "/a/b"))
+	require.Negative(t, compareWalkOrder("/a/z", "/a-b"))
+	require.Negative(t, compareWalkOrder("/B", "/a"))
+	require.Positive(t, compareWalkOrder("/b", "/a/z/y"))
+	require.Zero(t, compareWalkOrder("/a/b", "/a/b"))
+}
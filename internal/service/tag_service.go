@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-file-explorer/internal/event"
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/repository"
+	"go-file-explorer/internal/storage"
+	"go-file-explorer/pkg/apierror"
+)
+
+const (
+	maxTagsPerRequest = 50
+	maxBulkTagPaths   = 1000
+)
+
+// tagPattern is a valid tag: letters, digits, spaces, dots, dashes and
+// underscores, starting with a letter or digit.
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} ._-]{0,63}$`)
+
+// TagService labels files and folders. Tags are keyed by path: they follow
+// entries that OperationsService renames or moves, are parked in the trash
+// with them and are removed when the trash is purged. Those updates run
+// after the files changed, so they ignore cancellation and only log
+// failures. A nil TagService ignores them.
+type TagService struct {
+	repo  *repository.TagRepository
+	store storage.Storage
+	audit *AuditService
+	bus   event.Bus
+}
+
+func NewTagService(repo *repository.TagRepository, store storage.Storage, audit *AuditService, bus event.Bus) *TagService {
+	return &TagService{repo: repo, store: store, audit: audit, bus: bus}
+}
+
+// Get returns the tags of apiPath.
+func (s *TagService) Get(ctx context.Context, apiPath string) (model.FileTags, error) {
+	apiPath, err := s.taggablePath(apiPath)
+	if err != nil {
+		return model.FileTags{}, err
+	}
+	return s.current(ctx, apiPath)
+}
+
+func (s *TagService) Add(ctx context.Context, request model.TagRequest, actor model.AuditActor) (model.FileTags, error) {
+	return s.change(ctx, request.Path, request.Tags, nil, actor)
+}
+
+func (s *TagService) Remove(ctx context.Context, request model.TagRequest, actor model.AuditActor) (model.FileTags, error) {
+	return s.change(ctx, request.Path, nil, request.Tags, actor)
+}
+
+// Bulk adds and removes tags on every path of a selection. Paths that cannot
+// be tagged are reported as failed; the others are still updated.
+func (s *TagService) Bulk(ctx context.Context, request model.BulkTagRequest, actor model.AuditActor) (model.BulkTagResponse, error) {
+	if len(request.Paths) == 0 {
+		return model.BulkTagResponse{}, apierror.New("BAD_REQUEST", "paths are required", "paths", http.StatusBadRequest)
+	}
+	if len(request.Paths) > maxBulkTagPaths {
+		return model.BulkTagResponse{}, apierror.New("BAD_REQUEST", "too many paths", "paths", http.StatusBadRequest)
+	}
+	add, remove, err := normalizeTagChange(request.Add, request.Remove)
+	if err != nil {
+		return model.BulkTagResponse{}, err
+	}
+
+	result := model.BulkTagResponse{Updated: []model.FileTags{}, Failed: []model.TagFailure{}}
+	for _, apiPath := range request.Paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		tags, err := s.apply(ctx, apiPath, add, remove, actor)
+		if err != nil {
+			result.Failed = append(result.Failed, model.TagFailure{Path: normalizeAPIPath(apiPath), Reason: err.Error()})
+			continue
+		}
+		result.Updated = append(result.Updated, tags)
+	}
+	return result, nil
+}
+
+// List returns every tag in use with how many entries carry it.
+func (s *TagService) List(ctx context.Context) (model.TagListData, error) {
+	counts, err := s.repo.Counts(ctx)
+	if err != nil {
+		return model.TagListData{}, err
+	}
+	return model.TagListData{Items: counts}, nil
+}
+
+// Annotate sets the tags of items.
+func (s *TagService) Annotate(ctx context.Context, items []model.FileItem) error {
+	if s == nil || len(items) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(items))
+	for _, item := range items {
+		paths = append(paths, item.Path)
+	}
+	tags, err := s.repo.ForPaths(ctx, paths)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Tags = tags[items[i].Path]
+	}
+	return nil
+}
+
+// Tagged returns the paths among apiPaths that carry tag.
+func (s *TagService) Tagged(ctx context.Context, apiPaths []string, tag string) (map[string]bool, error) {
+	tags, err := s.repo.ForPaths(ctx, apiPaths)
+	if err != nil {
+		return nil, err
+	}
+	tagged := make(map[string]bool)
+	for apiPath, pathTags := range tags {
+		if slices.Contains(pathTags, tag) {
+			tagged[apiPath] = true
+		}
+	}
+	return tagged, nil
+}
+
+func (s *TagService) change(ctx context.Context, apiPath string, add []string, remove []string, actor model.AuditActor) (model.FileTags, error) {
+	add, remove, err := normalizeTagChange(add, remove)
+	if err != nil {
+		return model.FileTags{}, err
+	}
+	return s.apply(ctx, apiPath, add, remove, actor)
+}
+
+func (s *TagService) apply(ctx context.Context, apiPath string, add []string, remove []string, actor model.AuditActor) (model.FileTags, error) {
+	apiPath, err := s.taggablePath(apiPath)
+	if err != nil {
+		return model.FileTags{}, err
+	}
+
+	before, err := s.current(ctx, apiPath)
+	if err != nil {
+		return model.FileTags{}, err
+	}
+	if len(add) > 0 {
+		if err := s.repo.Add(ctx, apiPath, add, actor.Username); err != nil {
+			s.audit.Log("tag", actor, "failed", apiPath, map[string]any{"tags": before.Tags}, nil, err.Error())
+			return model.FileTags{}, err
+		}
+	}
+	if len(remove) > 0 {
+		if err := s.repo.Remove(ctx, apiPath, remove); err != nil {
+			s.audit.Log("tag", actor, "failed", apiPath, map[string]any{"tags": before.Tags}, nil, err.Error())
+			return model.FileTags{}, err
+		}
+	}
+
+	after, err := s.current(ctx, apiPath)
+	if err != nil {
+		return model.FileTags{}, err
+	}
+	if slices.Equal(before.Tags, after.Tags) {
+		return after, nil
+	}
+
+	s.audit.Log("tag", actor, "success", apiPath, map[string]any{"tags": before.Tags}, map[string]any{"tags": after.Tags}, "")
+	if s.bus != nil {
+		s.bus.Publish(event.Event{
+			ID:        uuid.NewString(),
+			Type:      event.TypeFileTagged,
+			Payload:   after,
+			Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+			ActorID:   actor.Username,
+		})
+	}
+	return after, nil
+}
+
+func (s *TagService) current(ctx context.Context, apiPath string) (model.FileTags, error) {
+	tags, err := s.repo.ForPaths(ctx, []string{apiPath})
+	if err != nil {
+		return model.FileTags{}, err
+	}
+	current := model.FileTags{Path: apiPath, Tags: tags[apiPath]}
+	if current.Tags == nil {
+		current.Tags = []string{}
+	}
+	return current, nil
+}
+
+// taggablePath normalizes apiPath and checks that it names an existing file
+// or folder other than the root.
+func (s *TagService) taggablePath(apiPath string) (string, error) {
+	return existingEntryPath(s.store, apiPath, "root path cannot be tagged")
+}
+
+// existingEntryPath normalizes apiPath and checks that it names an existing
+// file or folder other than the root, which is rejected with rootMessage.
+func existingEntryPath(store storage.Storage, apiPath string, rootMessage string) (string, error) {
+	if strings.TrimSpace(apiPath) == "" {
+		return "", apierror.New("BAD_REQUEST", "path is required", "path", http.StatusBadRequest)
+	}
+	apiPath = normalizeAPIPath(apiPath)
+	if apiPath == "/" {
+		return "", apierror.New("BAD_REQUEST", rootMessage, "path", http.StatusBadRequest)
+	}
+	if isInternalStoragePath(apiPath) {
+		return "", model.ErrFileNotFound
+	}
+
+	resolved, err := store.Resolve(apiPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(resolved); err != nil {
+		if os.IsNotExist(err) {
+			return "", model.ErrFileNotFound
+		}
+		return "", err
+	}
+	return apiPath, nil
+}
+
+// moved makes the tags of from and everything below it follow the entry to
+// to.
+func (s *TagService) moved(ctx context.Context, from string, to string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.MoveTree(context.WithoutCancel(ctx), normalizeAPIPath(from), normalizeAPIPath(to)); err != nil {
+		slog.Warn("failed to move file tags", "from", from, "to", to, "error", err)
+	}
+}
+
+// trashed parks the tags of apiPath and everything below it with the trash
+// record trashID.
+func (s *TagService) trashed(ctx context.Context, apiPath string, trashID string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.Trash(context.WithoutCancel(ctx), normalizeAPIPath(apiPath), trashID); err != nil {
+		slog.Warn("failed to trash file tags", "path", apiPath, "trash_id", trashID, "error", err)
+	}
+}
+
+// restored brings back the tags parked with the trash record trashID.
+func (s *TagService) restored(ctx context.Context, trashID string) {
+	if s == nil {
+		return
+	}
+	if err := s.repo.Restore(context.WithoutCancel(ctx), trashID); err != nil {
+		slog.Warn("failed to restore file tags", "trash_id", trashID, "error", err)
+	}
+}
+
+// purged removes the tags parked with the trash record trashID, or with
+// every trash record when trashID is empty.
+func (s *TagService) purged(ctx context.Context, trashID string) {
+	if s == nil {
+		return
+	}
+	var err error
+	if trashID == "" {
+		err = s.repo.PurgeTrashed(context.WithoutCancel(ctx))
+	} else {
+		err = s.repo.Purge(context.WithoutCancel(ctx), trashID)
+	}
+	if err != nil {
+		slog.Warn("failed to purge file tags", "trash_id", trashID, "error", err)
+	}
+}
+
+// normalizeTagChange validates the tags to add and remove.
+func normalizeTagChange(add []string, remove []string) ([]string, []string, error) {
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, nil, apierror.New("BAD_REQUEST", "tags are required", "tags", http.StatusBadRequest)
+	}
+	if len(add)+len(remove) > maxTagsPerRequest {
+		return nil, nil, apierror.New("BAD_REQUEST", "too many tags", "tags", http.StatusBadRequest)
+	}
+
+	normalizedAdd, err := normalizeTags(add)
+	if err != nil {
+		return nil, nil, err
+	}
+	normalizedRemove, err := normalizeTags(remove)
+	if err != nil {
+		return nil, nil, err
+	}
+	return normalizedAdd, normalizedRemove, nil
+}
+
+// normalizeTags lower-cases tags, collapses runs of spaces and drops
+// duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if !tagPattern.MatchString(tag) {
+			return nil, apierror.New("BAD_REQUEST", "tags must be 1 to 64 letters, digits, spaces, dots, dashes or underscores", tag, http.StatusBadRequest)
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
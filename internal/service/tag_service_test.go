@@ -0,0 +1,32 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{" Urgent ", "q1   review", "URGENT", "año-2026", "v1.2_final"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"urgent", "q1 review", "año-2026", "v1.2_final"}, tags)
+
+	for _, bad := range []string{"", "   ", "-draft", "a/b", "#urgent", strings.Repeat("x", 65)} {
+		_, err := normalizeTags([]string{bad})
+		require.Error(t, err, bad)
+	}
+}
+
+func TestNormalizeTagChange(t *testing.T) {
+	add, remove, err := normalizeTagChange([]string{"Draft"}, []string{"Final", "final"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"draft"}, add)
+	require.Equal(t, []string{"final"}, remove)
+
+	_, _, err = normalizeTagChange(nil, nil)
+	require.ErrorContains(t, err, "tags are required")
+
+	_, _, err = normalizeTagChange(make([]string, maxTagsPerRequest+1), nil)
+	require.ErrorContains(t, err, "too many tags")
+}
@@ -0,0 +1,555 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-file-explorer/internal/event"
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/storage"
+	"go-file-explorer/internal/util"
+)
+
+const (
+	defaultThumbnailWorkers       = 2
+	defaultThumbnailSize          = 256
+	defaultThumbnailSweepInterval = 24 * time.Hour
+	thumbnailQueueSize            = 1024
+	// thumbnailTouchInterval limits how often a cache hit rewrites the
+	// modification time that persists the LRU order across restarts.
+	thumbnailTouchInterval = 10 * time.Minute
+	// thumbnailEvictTarget is the share of MaxBytes eviction frees down to,
+	// so it does not run again for every new thumbnail.
+	thumbnailEvictTarget = 0.9
+	// thumbnailStrayAge is how old a file in the thumbnail root that is not a
+	// thumbnail must be before the sweep removes it; younger ones may still
+	// be written.
+	thumbnailStrayAge = time.Hour
+)
+
+// ThumbnailOptions configures the thumbnail cache. MaxBytes caps the size of
+// the cache, 0 meaning unlimited. Workers bounds how many thumbnails are
+// pre-generated at once, Sizes lists the sizes that are pre-generated and
+// SweepInterval is how often thumbnails of files that no longer exist are
+// removed.
+type ThumbnailOptions struct {
+	MaxBytes      int64
+	Workers       int
+	Sizes         []int
+	SweepInterval time.Duration
+}
+
+type thumbnailEntry struct {
+	name   string
+	key    string
+	size   int64
+	usedAt time.Time
+}
+
+// ThumbnailService manages THUMBNAIL_ROOT. It keeps the cache under its size
+// cap by evicting the least recently used thumbnails, renames or removes the
+// thumbnails of moved and deleted files, sweeps orphans left behind by
+// changes made outside the API, and pre-generates thumbnails of uploaded and
+// copied images so a gallery does not wait for them on first view.
+//
+// A thumbnail's modification time records its last use, so the LRU order
+// survives restarts; the cache is rebuilt from the directory at start.
+type ThumbnailService struct {
+	files *FileService
+	store storage.Storage
+	bus   event.Bus
+	root  string
+	opts  ThumbnailOptions
+	queue chan string
+	// slots bounds the thumbnails generated at once by event workers and
+	// thumbnails jobs together.
+	slots chan struct{}
+
+	mu      sync.Mutex
+	lru     *list.List // of *thumbnailEntry, most recently used first
+	entries map[string]*list.Element
+	sources map[string]map[string]struct{}
+	total   int64
+}
+
+func NewThumbnailService(files *FileService, store storage.Storage, bus event.Bus, opts ThumbnailOptions) (*ThumbnailService, error) {
+	if opts.MaxBytes < 0 {
+		opts.MaxBytes = 0
+	}
+	if opts.Workers < 1 {
+		opts.Workers = defaultThumbnailWorkers
+	}
+	if len(opts.Sizes) == 0 {
+		opts.Sizes = []int{defaultThumbnailSize}
+	}
+	if opts.SweepInterval <= 0 {
+		opts.SweepInterval = defaultThumbnailSweepInterval
+	}
+
+	s := &ThumbnailService{
+		files:   files,
+		store:   store,
+		bus:     bus,
+		root:    files.thumbnailRoot,
+		opts:    opts,
+		queue:   make(chan string, thumbnailQueueSize),
+		slots:   make(chan struct{}, opts.Workers),
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+		sources: map[string]map[string]struct{}{},
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run pre-generates thumbnails for file events and sweeps orphans until ctx
+// is cancelled.
+func (s *ThumbnailService) Run(ctx context.Context) {
+	events, unsubscribe := s.bus.Subscribe()
+	defer unsubscribe()
+
+	for range s.opts.Workers {
+		go s.worker(ctx)
+	}
+	go s.sweepLoop(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			s.apply(e)
+		}
+	}
+}
+
+// Generate pre-generates the thumbnails of every image at and below apiPath
+// and returns how many images were processed and how many of them failed.
+func (s *ThumbnailService) Generate(ctx context.Context, apiPath string) (int, int, error) {
+	resolved, err := s.store.Resolve(apiPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	type candidate struct {
+		resolved string
+		info     os.FileInfo
+	}
+	images := make([]candidate, 0)
+	var total int64
+	walkErr := s.walkImages(ctx, resolved, func(current string, info os.FileInfo) {
+		images = append(images, candidate{resolved: current, info: info})
+		total += info.Size()
+	})
+	if walkErr != nil {
+		return 0, 0, walkErr
+	}
+
+	progress := util.ProgressFromContext(ctx)
+	progress.AddTotal(total)
+	failed := 0
+	for i, img := range images {
+		if err := ctx.Err(); err != nil {
+			return i, failed, err
+		}
+		progress.SetItem(toAPIPath(img.resolved, s.store.RootAbs()))
+		if err := s.pregenerate(ctx, img.resolved, img.info); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return i, failed, err
+			}
+			failed++
+		}
+		progress.Add(img.info.Size())
+	}
+	return len(images), failed, nil
+}
+
+// used moves a served thumbnail to the front of the LRU order.
+func (s *ThumbnailService) used(thumbPath string, info os.FileInfo) {
+	if s == nil {
+		return
+	}
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	element, ok := s.entries[filepath.Base(thumbPath)]
+	if !ok {
+		s.add(filepath.Base(thumbPath), info.Size(), info.ModTime())
+		return
+	}
+	s.lru.MoveToFront(element)
+	entry := element.Value.(*thumbnailEntry)
+	if now.Sub(entry.usedAt) >= thumbnailTouchInterval {
+		if err := os.Chtimes(thumbPath, now, now); err == nil {
+			entry.usedAt = now
+		}
+	}
+}
+
+// stored accounts a newly written thumbnail and evicts the least recently
+// used ones if the cache went over its cap.
+func (s *ThumbnailService) stored(thumbPath string, info os.FileInfo) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(filepath.Base(thumbPath), info.Size(), info.ModTime())
+	s.evict()
+}
+
+// removeSource removes every thumbnail of the file at resolved.
+func (s *ThumbnailService) removeSource(resolved string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.sources[thumbnailSourceKey(resolved)] {
+		if err := os.Remove(filepath.Join(s.root, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove thumbnail %q: %w", name, err)
+		}
+		s.remove(name)
+	}
+	return nil
+}
+
+// moved renames the thumbnails of the files now at or below to, which were
+// at or below from, so they stay valid instead of becoming orphans.
+func (s *ThumbnailService) moved(from, to string) {
+	resolvedFrom, err := s.store.Resolve(from)
+	if err != nil {
+		return
+	}
+	resolvedTo, err := s.store.Resolve(to)
+	if err != nil {
+		return
+	}
+
+	info, err := os.Stat(resolvedTo)
+	if err != nil {
+		return
+	}
+	if !info.IsDir() {
+		s.renameSource(resolvedFrom, resolvedTo)
+		return
+	}
+
+	_ = filepath.WalkDir(resolvedTo, func(current string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || d.IsDir() {
+			return nil
+		}
+		rel, relErr := filepath.Rel(resolvedTo, current)
+		if relErr == nil {
+			s.renameSource(filepath.Join(resolvedFrom, rel), current)
+		}
+		return nil
+	})
+}
+
+func (s *ThumbnailService) renameSource(from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fromKey, toKey := thumbnailSourceKey(from), thumbnailSourceKey(to)
+	for name := range s.sources[fromKey] {
+		renamed := toKey + strings.TrimPrefix(name, fromKey)
+		if err := os.Rename(filepath.Join(s.root, name), filepath.Join(s.root, renamed)); err != nil {
+			_ = os.Remove(filepath.Join(s.root, name))
+			s.remove(name)
+			continue
+		}
+
+		// The renamed thumbnail keeps its place in the LRU order.
+		element := s.entries[name]
+		entry := element.Value.(*thumbnailEntry)
+		s.remove(renamed)
+		delete(s.entries, name)
+		delete(s.sources[fromKey], name)
+		entry.name, entry.key = renamed, toKey
+		s.entries[renamed] = element
+		s.index(entry)
+	}
+	delete(s.sources, fromKey)
+}
+
+func (s *ThumbnailService) apply(e event.Event) {
+	switch payload := e.Payload.(type) {
+	case model.UploadItem:
+		if e.Type == event.TypeFileUploaded {
+			s.enqueue(payload.Path)
+		}
+	case model.MoveCopyResult:
+		switch e.Type {
+		case event.TypeFileCopied:
+			s.enqueue(payload.To)
+		case event.TypeFileMoved:
+			s.moved(payload.From, payload.To)
+		}
+	case model.RenameResponse:
+		s.moved(payload.OldPath, payload.NewPath)
+	}
+}
+
+// enqueue queues apiPath for pre-generation. When the queue is full the path
+// is dropped; its thumbnails are then generated on first view.
+func (s *ThumbnailService) enqueue(apiPath string) {
+	select {
+	case s.queue <- apiPath:
+	default:
+		slog.Debug("thumbnail queue full, skipping pre-generation", "path", apiPath)
+	}
+}
+
+func (s *ThumbnailService) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case apiPath := <-s.queue:
+			if _, failed, err := s.Generate(ctx, apiPath); err != nil && ctx.Err() == nil {
+				slog.Warn("thumbnail pre-generation failed", "path", apiPath, "error", err)
+			} else if failed > 0 {
+				slog.Debug("some thumbnails could not be pre-generated", "path", apiPath, "failed", failed)
+			}
+		}
+	}
+}
+
+// walkImages calls fn for every image at and below resolved that thumbnails
+// can be generated for, skipping the internal directories.
+func (s *ThumbnailService) walkImages(ctx context.Context, resolved string, fn func(string, os.FileInfo)) error {
+	return filepath.WalkDir(resolved, func(current string, d fs.DirEntry, walkErr error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if walkErr != nil {
+			if current == resolved {
+				return walkErr
+			}
+			return nil
+		}
+		if isInternalStoragePath(toAPIPath(current, s.store.RootAbs())) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !util.IsThumbnailExtension(filepath.Ext(current)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fn(current, info)
+		return nil
+	})
+}
+
+// pregenerate writes the missing or stale thumbnails of one image in the
+// pre-generated sizes, holding a generation slot for each.
+func (s *ThumbnailService) pregenerate(ctx context.Context, resolved string, info os.FileInfo) error {
+	for _, size := range s.opts.Sizes {
+		thumbPath := filepath.Join(s.root, thumbnailFileName(resolved, size, ThumbnailJPEG))
+		if _, ok := freshThumbnail(thumbPath, info); ok {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s.slots <- struct{}{}:
+		}
+		file, _, err := s.files.generateThumbnail(resolved, thumbPath, size, ThumbnailJPEG, info)
+		<-s.slots
+		if err != nil {
+			return err
+		}
+		_ = file.Close()
+	}
+	return nil
+}
+
+func (s *ThumbnailService) sweepLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sweep(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("thumbnail sweep failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep removes the thumbnails of files that no longer exist, which deletes
+// and moves made outside the API leave behind, and stray files such as
+// leftovers of interrupted writes.
+func (s *ThumbnailService) sweep(ctx context.Context) error {
+	live := map[string]struct{}{}
+	rootAbs := s.store.RootAbs()
+	err := filepath.WalkDir(rootAbs, func(current string, d fs.DirEntry, walkErr error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if walkErr != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if current != rootAbs && isInternalStoragePath(toAPIPath(current, rootAbs)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		live[thumbnailSourceKey(current)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dirEntries, err := os.ReadDir(s.root)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-thumbnailStrayAge)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() {
+			continue
+		}
+		key, ok := parseThumbnailFileName(name)
+		if ok {
+			if _, exists := live[key]; exists {
+				continue
+			}
+		} else if info, infoErr := dirEntry.Info(); infoErr != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.root, name)); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		s.remove(name)
+		removed++
+	}
+	if removed > 0 {
+		slog.Info("thumbnail sweep removed orphans", "removed", removed)
+	}
+	return nil
+}
+
+// load rebuilds the cache from the thumbnail root, ordering it by the
+// modification time of each thumbnail.
+func (s *ThumbnailService) load() error {
+	if err := os.MkdirAll(s.root, 0o755); err != nil {
+		return err
+	}
+	dirEntries, err := os.ReadDir(s.root)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]os.FileInfo, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if _, ok := parseThumbnailFileName(dirEntry.Name()); !ok || dirEntry.IsDir() {
+			continue
+		}
+		if info, err := dirEntry.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, info := range infos {
+		s.add(info.Name(), info.Size(), info.ModTime())
+	}
+	s.evict()
+	return nil
+}
+
+// add records a thumbnail as the most recently used one. s.mu must be held.
+func (s *ThumbnailService) add(name string, size int64, usedAt time.Time) {
+	key, ok := parseThumbnailFileName(name)
+	if !ok {
+		return
+	}
+	s.remove(name)
+
+	entry := &thumbnailEntry{name: name, key: key, size: size, usedAt: usedAt}
+	s.entries[name] = s.lru.PushFront(entry)
+	s.index(entry)
+	s.total += size
+}
+
+// index files entry under its source key. s.mu must be held.
+func (s *ThumbnailService) index(entry *thumbnailEntry) {
+	if s.sources[entry.key] == nil {
+		s.sources[entry.key] = map[string]struct{}{}
+	}
+	s.sources[entry.key][entry.name] = struct{}{}
+}
+
+// remove forgets a thumbnail. s.mu must be held.
+func (s *ThumbnailService) remove(name string) {
+	element, ok := s.entries[name]
+	if !ok {
+		return
+	}
+	entry := element.Value.(*thumbnailEntry)
+	s.lru.Remove(element)
+	delete(s.entries, name)
+	delete(s.sources[entry.key], name)
+	if len(s.sources[entry.key]) == 0 {
+		delete(s.sources, entry.key)
+	}
+	s.total -= entry.size
+}
+
+// evict removes the least recently used thumbnails while the cache is over
+// its cap, down to thumbnailEvictTarget of it. Thumbnails already removed
+// from disk by someone else are only forgotten. s.mu must be held.
+func (s *ThumbnailService) evict() {
+	if s.opts.MaxBytes <= 0 || s.total <= s.opts.MaxBytes {
+		return
+	}
+	target := int64(float64(s.opts.MaxBytes) * thumbnailEvictTarget)
+	for s.total > target && s.lru.Len() > 1 {
+		entry := s.lru.Back().Value.(*thumbnailEntry)
+		if err := os.Remove(filepath.Join(s.root, entry.name)); err != nil && !os.IsNotExist(err) {
+			slog.Warn("thumbnail eviction failed", "name", entry.name, "error", err)
+			return
+		}
+		s.remove(entry.name)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/event"
+	"go-file-explorer/internal/storage"
+)
+
+func newThumbnailTestService(t *testing.T, opts ThumbnailOptions) (*ThumbnailService, string) {
+	t.Helper()
+	root := t.TempDir()
+	store, err := storage.New(root)
+	require.NoError(t, err)
+
+	files := NewFileService(store, nil, filepath.Join(t.TempDir(), "thumbnails"), nil)
+	thumbnails, err := NewThumbnailService(files, store, event.NewBus(), opts)
+	require.NoError(t, err)
+	files.UseThumbnails(thumbnails)
+	return thumbnails, root
+}
+
+func writeTestPNG(t *testing.T, path string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{A: 255})
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+	require.NoError(t, png.Encode(file, img))
+}
+
+func thumbnailNames(t *testing.T, s *ThumbnailService) []string {
+	t.Helper()
+	entries, err := os.ReadDir(s.root)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestParseThumbnailFileName(t *testing.T) {
+	name := thumbnailFileName("/data/a.png", 256, ThumbnailWebP)
+	key, ok := parseThumbnailFileName(name)
+	require.True(t, ok)
+	require.Equal(t, thumbnailSourceKey("/data/a.png"), key)
+
+	for _, stray := range []string{"tmp-123.jpg", "vtmp-1.jpg", key + ".jpg", key + "-x.jpg", key + "-256.gif", "zz" + key[2:] + "-256.jpg"} {
+		_, ok := parseThumbnailFileName(stray)
+		require.False(t, ok, stray)
+	}
+}
+
+func TestThumbnailServiceEvictsLeastRecentlyUsed(t *testing.T) {
+	s, root := newThumbnailTestService(t, ThumbnailOptions{})
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		writeTestPNG(t, filepath.Join(root, name))
+	}
+
+	sizes := map[string]int64{}
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		file, info, err := s.files.GetThumbnail("/"+name, 64, ThumbnailPNG)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+		sizes[name] = info.Size()
+	}
+
+	// Serving a.png again makes b.png the least recently used thumbnail.
+	file, _, err := s.files.GetThumbnail("/a.png", 64, ThumbnailPNG)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	s.mu.Lock()
+	s.opts.MaxBytes = sizes["a.png"] + sizes["c.png"] + sizes["b.png"]/2
+	s.evict()
+	s.mu.Unlock()
+
+	resolve := func(name string) string { return filepath.Join(s.store.RootAbs(), name) }
+	require.ElementsMatch(t, []string{
+		thumbnailFileName(resolve("a.png"), 64, ThumbnailPNG),
+		thumbnailFileName(resolve("c.png"), 64, ThumbnailPNG),
+	}, thumbnailNames(t, s))
+	require.Equal(t, sizes["a.png"]+sizes["c.png"], s.total)
+}
+
+func TestThumbnailServiceLoadsCacheInUseOrder(t *testing.T) {
+	s, root := newThumbnailTestService(t, ThumbnailOptions{})
+	writeTestPNG(t, filepath.Join(root, "old.png"))
+	writeTestPNG(t, filepath.Join(root, "new.png"))
+
+	for _, name := range []string{"old.png", "new.png"} {
+		file, _, err := s.files.GetThumbnail("/"+name, 64, ThumbnailJPEG)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+	oldThumb := filepath.Join(s.root, thumbnailFileName(filepath.Join(s.store.RootAbs(), "old.png"), 64, ThumbnailJPEG))
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(oldThumb, lastWeek, lastWeek))
+	require.NoError(t, os.WriteFile(filepath.Join(s.root, "tmp-stray.jpg"), []byte("x"), 0o644))
+
+	reloaded, err := NewThumbnailService(s.files, s.store, event.NewBus(), ThumbnailOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, reloaded.lru.Len())
+	require.Equal(t, filepath.Base(oldThumb), reloaded.lru.Back().Value.(*thumbnailEntry).name)
+}
+
+func TestThumbnailServiceFollowsMoves(t *testing.T) {
+	s, root := newThumbnailTestService(t, ThumbnailOptions{})
+	writeTestPNG(t, filepath.Join(root, "album", "a.png"))
+	writeTestPNG(t, filepath.Join(root, "single.png"))
+
+	for _, path := range []string{"/album/a.png", "/single.png"} {
+		file, _, err := s.files.GetThumbnail(path, 64, ThumbnailJPEG)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	require.NoError(t, os.Rename(filepath.Join(root, "album"), filepath.Join(root, "photos")))
+	require.NoError(t, os.Rename(filepath.Join(root, "single.png"), filepath.Join(root, "renamed.png")))
+	s.moved("/album", "/photos")
+	s.moved("/single.png", "/renamed.png")
+
+	resolve := func(path string) string { return filepath.Join(s.store.RootAbs(), path) }
+	require.ElementsMatch(t, []string{
+		thumbnailFileName(resolve("photos/a.png"), 64, ThumbnailJPEG),
+		thumbnailFileName(resolve("renamed.png"), 64, ThumbnailJPEG),
+	}, thumbnailNames(t, s))
+
+	require.NoError(t, s.removeSource(resolve("renamed.png")))
+	require.Len(t, thumbnailNames(t, s), 1)
+	require.Equal(t, 1, s.lru.Len())
+}
+
+func TestThumbnailServiceSweepRemovesOrphans(t *testing.T) {
+	s, root := newThumbnailTestService(t, ThumbnailOptions{})
+	writeTestPNG(t, filepath.Join(root, "kept.png"))
+	writeTestPNG(t, filepath.Join(root, "gone.png"))
+
+	for _, name := range []string{"kept.png", "gone.png"} {
+		file, _, err := s.files.GetThumbnail("/"+name, 64, ThumbnailJPEG)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+	require.NoError(t, os.Remove(filepath.Join(root, "gone.png")))
+
+	stale := filepath.Join(s.root, "tmp-stale.jpg")
+	fresh := filepath.Join(s.root, "tmp-fresh.jpg")
+	require.NoError(t, os.WriteFile(stale, []byte("x"), 0o644))
+	require.NoError(t, os.WriteFile(fresh, []byte("x"), 0o644))
+	twoHoursAgo := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(stale, twoHoursAgo, twoHoursAgo))
+
+	require.NoError(t, s.sweep(context.Background()))
+	require.ElementsMatch(t, []string{
+		thumbnailFileName(filepath.Join(s.store.RootAbs(), "kept.png"), 64, ThumbnailJPEG),
+		"tmp-fresh.jpg",
+	}, thumbnailNames(t, s))
+	require.Equal(t, 1, s.lru.Len())
+}
+
+func TestThumbnailServiceGenerate(t *testing.T) {
+	s, root := newThumbnailTestService(t, ThumbnailOptions{Sizes: []int{64, 128}})
+	writeTestPNG(t, filepath.Join(root, "gallery", "a.png"))
+	writeTestPNG(t, filepath.Join(root, "gallery", "nested", "b.png"))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "gallery", "notes.txt"), []byte("text"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "gallery", "broken.png"), []byte("not a png"), 0o644))
+
+	processed, failed, err := s.Generate(context.Background(), "/gallery")
+	require.NoError(t, err)
+	require.Equal(t, 3, processed)
+	require.Equal(t, 1, failed)
+	require.Len(t, thumbnailNames(t, s), 4)
+
+	// Fresh thumbnails are not generated again.
+	before, err := os.Stat(filepath.Join(s.root, thumbnailFileName(filepath.Join(s.store.RootAbs(), "gallery", "a.png"), 64, ThumbnailJPEG)))
+	require.NoError(t, err)
+	_, _, err = s.Generate(context.Background(), "/gallery/a.png")
+	require.NoError(t, err)
+	after, err := os.Stat(filepath.Join(s.root, thumbnailFileName(filepath.Join(s.store.RootAbs(), "gallery", "a.png"), 64, ThumbnailJPEG)))
+	require.NoError(t, err)
+	require.Equal(t, before.ModTime(), after.ModTime())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = s.Generate(ctx, "/gallery")
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -2,15 +2,11 @@ package service
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
@@ -19,13 +15,17 @@ import (
 	"go-file-explorer/internal/model"
 	"go-file-explorer/internal/repository"
 	"go-file-explorer/internal/storage"
+	"go-file-explorer/internal/util"
 )
 
 type TrashService struct {
-	store         storage.Storage
-	trashRoot     string
-	thumbnailRoot string
-	trashRepo     *repository.TrashRepository
+	store      storage.Storage
+	trashRoot  string
+	thumbnails *ThumbnailService
+	trashRepo  *repository.TrashRepository
+	tags       *TagService
+	metadata   *MetadataService
+	quick      *QuickAccessService
 }
 
 func NewTrashService(store storage.Storage, trashRoot string, trashRepo *repository.TrashRepository) (*TrashService, error) {
@@ -33,20 +33,33 @@ func NewTrashService(store storage.Storage, trashRoot string, trashRepo *reposit
 		return nil, fmt.Errorf("prepare trash directory: %w", err)
 	}
 
-	return &TrashService{store: store, trashRoot: trashRoot, thumbnailRoot: "./data/.thumbnails", trashRepo: trashRepo}, nil
+	return &TrashService{store: store, trashRoot: trashRoot, trashRepo: trashRepo}, nil
 }
 
-func (s *TrashService) SetThumbnailRoot(thumbnailRoot string) {
-	trimmed := strings.TrimSpace(thumbnailRoot)
-	if trimmed == "" {
-		return
-	}
-	s.thumbnailRoot = trimmed
+// UseTags makes trashed entries take their tags with them.
+func (s *TrashService) UseTags(tags *TagService) {
+	s.tags = tags
 }
 
-func (s *TrashService) SoftDelete(apiPath string, actor model.AuditActor) (model.TrashRecord, error) {
-	ctx := context.Background()
+// UseMetadata makes trashed entries take their custom metadata with them.
+func (s *TrashService) UseMetadata(metadata *MetadataService) {
+	s.metadata = metadata
+}
+
+// UseQuickAccess drops trashed entries from favorites and recent files.
+func (s *TrashService) UseQuickAccess(quick *QuickAccessService) {
+	s.quick = quick
+}
+
+// UseThumbnails makes permanently deleted entries take their thumbnails
+// with them.
+func (s *TrashService) UseThumbnails(thumbnails *ThumbnailService) {
+	s.thumbnails = thumbnails
+}
 
+// SoftDelete moves apiPath into the trash. ctx only bounds the move itself:
+// once the entry is in the trash the record is written even if ctx is done.
+func (s *TrashService) SoftDelete(ctx context.Context, apiPath string, actor model.AuditActor) (model.TrashRecord, error) {
 	resolved, err := s.store.Resolve(apiPath)
 	if err != nil {
 		return model.TrashRecord{}, err
@@ -65,14 +78,17 @@ func (s *TrashService) SoftDelete(apiPath string, actor model.AuditActor) (model
 	}
 
 	trashPath := filepath.Join(s.trashRoot, record.TrashName)
-	if err := movePath(resolved, trashPath); err != nil {
+	if err := movePath(ctx, resolved, trashPath); err != nil {
 		return model.TrashRecord{}, fmt.Errorf("move to trash %q: %w", apiPath, err)
 	}
 
-	if err := s.trashRepo.Create(ctx, record); err != nil {
-		_ = movePath(trashPath, resolved)
+	if err := s.trashRepo.Create(context.Background(), record); err != nil {
+		_ = movePath(context.Background(), trashPath, resolved)
 		return model.TrashRecord{}, err
 	}
+	s.tags.trashed(ctx, apiPath, record.ID)
+	s.metadata.trashed(ctx, apiPath, record.ID)
+	s.quick.trashed(ctx, apiPath, record.ID)
 
 	return record, nil
 }
@@ -101,16 +117,20 @@ func (s *TrashService) RestoreLatest(apiPath string, actor model.AuditActor) (mo
 	}
 
 	trashPath := filepath.Join(s.trashRoot, record.TrashName)
-	if err := movePath(trashPath, targetResolved); err != nil {
+	if err := movePath(ctx, trashPath, targetResolved); err != nil {
 		return model.TrashRecord{}, fmt.Errorf("restore %q: %w", apiPath, err)
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	if err := s.trashRepo.MarkRestored(ctx, record.ID, actor); err != nil {
-		_ = movePath(targetResolved, trashPath)
+		_ = movePath(ctx, targetResolved, trashPath)
 		return model.TrashRecord{}, err
 	}
 
+	s.tags.restored(ctx, record.ID)
+	s.metadata.restored(ctx, record.ID)
+	s.quick.restored(ctx, record.ID)
+
 	record.RestoredAt = now
 	record.RestoredBy = actor
 	return record, nil
@@ -145,10 +165,18 @@ func (s *TrashService) PermanentDelete(trashID string) error {
 		}
 	}
 
-	return s.trashRepo.Delete(ctx, trashID)
+	if err := s.trashRepo.Delete(ctx, trashID); err != nil {
+		return err
+	}
+	s.tags.purged(ctx, trashID)
+	s.metadata.purged(ctx, trashID)
+	s.quick.purged(ctx, trashID)
+	return nil
 }
 
-func (s *TrashService) EmptyTrash() (int, error) {
+// EmptyTrash permanently deletes trashed items. With a positive olderThan
+// only items deleted longer ago than that are removed.
+func (s *TrashService) EmptyTrash(olderThan time.Duration) (int, error) {
 	ctx := context.Background()
 
 	records, err := s.trashRepo.List(ctx, false)
@@ -156,6 +184,22 @@ func (s *TrashService) EmptyTrash() (int, error) {
 		return 0, err
 	}
 
+	if olderThan > 0 {
+		cutoff := time.Now().UTC().Add(-olderThan)
+		count := 0
+		for _, record := range records {
+			deletedAt, parseErr := time.Parse(time.RFC3339Nano, record.DeletedAt)
+			if parseErr != nil || !deletedAt.Before(cutoff) {
+				continue
+			}
+			if err := s.PermanentDelete(record.ID); err != nil {
+				continue
+			}
+			count++
+		}
+		return count, nil
+	}
+
 	count := 0
 	for _, record := range records {
 		trashPath := filepath.Join(s.trashRoot, record.TrashName)
@@ -185,6 +229,9 @@ func (s *TrashService) EmptyTrash() (int, error) {
 	if _, err := s.trashRepo.DeleteAllNotRestored(ctx); err != nil {
 		return count, err
 	}
+	s.tags.purged(ctx, "")
+	s.metadata.purged(ctx, "")
+	s.quick.purged(ctx, "")
 
 	return count, nil
 }
@@ -195,19 +242,7 @@ func (s *TrashService) removeThumbnailsForPath(apiPath string) error {
 		return nil
 	}
 
-	for size := 32; size <= 2048; size++ {
-		thumbPath := filepath.Join(s.thumbnailRoot, thumbnailFileName(resolved, size))
-		if removeErr := os.Remove(thumbPath); removeErr != nil && !os.IsNotExist(removeErr) {
-			return fmt.Errorf("remove thumbnail %q: %w", thumbPath, removeErr)
-		}
-	}
-
-	return nil
-}
-
-func thumbnailFileName(resolvedPath string, size int) string {
-	hash := sha256.Sum256([]byte(resolvedPath + "|" + strconv.Itoa(size)))
-	return hex.EncodeToString(hash[:]) + ".jpg"
+	return s.thumbnails.removeSource(resolved)
 }
 
 func collectOriginalFilePathsForTrashRecord(trashPath string, originalAPIPath string) ([]string, error) {
@@ -260,7 +295,10 @@ func normalizeAPIPathForTrash(raw string) string {
 	return cleaned
 }
 
-func movePath(source string, destination string) error {
+// movePath renames source to destination, falling back to copy and remove
+// across devices. A failed or cancelled fallback copy removes the partial
+// destination and leaves source in place.
+func movePath(ctx context.Context, source string, destination string) error {
 	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
 		return err
 	}
@@ -271,7 +309,14 @@ func movePath(source string, destination string) error {
 		return err
 	}
 
-	if err := copyPathRecursive(source, destination); err != nil {
+	if progress := util.ProgressFromContext(ctx); progress != nil {
+		if size, err := util.TreeSize(source); err == nil {
+			progress.AddTotal(size)
+		}
+	}
+
+	if err := copyPathRecursive(ctx, source, destination); err != nil {
+		_ = os.RemoveAll(destination)
 		return err
 	}
 
@@ -291,14 +336,14 @@ func isCrossDeviceRenameError(err error) bool {
 	return strings.Contains(strings.ToLower(err.Error()), "cross-device")
 }
 
-func copyPathRecursive(source string, destination string) error {
+func copyPathRecursive(ctx context.Context, source string, destination string) error {
 	info, err := os.Stat(source)
 	if err != nil {
 		return err
 	}
 
 	if !info.IsDir() {
-		return copyFile(source, destination, info.Mode())
+		return copyFile(ctx, source, destination, info.Mode())
 	}
 
 	if err := os.MkdirAll(destination, info.Mode().Perm()); err != nil {
@@ -309,6 +354,9 @@ func copyPathRecursive(source string, destination string) error {
 		if walkErr != nil {
 			return walkErr
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		rel, relErr := filepath.Rel(source, current)
 		if relErr != nil {
@@ -328,11 +376,11 @@ func copyPathRecursive(source string, destination string) error {
 			return os.MkdirAll(target, entryInfo.Mode().Perm())
 		}
 
-		return copyFile(current, target, entryInfo.Mode())
+		return copyFile(ctx, current, target, entryInfo.Mode())
 	})
 }
 
-func copyFile(source string, destination string, mode os.FileMode) error {
+func copyFile(ctx context.Context, source string, destination string, mode os.FileMode) error {
 	input, err := os.Open(source)
 	if err != nil {
 		return err
@@ -348,7 +396,7 @@ func copyFile(source string, destination string, mode os.FileMode) error {
 		return err
 	}
 
-	_, copyErr := io.Copy(output, input)
+	_, copyErr := util.CopyWithContext(ctx, output, input)
 	closeErr := output.Close()
 	if copyErr != nil {
 		return copyErr
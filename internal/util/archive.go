@@ -2,10 +2,13 @@ package util
 
 import (
 	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -59,18 +62,52 @@ func StreamZipFromDirectory(rootDir string, writer io.Writer) error {
 	})
 }
 
-// Compress creates a zip file from multiple source paths.
-func Compress(sources []string, destZip string) error {
+// Compress creates a zip file from multiple source paths. It stops between
+// files and copy chunks once ctx is cancelled; on any error the partially
+// written zip is removed. Uncompressed bytes are reported to the Progress
+// attached to ctx.
+func Compress(ctx context.Context, sources []string, destZip string) (err error) {
+	if progress := ProgressFromContext(ctx); progress != nil {
+		for _, source := range sources {
+			size, sizeErr := TreeSize(source)
+			if sizeErr != nil {
+				return sizeErr
+			}
+			progress.AddTotal(size)
+		}
+	}
+
 	zipFile, err := os.Create(destZip)
 	if err != nil {
 		return err
 	}
-	defer zipFile.Close()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(destZip)
+		}
+	}()
 
 	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	if err = writeZipSources(ctx, zipWriter, sources); err != nil {
+		zipWriter.Close()
+		zipFile.Close()
+		return err
+	}
+
+	if err = zipWriter.Close(); err != nil {
+		zipFile.Close()
+		return err
+	}
+	return zipFile.Close()
+}
 
+func writeZipSources(ctx context.Context, zipWriter *zip.Writer, sources []string) error {
+	progress := ProgressFromContext(ctx)
 	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		info, err := os.Stat(source)
 		if err != nil {
 			return err
@@ -82,6 +119,9 @@ func Compress(sources []string, destZip string) error {
 				if err != nil {
 					return err
 				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
 
 				relPath, err := filepath.Rel(baseDir, path)
 				if err != nil {
@@ -107,8 +147,9 @@ func Compress(sources []string, destZip string) error {
 				if err != nil {
 					return err
 				}
+				progress.SetItem(zipPath)
 
-				_, err = io.Copy(w, fileToZip)
+				_, err = CopyWithContext(ctx, w, fileToZip)
 				return err
 			})
 			if err != nil {
@@ -120,14 +161,15 @@ func Compress(sources []string, destZip string) error {
 			if err != nil {
 				return err
 			}
-			
+
 			w, err := zipWriter.Create(relPath)
 			if err != nil {
 				fileToZip.Close()
 				return err
 			}
+			progress.SetItem(relPath)
 
-			_, err = io.Copy(w, fileToZip)
+			_, err = CopyWithContext(ctx, w, fileToZip)
 			fileToZip.Close()
 			if err != nil {
 				return err
@@ -138,8 +180,39 @@ func Compress(sources []string, destZip string) error {
 	return nil
 }
 
-// CheckZipConflicts checks if extracting the zip file would overwrite any existing files.
-func CheckZipConflicts(srcZip string, destDir string) ([]string, error) {
+// ExtractOptions narrows and reshapes what gets written when extracting a zip.
+// Entries holds archive paths or path.Match globs; a pattern also selects
+// everything below a matching directory. An empty Entries selects everything.
+type ExtractOptions struct {
+	Entries         []string
+	StripComponents int
+	Flatten         bool
+}
+
+// ExtractResult reports the outcome for a single archive entry. Target is the
+// slash-separated path relative to the destination directory.
+type ExtractResult struct {
+	Entry  string
+	Target string
+	Status string
+	Reason string
+}
+
+// ErrNoMatchingEntries is returned when ExtractOptions.Entries selects nothing.
+var ErrNoMatchingEntries = errors.New("no archive entries match the requested entries")
+
+// ErrInvalidEntryPattern is returned when an ExtractOptions.Entries pattern is malformed.
+var ErrInvalidEntryPattern = errors.New("invalid entry pattern")
+
+type extractTarget struct {
+	file   *zip.File
+	target string
+	reason string
+}
+
+// CheckZipConflicts reports the archive entries that would overwrite existing
+// files when extracted into destDir with the given options.
+func CheckZipConflicts(srcZip string, destDir string, opts ExtractOptions) ([]string, error) {
 	var conflicts []string
 
 	r, err := zip.OpenReader(srcZip)
@@ -148,24 +221,30 @@ func CheckZipConflicts(srcZip string, destDir string) ([]string, error) {
 	}
 	defer r.Close()
 
-	for _, f := range r.File {
-		fpath := filepath.Join(destDir, f.Name)
+	plan, err := planExtraction(r.File, destDir, opts)
+	if err != nil {
+		return nil, err
+	}
 
-		// Check for Zip Slip
-		if !strings.HasPrefix(fpath, filepath.Clean(destDir)+string(os.PathSeparator)) {
-			return nil, fmt.Errorf("illegal file path: %s", fpath)
+	for _, item := range plan {
+		if item.reason != "" || item.file.FileInfo().IsDir() {
+			continue
 		}
-
-		if _, err := os.Stat(fpath); err == nil {
-			conflicts = append(conflicts, f.Name)
+		if _, err := os.Stat(filepath.Join(destDir, filepath.FromSlash(item.target))); err == nil {
+			conflicts = append(conflicts, item.file.Name)
 		}
 	}
 	return conflicts, nil
 }
 
-// Decompress extracts a zip file to a destination directory.
-func Decompress(srcZip string, destDir string) ([]string, error) {
-	var extractedFiles []string
+// Decompress extracts the selected entries of a zip file into destDir. Existing
+// files are replaced when overwrite is true and reported as skipped otherwise.
+// Directory entries are created but not included in the results. When ctx is
+// cancelled extraction stops before the next entry, the file being written is
+// removed and the results gathered so far are returned with ctx.Err().
+// Uncompressed bytes are reported to the Progress attached to ctx.
+func Decompress(ctx context.Context, srcZip string, destDir string, opts ExtractOptions, overwrite bool) ([]ExtractResult, error) {
+	var results []ExtractResult
 
 	r, err := zip.OpenReader(srcZip)
 	if err != nil {
@@ -173,44 +252,177 @@ func Decompress(srcZip string, destDir string) ([]string, error) {
 	}
 	defer r.Close()
 
-	for _, f := range r.File {
-		fpath := filepath.Join(destDir, f.Name)
+	plan, err := planExtraction(r.File, destDir, opts)
+	if err != nil {
+		return nil, err
+	}
 
-		// Check for Zip Slip
-		if !strings.HasPrefix(fpath, filepath.Clean(destDir)+string(os.PathSeparator)) {
-			return nil, fmt.Errorf("illegal file path: %s", fpath)
+	progress := ProgressFromContext(ctx)
+	for _, item := range plan {
+		if item.reason == "" && !item.file.FileInfo().IsDir() {
+			progress.AddTotal(int64(item.file.UncompressedSize64))
 		}
+	}
 
-		extractedFiles = append(extractedFiles, f.Name)
+	for _, item := range plan {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
 
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, os.ModePerm)
+		f := item.file
+		if item.reason != "" {
+			results = append(results, ExtractResult{Entry: f.Name, Status: "skipped", Reason: item.reason})
 			continue
 		}
 
-		if err = os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
-			return nil, err
+		fpath := filepath.Join(destDir, filepath.FromSlash(item.target))
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+				return results, err
+			}
+			continue
 		}
 
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return nil, err
+		if !overwrite {
+			if _, err := os.Stat(fpath); err == nil {
+				results = append(results, ExtractResult{Entry: f.Name, Target: item.target, Status: "skipped", Reason: "skipped: target already exists"})
+				// Count skipped bytes as done so the total is still reached.
+				progress.Add(int64(f.UncompressedSize64))
+				continue
+			}
 		}
 
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			return nil, err
+		progress.SetItem(f.Name)
+		if err := extractFile(ctx, f, fpath); err != nil {
+			return results, err
 		}
 
-		_, err = io.Copy(outFile, rc)
+		results = append(results, ExtractResult{Entry: f.Name, Target: item.target, Status: "success"})
+	}
+	return results, nil
+}
 
+func extractFile(ctx context.Context, f *zip.File, fpath string) error {
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return err
+	}
+
+	outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
 		outFile.Close()
-		rc.Close()
+		return err
+	}
 
-		if err != nil {
-			return nil, err
+	_, err = CopyWithContext(ctx, outFile, rc)
+
+	outFile.Close()
+	rc.Close()
+
+	if err != nil && ctx.Err() != nil {
+		_ = os.Remove(fpath)
+	}
+	return err
+}
+
+// planExtraction selects the entries matching opts and computes where each one
+// lands. Entries dropped by StripComponents or colliding after flattening carry
+// a reason instead of a target. Every target is checked against Zip Slip.
+func planExtraction(files []*zip.File, destDir string, opts ExtractOptions) ([]extractTarget, error) {
+	if opts.StripComponents < 0 {
+		return nil, fmt.Errorf("strip_components must not be negative")
+	}
+
+	patterns := make([]string, 0, len(opts.Entries))
+	for _, entry := range opts.Entries {
+		pattern := strings.Trim(strings.TrimSpace(filepath.ToSlash(entry)), "/")
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidEntryPattern, entry, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	cleanDest := filepath.Clean(destDir)
+	seen := map[string]bool{}
+	plan := make([]extractTarget, 0, len(files))
+
+	for _, f := range files {
+		name := strings.Trim(f.Name, "/")
+		if name == "" || !matchesEntryPatterns(name, patterns) {
+			continue
+		}
+
+		isDir := f.FileInfo().IsDir()
+		if opts.Flatten && isDir {
+			continue
+		}
+
+		parts := strings.Split(name, "/")
+		if opts.StripComponents > 0 {
+			if len(parts) <= opts.StripComponents {
+				if !isDir {
+					plan = append(plan, extractTarget{file: f, reason: "skipped: removed by strip_components"})
+				}
+				continue
+			}
+			parts = parts[opts.StripComponents:]
+		}
+
+		target := strings.Join(parts, "/")
+		if opts.Flatten {
+			target = parts[len(parts)-1]
+		}
+
+		fpath := filepath.Join(cleanDest, filepath.FromSlash(target))
+		if !strings.HasPrefix(fpath, cleanDest+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("illegal file path: %s", fpath)
+		}
+
+		if !isDir {
+			if seen[target] {
+				plan = append(plan, extractTarget{file: f, reason: "skipped: duplicate target " + target})
+				continue
+			}
+			seen[target] = true
+		}
+
+		plan = append(plan, extractTarget{file: f, target: target})
+	}
+
+	if len(patterns) > 0 && len(plan) == 0 {
+		return nil, ErrNoMatchingEntries
+	}
+
+	return plan, nil
+}
+
+// matchesEntryPatterns reports whether name, or any of its parent directories,
+// matches one of the patterns.
+func matchesEntryPatterns(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		candidate := name
+		for {
+			if matched, _ := path.Match(pattern, candidate); matched {
+				return true
+			}
+			index := strings.LastIndex(candidate, "/")
+			if index < 0 {
+				break
+			}
+			candidate = candidate[:index]
 		}
 	}
-	return extractedFiles, nil
+	return false
 }
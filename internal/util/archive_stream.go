@@ -0,0 +1,165 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// ArchiveEntry is a single file or directory written into a streamed archive.
+// Name is the slash-separated path inside the archive and Source the absolute
+// path on disk (empty for directories).
+type ArchiveEntry struct {
+	Name    string
+	Source  string
+	IsDir   bool
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+}
+
+// WriteZipEntries streams entries as a deflated zip archive. File contents are
+// read straight from disk, so nothing is buffered besides the copy buffer.
+func WriteZipEntries(ctx context.Context, writer io.Writer, entries []ArchiveEntry) error {
+	zipWriter := zip.NewWriter(writer)
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		header := &zip.FileHeader{
+			Name:     entry.Name,
+			Method:   zip.Deflate,
+			Modified: entry.ModTime,
+		}
+		if entry.IsDir {
+			header.Name = strings.TrimSuffix(entry.Name, "/") + "/"
+			header.Method = zip.Store
+			header.SetMode(fs.ModeDir | 0o755)
+			if _, err := zipWriter.CreateHeader(header); err != nil {
+				return err
+			}
+			continue
+		}
+		header.SetMode(entry.Mode.Perm())
+
+		w, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := copyArchiveSource(ctx, w, entry.Source); err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+// WriteTarEntries streams entries as an uncompressed tar archive. The header
+// size is taken from the open file so a file that changed since it was listed
+// still produces a valid archive.
+func WriteTarEntries(ctx context.Context, writer io.Writer, entries []ArchiveEntry) error {
+	tarWriter := tar.NewWriter(writer)
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if entry.IsDir {
+			header := &tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     strings.TrimSuffix(entry.Name, "/") + "/",
+				Mode:     0o755,
+				ModTime:  entry.ModTime,
+			}
+			if err := tarWriter.WriteHeader(header); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := writeTarFile(ctx, tarWriter, entry); err != nil {
+			return err
+		}
+	}
+
+	return tarWriter.Close()
+}
+
+func writeTarFile(ctx context.Context, tarWriter *tar.Writer, entry ArchiveEntry) error {
+	source, err := os.Open(entry.Source)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.Name,
+		Mode:     int64(info.Mode().Perm()),
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = CopyWithContext(ctx, tarWriter, io.LimitReader(source, info.Size()))
+	return err
+}
+
+func copyArchiveSource(ctx context.Context, writer io.Writer, sourcePath string) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	_, err = CopyWithContext(ctx, writer, source)
+	return err
+}
+
+// CopyWithContext copies src to dst like io.Copy but stops with ctx.Err() as
+// soon as the context is cancelled.
+func CopyWithContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var written int64
+	progress := ProgressFromContext(ctx)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			w, writeErr := dst.Write(buf[:n])
+			written += int64(w)
+			progress.Add(int64(w))
+			if writeErr != nil {
+				return written, writeErr
+			}
+			if w != n {
+				return written, io.ErrShortWrite
+			}
+		}
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestZip(t *testing.T, entries map[string]string) string {
+	t.Helper()
+
+	zipPath := filepath.Join(t.TempDir(), "archive.zip")
+	file, err := os.Create(zipPath)
+	require.NoError(t, err)
+
+	writer := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, file.Close())
+	return zipPath
+}
+
+func TestDecompressSelection(t *testing.T) {
+	t.Parallel()
+
+	archive := writeTestZip(t, map[string]string{
+		"project/README.md":        "readme",
+		"project/src/main.go":      "main",
+		"project/src/util/util.go": "util",
+		"project/docs/guide.txt":   "guide",
+	})
+
+	t.Run("extracts only matching entries and directories", func(t *testing.T) {
+		dest := t.TempDir()
+		results, err := Decompress(context.Background(), archive, dest, ExtractOptions{Entries: []string{"project/src", "*/*.md"}}, false)
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+
+		require.FileExists(t, filepath.Join(dest, "project", "README.md"))
+		require.FileExists(t, filepath.Join(dest, "project", "src", "util", "util.go"))
+		require.NoFileExists(t, filepath.Join(dest, "project", "docs", "guide.txt"))
+	})
+
+	t.Run("strips leading components", func(t *testing.T) {
+		dest := t.TempDir()
+		results, err := Decompress(context.Background(), archive, dest, ExtractOptions{Entries: []string{"project/src/*"}, StripComponents: 2}, false)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		require.FileExists(t, filepath.Join(dest, "main.go"))
+		require.FileExists(t, filepath.Join(dest, "util", "util.go"))
+	})
+
+	t.Run("flattens into the destination", func(t *testing.T) {
+		dest := t.TempDir()
+		_, err := Decompress(context.Background(), archive, dest, ExtractOptions{Flatten: true}, false)
+		require.NoError(t, err)
+
+		require.FileExists(t, filepath.Join(dest, "util.go"))
+		require.FileExists(t, filepath.Join(dest, "guide.txt"))
+		require.NoDirExists(t, filepath.Join(dest, "project"))
+	})
+
+	t.Run("reports existing files as conflicts and skips them", func(t *testing.T) {
+		dest := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dest, "main.go"), []byte("local"), 0o644))
+
+		options := ExtractOptions{Entries: []string{"project/src/main.go"}, Flatten: true}
+		conflicts, err := CheckZipConflicts(archive, dest, options)
+		require.NoError(t, err)
+		require.Equal(t, []string{"project/src/main.go"}, conflicts)
+
+		results, err := Decompress(context.Background(), archive, dest, options, false)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "skipped", results[0].Status)
+
+		content, err := os.ReadFile(filepath.Join(dest, "main.go"))
+		require.NoError(t, err)
+		require.Equal(t, "local", string(content))
+	})
+
+	t.Run("rejects selections that match nothing", func(t *testing.T) {
+		_, err := Decompress(context.Background(), archive, t.TempDir(), ExtractOptions{Entries: []string{"missing/*"}}, false)
+		require.ErrorIs(t, err, ErrNoMatchingEntries)
+	})
+}
+
+func TestCompressCancelledRemovesOutput(t *testing.T) {
+	t.Parallel()
+
+	source := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(source, "a.txt"), []byte("a"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	destZip := filepath.Join(t.TempDir(), "out.zip")
+	err := Compress(ctx, []string{source}, destZip)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NoFileExists(t, destZip)
+}
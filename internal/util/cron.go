@@ -0,0 +1,217 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five-field cron expression (minute, hour, day of
+// month, month, day of week). Fields accept "*", values, ranges "a-b", steps
+// "*/n" or "a-b/n" and comma-separated lists; months and weekdays also accept
+// three-letter names. The macros @yearly, @annually, @monthly, @weekly,
+// @daily, @midnight and @hourly are supported. As in standard cron, when both
+// day of month and day of week are restricted a day matching either one runs;
+// a field starting with "*" counts as unrestricted.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression.
+func ParseCron(expr string) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	masks := make([]uint64, len(cronFields))
+	for index, raw := range fields {
+		mask, err := parseCronField(raw, cronFields[index])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		masks[index] = mask
+	}
+
+	// 7 is an alias for Sunday.
+	dow := masks[4]
+	if dow&(1<<7) != 0 {
+		dow = (dow &^ (1 << 7)) | 1
+	}
+
+	return &CronSchedule{
+		minute: masks[0],
+		hour:   masks[1],
+		dom:    masks[2],
+		month:  masks[3],
+		dow:    dow,
+		domAny: strings.HasPrefix(fields[2], "*") || fields[2] == "?",
+		dowAny: strings.HasPrefix(fields[4], "*") || fields[4] == "?",
+	}, nil
+}
+
+func parseCronField(raw string, field cronField) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(strings.ToLower(raw), ",") {
+		if part == "" {
+			return 0, fmt.Errorf("empty %s value", field.name)
+		}
+
+		rangePart, step := part, 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			rangePart = part[:slash]
+			parsed, err := strconv.Atoi(part[slash+1:])
+			if err != nil || parsed < 1 {
+				return 0, fmt.Errorf("invalid %s step %q", field.name, part)
+			}
+			step = parsed
+		}
+
+		low, high := field.min, field.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = cronValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			if high, err = cronValue(bounds[1], field); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid %s range %q", field.name, rangePart)
+			}
+		default:
+			value, err := cronValue(rangePart, field)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			// "5/15" means every 15 starting at 5.
+			if step == 1 {
+				high = value
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			mask |= 1 << uint(value)
+		}
+	}
+	return mask, nil
+}
+
+func cronValue(raw string, field cronField) (int, error) {
+	if value, ok := field.names[raw]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < field.min || value > field.max {
+		return 0, fmt.Errorf("invalid %s value %q (allowed %d-%d)", field.name, raw, field.min, field.max)
+	}
+	return value, nil
+}
+
+// Next returns the first time after after that matches the schedule, in
+// after's location, or the zero time when none exists within five years.
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			// A DST jump can map the next wall-clock hour back onto t.
+			if !next.After(t) {
+				next = t.Add(time.Hour).Truncate(time.Hour)
+			}
+			t = next
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowMatch
+	case c.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// ParseAge parses a duration that may also be given in whole days, such as
+// "30d", in addition to the time.ParseDuration forms ("36h", "90m").
+func ParseAge(raw string) (time.Duration, error) {
+	value := strings.TrimSpace(raw)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("invalid age %q", raw)
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", raw)
+	}
+	return age, nil
+}
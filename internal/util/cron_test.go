@@ -0,0 +1,58 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, time.March, 14, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 14, 10, 31, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2026, time.March, 15, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 14, 10, 45, 0, 0, time.UTC)},
+		{"30 3 * * sun", time.Date(2026, time.March, 15, 3, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 9 1 jan-mar *", time.Date(2027, time.January, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 12 13 * fri", time.Date(2026, time.March, 20, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"5,35 10-11 * * mon-fri", time.Date(2026, time.March, 16, 10, 5, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.expr)
+		require.NoError(t, err, tt.expr)
+		require.Equal(t, tt.want, schedule.Next(base), tt.expr)
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("UTC-5", -5*3600)
+	schedule, err := ParseCron("0 1 * * *")
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2026, time.June, 1, 23, 0, 0, 0, loc))
+	require.Equal(t, time.Date(2026, time.June, 2, 1, 0, 0, 0, loc), next)
+	require.Equal(t, loc, next.Location())
+}
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "* * * * funday", "@often"} {
+		_, err := ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,463 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+	"strings"
+	"time"
+)
+
+// TIFF tags read from the EXIF IFDs.
+const (
+	tiffTagMake             = 0x010f
+	tiffTagModel            = 0x0110
+	tiffTagOrientation      = 0x0112
+	tiffTagSoftware         = 0x0131
+	tiffTagDateTime         = 0x0132
+	tiffTagExifIFD          = 0x8769
+	tiffTagGPSIFD           = 0x8825
+	exifTagExposureTime     = 0x829a
+	exifTagFNumber          = 0x829d
+	exifTagISO              = 0x8827
+	exifTagDateTimeOriginal = 0x9003
+	exifTagOffsetOriginal   = 0x9011
+	exifTagFocalLength      = 0x920a
+	exifTagLensModel        = 0xa434
+	gpsTagLatitudeRef       = 0x0001
+	gpsTagLatitude          = 0x0002
+	gpsTagLongitudeRef      = 0x0003
+	gpsTagLongitude         = 0x0004
+	gpsTagAltitudeRef       = 0x0005
+	gpsTagAltitude          = 0x0006
+)
+
+const (
+	// maxEXIFSegments bounds how many JPEG segments or RIFF chunks are
+	// skipped while looking for EXIF data.
+	maxEXIFSegments = 256
+	// maxPNGChunks bounds how many PNG chunks are read; image data may be
+	// split over many IDAT chunks.
+	maxPNGChunks = 16384
+	// maxIFDEntries and maxTIFFValueSize bound what a corrupt or hostile
+	// file can make the parser allocate.
+	maxIFDEntries    = 1024
+	maxTIFFValueSize = 64 * 1024
+)
+
+// EXIF holds the EXIF fields reported in file info. Zero values mean the
+// field is absent.
+type EXIF struct {
+	Orientation int
+	Make        string
+	Model       string
+	LensModel   string
+	Software    string
+	// CapturedAt is DateTimeOriginal, falling back to DateTime. Cameras
+	// record local time; CapturedAtOffset is set when the offset is known.
+	CapturedAt       time.Time
+	CapturedAtOffset bool
+	ISO              int
+	// ExposureTime is in seconds.
+	ExposureTime float64
+	FNumber      float64
+	// FocalLength is in millimetres.
+	FocalLength float64
+	GPS         *GPSPosition
+}
+
+// GPSPosition is a position in decimal degrees; Altitude is in metres above
+// sea level.
+type GPSPosition struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  *float64
+}
+
+// ImageOrientation returns the EXIF orientation (1-8) stored in a JPEG, TIFF,
+// PNG or WebP image. Images without a readable orientation yield 1, meaning
+// the pixels are stored upright.
+func ImageOrientation(r io.ReaderAt) int {
+	tiff, ok := locateTIFFHeader(r)
+	if !ok {
+		return 1
+	}
+	ifd, ok := tiff.readIFD(tiff.firstIFD())
+	if !ok {
+		return 1
+	}
+	orientation, ok := tiff.uint(ifd[tiffTagOrientation])
+	if !ok || orientation < 1 || orientation > 8 {
+		return 1
+	}
+	return int(orientation)
+}
+
+// ReadEXIF returns the EXIF data of a JPEG, TIFF, PNG or WebP image, or false
+// when it has none.
+func ReadEXIF(r io.ReaderAt) (EXIF, bool) {
+	tiff, ok := locateTIFFHeader(r)
+	if !ok {
+		return EXIF{}, false
+	}
+	ifd0, ok := tiff.readIFD(tiff.firstIFD())
+	if !ok {
+		return EXIF{}, false
+	}
+
+	var exif EXIF
+	if orientation, ok := tiff.uint(ifd0[tiffTagOrientation]); ok && orientation >= 1 && orientation <= 8 {
+		exif.Orientation = int(orientation)
+	}
+	exif.Make = tiff.ascii(ifd0[tiffTagMake])
+	exif.Model = tiff.ascii(ifd0[tiffTagModel])
+	exif.Software = tiff.ascii(ifd0[tiffTagSoftware])
+	capturedAt := tiff.ascii(ifd0[tiffTagDateTime])
+	offset := ""
+
+	if pointer, ok := tiff.uint(ifd0[tiffTagExifIFD]); ok {
+		if sub, ok := tiff.readIFD(pointer); ok {
+			if original := tiff.ascii(sub[exifTagDateTimeOriginal]); original != "" {
+				capturedAt = original
+				offset = tiff.ascii(sub[exifTagOffsetOriginal])
+			}
+			if iso, ok := tiff.uint(sub[exifTagISO]); ok {
+				exif.ISO = int(iso)
+			}
+			exif.ExposureTime = tiff.rational(sub[exifTagExposureTime])
+			exif.FNumber = tiff.rational(sub[exifTagFNumber])
+			exif.FocalLength = tiff.rational(sub[exifTagFocalLength])
+			exif.LensModel = tiff.ascii(sub[exifTagLensModel])
+		}
+	}
+	exif.CapturedAt, exif.CapturedAtOffset = parseEXIFTime(capturedAt, offset)
+
+	if pointer, ok := tiff.uint(ifd0[tiffTagGPSIFD]); ok {
+		if gps, ok := tiff.readIFD(pointer); ok {
+			exif.GPS = tiff.gpsPosition(gps)
+		}
+	}
+	return exif, true
+}
+
+// parseEXIFTime parses an EXIF "2006:01:02 15:04:05" time with an optional
+// "+07:00" offset. Without an offset the time is returned as UTC and false.
+func parseEXIFTime(value, offset string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if offset = strings.TrimSpace(offset); offset != "" {
+		if parsed, err := time.Parse("2006:01:02 15:04:05-07:00", value+offset); err == nil {
+			return parsed, true
+		}
+	}
+	parsed, err := time.Parse("2006:01:02 15:04:05", value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, false
+}
+
+// tiffReader reads IFDs of the TIFF structure at base.
+type tiffReader struct {
+	r     io.ReaderAt
+	base  int64
+	order binary.ByteOrder
+}
+
+type tiffEntry struct {
+	kind  uint16
+	count uint32
+	// value holds the value, or its offset when it does not fit.
+	value []byte
+}
+
+// tiffTypeSizes maps TIFF field types to the size of one value.
+var tiffTypeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+func (t tiffReader) firstIFD() uint32 {
+	buf := make([]byte, 4)
+	if _, err := t.r.ReadAt(buf, t.base+4); err != nil {
+		return 0
+	}
+	return t.order.Uint32(buf)
+}
+
+// readIFD returns the entries of the IFD at offset, by tag.
+func (t tiffReader) readIFD(offset uint32) (map[uint16]tiffEntry, bool) {
+	if offset < 8 {
+		return nil, false
+	}
+	count := make([]byte, 2)
+	if _, err := t.r.ReadAt(count, t.base+int64(offset)); err != nil {
+		return nil, false
+	}
+	n := min(int(t.order.Uint16(count)), maxIFDEntries)
+	raw := make([]byte, n*12)
+	if _, err := t.r.ReadAt(raw, t.base+int64(offset)+2); err != nil && err != io.EOF {
+		return nil, false
+	}
+
+	entries := make(map[uint16]tiffEntry, n)
+	for i := 0; i < n; i++ {
+		entry := raw[i*12 : i*12+12]
+		entries[t.order.Uint16(entry[0:2])] = tiffEntry{
+			kind:  t.order.Uint16(entry[2:4]),
+			count: t.order.Uint32(entry[4:8]),
+			value: entry[8:12],
+		}
+	}
+	return entries, true
+}
+
+// data returns the bytes of an entry's values.
+func (t tiffReader) data(entry tiffEntry) ([]byte, bool) {
+	size, ok := tiffTypeSizes[entry.kind]
+	if !ok || entry.count == 0 || entry.count > maxTIFFValueSize/size {
+		return nil, false
+	}
+	total := size * entry.count
+	if total <= 4 {
+		return entry.value[:total], true
+	}
+	buf := make([]byte, total)
+	if _, err := t.r.ReadAt(buf, t.base+int64(t.order.Uint32(entry.value))); err != nil {
+		return nil, false
+	}
+	return buf, true
+}
+
+// uint returns the first value of a BYTE, SHORT or LONG entry.
+func (t tiffReader) uint(entry tiffEntry) (uint32, bool) {
+	if entry.count == 0 {
+		return 0, false
+	}
+	switch entry.kind {
+	case 1:
+		return uint32(entry.value[0]), true
+	case 3:
+		return uint32(t.order.Uint16(entry.value)), true
+	case 4:
+		return t.order.Uint32(entry.value), true
+	default:
+		return 0, false
+	}
+}
+
+func (t tiffReader) ascii(entry tiffEntry) string {
+	if entry.kind != 2 {
+		return ""
+	}
+	data, ok := t.data(entry)
+	if !ok {
+		return ""
+	}
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		data = data[:end]
+	}
+	return strings.TrimSpace(strings.ToValidUTF8(string(data), ""))
+}
+
+// rationals returns the values of a RATIONAL or SRATIONAL entry; values with
+// a zero denominator are reported as 0.
+func (t tiffReader) rationals(entry tiffEntry) []float64 {
+	if entry.kind != 5 && entry.kind != 10 {
+		return nil
+	}
+	data, ok := t.data(entry)
+	if !ok {
+		return nil
+	}
+	values := make([]float64, 0, entry.count)
+	for i := 0; i+8 <= len(data); i += 8 {
+		numerator, denominator := t.order.Uint32(data[i:]), t.order.Uint32(data[i+4:])
+		if denominator == 0 {
+			values = append(values, 0)
+		} else if entry.kind == 10 {
+			values = append(values, float64(int32(numerator))/float64(int32(denominator)))
+		} else {
+			values = append(values, float64(numerator)/float64(denominator))
+		}
+	}
+	return values
+}
+
+func (t tiffReader) rational(entry tiffEntry) float64 {
+	if values := t.rationals(entry); len(values) > 0 {
+		return values[0]
+	}
+	return 0
+}
+
+func (t tiffReader) gpsPosition(gps map[uint16]tiffEntry) *GPSPosition {
+	latitude, latOK := gpsDegrees(t.rationals(gps[gpsTagLatitude]))
+	longitude, lonOK := gpsDegrees(t.rationals(gps[gpsTagLongitude]))
+	if !latOK || !lonOK {
+		return nil
+	}
+	if strings.EqualFold(t.ascii(gps[gpsTagLatitudeRef]), "S") {
+		latitude = -latitude
+	}
+	if strings.EqualFold(t.ascii(gps[gpsTagLongitudeRef]), "W") {
+		longitude = -longitude
+	}
+
+	position := &GPSPosition{Latitude: latitude, Longitude: longitude}
+	if altitude := t.rationals(gps[gpsTagAltitude]); len(altitude) > 0 {
+		value := altitude[0]
+		if ref, ok := t.uint(gps[gpsTagAltitudeRef]); ok && ref == 1 {
+			value = -value
+		}
+		position.Altitude = &value
+	}
+	return position
+}
+
+// gpsDegrees converts degrees, minutes and seconds to decimal degrees.
+func gpsDegrees(dms []float64) (float64, bool) {
+	if len(dms) != 3 {
+		return 0, false
+	}
+	return dms[0] + dms[1]/60 + dms[2]/3600, true
+}
+
+// locateTIFFHeader finds the TIFF structure that carries the EXIF IFDs: the
+// file itself for TIFF, the Exif APP1 segment for JPEG, the eXIf chunk for
+// PNG and the EXIF chunk for WebP.
+func locateTIFFHeader(r io.ReaderAt) (tiffReader, bool) {
+	head := make([]byte, 12)
+	if _, err := r.ReadAt(head, 0); err != nil && err != io.EOF {
+		return tiffReader{}, false
+	}
+
+	var base int64
+	var ok bool
+	switch {
+	case head[0] == 0xff && head[1] == 0xd8:
+		base, ok = locateJPEGEXIF(r)
+	case bytes.Equal(head[0:8], pngSignature):
+		base, ok = locatePNGChunk(r, "eXIf")
+	case bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		base, ok = locateWebPEXIF(r)
+	default:
+		ok = true
+	}
+	if !ok {
+		return tiffReader{}, false
+	}
+
+	header := make([]byte, 4)
+	if _, err := r.ReadAt(header, base); err != nil {
+		return tiffReader{}, false
+	}
+	order, ok := tiffByteOrder(header)
+	return tiffReader{r: r, base: base, order: order}, ok
+}
+
+func locateJPEGEXIF(r io.ReaderAt) (int64, bool) {
+	offset := int64(2)
+	marker := make([]byte, 4)
+	for range maxEXIFSegments {
+		if _, err := r.ReadAt(marker, offset); err != nil {
+			return 0, false
+		}
+		if marker[0] != 0xff {
+			return 0, false
+		}
+		// Start of scan: image data follows and no more metadata segments.
+		if marker[1] == 0xda || marker[1] == 0xd9 {
+			return 0, false
+		}
+		length := int64(binary.BigEndian.Uint16(marker[2:4]))
+		if length < 2 {
+			return 0, false
+		}
+		if marker[1] == 0xe1 && length >= 16 {
+			header := make([]byte, 6)
+			if _, err := r.ReadAt(header, offset+4); err == nil && bytes.Equal(header, []byte("Exif\x00\x00")) {
+				return offset + 10, true
+			}
+		}
+		offset += 2 + length
+	}
+	return 0, false
+}
+
+func locateWebPEXIF(r io.ReaderAt) (int64, bool) {
+	offset := int64(12)
+	chunk := make([]byte, 8)
+	for range maxEXIFSegments {
+		if _, err := r.ReadAt(chunk, offset); err != nil {
+			return 0, false
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		if bytes.Equal(chunk[0:4], []byte("EXIF")) {
+			// Some encoders keep the JPEG style "Exif" prefix in the chunk.
+			prefix := make([]byte, 6)
+			if _, err := r.ReadAt(prefix, offset+8); err == nil && bytes.Equal(prefix, []byte("Exif\x00\x00")) {
+				return offset + 14, true
+			}
+			return offset + 8, true
+		}
+		offset += 8 + size + size&1
+	}
+	return 0, false
+}
+
+func tiffByteOrder(header []byte) (binary.ByteOrder, bool) {
+	if len(header) < 4 {
+		return nil, false
+	}
+	switch {
+	case header[0] == 'I' && header[1] == 'I' && header[2] == 42 && header[3] == 0:
+		return binary.LittleEndian, true
+	case header[0] == 'M' && header[1] == 'M' && header[2] == 0 && header[3] == 42:
+		return binary.BigEndian, true
+	default:
+		return nil, false
+	}
+}
+
+// ApplyOrientation returns img turned upright according to an EXIF
+// orientation: orientations 2-8 mirror and/or rotate the pixels, and 5-8 swap
+// width and height.
+func ApplyOrientation(img *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := range dstHeight {
+		for x := range dstWidth {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = width-1-x, y
+			case 3:
+				sx, sy = width-1-x, height-1-y
+			case 4:
+				sx, sy = x, height-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, height-1-x
+			case 7:
+				sx, sy = width-1-y, height-1-x
+			case 8:
+				sx, sy = width-1-y, x
+			}
+			si := img.PixOffset(img.Rect.Min.X+sx, img.Rect.Min.Y+sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+	return dst
+}
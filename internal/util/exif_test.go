@@ -0,0 +1,109 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tiffWithOrientation builds a minimal TIFF header whose first IFD holds only
+// the orientation tag.
+func tiffWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	buf := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(buf, "II")
+	} else {
+		copy(buf, "MM")
+	}
+	order.PutUint16(buf[2:4], 42)
+	order.PutUint32(buf[4:8], 8)
+	order.PutUint16(buf[8:10], 1)
+	order.PutUint16(buf[10:12], tiffTagOrientation)
+	order.PutUint16(buf[12:14], 3)
+	order.PutUint32(buf[14:18], 1)
+	order.PutUint16(buf[18:20], orientation)
+	return buf
+}
+
+func jpegWithOrientation(t *testing.T, orientation uint16) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, image.NewGray(image.Rect(0, 0, 4, 4)), nil))
+
+	exif := append([]byte("Exif\x00\x00"), tiffWithOrientation(binary.BigEndian, orientation)...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(exif)+2))
+
+	data := append([]byte{}, encoded.Bytes()[:2]...)
+	data = append(data, segment...)
+	data = append(data, exif...)
+	return append(data, encoded.Bytes()[2:]...)
+}
+
+func TestImageOrientation(t *testing.T) {
+	var webp bytes.Buffer
+	require.NoError(t, EncodeWebPLossless(&webp, image.NewNRGBA(image.Rect(0, 0, 2, 2))))
+	exifChunk := tiffWithOrientation(binary.LittleEndian, 8)
+	chunkHeader := make([]byte, 8)
+	copy(chunkHeader, "EXIF")
+	binary.LittleEndian.PutUint32(chunkHeader[4:], uint32(len(exifChunk)))
+	webpWithEXIF := append(append(append([]byte{}, webp.Bytes()...), chunkHeader...), exifChunk...)
+
+	var plainJPEG bytes.Buffer
+	require.NoError(t, jpeg.Encode(&plainJPEG, image.NewGray(image.Rect(0, 0, 4, 4)), nil))
+
+	cases := map[string]struct {
+		data []byte
+		want int
+	}{
+		"jpeg":              {jpegWithOrientation(t, 6), 6},
+		"jpeg without exif": {plainJPEG.Bytes(), 1},
+		"tiff little":       {tiffWithOrientation(binary.LittleEndian, 3), 3},
+		"tiff big":          {tiffWithOrientation(binary.BigEndian, 5), 5},
+		"webp":              {webpWithEXIF, 8},
+		"out of range":      {tiffWithOrientation(binary.LittleEndian, 9), 1},
+		"not an image":      {[]byte("hello world"), 1},
+		"empty":             {nil, 1},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, ImageOrientation(bytes.NewReader(tc.data)))
+		})
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// A 3x2 image whose pixels are numbered in reading order:
+	//   1 2 3
+	//   4 5 6
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := range 6 {
+		src.SetNRGBA(i%3, i/3, color.NRGBA{R: uint8(i + 1), A: 255})
+	}
+
+	cases := map[int][]string{
+		1: {"123", "456"},
+		2: {"321", "654"},
+		3: {"654", "321"},
+		4: {"456", "123"},
+		5: {"14", "25", "36"},
+		6: {"41", "52", "63"},
+		7: {"63", "52", "41"},
+		8: {"36", "25", "14"},
+	}
+	for orientation, want := range cases {
+		dst := ApplyOrientation(src, orientation)
+		rows := make([]string, dst.Bounds().Dy())
+		for y := range rows {
+			for x := range dst.Bounds().Dx() {
+				rows[y] += string(rune('0' + dst.NRGBAAt(x, y).R))
+			}
+		}
+		require.Equal(t, want, rows, "orientation %d", orientation)
+	}
+}
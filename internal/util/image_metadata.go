@@ -0,0 +1,183 @@
+package util
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"io"
+	"strings"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// maxPNGTextEntries and maxPNGTextSize bound the text chunks kept for
+	// one image; chunks over maxPNGTextChunk are skipped unread.
+	maxPNGTextEntries = 64
+	maxPNGTextSize    = 4096
+	maxPNGTextChunk   = 1 << 20
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// ImageMetadata is what ReadImageMetadata reports about an image. Width and
+// Height are the displayed dimensions: for EXIF orientations 5-8 they are
+// the stored dimensions swapped.
+type ImageMetadata struct {
+	Format string
+	Width  int
+	Height int
+	EXIF   *EXIF
+	// Text holds PNG tEXt, zTXt and iTXt chunks by keyword.
+	Text map[string]string
+}
+
+// ReadImageMetadata reads the dimensions, EXIF data and PNG text of the image
+// in r without decoding its pixels.
+func ReadImageMetadata(r io.ReaderAt, size int64) (ImageMetadata, error) {
+	config, format, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return ImageMetadata{}, err
+	}
+
+	metadata := ImageMetadata{Format: format, Width: config.Width, Height: config.Height}
+	if exif, ok := ReadEXIF(r); ok {
+		metadata.EXIF = &exif
+		if exif.Orientation >= 5 {
+			metadata.Width, metadata.Height = metadata.Height, metadata.Width
+		}
+	}
+	if format == "png" {
+		metadata.Text = readPNGText(r)
+	}
+	return metadata, nil
+}
+
+// locatePNGChunk returns the offset of the data of the first chunk of type
+// name.
+func locatePNGChunk(r io.ReaderAt, name string) (int64, bool) {
+	found := int64(-1)
+	walkPNGChunks(r, func(kind string, offset int64, _ uint32) bool {
+		if kind == name {
+			found = offset
+			return false
+		}
+		return true
+	})
+	return found, found >= 0
+}
+
+// walkPNGChunks calls fn with the type, data offset and data length of each
+// chunk until fn returns false or the image ends.
+func walkPNGChunks(r io.ReaderAt, fn func(kind string, offset int64, length uint32) bool) {
+	offset := int64(len(pngSignature))
+	header := make([]byte, 8)
+	for range maxPNGChunks {
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		kind := string(header[4:8])
+		if kind == "IEND" || !fn(kind, offset+8, length) {
+			return
+		}
+		offset += 12 + int64(length)
+	}
+}
+
+func readPNGText(r io.ReaderAt) map[string]string {
+	text := map[string]string{}
+	walkPNGChunks(r, func(kind string, offset int64, length uint32) bool {
+		if kind != "tEXt" && kind != "zTXt" && kind != "iTXt" {
+			return true
+		}
+		if length > maxPNGTextChunk {
+			return true
+		}
+		data := make([]byte, length)
+		if _, err := r.ReadAt(data, offset); err != nil {
+			return false
+		}
+		if keyword, value, ok := parsePNGText(kind, data); ok {
+			text[keyword] = value
+		}
+		return len(text) < maxPNGTextEntries
+	})
+	if len(text) == 0 {
+		return nil
+	}
+	return text
+}
+
+// parsePNGText decodes one text chunk. tEXt and zTXt are Latin-1, iTXt is
+// UTF-8; zTXt and compressed iTXt use zlib.
+func parsePNGText(kind string, data []byte) (string, string, bool) {
+	keyword, rest, found := bytes.Cut(data, []byte{0})
+	if !found || len(keyword) == 0 {
+		return "", "", false
+	}
+
+	var value []byte
+	latin1 := true
+	switch kind {
+	case "tEXt":
+		value = rest
+	case "zTXt":
+		if len(rest) < 1 {
+			return "", "", false
+		}
+		value = inflatePNGText(rest[1:])
+	case "iTXt":
+		if len(rest) < 2 {
+			return "", "", false
+		}
+		compressed := rest[0] == 1
+		// Skip the language tag and the translated keyword.
+		_, rest, found = bytes.Cut(rest[2:], []byte{0})
+		if !found {
+			return "", "", false
+		}
+		_, rest, found = bytes.Cut(rest, []byte{0})
+		if !found {
+			return "", "", false
+		}
+		value = rest
+		if compressed {
+			value = inflatePNGText(rest)
+		}
+		latin1 = false
+	}
+	if len(value) > maxPNGTextSize {
+		value = value[:maxPNGTextSize]
+	}
+
+	if latin1 {
+		return decodeLatin1(keyword), decodeLatin1(value), true
+	}
+	return decodeLatin1(keyword), strings.ToValidUTF8(string(value), ""), true
+}
+
+func inflatePNGText(data []byte) []byte {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+	value, _ := io.ReadAll(io.LimitReader(reader, maxPNGTextSize))
+	return value
+}
+
+func decodeLatin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
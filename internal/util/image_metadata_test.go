@@ -0,0 +1,219 @@
+package util
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTIFFTag struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	data  []byte
+}
+
+func asciiTag(tag uint16, value string) testTIFFTag {
+	return testTIFFTag{tag: tag, kind: 2, count: uint32(len(value) + 1), data: append([]byte(value), 0)}
+}
+
+func shortTag(order binary.ByteOrder, tag uint16, value uint16) testTIFFTag {
+	data := make([]byte, 2)
+	order.PutUint16(data, value)
+	return testTIFFTag{tag: tag, kind: 3, count: 1, data: data}
+}
+
+func rationalTag(order binary.ByteOrder, tag uint16, values ...uint32) testTIFFTag {
+	data := make([]byte, 4*len(values))
+	for i, value := range values {
+		order.PutUint32(data[i*4:], value)
+	}
+	return testTIFFTag{tag: tag, kind: 5, count: uint32(len(values) / 2), data: data}
+}
+
+// buildTIFF lays out a TIFF structure with IFD0 and, when given, EXIF and GPS
+// sub-IFDs that IFD0 points to.
+func buildTIFF(order binary.ByteOrder, ifd0, exif, gps []testTIFFTag) []byte {
+	ifdSize := func(tags []testTIFFTag) int {
+		if len(tags) == 0 {
+			return 0
+		}
+		return 2 + 12*len(tags) + 4
+	}
+	if len(exif) > 0 {
+		ifd0 = append(ifd0, testTIFFTag{tag: tiffTagExifIFD, kind: 4, count: 1})
+	}
+	if len(gps) > 0 {
+		ifd0 = append(ifd0, testTIFFTag{tag: tiffTagGPSIFD, kind: 4, count: 1})
+	}
+	exifOffset := 8 + ifdSize(ifd0)
+	gpsOffset := exifOffset + ifdSize(exif)
+	dataOffset := gpsOffset + ifdSize(gps)
+
+	header := make([]byte, 8)
+	if order == binary.LittleEndian {
+		copy(header, "II")
+	} else {
+		copy(header, "MM")
+	}
+	order.PutUint16(header[2:], 42)
+	order.PutUint32(header[4:], 8)
+
+	var ifds, data []byte
+	writeIFD := func(tags []testTIFFTag) {
+		if len(tags) == 0 {
+			return
+		}
+		buf := make([]byte, ifdSize(tags))
+		order.PutUint16(buf, uint16(len(tags)))
+		for i, tag := range tags {
+			entry := buf[2+i*12:]
+			order.PutUint16(entry[0:], tag.tag)
+			order.PutUint16(entry[2:], tag.kind)
+			order.PutUint32(entry[4:], tag.count)
+			switch {
+			case tag.tag == tiffTagExifIFD:
+				order.PutUint32(entry[8:], uint32(exifOffset))
+			case tag.tag == tiffTagGPSIFD:
+				order.PutUint32(entry[8:], uint32(gpsOffset))
+			case len(tag.data) <= 4:
+				copy(entry[8:12], tag.data)
+			default:
+				order.PutUint32(entry[8:], uint32(dataOffset+len(data)))
+				data = append(data, tag.data...)
+			}
+		}
+		ifds = append(ifds, buf...)
+	}
+	writeIFD(ifd0)
+	writeIFD(exif)
+	writeIFD(gps)
+	return append(append(header, ifds...), data...)
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], kind)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestReadImageMetadataJPEG(t *testing.T) {
+	order := binary.BigEndian
+	tiff := buildTIFF(order,
+		[]testTIFFTag{
+			asciiTag(tiffTagMake, "Canon"),
+			asciiTag(tiffTagModel, "Canon EOS R6"),
+			shortTag(order, tiffTagOrientation, 6),
+			asciiTag(tiffTagDateTime, "2024:06:01 10:00:00"),
+		},
+		[]testTIFFTag{
+			rationalTag(order, exifTagExposureTime, 1, 250),
+			rationalTag(order, exifTagFNumber, 28, 10),
+			shortTag(order, exifTagISO, 400),
+			asciiTag(exifTagDateTimeOriginal, "2024:05:31 18:30:15"),
+			asciiTag(exifTagOffsetOriginal, "+02:00"),
+			rationalTag(order, exifTagFocalLength, 50, 1),
+			asciiTag(exifTagLensModel, "RF50mm F1.8 STM"),
+		},
+		[]testTIFFTag{
+			asciiTag(gpsTagLatitudeRef, "N"),
+			rationalTag(order, gpsTagLatitude, 40, 1, 24, 1, 3000, 100),
+			asciiTag(gpsTagLongitudeRef, "W"),
+			rationalTag(order, gpsTagLongitude, 3, 1, 42, 1, 0, 1),
+			{tag: gpsTagAltitudeRef, kind: 1, count: 1, data: []byte{0}},
+			rationalTag(order, gpsTagAltitude, 6550, 10),
+		},
+	)
+
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, image.NewGray(image.Rect(0, 0, 40, 30)), nil))
+	exif := append([]byte("Exif\x00\x00"), tiff...)
+	segment := binary.BigEndian.AppendUint16([]byte{0xff, 0xe1}, uint16(len(exif)+2))
+	data := append(append(append([]byte{0xff, 0xd8}, segment...), exif...), encoded.Bytes()[2:]...)
+
+	metadata, err := ReadImageMetadata(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", metadata.Format)
+	// Orientation 6 turns the 40x30 image into a 30x40 one.
+	require.Equal(t, 30, metadata.Width)
+	require.Equal(t, 40, metadata.Height)
+	require.Nil(t, metadata.Text)
+
+	got := metadata.EXIF
+	require.NotNil(t, got)
+	require.Equal(t, 6, got.Orientation)
+	require.Equal(t, "Canon", got.Make)
+	require.Equal(t, "Canon EOS R6", got.Model)
+	require.Equal(t, "RF50mm F1.8 STM", got.LensModel)
+	require.Equal(t, 400, got.ISO)
+	require.InDelta(t, 0.004, got.ExposureTime, 1e-9)
+	require.InDelta(t, 2.8, got.FNumber, 1e-9)
+	require.InDelta(t, 50, got.FocalLength, 1e-9)
+	require.True(t, got.CapturedAtOffset)
+	require.True(t, got.CapturedAt.Equal(time.Date(2024, 5, 31, 16, 30, 15, 0, time.UTC)))
+
+	require.NotNil(t, got.GPS)
+	require.InDelta(t, 40.4083333, got.GPS.Latitude, 1e-6)
+	require.InDelta(t, -3.7, got.GPS.Longitude, 1e-9)
+	require.NotNil(t, got.GPS.Altitude)
+	require.InDelta(t, 655, *got.GPS.Altitude, 1e-9)
+}
+
+func TestReadImageMetadataPNG(t *testing.T) {
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 12, 7))))
+	raw := encoded.Bytes()
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	_, _ = writer.Write([]byte("A long description"))
+	require.NoError(t, writer.Close())
+
+	chunks := pngChunk("tEXt", []byte("Title\x00Caf\xe9"))
+	chunks = append(chunks, pngChunk("zTXt", append([]byte("Description\x00\x00"), compressed.Bytes()...))...)
+	chunks = append(chunks, pngChunk("iTXt", []byte("Author\x00\x00\x00en\x00Autor\x00Zoë"))...)
+	chunks = append(chunks, pngChunk("eXIf", buildTIFF(binary.LittleEndian, []testTIFFTag{asciiTag(tiffTagSoftware, "GIMP 2.10")}, nil, nil))...)
+
+	// Insert the chunks after IHDR (signature + 25 byte chunk).
+	data := append(append(append([]byte{}, raw[:33]...), chunks...), raw[33:]...)
+
+	metadata, err := ReadImageMetadata(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Equal(t, "png", metadata.Format)
+	require.Equal(t, 12, metadata.Width)
+	require.Equal(t, 7, metadata.Height)
+	require.Equal(t, map[string]string{
+		"Title":       "Café",
+		"Description": "A long description",
+		"Author":      "Zoë",
+	}, metadata.Text)
+	require.NotNil(t, metadata.EXIF)
+	require.Equal(t, "GIMP 2.10", metadata.EXIF.Software)
+}
+
+func TestReadImageMetadataRejectsNonImages(t *testing.T) {
+	data := []byte("definitely not an image")
+	_, err := ReadImageMetadata(bytes.NewReader(data), int64(len(data)))
+	require.Error(t, err)
+}
+
+func TestReadEXIFIgnoresCorruptOffsets(t *testing.T) {
+	order := binary.LittleEndian
+	tiff := buildTIFF(order, []testTIFFTag{asciiTag(tiffTagModel, "Pixel 8 Pro")}, nil, nil)
+	// Point the model string past the end of the data.
+	order.PutUint32(tiff[8+2+8:], 1<<20)
+
+	exif, ok := ReadEXIF(bytes.NewReader(tiff))
+	require.True(t, ok)
+	require.Empty(t, exif.Model)
+}
@@ -0,0 +1,214 @@
+package util
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// postgresMaxRepeat is the largest repetition count PostgreSQL accepts.
+const postgresMaxRepeat = 255
+
+// asciiWord is the class of characters Go's \b treats as word characters.
+const asciiWord = `[0-9A-Za-z_]`
+
+// PostgresRegex rewrites a Go regular expression as a PostgreSQL advanced
+// regular expression that matches the same strings, so a name filter gives
+// the same results whether it runs in Go or in SQL. Only whether a string
+// matches is kept: groups do not capture and greediness is dropped. Every
+// character other than an ASCII letter or digit is written as a \U escape,
+// classes as explicit ranges, and \b and multi-line anchors as lookaround,
+// since PostgreSQL reads those differently. Patterns using repetition
+// counts above 255 are rejected.
+func PostgresRegex(pattern string) (string, error) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := writePostgresRegex(&out, parsed); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func writePostgresRegex(out *strings.Builder, re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		writePostgresClass(out, nil)
+	case syntax.OpEmptyMatch:
+		out.WriteString(`(?:)`)
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				writePostgresClass(out, foldRanges(r))
+			} else {
+				writePostgresRune(out, r)
+			}
+		}
+	case syntax.OpCharClass:
+		writePostgresClass(out, re.Rune)
+	case syntax.OpAnyCharNotNL:
+		writePostgresClass(out, []rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune})
+	case syntax.OpAnyChar:
+		// Outside newline-sensitive mode "." matches newlines as well.
+		out.WriteString(`.`)
+	case syntax.OpBeginLine:
+		out.WriteString(`(?:^|(?<=\U0000000A))`)
+	case syntax.OpEndLine:
+		out.WriteString(`(?:$|(?=\U0000000A))`)
+	case syntax.OpBeginText:
+		out.WriteString(`^`)
+	case syntax.OpEndText:
+		out.WriteString(`$`)
+	case syntax.OpWordBoundary:
+		out.WriteString(`(?:(?<=` + asciiWord + `)(?!` + asciiWord + `)|(?<!` + asciiWord + `)(?=` + asciiWord + `))`)
+	case syntax.OpNoWordBoundary:
+		out.WriteString(`(?:(?<=` + asciiWord + `)(?=` + asciiWord + `)|(?<!` + asciiWord + `)(?!` + asciiWord + `))`)
+	case syntax.OpCapture:
+		return writePostgresGroup(out, re.Sub[0], "")
+	case syntax.OpStar:
+		return writePostgresGroup(out, re.Sub[0], "*")
+	case syntax.OpPlus:
+		return writePostgresGroup(out, re.Sub[0], "+")
+	case syntax.OpQuest:
+		return writePostgresGroup(out, re.Sub[0], "?")
+	case syntax.OpRepeat:
+		if re.Min > postgresMaxRepeat || re.Max > postgresMaxRepeat {
+			return fmt.Errorf("repetition counts above %d are not supported", postgresMaxRepeat)
+		}
+		bounds := fmt.Sprintf("{%d,%d}", re.Min, re.Max)
+		if re.Max == -1 {
+			bounds = fmt.Sprintf("{%d,}", re.Min)
+		} else if re.Min == re.Max {
+			bounds = fmt.Sprintf("{%d}", re.Min)
+		}
+		return writePostgresGroup(out, re.Sub[0], bounds)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := writePostgresRegex(out, sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		out.WriteString(`(?:`)
+		for i, sub := range re.Sub {
+			if i > 0 {
+				out.WriteString(`|`)
+			}
+			if err := writePostgresRegex(out, sub); err != nil {
+				return err
+			}
+		}
+		out.WriteString(`)`)
+	default:
+		return fmt.Errorf("unsupported regular expression operator %v", re.Op)
+	}
+	return nil
+}
+
+func writePostgresGroup(out *strings.Builder, sub *syntax.Regexp, quantifier string) error {
+	out.WriteString(`(?:`)
+	if err := writePostgresRegex(out, sub); err != nil {
+		return err
+	}
+	out.WriteString(`)` + quantifier)
+	return nil
+}
+
+// writePostgresClass writes the class of the lo-hi rune pairs in ranges.
+// Surrogates never occur in text and are left out. A class containing NUL,
+// which cannot be written in a pattern, is written as the negation of its
+// complement.
+func writePostgresClass(out *strings.Builder, ranges []rune) {
+	ranges = withoutSurrogates(ranges)
+	negate := len(ranges) > 0 && ranges[0] == 0
+	if negate {
+		ranges = withoutSurrogates(complementRanges(ranges))
+		if len(ranges) == 0 {
+			out.WriteString(`.`)
+			return
+		}
+	}
+	if len(ranges) == 0 {
+		// Nothing but NUL, which never occurs in text.
+		out.WriteString(`[^\U00000001-\U0010FFFF]`)
+		return
+	}
+
+	out.WriteString(`[`)
+	if negate {
+		out.WriteString(`^`)
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		writePostgresRune(out, ranges[i])
+		if ranges[i+1] > ranges[i] {
+			out.WriteString(`-`)
+			writePostgresRune(out, ranges[i+1])
+		}
+	}
+	out.WriteString(`]`)
+}
+
+func writePostgresRune(out *strings.Builder, r rune) {
+	if r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' {
+		out.WriteRune(r)
+		return
+	}
+	fmt.Fprintf(out, `\U%08X`, r)
+}
+
+// foldRanges returns the runes that match r case-insensitively as lo-hi
+// pairs.
+func foldRanges(r rune) []rune {
+	orbit := []rune{r}
+	for folded := unicode.SimpleFold(r); folded != r; folded = unicode.SimpleFold(folded) {
+		orbit = append(orbit, folded)
+	}
+	sort.Slice(orbit, func(i, j int) bool { return orbit[i] < orbit[j] })
+
+	ranges := make([]rune, 0, 2*len(orbit))
+	for _, c := range orbit {
+		ranges = append(ranges, c, c)
+	}
+	return ranges
+}
+
+// complementRanges returns the runes up to unicode.MaxRune that are not in
+// the sorted lo-hi pairs of ranges.
+func complementRanges(ranges []rune) []rune {
+	complement := make([]rune, 0, len(ranges)+2)
+	next := rune(0)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] > next {
+			complement = append(complement, next, ranges[i]-1)
+		}
+		next = ranges[i+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		complement = append(complement, next, unicode.MaxRune)
+	}
+	return complement
+}
+
+func withoutSurrogates(ranges []rune) []rune {
+	const surrogateLo, surrogateHi = 0xD800, 0xDFFF
+	clipped := make([]rune, 0, len(ranges)+2)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if hi < surrogateLo || lo > surrogateHi {
+			clipped = append(clipped, lo, hi)
+			continue
+		}
+		if lo < surrogateLo {
+			clipped = append(clipped, lo, surrogateLo-1)
+		}
+		if hi > surrogateHi {
+			clipped = append(clipped, surrogateHi+1, hi)
+		}
+	}
+	return clipped
+}
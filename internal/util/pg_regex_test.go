@@ -0,0 +1,43 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresRegex(t *testing.T) {
+	t.Parallel()
+
+	const word = `[0-9A-Za-z_]`
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`report\z`, `report$`},
+		{`(?P<n>q)3`, `(?:q)3`},
+		{`\bq3\b`, `(?:(?<=` + word + `)(?!` + word + `)|(?<!` + word + `)(?=` + word + `))q3(?:(?<=` + word + `)(?!` + word + `)|(?<!` + word + `)(?=` + word + `))`},
+		{`(?i)k`, `[Kk\U0000212A]`},
+		{`^a.b$`, `^a[^\U0000000A]b$`},
+		{`(?s)a.b`, `a.b`},
+		{`(?m)^x`, `(?:^|(?<=\U0000000A))x`},
+		{`[^a]`, `[^a]`},
+		{`a{2,5}b{3}c{1,}`, `(?:a){2,5}(?:b){3}(?:c){1,}`},
+		{`x\.csv|y`, `(?:x\U0000002Ecsv|y)`},
+	}
+	for _, tt := range tests {
+		got, err := PostgresRegex(tt.pattern)
+		require.NoError(t, err, tt.pattern)
+		require.Equal(t, tt.want, got, tt.pattern)
+	}
+}
+
+func TestPostgresRegexRejectsLargeRepeats(t *testing.T) {
+	t.Parallel()
+
+	_, err := PostgresRegex(`a{256}`)
+	require.ErrorContains(t, err, "repetition counts above 255")
+
+	_, err = PostgresRegex(`(unclosed`)
+	require.Error(t, err)
+}
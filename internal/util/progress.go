@@ -0,0 +1,174 @@
+package util
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ProgressReport is a snapshot of a byte-level transfer. Throughput is the
+// average rate in bytes per second since the first byte; ETASeconds is only
+// set once a rate and a total are known.
+type ProgressReport struct {
+	BytesDone   int64
+	BytesTotal  int64
+	CurrentItem string
+	Throughput  float64
+	ETASeconds  int64
+}
+
+// Progress accumulates bytes copied by CopyWithContext and the archive helpers
+// and hands throttled reports to a callback. All methods are safe on a nil
+// *Progress, so callers never need to check whether progress is tracked.
+type Progress struct {
+	interval time.Duration
+	report   func(ProgressReport)
+
+	mu       sync.Mutex
+	done     int64
+	total    int64
+	item     string
+	started  time.Time
+	lastSent time.Time
+}
+
+type progressKey struct{}
+
+// NewProgress returns a tracker that calls report at most once per interval.
+func NewProgress(interval time.Duration, report func(ProgressReport)) *Progress {
+	return &Progress{interval: interval, report: report}
+}
+
+// WithProgress attaches p to ctx so the copy helpers further down can report
+// to it.
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// ProgressFromContext returns the tracker attached to ctx, or nil.
+func ProgressFromContext(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	return p
+}
+
+// AddTotal grows the number of bytes the transfer is expected to move.
+func (p *Progress) AddTotal(n int64) {
+	if p == nil || n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	p.total += n
+	p.mu.Unlock()
+}
+
+// SetItem records the item currently being transferred and reports it.
+func (p *Progress) SetItem(item string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.item = item
+	p.mu.Unlock()
+	p.emit(false)
+}
+
+// Add records n transferred bytes.
+func (p *Progress) Add(n int64) {
+	if p == nil || n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	if p.started.IsZero() {
+		p.started = time.Now()
+	}
+	p.done += n
+	p.mu.Unlock()
+	p.emit(false)
+}
+
+// Flush reports the current state regardless of the throttle.
+func (p *Progress) Flush() {
+	if p == nil {
+		return
+	}
+	p.emit(true)
+}
+
+// Snapshot returns the current state without reporting it.
+func (p *Progress) Snapshot() ProgressReport {
+	if p == nil {
+		return ProgressReport{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.snapshotLocked(time.Now())
+}
+
+func (p *Progress) emit(force bool) {
+	now := time.Now()
+
+	p.mu.Lock()
+	if !force && now.Sub(p.lastSent) < p.interval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastSent = now
+	report := p.snapshotLocked(now)
+	p.mu.Unlock()
+
+	if p.report != nil {
+		p.report(report)
+	}
+}
+
+func (p *Progress) snapshotLocked(now time.Time) ProgressReport {
+	report := ProgressReport{BytesDone: p.done, BytesTotal: p.total, CurrentItem: p.item}
+	if p.started.IsZero() {
+		return report
+	}
+
+	elapsed := now.Sub(p.started).Seconds()
+	if elapsed <= 0 {
+		return report
+	}
+	report.Throughput = float64(p.done) / elapsed
+	if report.Throughput > 0 && p.total > p.done {
+		report.ETASeconds = int64(float64(p.total-p.done)/report.Throughput + 0.5)
+	}
+	return report
+}
+
+// TreeSize returns the total size of the regular files under path, or the
+// size of path itself when it is a file. Symlinks are not followed.
+func TreeSize(path string) (int64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		if !info.Mode().IsRegular() {
+			return 0, nil
+		}
+		return info.Size(), nil
+	}
+
+	var total int64
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		entryInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += entryInfo.Size()
+		return nil
+	})
+	return total, err
+}
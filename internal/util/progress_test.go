@@ -0,0 +1,70 @@
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressReportsArchiveBytes(t *testing.T) {
+	t.Parallel()
+
+	sourceDir := filepath.Join(t.TempDir(), "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "nested"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte(strings.Repeat("a", 1000)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "nested", "b.txt"), []byte(strings.Repeat("b", 500)), 0o644))
+
+	var mu sync.Mutex
+	var reports []ProgressReport
+	progress := NewProgress(0, func(report ProgressReport) {
+		mu.Lock()
+		reports = append(reports, report)
+		mu.Unlock()
+	})
+
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	require.NoError(t, Compress(WithProgress(context.Background(), progress), []string{sourceDir}, zipPath))
+
+	final := progress.Snapshot()
+	require.Equal(t, int64(1500), final.BytesTotal)
+	require.Equal(t, int64(1500), final.BytesDone)
+	require.NotEmpty(t, reports)
+	require.Equal(t, "src/nested/b.txt", reports[len(reports)-1].CurrentItem)
+
+	extracted := NewProgress(0, nil)
+	_, err := Decompress(WithProgress(context.Background(), extracted), zipPath, t.TempDir(), ExtractOptions{}, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), extracted.Snapshot().BytesTotal)
+	require.Equal(t, int64(1500), extracted.Snapshot().BytesDone)
+}
+
+func TestProgressThrottlesAndIsNilSafe(t *testing.T) {
+	t.Parallel()
+
+	var nilProgress *Progress
+	nilProgress.AddTotal(10)
+	nilProgress.Add(10)
+	nilProgress.SetItem("x")
+	nilProgress.Flush()
+	require.Nil(t, ProgressFromContext(context.Background()))
+
+	calls := 0
+	progress := NewProgress(1<<62, func(ProgressReport) { calls++ })
+	progress.AddTotal(100)
+	for i := 0; i < 10; i++ {
+		progress.Add(10)
+	}
+	require.Equal(t, 1, calls)
+
+	progress.Flush()
+	require.Equal(t, 2, calls)
+
+	report := progress.Snapshot()
+	require.Equal(t, int64(100), report.BytesDone)
+	require.Zero(t, report.ETASeconds)
+}
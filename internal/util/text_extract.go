@@ -0,0 +1,247 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MaxExtractedText caps the text kept for one file. PostgreSQL rejects
+// tsvectors over 1 MB, so the cap leaves room for positions.
+const MaxExtractedText = 256 * 1024
+
+// maxOfficePartSize bounds how much of one decompressed XML part is read, so a
+// zip bomb cannot keep the extractor busy.
+const maxOfficePartSize = 64 * 1024 * 1024
+
+// maxStructuredTextSize bounds how much of a CSV, TSV or JSON file is parsed,
+// so whitespace or skipped tokens cannot keep the extractor reading a huge
+// file that adds no text.
+const maxStructuredTextSize = 64 * 1024 * 1024
+
+// officeTextParts lists, per Office Open XML format, the archive parts that
+// hold the document text.
+var officeTextParts = map[string][]string{
+	".docx": {"word/document.xml", "word/header*.xml", "word/footer*.xml", "word/footnotes.xml", "word/endnotes.xml"},
+	".xlsx": {"xl/sharedStrings.xml", "xl/worksheets/sheet*.xml"},
+	".pptx": {"ppt/slides/slide*.xml", "ppt/notesSlides/notesSlide*.xml"},
+}
+
+// IsTextExtractable reports whether ExtractText can read the content of files
+// with the given extension.
+func IsTextExtractable(extension string) bool {
+	switch strings.ToLower(strings.TrimSpace(extension)) {
+	case ".txt", ".text", ".log", ".md", ".markdown", ".rst", ".csv", ".tsv", ".json", ".xml", ".yaml", ".yml", ".toml", ".ini", ".cfg", ".conf", ".env",
+		".html", ".htm", ".css", ".scss", ".js", ".mjs", ".jsx", ".ts", ".tsx", ".go", ".py", ".rb", ".php", ".java", ".kt", ".scala", ".c", ".h", ".cc", ".cpp", ".hpp",
+		".cs", ".rs", ".swift", ".sh", ".bash", ".ps1", ".bat", ".sql", ".lua", ".pl", ".r", ".vue", ".svelte", ".tex",
+		".docx", ".xlsx", ".pptx":
+		return true
+	default:
+		return false
+	}
+}
+
+// ExtractText returns the searchable text of the file at filePath, at most
+// limit bytes. Files that turn out to be binary yield no text.
+func ExtractText(filePath string, limit int) (string, error) {
+	if limit <= 0 {
+		limit = MaxExtractedText
+	}
+	text := &textBuilder{limit: limit}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if _, ok := officeTextParts[ext]; ok {
+		if err := extractOfficeText(filePath, ext, text); err != nil {
+			return "", err
+		}
+		return text.String(), nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	switch ext {
+	case ".csv", ".tsv":
+		extractDelimitedText(file, ext, text)
+	case ".json":
+		extractJSONText(file, text)
+	default:
+		if err := extractPlainText(file, text); err != nil {
+			return "", err
+		}
+	}
+	return text.String(), nil
+}
+
+// textBuilder collects extracted text up to limit bytes.
+type textBuilder struct {
+	sb    strings.Builder
+	limit int
+}
+
+func (b *textBuilder) full() bool {
+	return b.sb.Len() >= b.limit
+}
+
+func (b *textBuilder) write(text string) {
+	if remaining := b.limit - b.sb.Len(); len(text) > remaining {
+		text = text[:max(remaining, 0)]
+	}
+	b.sb.WriteString(text)
+}
+
+// space separates the next write from the text so far.
+func (b *textBuilder) space() {
+	current := b.sb.String()
+	if current != "" && !strings.HasSuffix(current, " ") {
+		b.write(" ")
+	}
+}
+
+func (b *textBuilder) String() string {
+	cleaned := strings.ToValidUTF8(b.sb.String(), "")
+	return strings.TrimSpace(strings.ReplaceAll(cleaned, "\x00", ""))
+}
+
+func extractPlainText(reader io.Reader, text *textBuilder) error {
+	content, err := io.ReadAll(io.LimitReader(reader, int64(text.limit)))
+	if err != nil {
+		return err
+	}
+
+	sniff := content[:min(len(content), 8192)]
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return nil
+	}
+	text.write(string(content))
+	return nil
+}
+
+func extractDelimitedText(reader io.Reader, ext string, text *textBuilder) {
+	records := csv.NewReader(io.LimitReader(reader, maxStructuredTextSize))
+	records.FieldsPerRecord = -1
+	records.LazyQuotes = true
+	if ext == ".tsv" {
+		records.Comma = '\t'
+	}
+
+	for !text.full() {
+		record, err := records.Read()
+		if err != nil {
+			return
+		}
+		for _, field := range record {
+			if field = strings.TrimSpace(field); field != "" {
+				text.write(field)
+				text.space()
+			}
+		}
+	}
+}
+
+// extractJSONText keeps the keys and scalar values of a JSON document.
+func extractJSONText(reader io.Reader, text *textBuilder) {
+	decoder := json.NewDecoder(io.LimitReader(reader, maxStructuredTextSize))
+	decoder.UseNumber()
+
+	for !text.full() {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		switch value := token.(type) {
+		case string:
+			text.write(value)
+			text.space()
+		case json.Number:
+			text.write(value.String())
+			text.space()
+		}
+	}
+}
+
+func extractOfficeText(filePath string, ext string, text *textBuilder) error {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return fmt.Errorf("open office document: %w", err)
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if text.full() {
+			break
+		}
+		if !matchesOfficePart(file.Name, officeTextParts[ext]) {
+			continue
+		}
+		if err := extractOfficePart(file, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchesOfficePart(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// extractOfficePart reads the text runs (w:t, a:t, t) of one XML part. Runs
+// of a paragraph are joined as-is, since Word splits words across runs.
+func extractOfficePart(file *zip.File, text *textBuilder) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(reader, maxOfficePartSize))
+	inText := false
+	for !text.full() {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", file.Name, err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab", "br":
+				text.space()
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "t":
+				inText = false
+			case "p", "si", "c":
+				text.space()
+			}
+		case xml.CharData:
+			if inText {
+				text.write(string(element))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTextFormats(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		filePath := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+		return filePath
+	}
+
+	text, err := ExtractText(write("notes.md", "# Quarterly plan\nShip the *index*."), 0)
+	require.NoError(t, err)
+	require.Equal(t, "# Quarterly plan\nShip the *index*.", text)
+
+	text, err = ExtractText(write("people.csv", "name,city\nAna,\"Bogotá, DC\"\n"), 0)
+	require.NoError(t, err)
+	require.Equal(t, "name city Ana Bogotá, DC", text)
+
+	text, err = ExtractText(write("config.json", `{"service":"files","port":8080,"tags":["alpha",true]}`), 0)
+	require.NoError(t, err)
+	require.Equal(t, "service files port 8080 tags alpha", text)
+
+	text, err = ExtractText(write("binary.txt", "abc\x00def"), 0)
+	require.NoError(t, err)
+	require.Empty(t, text)
+
+	text, err = ExtractText(write("long.txt", "abcdefghij"), 4)
+	require.NoError(t, err)
+	require.Equal(t, "abcd", text)
+}
+
+func TestExtractTextStopsReadingStructuredFilesAtSizeCap(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	padding := strings.Repeat(" ", maxStructuredTextSize)
+
+	jsonPath := filepath.Join(dir, "padded.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`["early",`+padding+`"late"]`), 0o644))
+	text, err := ExtractText(jsonPath, 0)
+	require.NoError(t, err)
+	require.Equal(t, "early", text)
+
+	csvPath := filepath.Join(dir, "padded.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("early\n"+strings.Repeat("\n", maxStructuredTextSize)+"late\n"), 0o644))
+	text, err = ExtractText(csvPath, 0)
+	require.NoError(t, err)
+	require.Equal(t, "early", text)
+}
+
+func TestExtractTextOfficeDocuments(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeZip := func(name string, parts map[string]string) string {
+		filePath := filepath.Join(dir, name)
+		file, err := os.Create(filePath)
+		require.NoError(t, err)
+		archive := zip.NewWriter(file)
+		for partName, content := range parts {
+			part, err := archive.Create(partName)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, archive.Close())
+		require.NoError(t, file.Close())
+		return filePath
+	}
+
+	docx := writeZip("report.docx", map[string]string{
+		"word/document.xml": `<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>Annual</w:t></w:r><w:r><w:t>report</w:t></w:r></w:p><w:p><w:r><w:t>Second</w:t></w:r></w:p></w:body></w:document>`,
+		"word/styles.xml":   `<w:styles xmlns:w="w"><w:t>ignored</w:t></w:styles>`,
+	})
+	text, err := ExtractText(docx, 0)
+	require.NoError(t, err)
+	require.Equal(t, "Annualreport Second", text)
+
+	xlsx := writeZip("budget.xlsx", map[string]string{
+		"xl/sharedStrings.xml":     `<sst><si><t>Revenue</t></si><si><t>Costs</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row><c t="inlineStr"><is><t>Inline</t></is></c><c><v>0</v></c></row></sheetData></worksheet>`,
+	})
+	text, err = ExtractText(xlsx, 0)
+	require.NoError(t, err)
+	require.Contains(t, text, "Revenue Costs")
+	require.Contains(t, text, "Inline")
+	require.NotContains(t, text, "0")
+
+	pptx := writeZip("deck.pptx", map[string]string{
+		"ppt/slides/slide1.xml": `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Roadmap</a:t></a:r></a:p></p:sld>`,
+	})
+	text, err = ExtractText(pptx, 0)
+	require.NoError(t, err)
+	require.Equal(t, "Roadmap", text)
+
+	_, err = ExtractText(writeZip("broken.docx", map[string]string{"word/document.xml": "<w:t>unclosed"}), 0)
+	require.Error(t, err)
+}
+
+func TestIsTextExtractable(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsTextExtractable(".go"))
+	require.True(t, IsTextExtractable(" .DOCX "))
+	require.True(t, IsTextExtractable(".csv"))
+	require.False(t, IsTextExtractable(".pdf"))
+	require.False(t, IsTextExtractable(".png"))
+}
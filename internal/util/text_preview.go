@@ -0,0 +1,300 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings reported by DetectTextEncoding.
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "iso-8859-1"
+)
+
+// DetectTextEncoding guesses the encoding of text that starts with sample
+// from its byte order mark or, without one, from the bytes themselves.
+// Text that is not valid UTF-8 is taken as Latin-1. It reports false for
+// binary data.
+func DetectTextEncoding(sample []byte) (string, bool) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xef, 0xbb, 0xbf}):
+		return EncodingUTF8, true
+	case bytes.HasPrefix(sample, []byte{0xff, 0xfe}):
+		return EncodingUTF16LE, true
+	case bytes.HasPrefix(sample, []byte{0xfe, 0xff}):
+		return EncodingUTF16BE, true
+	}
+
+	if bytes.IndexByte(sample, 0) >= 0 {
+		// UTF-16 without a BOM: mostly ASCII text has a zero in every
+		// other byte.
+		var even, odd int
+		for i, b := range sample {
+			if b == 0 {
+				if i%2 == 0 {
+					even++
+				} else {
+					odd++
+				}
+			}
+		}
+		pairs := len(sample) / 2
+		var encoding string
+		var high int
+		switch {
+		case odd*2 >= pairs && even == 0:
+			encoding, high = EncodingUTF16LE, 1
+		case even*2 >= pairs && odd == 0:
+			encoding, high = EncodingUTF16BE, 0
+		default:
+			return "", false
+		}
+		low := make([]byte, 0, pairs)
+		for i := 0; i+1 < len(sample); i += 2 {
+			if sample[i+high] == 0 {
+				low = append(low, sample[i+1-high])
+			}
+		}
+		if hasManyControls(low) {
+			return "", false
+		}
+		return encoding, true
+	}
+
+	if hasManyControls(sample) {
+		return "", false
+	}
+
+	// The sample may end in the middle of a character.
+	valid := sample
+	for i := 0; i < utf8.UTFMax-1 && len(valid) > 0 && !utf8.Valid(valid); i++ {
+		valid = valid[:len(valid)-1]
+	}
+	if utf8.Valid(valid) {
+		return EncodingUTF8, true
+	}
+	return EncodingLatin1, true
+}
+
+// hasManyControls reports whether text has more control characters than
+// whitespace and escape sequences explain.
+func hasManyControls(text []byte) bool {
+	controls := 0
+	for _, b := range text {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != 0x1b {
+			controls++
+		}
+	}
+	return controls > len(text)/100
+}
+
+// NewTextReader returns a reader that yields the text of r, in the given
+// encoding, as UTF-8 without a byte order mark. Invalid sequences become
+// U+FFFD.
+func NewTextReader(r io.Reader, encoding string) io.Reader {
+	return &textReader{r: r, encoding: encoding, buf: make([]byte, 32*1024), start: true}
+}
+
+type textReader struct {
+	r        io.Reader
+	encoding string
+	buf      []byte
+	// pending holds bytes of an incomplete character; out holds decoded
+	// text not yet returned.
+	pending []byte
+	out     []byte
+	start   bool
+	err     error
+}
+
+func (t *textReader) Read(p []byte) (int, error) {
+	for len(t.out) == 0 {
+		if t.err != nil {
+			return 0, t.err
+		}
+		n, err := t.r.Read(t.buf)
+		data := append(t.pending, t.buf[:n]...)
+		t.pending = nil
+		if t.start {
+			if len(data) < 3 && err == nil {
+				// Wait for enough bytes to recognise a byte order mark.
+				t.pending = data
+				continue
+			}
+			data = t.stripBOM(data)
+		}
+		t.err = err
+		t.out = t.decode(data, err != nil)
+	}
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+func (t *textReader) stripBOM(data []byte) []byte {
+	t.start = false
+	switch t.encoding {
+	case EncodingUTF8:
+		return bytes.TrimPrefix(data, []byte{0xef, 0xbb, 0xbf})
+	case EncodingUTF16LE:
+		return bytes.TrimPrefix(data, []byte{0xff, 0xfe})
+	case EncodingUTF16BE:
+		return bytes.TrimPrefix(data, []byte{0xfe, 0xff})
+	}
+	return data
+}
+
+// decode converts data to UTF-8, keeping a trailing incomplete character in
+// pending unless final is set.
+func (t *textReader) decode(data []byte, final bool) []byte {
+	switch t.encoding {
+	case EncodingLatin1:
+		return []byte(decodeLatin1(data))
+	case EncodingUTF16LE, EncodingUTF16BE:
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			if t.encoding == EncodingUTF16LE {
+				units = append(units, uint16(data[i])|uint16(data[i+1])<<8)
+			} else {
+				units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+			}
+		}
+		rest := data[len(units)*2:]
+		// A high surrogate waits for the low one that completes it.
+		if last := len(units) - 1; !final && last >= 0 && units[last] >= 0xd800 && units[last] < 0xdc00 {
+			rest = data[len(units)*2-2:]
+			units = units[:len(units)-1]
+		}
+		decoded := []byte(string(utf16.Decode(units)))
+		if final && len(rest) > 0 {
+			return utf8.AppendRune(decoded, utf8.RuneError)
+		}
+		t.pending = append([]byte(nil), rest...)
+		return decoded
+	default:
+		if !final {
+			// Keep the start of a character cut off by the read.
+			for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+				if utf8.RuneStart(data[len(data)-i]) {
+					if !utf8.FullRune(data[len(data)-i:]) {
+						t.pending = append([]byte(nil), data[len(data)-i:]...)
+						data = data[:len(data)-i]
+					}
+					break
+				}
+			}
+		}
+		return bytes.ToValidUTF8(data, []byte(string(utf8.RuneError)))
+	}
+}
+
+// languageByExtension maps file extensions to the language names common
+// syntax highlighters use.
+var languageByExtension = map[string]string{
+	".go": "go", ".py": "python", ".rb": "ruby", ".php": "php", ".java": "java",
+	".kt": "kotlin", ".kts": "kotlin", ".scala": "scala", ".c": "c", ".h": "c",
+	".cc": "cpp", ".cpp": "cpp", ".cxx": "cpp", ".hpp": "cpp", ".cs": "csharp",
+	".rs": "rust", ".swift": "swift", ".js": "javascript", ".mjs": "javascript",
+	".cjs": "javascript", ".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript",
+	".vue": "vue", ".svelte": "svelte", ".html": "html", ".htm": "html", ".css": "css",
+	".scss": "scss", ".less": "less", ".json": "json", ".jsonl": "json", ".xml": "xml",
+	".svg": "xml", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".ini": "ini",
+	".cfg": "ini", ".conf": "ini", ".env": "dotenv", ".md": "markdown", ".markdown": "markdown",
+	".rst": "restructuredtext", ".tex": "latex", ".sql": "sql", ".sh": "shell", ".bash": "shell",
+	".zsh": "shell", ".ps1": "powershell", ".bat": "batch", ".cmd": "batch", ".lua": "lua",
+	".pl": "perl", ".r": "r", ".dart": "dart", ".ex": "elixir", ".exs": "elixir",
+	".erl": "erlang", ".hs": "haskell", ".clj": "clojure", ".proto": "protobuf",
+	".graphql": "graphql", ".tf": "hcl", ".csv": "csv", ".tsv": "tsv", ".log": "log",
+	".diff": "diff", ".patch": "diff",
+}
+
+var languageByName = map[string]string{
+	"dockerfile": "dockerfile", "makefile": "makefile", "gnumakefile": "makefile",
+	"cmakelists.txt": "cmake", "jenkinsfile": "groovy", "gemfile": "ruby", "rakefile": "ruby",
+	"go.mod": "go", ".gitignore": "ignore", ".dockerignore": "ignore",
+}
+
+var languageByInterpreter = map[string]string{
+	"sh": "shell", "bash": "shell", "zsh": "shell", "dash": "shell", "python": "python",
+	"node": "javascript", "ruby": "ruby", "perl": "perl", "php": "php",
+	"lua": "lua", "pwsh": "powershell", "rscript": "r",
+}
+
+// DetectLanguage names the language of a text file from its name or, for
+// scripts without an extension, from the interpreter on its first line. It
+// returns "plaintext" when neither tells.
+func DetectLanguage(name string, firstLine string) string {
+	base := strings.ToLower(filepath.Base(name))
+	if language, ok := languageByName[base]; ok {
+		return language
+	}
+	if language, ok := languageByExtension[filepath.Ext(base)]; ok {
+		return language
+	}
+
+	if interpreter, ok := strings.CutPrefix(strings.TrimSpace(firstLine), "#!"); ok {
+		fields := strings.Fields(interpreter)
+		if len(fields) > 0 {
+			program := filepath.Base(fields[0])
+			if program == "env" && len(fields) > 1 {
+				program = fields[1]
+			}
+			program = strings.ToLower(strings.TrimRight(program, "0123456789."))
+			if language, ok := languageByInterpreter[program]; ok {
+				return language
+			}
+		}
+	}
+	return "plaintext"
+}
+
+// DetectDelimiter picks the field delimiter of delimited text from its first
+// lines: the candidate that appears the same, non-zero number of times on
+// the most lines, preferring comma on ties.
+func DetectDelimiter(sample string) rune {
+	lines := strings.Split(sample, "\n")
+	if len(lines) > 1 {
+		// The last line may be cut off.
+		lines = lines[:len(lines)-1]
+	}
+	lines = lines[:min(len(lines), 20)]
+
+	best, bestScore := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		counts := map[int]int{}
+		for _, line := range lines {
+			if count := countOutsideQuotes(line, candidate); count > 0 {
+				counts[count]++
+			}
+		}
+		score := 0
+		for _, lines := range counts {
+			score = max(score, lines)
+		}
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
+
+func countOutsideQuotes(line string, delimiter rune) int {
+	count, quoted := 0, false
+	for _, r := range line {
+		switch r {
+		case '"':
+			quoted = !quoted
+		case delimiter:
+			if !quoted {
+				count++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+)
+
+func utf16Bytes(text string, bigEndian bool, bom bool) []byte {
+	units := utf16.Encode([]rune(text))
+	if bom {
+		units = append([]uint16{0xfeff}, units...)
+	}
+	out := make([]byte, 0, len(units)*2)
+	for _, unit := range units {
+		if bigEndian {
+			out = append(out, byte(unit>>8), byte(unit))
+		} else {
+			out = append(out, byte(unit), byte(unit>>8))
+		}
+	}
+	return out
+}
+
+func TestDetectTextEncoding(t *testing.T) {
+	cases := map[string]struct {
+		data []byte
+		want string
+		ok   bool
+	}{
+		"ascii":              {[]byte("hello\nworld\n"), EncodingUTF8, true},
+		"utf-8 bom":          {[]byte("\xef\xbb\xbfcafé"), EncodingUTF8, true},
+		"utf-8 cut mid rune": {[]byte("café")[:4], EncodingUTF8, true},
+		"latin-1":            {[]byte("caf\xe9 cr\xe8me"), EncodingLatin1, true},
+		"utf-16le bom":       {utf16Bytes("hello", false, true), EncodingUTF16LE, true},
+		"utf-16be bom":       {utf16Bytes("hello", true, true), EncodingUTF16BE, true},
+		"utf-16le":           {utf16Bytes("plain text", false, false), EncodingUTF16LE, true},
+		"utf-16be":           {utf16Bytes("plain text", true, false), EncodingUTF16BE, true},
+		"binary":             {[]byte{0x7f, 'E', 'L', 'F', 2, 1, 1, 0, 0, 0, 0, 0, 3, 0}, "", false},
+		"control characters": {bytes.Repeat([]byte{1, 2, 'a'}, 20), "", false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := DetectTextEncoding(tc.data)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestTextReader(t *testing.T) {
+	text := "naïve 🙂 text\nsecond line"
+	cases := map[string]struct {
+		data     []byte
+		encoding string
+		want     string
+	}{
+		"utf-8 bom":    {append([]byte("\xef\xbb\xbf"), text...), EncodingUTF8, text},
+		"utf-16le bom": {utf16Bytes(text, false, true), EncodingUTF16LE, text},
+		"utf-16be":     {utf16Bytes(text, true, false), EncodingUTF16BE, text},
+		"latin-1":      {[]byte("na\xefve"), EncodingLatin1, "naïve"},
+		"invalid":      {[]byte("ok\xff"), EncodingUTF8, "ok�"},
+		"odd utf-16":   {append(utf16Bytes("ab", false, false), 'c'), EncodingUTF16LE, "ab�"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// One byte at a time splits every character across reads.
+			got, err := io.ReadAll(NewTextReader(iotest.OneByteReader(bytes.NewReader(tc.data)), tc.encoding))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	require.Equal(t, "go", DetectLanguage("/src/main.go", "package main"))
+	require.Equal(t, "typescript", DetectLanguage("App.TSX", ""))
+	require.Equal(t, "dockerfile", DetectLanguage("/build/Dockerfile", "FROM golang"))
+	require.Equal(t, "python", DetectLanguage("/bin/tool", "#!/usr/bin/env python3"))
+	require.Equal(t, "shell", DetectLanguage("/bin/run", "#!/bin/bash -e"))
+	require.Equal(t, "plaintext", DetectLanguage("notes", "just some notes"))
+}
+
+func TestDetectDelimiter(t *testing.T) {
+	require.Equal(t, ',', DetectDelimiter("a,b,c\n1,2,3\n"))
+	require.Equal(t, ';', DetectDelimiter("name;amount\n\"Smith, J\";1,5\n\"Doe, A\";2,0\n"))
+	require.Equal(t, '\t', DetectDelimiter("a\tb\n1\t2\n3\t4"))
+	require.Equal(t, '|', DetectDelimiter("a|b|c\n1|2|3\n4|5|6\n"))
+	require.Equal(t, ',', DetectDelimiter("single column\nvalue\n"))
+}
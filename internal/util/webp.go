@@ -0,0 +1,344 @@
+package util
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+const (
+	vp8lSignature     = 0x2f
+	vp8lMaxDimension  = 1 << 14
+	vp8lSubtractGreen = 2
+	vp8lMaxCodeLength = 15
+	vp8lMaxCLCLength  = 7
+	vp8lLengthCodes   = 24
+	vp8lDistanceCodes = 40
+)
+
+// vp8lCodeLengthOrder is the order in which the lengths of the code length
+// code are stored.
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebPLossless writes img as a lossless WebP (VP8L) image. It applies
+// the subtract-green transform and entropy codes every pixel with one set of
+// prefix codes built for the image, without backward references: simple,
+// exact, and far smaller than raw pixels.
+func EncodeWebPLossless(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return errors.New("webp: invalid image dimensions")
+	}
+
+	pixels := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(pixels, pixels.Bounds(), img, bounds.Min, draw.Src)
+
+	alphaUsed := false
+	var histograms [4][]uint32
+	histograms[0] = make([]uint32, 256+vp8lLengthCodes)
+	for i := 1; i < 4; i++ {
+		histograms[i] = make([]uint32, 256)
+	}
+	for i := 0; i < len(pixels.Pix); i += 4 {
+		r, g, b, a := pixels.Pix[i], pixels.Pix[i+1], pixels.Pix[i+2], pixels.Pix[i+3]
+		// Subtract green: red and blue are stored as differences from green.
+		pixels.Pix[i], pixels.Pix[i+2] = r-g, b-g
+		histograms[0][g]++
+		histograms[1][r-g]++
+		histograms[2][b-g]++
+		histograms[3][a]++
+		alphaUsed = alphaUsed || a != 0xff
+	}
+
+	bw := &bitWriter{}
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if alphaUsed {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3) // version
+	bw.write(1, 1) // transform present
+	bw.write(vp8lSubtractGreen, 2)
+	bw.write(0, 1) // no more transforms
+	bw.write(0, 1) // no color cache
+	bw.write(0, 1) // one prefix code group for the whole image
+
+	var codes [4]prefixCode
+	for i, histogram := range histograms {
+		codes[i] = newPrefixCode(histogram, vp8lMaxCodeLength)
+		codes[i].writeTo(bw)
+	}
+	distance := make([]uint32, vp8lDistanceCodes)
+	distance[0] = 1
+	newPrefixCode(distance, vp8lMaxCodeLength).writeTo(bw)
+
+	for i := 0; i < len(pixels.Pix); i += 4 {
+		codes[0].writeSymbol(bw, int(pixels.Pix[i+1]))
+		codes[1].writeSymbol(bw, int(pixels.Pix[i]))
+		codes[2].writeSymbol(bw, int(pixels.Pix[i+2]))
+		codes[3].writeSymbol(bw, int(pixels.Pix[i+3]))
+	}
+	data := bw.bytes()
+
+	padding := len(data) & 1
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+len(data)+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bitWriter packs values least significant bit first, as VP8L reads them.
+type bitWriter struct {
+	buf  []byte
+	acc  uint64
+	bits uint
+}
+
+func (b *bitWriter) write(value uint32, n uint) {
+	b.acc |= uint64(value) << b.bits
+	b.bits += n
+	for b.bits >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.bits -= 8
+	}
+}
+
+func (b *bitWriter) bytes() []byte {
+	if b.bits > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.bits = 0, 0
+	}
+	return b.buf
+}
+
+// prefixCode is a canonical Huffman code. codes hold each code bit-reversed
+// so it can be written least significant bit first; a code with a single
+// symbol takes no bits.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+	symbols []int
+}
+
+func newPrefixCode(histogram []uint32, maxLength int) prefixCode {
+	code := prefixCode{lengths: make([]uint8, len(histogram)), codes: make([]uint16, len(histogram))}
+	for symbol, count := range histogram {
+		if count > 0 {
+			code.symbols = append(code.symbols, symbol)
+		}
+	}
+	if len(code.symbols) == 0 {
+		code.symbols = []int{0}
+	}
+	if len(code.symbols) == 1 {
+		code.lengths[code.symbols[0]] = 1
+		return code
+	}
+
+	code.lengths = huffmanLengths(histogram, maxLength)
+	var lengthCounts [vp8lMaxCodeLength + 1]uint16
+	for _, length := range code.lengths {
+		lengthCounts[length]++
+	}
+	lengthCounts[0] = 0
+	var next [vp8lMaxCodeLength + 1]uint16
+	current := uint16(0)
+	for length := 1; length <= vp8lMaxCodeLength; length++ {
+		current = (current + lengthCounts[length-1]) << 1
+		next[length] = current
+	}
+	for symbol, length := range code.lengths {
+		if length == 0 {
+			continue
+		}
+		code.codes[symbol] = reverseBits(next[length], length)
+		next[length]++
+	}
+	return code
+}
+
+func (c prefixCode) writeSymbol(bw *bitWriter, symbol int) {
+	if len(c.symbols) > 1 {
+		bw.write(uint32(c.codes[symbol]), uint(c.lengths[symbol]))
+	}
+}
+
+// writeTo stores the code: as a simple code when it has one or two symbols
+// below 256, otherwise as run-length coded code lengths.
+func (c prefixCode) writeTo(bw *bitWriter) {
+	if len(c.symbols) <= 2 && c.symbols[len(c.symbols)-1] < 256 {
+		bw.write(1, 1)
+		bw.write(uint32(len(c.symbols)-1), 1)
+		if c.symbols[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(c.symbols[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(c.symbols[0]), 8)
+		}
+		if len(c.symbols) == 2 {
+			bw.write(uint32(c.symbols[1]), 8)
+		}
+		return
+	}
+
+	type token struct{ symbol, extra, extraBits int }
+	tokens := make([]token, 0, len(c.lengths))
+	for i := 0; i < len(c.lengths); {
+		if c.lengths[i] != 0 {
+			tokens = append(tokens, token{symbol: int(c.lengths[i])})
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(c.lengths) && c.lengths[i+run] == 0 && run < 138 {
+			run++
+		}
+		switch {
+		case run >= 11:
+			tokens = append(tokens, token{symbol: 18, extra: run - 11, extraBits: 7})
+		case run >= 3:
+			tokens = append(tokens, token{symbol: 17, extra: run - 3, extraBits: 3})
+		default:
+			for range run {
+				tokens = append(tokens, token{symbol: 0})
+			}
+		}
+		i += run
+	}
+
+	histogram := make([]uint32, len(vp8lCodeLengthOrder))
+	for _, t := range tokens {
+		histogram[t.symbol]++
+	}
+	lengthCode := newPrefixCode(histogram, vp8lMaxCLCLength)
+	stored := len(vp8lCodeLengthOrder)
+	for stored > 4 && lengthCode.lengths[vp8lCodeLengthOrder[stored-1]] == 0 {
+		stored--
+	}
+
+	bw.write(0, 1)
+	bw.write(uint32(stored-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:stored] {
+		bw.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	bw.write(0, 1) // lengths follow for the whole alphabet
+	for _, t := range tokens {
+		lengthCode.writeSymbol(bw, t.symbol)
+		if t.extraBits > 0 {
+			bw.write(uint32(t.extra), uint(t.extraBits))
+		}
+	}
+}
+
+// huffmanLengths returns Huffman code lengths for histogram of at most
+// maxLength bits. Counts are halved until the tree is shallow enough.
+func huffmanLengths(histogram []uint32, maxLength int) []uint8 {
+	counts := append([]uint32(nil), histogram...)
+	for {
+		lengths, deepest := huffmanTreeLengths(counts)
+		if deepest <= maxLength {
+			return lengths
+		}
+		for i, count := range counts {
+			if count > 1 {
+				counts[i] = (count + 1) / 2
+			}
+		}
+	}
+}
+
+func huffmanTreeLengths(counts []uint32) ([]uint8, int) {
+	type node struct {
+		weight uint64
+		parent int
+	}
+	nodes := make([]node, 0, 2*len(counts))
+	leaves := make(map[int]int)
+	queue := &huffmanQueue{}
+	for symbol, count := range counts {
+		if count == 0 {
+			continue
+		}
+		leaves[symbol] = len(nodes)
+		heap.Push(queue, huffmanItem{weight: uint64(count), node: len(nodes)})
+		nodes = append(nodes, node{weight: uint64(count), parent: -1})
+	}
+	for queue.Len() > 1 {
+		a := heap.Pop(queue).(huffmanItem)
+		b := heap.Pop(queue).(huffmanItem)
+		parent := len(nodes)
+		nodes = append(nodes, node{weight: a.weight + b.weight, parent: -1})
+		nodes[a.node].parent = parent
+		nodes[b.node].parent = parent
+		heap.Push(queue, huffmanItem{weight: a.weight + b.weight, node: parent})
+	}
+
+	lengths := make([]uint8, len(counts))
+	deepest := 0
+	for symbol, leaf := range leaves {
+		depth := 0
+		for n := leaf; nodes[n].parent >= 0; n = nodes[n].parent {
+			depth++
+		}
+		lengths[symbol] = uint8(min(depth, 255))
+		deepest = max(deepest, depth)
+	}
+	return lengths, deepest
+}
+
+type huffmanItem struct {
+	weight uint64
+	node   int
+}
+
+// huffmanQueue orders items by weight, then by node so that ties are
+// broken the same way every time.
+type huffmanQueue []huffmanItem
+
+func (q huffmanQueue) Len() int { return len(q) }
+func (q huffmanQueue) Less(i, j int) bool {
+	if q[i].weight != q[j].weight {
+		return q[i].weight < q[j].weight
+	}
+	return q[i].node < q[j].node
+}
+func (q huffmanQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *huffmanQueue) Push(x any)   { *q = append(*q, x.(huffmanItem)) }
+func (q *huffmanQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func reverseBits(code uint16, length uint8) uint16 {
+	reversed := uint16(0)
+	for range length {
+		reversed = reversed<<1 | code&1
+		code >>= 1
+	}
+	return reversed
+}
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPLosslessRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	noise := image.NewNRGBA(image.Rect(0, 0, 67, 41))
+	random.Read(noise.Pix)
+
+	gradient := image.NewRGBA(image.Rect(10, 10, 110, 60))
+	for y := 10; y < 60; y++ {
+		for x := 10; x < 110; x++ {
+			gradient.Set(x, y, color.RGBA{R: uint8(x * 2), G: uint8(y * 3), B: 128, A: 255})
+		}
+	}
+
+	flat := image.NewNRGBA(image.Rect(0, 0, 300, 2))
+	for i := range flat.Pix {
+		flat.Pix[i] = 200
+	}
+
+	twoColors := image.NewNRGBA(image.Rect(0, 0, 5, 5))
+	for y := range 5 {
+		for x := range 5 {
+			twoColors.SetNRGBA(x, y, color.NRGBA{G: uint8(255 * ((x + y) % 2)), A: 255})
+		}
+	}
+
+	cases := map[string]image.Image{
+		"noise with alpha": noise,
+		"gradient":         gradient,
+		"flat":             flat,
+		"two colors":       twoColors,
+		"single pixel":     image.NewNRGBA(image.Rect(0, 0, 1, 1)),
+	}
+	for name, img := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, EncodeWebPLossless(&buf, img))
+			require.Zero(t, buf.Len()%2)
+
+			decoded, err := webp.Decode(&buf)
+			require.NoError(t, err)
+			bounds := img.Bounds()
+			require.Equal(t, bounds.Dx(), decoded.Bounds().Dx())
+			require.Equal(t, bounds.Dy(), decoded.Bounds().Dy())
+			for y := 0; y < bounds.Dy(); y++ {
+				for x := 0; x < bounds.Dx(); x++ {
+					want := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+					got := color.NRGBAModel.Convert(decoded.At(x, y))
+					require.Equal(t, want, got, "pixel %d,%d", x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebPLosslessCompresses(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for y := range 256 {
+		for x := range 256 {
+			gray := uint8((x/16 + y/16) * 8)
+			img.SetNRGBA(x, y, color.NRGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeWebPLossless(&buf, img))
+	require.Less(t, buf.Len(), len(img.Pix)/3)
+}
+
+func TestEncodeWebPLosslessRejectsEmptyImages(t *testing.T) {
+	require.Error(t, EncodeWebPLossless(&bytes.Buffer{}, image.NewNRGBA(image.Rect(0, 0, 0, 4))))
+}
@@ -0,0 +1,561 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrSnapshotChanged is returned while serving a StoreZipLayout when a file no
+// longer matches the snapshot the layout was built from.
+var ErrSnapshotChanged = errors.New("directory changed since the archive layout was built")
+
+const (
+	zipLocalHeaderLen      = 30
+	zipCentralHeaderLen    = 46
+	zipDescriptorLen       = 16
+	zipDescriptor64Len     = 24
+	zipZip64ExtraLen       = 28
+	zipEndLen              = 22
+	zipEnd64Len            = 56
+	zipEnd64LocatorLen     = 20
+	zipUint16Max           = 1<<16 - 1
+	zipUint32Max           = 1<<32 - 1
+	zipVersion20           = 20
+	zipVersion45           = 45
+	zipCreatorUnix         = 3
+	zipFlagDataDescriptor  = 0x8
+	zipFlagUTF8            = 0x800
+	zipLocalHeaderSig      = 0x04034b50
+	zipCentralHeaderSig    = 0x02014b50
+	zipDescriptorSig       = 0x08074b50
+	zipEndSig              = 0x06054b50
+	zipEnd64Sig            = 0x06064b50
+	zipEnd64LocatorSig     = 0x07064b50
+	zipZip64ExtraID        = 0x0001
+	zipUnixRegularFileMode = 0o100000
+	zipUnixDirMode         = 0o040000
+	zipMSDOSDirAttr        = 0x10
+)
+
+type segmentKind int
+
+const (
+	segmentLocalHeader segmentKind = iota
+	segmentData
+	segmentDescriptor
+	segmentTrailer
+)
+
+type layoutSegment struct {
+	offset int64
+	size   int64
+	kind   segmentKind
+	entry  int
+}
+
+type layoutEntry struct {
+	ArchiveEntry
+	localOffset int64
+	zip64       bool
+}
+
+// CRCCache remembers CRC-32 checksums of files keyed by path, size and
+// modification time so resumed downloads don't have to re-read whole files.
+type CRCCache struct {
+	mu         sync.Mutex
+	values     map[string]uint32
+	maxEntries int
+}
+
+func NewCRCCache(maxEntries int) *CRCCache {
+	if maxEntries <= 0 {
+		maxEntries = 100000
+	}
+	return &CRCCache{values: map[string]uint32{}, maxEntries: maxEntries}
+}
+
+func crcCacheKey(entry ArchiveEntry) string {
+	return fmt.Sprintf("%s\x00%d\x00%d", entry.Source, entry.Size, entry.ModTime.UnixNano())
+}
+
+func (c *CRCCache) get(entry ArchiveEntry) (uint32, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[crcCacheKey(entry)]
+	return value, ok
+}
+
+func (c *CRCCache) put(entry ArchiveEntry, value uint32) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.values) >= c.maxEntries {
+		// Dropping arbitrary entries is fine: a miss only costs a re-read.
+		for key := range c.values {
+			delete(c.values, key)
+			if len(c.values) < c.maxEntries/2 {
+				break
+			}
+		}
+	}
+	c.values[crcCacheKey(entry)] = value
+}
+
+// StoreZipLayout is a deterministic, uncompressed zip archive of a directory
+// snapshot. Because every entry is stored as-is its total size is known up
+// front and any byte range can be produced on demand, which allows
+// Content-Length, Range requests and resumable downloads. CRC-32 values live
+// in data descriptors and the central directory, so they are only computed
+// when those bytes are requested.
+type StoreZipLayout struct {
+	entries  []layoutEntry
+	segments []layoutSegment
+	size     int64
+	etag     string
+	crcs     *CRCCache
+
+	mu        sync.Mutex
+	trailer   []byte
+	open      *os.File
+	openIndex int
+	crcIndex  int
+	crcNext   int64
+	crcHash   hash.Hash32
+}
+
+// NewStoreZipLayout lays out entries in the given order. Entries must have
+// unique names; directory names must not carry a trailing slash.
+func NewStoreZipLayout(entries []ArchiveEntry, crcs *CRCCache) *StoreZipLayout {
+	layout := &StoreZipLayout{crcs: crcs, openIndex: -1, crcIndex: -1}
+	fingerprint := sha256.New()
+	_, _ = io.WriteString(fingerprint, "store-zip-v1\n")
+
+	var offset int64
+	for index, entry := range entries {
+		if entry.IsDir {
+			entry.Name += "/"
+			entry.Size = 0
+		}
+
+		item := layoutEntry{ArchiveEntry: entry, localOffset: offset}
+		item.zip64 = entry.Size >= zipUint32Max || offset >= zipUint32Max
+		layout.entries = append(layout.entries, item)
+
+		fmt.Fprintf(fingerprint, "%s\x00%t\x00%d\x00%d\n", entry.Name, entry.IsDir, entry.Size, entry.ModTime.UnixNano())
+
+		headerLen := int64(zipLocalHeaderLen + len(entry.Name))
+		layout.segments = append(layout.segments, layoutSegment{offset: offset, size: headerLen, kind: segmentLocalHeader, entry: index})
+		offset += headerLen
+
+		if entry.IsDir {
+			continue
+		}
+
+		if entry.Size > 0 {
+			layout.segments = append(layout.segments, layoutSegment{offset: offset, size: entry.Size, kind: segmentData, entry: index})
+			offset += entry.Size
+		}
+
+		descriptorLen := int64(zipDescriptorLen)
+		if entry.Size >= zipUint32Max {
+			descriptorLen = zipDescriptor64Len
+		}
+		layout.segments = append(layout.segments, layoutSegment{offset: offset, size: descriptorLen, kind: segmentDescriptor, entry: index})
+		offset += descriptorLen
+	}
+
+	centralOffset := offset
+	var centralSize int64
+	for _, item := range layout.entries {
+		centralSize += int64(zipCentralHeaderLen + len(item.Name))
+		if item.zip64 {
+			centralSize += zipZip64ExtraLen
+		}
+	}
+
+	trailerSize := centralSize + zipEndLen
+	if needsZip64End(len(layout.entries), centralSize, centralOffset) {
+		trailerSize += zipEnd64Len + zipEnd64LocatorLen
+	}
+	layout.segments = append(layout.segments, layoutSegment{offset: centralOffset, size: trailerSize, kind: segmentTrailer, entry: -1})
+	layout.size = centralOffset + trailerSize
+	layout.etag = `"` + hex.EncodeToString(fingerprint.Sum(nil))[:32] + `"`
+
+	return layout
+}
+
+// Size returns the exact length of the archive in bytes.
+func (l *StoreZipLayout) Size() int64 {
+	return l.size
+}
+
+// ETag identifies the snapshot; it changes whenever a name, size or
+// modification time in the tree changes.
+func (l *StoreZipLayout) ETag() string {
+	return l.etag
+}
+
+// Close releases the file handle kept open between reads.
+func (l *StoreZipLayout) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closeOpenLocked()
+}
+
+// ReadAt fills p with archive bytes starting at off.
+func (l *StoreZipLayout) ReadAt(p []byte, off int64) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	n := 0
+	for n < len(p) && off < l.size {
+		segment := l.segmentAt(off)
+		within := off - segment.offset
+		chunk := p[n:]
+		if remaining := segment.size - within; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+
+		read, err := l.readSegment(segment, within, chunk)
+		n += read
+		off += int64(read)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (l *StoreZipLayout) segmentAt(off int64) layoutSegment {
+	index := sort.Search(len(l.segments), func(i int) bool {
+		return l.segments[i].offset+l.segments[i].size > off
+	})
+	return l.segments[index]
+}
+
+func (l *StoreZipLayout) readSegment(segment layoutSegment, within int64, p []byte) (int, error) {
+	switch segment.kind {
+	case segmentLocalHeader:
+		return copy(p, l.localHeader(l.entries[segment.entry])[within:]), nil
+	case segmentData:
+		return l.readData(segment.entry, within, p)
+	case segmentDescriptor:
+		descriptor, err := l.descriptor(segment.entry)
+		if err != nil {
+			return 0, err
+		}
+		return copy(p, descriptor[within:]), nil
+	default:
+		trailer, err := l.buildTrailer()
+		if err != nil {
+			return 0, err
+		}
+		return copy(p, trailer[within:]), nil
+	}
+}
+
+func (l *StoreZipLayout) readData(index int, within int64, p []byte) (int, error) {
+	file, err := l.openEntry(index)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := file.ReadAt(p, within)
+	if err != nil && !(errors.Is(err, io.EOF) && n == len(p)) {
+		if errors.Is(err, io.EOF) {
+			return n, ErrSnapshotChanged
+		}
+		return n, err
+	}
+
+	// Track a running checksum while the file is read front to back so a
+	// plain sequential download never reads a file twice.
+	if within == 0 {
+		l.crcIndex, l.crcNext, l.crcHash = index, 0, crc32.NewIEEE()
+	}
+	if l.crcIndex == index && l.crcNext == within {
+		_, _ = l.crcHash.Write(p[:n])
+		l.crcNext += int64(n)
+		if l.crcNext == l.entries[index].Size {
+			l.crcs.put(l.entries[index].ArchiveEntry, l.crcHash.Sum32())
+			l.crcIndex = -1
+		}
+	}
+
+	return n, nil
+}
+
+func (l *StoreZipLayout) openEntry(index int) (*os.File, error) {
+	if l.openIndex == index && l.open != nil {
+		return l.open, nil
+	}
+	if err := l.closeOpenLocked(); err != nil {
+		return nil, err
+	}
+
+	entry := l.entries[index]
+	file, err := os.Open(entry.Source)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSnapshotChanged
+		}
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+		file.Close()
+		return nil, ErrSnapshotChanged
+	}
+
+	l.open, l.openIndex = file, index
+	return file, nil
+}
+
+func (l *StoreZipLayout) closeOpenLocked() error {
+	if l.open == nil {
+		return nil
+	}
+	err := l.open.Close()
+	l.open, l.openIndex = nil, -1
+	return err
+}
+
+func (l *StoreZipLayout) crc(index int) (uint32, error) {
+	entry := l.entries[index]
+	if entry.IsDir || entry.Size == 0 {
+		return 0, nil
+	}
+	if value, ok := l.crcs.get(entry.ArchiveEntry); ok {
+		return value, nil
+	}
+
+	file, err := l.openEntry(index)
+	if err != nil {
+		return 0, err
+	}
+
+	hasher := crc32.NewIEEE()
+	written, err := io.Copy(hasher, io.NewSectionReader(file, 0, entry.Size))
+	if err != nil {
+		return 0, err
+	}
+	if written != entry.Size {
+		return 0, ErrSnapshotChanged
+	}
+
+	value := hasher.Sum32()
+	l.crcs.put(entry.ArchiveEntry, value)
+	return value, nil
+}
+
+func (l *StoreZipLayout) localHeader(entry layoutEntry) []byte {
+	buf := make([]byte, zipLocalHeaderLen+len(entry.Name))
+	modTime, modDate := dosDateTime(entry.ModTime)
+
+	binary.LittleEndian.PutUint32(buf[0:], zipLocalHeaderSig)
+	binary.LittleEndian.PutUint16(buf[4:], readerVersion(entry))
+	binary.LittleEndian.PutUint16(buf[6:], entryFlags(entry))
+	binary.LittleEndian.PutUint16(buf[8:], 0) // store
+	binary.LittleEndian.PutUint16(buf[10:], modTime)
+	binary.LittleEndian.PutUint16(buf[12:], modDate)
+	// CRC-32 and sizes stay zero: files carry a data descriptor and
+	// directories are empty, mirroring archive/zip's streaming writer.
+	binary.LittleEndian.PutUint16(buf[26:], uint16(len(entry.Name)))
+	copy(buf[zipLocalHeaderLen:], entry.Name)
+	return buf
+}
+
+func (l *StoreZipLayout) descriptor(index int) ([]byte, error) {
+	entry := l.entries[index]
+	crc, err := l.crc(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Size >= zipUint32Max {
+		buf := make([]byte, zipDescriptor64Len)
+		binary.LittleEndian.PutUint32(buf[0:], zipDescriptorSig)
+		binary.LittleEndian.PutUint32(buf[4:], crc)
+		binary.LittleEndian.PutUint64(buf[8:], uint64(entry.Size))
+		binary.LittleEndian.PutUint64(buf[16:], uint64(entry.Size))
+		return buf, nil
+	}
+
+	buf := make([]byte, zipDescriptorLen)
+	binary.LittleEndian.PutUint32(buf[0:], zipDescriptorSig)
+	binary.LittleEndian.PutUint32(buf[4:], crc)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(entry.Size))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(entry.Size))
+	return buf, nil
+}
+
+// buildTrailer renders the central directory and end records. It needs every
+// CRC, so it is produced once on first use.
+func (l *StoreZipLayout) buildTrailer() ([]byte, error) {
+	if l.trailer != nil {
+		return l.trailer, nil
+	}
+
+	trailerSegment := l.segments[len(l.segments)-1]
+	centralOffset := trailerSegment.offset
+	buf := make([]byte, 0, trailerSegment.size)
+
+	for index, entry := range l.entries {
+		crc, err := l.crc(index)
+		if err != nil {
+			return nil, err
+		}
+
+		header := make([]byte, zipCentralHeaderLen)
+		modTime, modDate := dosDateTime(entry.ModTime)
+		binary.LittleEndian.PutUint32(header[0:], zipCentralHeaderSig)
+		binary.LittleEndian.PutUint16(header[4:], zipCreatorUnix<<8|readerVersion(entry))
+		binary.LittleEndian.PutUint16(header[6:], readerVersion(entry))
+		binary.LittleEndian.PutUint16(header[8:], entryFlags(entry))
+		binary.LittleEndian.PutUint16(header[10:], 0) // store
+		binary.LittleEndian.PutUint16(header[12:], modTime)
+		binary.LittleEndian.PutUint16(header[14:], modDate)
+		binary.LittleEndian.PutUint32(header[16:], crc)
+
+		var extra []byte
+		if entry.zip64 {
+			binary.LittleEndian.PutUint32(header[20:], zipUint32Max)
+			binary.LittleEndian.PutUint32(header[24:], zipUint32Max)
+			extra = make([]byte, zipZip64ExtraLen)
+			binary.LittleEndian.PutUint16(extra[0:], zipZip64ExtraID)
+			binary.LittleEndian.PutUint16(extra[2:], 24)
+			binary.LittleEndian.PutUint64(extra[4:], uint64(entry.Size))
+			binary.LittleEndian.PutUint64(extra[12:], uint64(entry.Size))
+			binary.LittleEndian.PutUint64(extra[20:], uint64(entry.localOffset))
+		} else {
+			binary.LittleEndian.PutUint32(header[20:], uint32(entry.Size))
+			binary.LittleEndian.PutUint32(header[24:], uint32(entry.Size))
+		}
+
+		binary.LittleEndian.PutUint16(header[28:], uint16(len(entry.Name)))
+		binary.LittleEndian.PutUint16(header[30:], uint16(len(extra)))
+		binary.LittleEndian.PutUint32(header[38:], externalAttrs(entry))
+		if entry.localOffset >= zipUint32Max {
+			binary.LittleEndian.PutUint32(header[42:], zipUint32Max)
+		} else {
+			binary.LittleEndian.PutUint32(header[42:], uint32(entry.localOffset))
+		}
+
+		buf = append(buf, header...)
+		buf = append(buf, entry.Name...)
+		buf = append(buf, extra...)
+	}
+
+	records := uint64(len(l.entries))
+	centralSize := uint64(len(buf))
+	offset := uint64(centralOffset)
+
+	if needsZip64End(len(l.entries), int64(centralSize), centralOffset) {
+		end64Offset := offset + centralSize
+		end64 := make([]byte, zipEnd64Len+zipEnd64LocatorLen)
+		binary.LittleEndian.PutUint32(end64[0:], zipEnd64Sig)
+		binary.LittleEndian.PutUint64(end64[4:], zipEnd64Len-12)
+		binary.LittleEndian.PutUint16(end64[12:], zipVersion45)
+		binary.LittleEndian.PutUint16(end64[14:], zipVersion45)
+		binary.LittleEndian.PutUint64(end64[24:], records)
+		binary.LittleEndian.PutUint64(end64[32:], records)
+		binary.LittleEndian.PutUint64(end64[40:], centralSize)
+		binary.LittleEndian.PutUint64(end64[48:], offset)
+		binary.LittleEndian.PutUint32(end64[56:], zipEnd64LocatorSig)
+		binary.LittleEndian.PutUint64(end64[64:], end64Offset)
+		binary.LittleEndian.PutUint32(end64[72:], 1)
+		buf = append(buf, end64...)
+
+		records, centralSize, offset = zipUint16Max, zipUint32Max, zipUint32Max
+	}
+
+	end := make([]byte, zipEndLen)
+	binary.LittleEndian.PutUint32(end[0:], zipEndSig)
+	binary.LittleEndian.PutUint16(end[8:], uint16(min(records, zipUint16Max)))
+	binary.LittleEndian.PutUint16(end[10:], uint16(min(records, zipUint16Max)))
+	binary.LittleEndian.PutUint32(end[12:], uint32(centralSize))
+	binary.LittleEndian.PutUint32(end[16:], uint32(offset))
+	buf = append(buf, end...)
+
+	if int64(len(buf)) != trailerSegment.size {
+		return nil, fmt.Errorf("zip layout trailer size mismatch: %d != %d", len(buf), trailerSegment.size)
+	}
+
+	l.trailer = buf
+	return buf, nil
+}
+
+func needsZip64End(records int, centralSize int64, centralOffset int64) bool {
+	return records >= zipUint16Max || centralSize >= zipUint32Max || centralOffset >= zipUint32Max
+}
+
+func readerVersion(entry layoutEntry) uint16 {
+	if entry.zip64 {
+		return zipVersion45
+	}
+	return zipVersion20
+}
+
+func entryFlags(entry layoutEntry) uint16 {
+	var flags uint16
+	if !entry.IsDir {
+		flags |= zipFlagDataDescriptor
+	}
+	for i := 0; i < len(entry.Name); i++ {
+		if entry.Name[i] >= utf8.RuneSelf {
+			flags |= zipFlagUTF8
+			break
+		}
+	}
+	return flags
+}
+
+func externalAttrs(entry layoutEntry) uint32 {
+	if entry.IsDir {
+		return uint32(zipUnixDirMode|0o755)<<16 | zipMSDOSDirAttr
+	}
+	perm := uint32(entry.Mode.Perm())
+	if perm == 0 {
+		perm = 0o644
+	}
+	return uint32(zipUnixRegularFileMode|perm) << 16
+}
+
+func dosDateTime(t time.Time) (uint16, uint16) {
+	t = t.UTC()
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	date := uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	clock := uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return clock, date
+}
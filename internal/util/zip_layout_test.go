@@ -0,0 +1,90 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func snapshotEntries(t *testing.T, root string) []ArchiveEntry {
+	t.Helper()
+
+	var entries []ArchiveEntry
+	require.NoError(t, filepath.Walk(root, func(current string, info os.FileInfo, err error) error {
+		require.NoError(t, err)
+		if current == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, current)
+		require.NoError(t, err)
+		entry := ArchiveEntry{Name: filepath.ToSlash(rel), IsDir: info.IsDir(), ModTime: info.ModTime(), Mode: info.Mode()}
+		if !info.IsDir() {
+			entry.Source = current
+			entry.Size = info.Size()
+		}
+		entries = append(entries, entry)
+		return nil
+	}))
+	return entries
+}
+
+func TestStoreZipLayout(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs", "empty"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "notes.txt"), []byte(strings.Repeat("hello zip ", 5000)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "zero.bin"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "über.txt"), []byte("unicode name"), 0o600))
+
+	layout := NewStoreZipLayout(snapshotEntries(t, root), NewCRCCache(0))
+	t.Cleanup(func() { _ = layout.Close() })
+
+	full, err := io.ReadAll(io.NewSectionReader(layout, 0, layout.Size()))
+	require.NoError(t, err)
+	require.EqualValues(t, layout.Size(), len(full))
+
+	t.Run("produces a valid archive", func(t *testing.T) {
+		reader, err := zip.NewReader(bytes.NewReader(full), int64(len(full)))
+		require.NoError(t, err)
+		require.Len(t, reader.File, 5)
+
+		for _, file := range reader.File {
+			require.Equal(t, zip.Store, file.Method)
+			rc, err := file.Open()
+			require.NoError(t, err)
+			_, err = io.Copy(io.Discard, rc) // verifies the CRC-32
+			require.NoError(t, err, file.Name)
+			require.NoError(t, rc.Close())
+		}
+	})
+
+	t.Run("serves arbitrary ranges from a fresh reader", func(t *testing.T) {
+		fresh := NewStoreZipLayout(snapshotEntries(t, root), NewCRCCache(0))
+		t.Cleanup(func() { _ = fresh.Close() })
+
+		for _, start := range []int64{0, 7, 1234, layout.Size() - 200, layout.Size() - 1} {
+			buf := make([]byte, min(int64(4096), layout.Size()-start))
+			n, err := fresh.ReadAt(buf, start)
+			require.NoError(t, err)
+			require.Equal(t, full[start:start+int64(n)], buf[:n])
+		}
+	})
+
+	t.Run("etag tracks the snapshot", func(t *testing.T) {
+		same := NewStoreZipLayout(snapshotEntries(t, root), nil)
+		require.Equal(t, layout.ETag(), same.ETag())
+
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(root, "zero.bin"), later, later))
+		changed := NewStoreZipLayout(snapshotEntries(t, root), nil)
+		require.NotEqual(t, layout.ETag(), changed.ETag())
+	})
+}
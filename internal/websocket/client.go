@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"go-file-explorer/internal/middleware"
 )
 
 const (
@@ -40,6 +42,9 @@ type Client struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// ID of the authenticated user, for events with an audience.
+	userID string
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -119,6 +124,9 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256)}
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+		client.userID = claims.UserID
+	}
 	client.hub.register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
@@ -3,6 +3,7 @@ package websocket
 import (
 	"encoding/json"
 	"log/slog"
+	"slices"
 
 	"go-file-explorer/internal/event"
 )
@@ -51,8 +52,11 @@ func (h *Hub) Run() {
 				slog.Error("failed to marshal event", "error", err)
 				continue
 			}
-			// Broadcast to all clients
+			// Broadcast to all clients in the audience
 			for client := range h.clients {
+				if len(e.Audience) > 0 && !slices.Contains(e.Audience, client.userID) {
+					continue
+				}
 				select {
 				case client.send <- message:
 				default:
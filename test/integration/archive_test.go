@@ -0,0 +1,131 @@
+//go:build integration
+
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/handler"
+	"go-file-explorer/internal/model"
+	"go-file-explorer/internal/service"
+	"go-file-explorer/internal/storage"
+)
+
+func TestArchiveDownloadsAndTickets(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for _, filePath := range []string{"/docs/a.txt", "/docs/b.txt"} {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		_, err = file.WriteString("content")
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	zipNames := func(resp *http.Response) []string {
+		t.Helper()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		require.NoError(t, err)
+		names := make([]string, 0, len(reader.File))
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+		}
+		return names
+	}
+	auditEntries := func(action string) []model.AuditEntry {
+		t.Helper()
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/audit?action="+action, accessToken)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var payload struct {
+			Data struct {
+				Items []model.AuditEntry `json:"items"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		return payload.Data.Items
+	}
+
+	// Direct downloads are audited under the requesting user.
+	selection := []byte(`{"paths":["/docs/a.txt","/docs/b.txt"]}`)
+	resp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/files/archive", selection, accessToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.ElementsMatch(t, []string{"a.txt", "b.txt"}, zipNames(resp))
+	require.NoError(t, resp.Body.Close())
+
+	entries := auditEntries("archive_download")
+	require.Len(t, entries, 1)
+	require.Equal(t, "admin", entries[0].Actor.Username)
+	require.Equal(t, "/docs/a.txt,/docs/b.txt", entries[0].Resource)
+
+	resp = doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/files/archive", []byte(`{"paths":["/missing"]}`), accessToken)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Len(t, auditEntries("archive_download"), 1)
+
+	// A ticket downloads once without credentials.
+	resp = doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/files/archive/tickets", []byte(`{"paths":["/docs"]}`), accessToken)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		Data model.ArchiveTicket `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NoError(t, resp.Body.Close())
+
+	resp, err = http.Get(server.URL + created.Data.DownloadURL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.ElementsMatch(t, []string{"docs/", "docs/a.txt", "docs/b.txt"}, zipNames(resp))
+	require.NoError(t, resp.Body.Close())
+
+	resp, err = http.Get(server.URL + created.Data.DownloadURL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	entries = auditEntries("archive_ticket_download")
+	require.Len(t, entries, 1)
+	require.Equal(t, "admin", entries[0].Actor.Username)
+}
+
+func TestArchiveTicketsExpire(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	file, err := store.OpenForWrite("/a.txt")
+	require.NoError(t, err)
+	_, err = file.WriteString("content")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	archiveService := service.NewArchiveService(store, 50*time.Millisecond)
+	router := chi.NewRouter()
+	router.Get("/api/v1/files/archive/{ticket}", handler.NewArchiveHandler(archiveService).TicketDownload)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	ticket, err := archiveService.CreateTicket(model.ArchiveRequest{Paths: []string{"/a.txt"}}, model.AuditActor{Username: "alice"})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get(server.URL + ticket.DownloadURL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,76 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/storage"
+)
+
+func TestFuzzySearchAndQuickOpen(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for _, filePath := range []string{"/QuarterlyReport.xlsx", "/archive/2024/quarterly-report-old.xlsx", "/notes/quick-questions.md", "/photos/queen.jpg"} {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	require.Eventually(t, func() bool {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?owner=nobody", accessToken)
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 10*time.Second, 100*time.Millisecond)
+
+	type searchPayload struct {
+		Data struct {
+			Items []struct {
+				Path         string `json:"path"`
+				MatchContext string `json:"match_context"`
+				Score        int    `json:"score"`
+			} `json:"items"`
+		} `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	get := func(endpoint string) searchPayload {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+endpoint, accessToken)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var payload searchPayload
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		return payload
+	}
+
+	payload := get("/api/v1/search?q=qreport&mode=fuzzy")
+	require.Equal(t, 2, payload.Meta.Total)
+	require.Equal(t, "/QuarterlyReport.xlsx", payload.Data.Items[0].Path)
+	require.Equal(t, "/archive/2024/quarterly-report-old.xlsx", payload.Data.Items[1].Path)
+	require.Greater(t, payload.Data.Items[0].Score, payload.Data.Items[1].Score)
+	require.Equal(t, "<mark>Q</mark>uarterly<mark>Report</mark>.xlsx", payload.Data.Items[0].MatchContext)
+
+	payload = get("/api/v1/search/quick?q=qr&limit=1")
+	require.Len(t, payload.Data.Items, 1)
+	require.Equal(t, "/QuarterlyReport.xlsx", payload.Data.Items[0].Path)
+
+	payload = get("/api/v1/search/quick?q=qu&type=file&path=/notes")
+	require.Len(t, payload.Data.Items, 1)
+	require.Equal(t, "/notes/quick-questions.md", payload.Data.Items[0].Path)
+
+	for _, endpoint := range []string{"/api/v1/search/quick", "/api/v1/search?q=report&mode=exact", "/api/v1/search?q=ext:pdf&mode=fuzzy"} {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+endpoint, accessToken)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, endpoint)
+		_ = resp.Body.Close()
+	}
+}
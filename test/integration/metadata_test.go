@@ -0,0 +1,141 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/storage"
+)
+
+func TestFileMetadata(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for _, filePath := range []string{"/invoices/a.pdf", "/invoices/b.pdf"} {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	send := func(method string, endpoint string, body string, status int, target any) {
+		t.Helper()
+		resp := doAuthJSONRequest(t, method, server.URL+endpoint, []byte(body), accessToken)
+		defer resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode, endpoint)
+		if target != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(target))
+		}
+	}
+	type metadataPayload struct {
+		Data struct {
+			Path   string         `json:"path"`
+			Values map[string]any `json:"values"`
+		} `json:"data"`
+	}
+	valuesOf := func(apiPath string) map[string]any {
+		var payload metadataPayload
+		send(http.MethodGet, "/api/v1/files/metadata?path="+apiPath, "", http.StatusOK, &payload)
+		return payload.Data.Values
+	}
+
+	send(http.MethodPost, "/api/v1/metadata/fields", `{"name":"client","type":"string"}`, http.StatusCreated, nil)
+	send(http.MethodPost, "/api/v1/metadata/fields", `{"name":"amount","type":"number"}`, http.StatusCreated, nil)
+	send(http.MethodPost, "/api/v1/metadata/fields", `{"name":"due","type":"date"}`, http.StatusCreated, nil)
+	send(http.MethodPost, "/api/v1/metadata/fields", `{"name":"status","type":"enum","options":["draft","final"]}`, http.StatusCreated, nil)
+	send(http.MethodPost, "/api/v1/metadata/fields", `{"name":"client","type":"string"}`, http.StatusConflict, nil)
+	send(http.MethodPost, "/api/v1/metadata/fields", `{"name":"Client","type":"string"}`, http.StatusBadRequest, nil)
+	send(http.MethodPut, "/api/v1/metadata/fields/status", `{"options":["draft","final","paid"]}`, http.StatusOK, nil)
+	send(http.MethodPut, "/api/v1/metadata/fields/status", `{"options":["paid"]}`, http.StatusBadRequest, nil)
+
+	var fields struct {
+		Data struct {
+			Items []struct {
+				Name    string   `json:"name"`
+				Options []string `json:"options"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	send(http.MethodGet, "/api/v1/metadata/fields", "", http.StatusOK, &fields)
+	require.Len(t, fields.Data.Items, 4)
+
+	var patched metadataPayload
+	send(http.MethodPatch, "/api/v1/files/metadata", `{"path":"/invoices/a.pdf","values":{"client":"Acme","amount":1200.5,"due":"2026-07-01","status":"final"}}`, http.StatusOK, &patched)
+	require.Equal(t, map[string]any{"client": "Acme", "amount": 1200.5, "due": "2026-07-01", "status": "final"}, patched.Data.Values)
+	send(http.MethodPatch, "/api/v1/files/metadata", `{"path":"/invoices/b.pdf","values":{"amount":80,"status":"draft"}}`, http.StatusOK, nil)
+
+	send(http.MethodPatch, "/api/v1/files/metadata", `{"path":"/invoices/b.pdf","values":{"amount":"80"}}`, http.StatusBadRequest, nil)
+	send(http.MethodPatch, "/api/v1/files/metadata", `{"path":"/invoices/b.pdf","values":{"status":"lost"}}`, http.StatusBadRequest, nil)
+	send(http.MethodPatch, "/api/v1/files/metadata", `{"path":"/invoices/b.pdf","values":{"due":"July 1"}}`, http.StatusBadRequest, nil)
+	send(http.MethodPatch, "/api/v1/files/metadata", `{"path":"/invoices/b.pdf","values":{"unknown":"x"}}`, http.StatusBadRequest, nil)
+	send(http.MethodPatch, "/api/v1/files/metadata", `{"path":"/missing.pdf","values":{"client":"x"}}`, http.StatusNotFound, nil)
+
+	// A null value removes the field.
+	send(http.MethodPatch, "/api/v1/files/metadata", `{"path":"/invoices/b.pdf","values":{"status":null}}`, http.StatusOK, nil)
+	require.Equal(t, map[string]any{"amount": 80.0}, valuesOf("/invoices/b.pdf"))
+
+	var info struct {
+		Data struct {
+			Metadata map[string]any `json:"metadata"`
+		} `json:"data"`
+	}
+	send(http.MethodGet, "/api/v1/files/info?path=/invoices/a.pdf", "", http.StatusOK, &info)
+	require.Equal(t, "Acme", info.Data.Metadata["client"])
+
+	// Values follow renames and are duplicated by copies.
+	send(http.MethodPut, "/api/v1/files/rename", `{"path":"/invoices","new_name":"billing"}`, http.StatusOK, nil)
+	require.Equal(t, "Acme", valuesOf("/billing/a.pdf")["client"])
+	send(http.MethodPost, "/api/v1/files/copy", `{"sources":["/billing/a.pdf"],"destination":"/"}`, http.StatusOK, nil)
+	require.Equal(t, "Acme", valuesOf("/a.pdf")["client"])
+	require.Equal(t, "Acme", valuesOf("/billing/a.pdf")["client"])
+
+	type listPayload struct {
+		Data struct {
+			Items []struct {
+				Path string `json:"path"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	search := func(query string) []string {
+		var paths []string
+		require.Eventually(t, func() bool {
+			resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?sort=path&"+query, accessToken)
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return false
+			}
+			var payload listPayload
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+			paths = paths[:0]
+			for _, item := range payload.Data.Items {
+				paths = append(paths, item.Path)
+			}
+			return true
+		}, 10*time.Second, 100*time.Millisecond)
+		return paths
+	}
+	require.Eventually(t, func() bool {
+		return len(search("meta=amount>100")) == 2
+	}, 10*time.Second, 100*time.Millisecond)
+	require.Equal(t, []string{"/a.pdf", "/billing/a.pdf"}, search("meta=amount>100"))
+	require.Equal(t, []string{"/billing/b.pdf"}, search("q=meta:amount<=80"))
+	require.Equal(t, []string{"/billing/a.pdf"}, search("meta=amount!=80&path=/billing"))
+	require.Equal(t, []string{"/a.pdf", "/billing/a.pdf"}, search("meta=status=final"))
+	require.Equal(t, []string{"/a.pdf", "/billing/a.pdf"}, search("meta=due<2026-12-31"))
+
+	resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?meta=client>acme", accessToken)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Deleting a field drops its values.
+	send(http.MethodDelete, "/api/v1/metadata/fields/client", "", http.StatusOK, nil)
+	require.NotContains(t, valuesOf("/a.pdf"), "client")
+}
@@ -5,6 +5,8 @@ package integration
 import (
 	"bytes"
 	"encoding/json"
+	"image"
+	"image/png"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -185,6 +187,10 @@ func TestAsyncJobOperationsLifecycle(t *testing.T) {
 
 	require.Contains(t, []string{"completed", "partial", "failed"}, status)
 
+	cancelResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/jobs/"+jobID+"/cancel", nil, accessToken)
+	t.Cleanup(func() { _ = cancelResp.Body.Close() })
+	require.Equal(t, http.StatusConflict, cancelResp.StatusCode)
+
 	itemsResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/jobs/"+jobID+"/items?page=1&limit=50", accessToken)
 	t.Cleanup(func() { _ = itemsResp.Body.Close() })
 	require.Equal(t, http.StatusOK, itemsResp.StatusCode)
@@ -204,4 +210,188 @@ func TestAsyncJobOperationsLifecycle(t *testing.T) {
 	for _, item := range itemsPayload.Data.Items {
 		require.True(t, strings.TrimSpace(item.Status) != "")
 	}
+
+	if status == "completed" {
+		retryResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/jobs/"+jobID+"/retry", nil, accessToken)
+		t.Cleanup(func() { _ = retryResp.Body.Close() })
+		require.Equal(t, http.StatusConflict, retryResp.StatusCode)
+	}
+
+	listResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/jobs?operation=copy&page=1&limit=10", accessToken)
+	t.Cleanup(func() { _ = listResp.Body.Close() })
+	require.Equal(t, http.StatusOK, listResp.StatusCode)
+
+	var listPayload struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Items []struct {
+				JobID string `json:"job_id"`
+			} `json:"items"`
+		} `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listPayload))
+	require.True(t, listPayload.Success)
+	require.GreaterOrEqual(t, listPayload.Meta.Total, 1)
+	require.Equal(t, jobID, listPayload.Data.Items[0].JobID)
+
+	deleteBody, err := json.Marshal(map[string]any{"job_ids": []string{jobID}})
+	require.NoError(t, err)
+	deleteResp := doAuthJSONRequest(t, http.MethodDelete, server.URL+"/api/v1/jobs", deleteBody, accessToken)
+	t.Cleanup(func() { _ = deleteResp.Body.Close() })
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	var deletePayload struct {
+		Data struct {
+			Deleted []string `json:"deleted"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(deleteResp.Body).Decode(&deletePayload))
+	require.Equal(t, []string{jobID}, deletePayload.Data.Deleted)
+
+	goneResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/jobs/"+jobID, accessToken)
+	t.Cleanup(func() { _ = goneResp.Body.Close() })
+	require.Equal(t, http.StatusNotFound, goneResp.StatusCode)
+}
+
+func TestJobAccessRestrictedToOwnerOrAdmin(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	f, err := store.OpenForWrite("/owned/a.txt")
+	require.NoError(t, err)
+	_, err = f.WriteString("a")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	server, adminToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	ownerToken := registerAndLogin(t, server.URL, adminToken, "job-owner", "editor")
+	otherToken := registerAndLogin(t, server.URL, adminToken, "job-other", "editor")
+
+	createBody, err := json.Marshal(map[string]any{
+		"operation":   "copy",
+		"sources":     []string{"/owned/a.txt"},
+		"destination": "/owned-copy",
+	})
+	require.NoError(t, err)
+	createResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/jobs/operations", createBody, ownerToken)
+	t.Cleanup(func() { _ = createResp.Body.Close() })
+	require.Equal(t, http.StatusAccepted, createResp.StatusCode)
+
+	var created struct {
+		Data struct {
+			JobID         string `json:"job_id"`
+			OwnerUsername string `json:"owner_username"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&created))
+	require.Equal(t, "job-owner", created.Data.OwnerUsername)
+	jobURL := server.URL + "/api/v1/jobs/" + created.Data.JobID
+
+	for _, path := range []string{"", "/items", "/stream"} {
+		resp := doAuthRequest(t, http.MethodGet, jobURL+path, otherToken)
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+	cancelResp := doAuthJSONRequest(t, http.MethodPost, jobURL+"/cancel", nil, otherToken)
+	t.Cleanup(func() { _ = cancelResp.Body.Close() })
+	require.Equal(t, http.StatusNotFound, cancelResp.StatusCode)
+
+	ownerResp := doAuthRequest(t, http.MethodGet, jobURL, ownerToken)
+	t.Cleanup(func() { _ = ownerResp.Body.Close() })
+	require.Equal(t, http.StatusOK, ownerResp.StatusCode)
+
+	adminResp := doAuthRequest(t, http.MethodGet, jobURL, adminToken)
+	t.Cleanup(func() { _ = adminResp.Body.Close() })
+	require.Equal(t, http.StatusOK, adminResp.StatusCode)
+
+	// The copy is audited as the job owner.
+	for attempt := 0; attempt < 25; attempt++ {
+		auditResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/audit?action=copy", adminToken)
+		var audit struct {
+			Data struct {
+				Items []struct {
+					Actor struct {
+						Username string `json:"username"`
+					} `json:"actor"`
+				} `json:"items"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(auditResp.Body).Decode(&audit)
+		_ = auditResp.Body.Close()
+		require.NoError(t, err)
+		if len(audit.Data.Items) > 0 {
+			require.Equal(t, "job-owner", audit.Data.Items[0].Actor.Username)
+			return
+		}
+		time.Sleep(80 * time.Millisecond)
+	}
+	t.Fatal("copy job was not audited")
+}
+
+func TestThumbnailsJobPregeneratesSubtree(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for _, path := range []string{"/photos/a.png", "/photos/2026/b.png"} {
+		file, err := store.OpenForWrite(path)
+		require.NoError(t, err)
+		require.NoError(t, png.Encode(file, image.NewRGBA(image.Rect(0, 0, 320, 200))))
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	createBody, err := json.Marshal(map[string]any{"operation": "thumbnails", "paths": []string{"/photos"}})
+	require.NoError(t, err)
+	createResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/jobs/operations", createBody, accessToken)
+	t.Cleanup(func() { _ = createResp.Body.Close() })
+	require.Equal(t, http.StatusAccepted, createResp.StatusCode)
+
+	var createPayload struct {
+		Data struct {
+			JobID string `json:"job_id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&createPayload))
+	require.NotEmpty(t, createPayload.Data.JobID)
+
+	var status string
+	require.Eventually(t, func() bool {
+		statusResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/jobs/"+createPayload.Data.JobID, accessToken)
+		defer statusResp.Body.Close()
+		var statusPayload struct {
+			Data struct {
+				Status string `json:"status"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(statusResp.Body).Decode(&statusPayload))
+		status = statusPayload.Data.Status
+		return status != "queued" && status != "running"
+	}, 10*time.Second, 80*time.Millisecond)
+	require.Equal(t, "completed", status)
+
+	itemsResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/jobs/"+createPayload.Data.JobID+"/items", accessToken)
+	t.Cleanup(func() { _ = itemsResp.Body.Close() })
+	require.Equal(t, http.StatusOK, itemsResp.StatusCode)
+
+	var itemsPayload struct {
+		Data struct {
+			Items []struct {
+				Path   string `json:"path"`
+				Status string `json:"status"`
+				Reason string `json:"reason"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(itemsResp.Body).Decode(&itemsPayload))
+	require.Len(t, itemsPayload.Data.Items, 1)
+	require.Equal(t, "/photos", itemsPayload.Data.Items[0].Path)
+	require.Equal(t, "success", itemsPayload.Data.Items[0].Status)
+	require.Equal(t, "2 images processed, 0 failed", itemsPayload.Data.Items[0].Reason)
 }
@@ -0,0 +1,90 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/storage"
+)
+
+func TestPipelineRunsStepsInOrder(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	f, err := store.OpenForWrite("/pipeline/a.txt")
+	require.NoError(t, err)
+	_, err = f.WriteString("a")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	invalidBody, err := json.Marshal(map[string]any{
+		"steps": []map[string]any{
+			{"request": map[string]any{"operation": "delete", "paths": []string{"{steps.later.paths}"}}},
+		},
+	})
+	require.NoError(t, err)
+	invalidResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/pipelines", invalidBody, accessToken)
+	t.Cleanup(func() { _ = invalidResp.Body.Close() })
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+
+	createBody, err := json.Marshal(map[string]any{
+		"name": "copy then clean up",
+		"steps": []map[string]any{
+			{"id": "copy", "request": map[string]any{"operation": "copy", "sources": []string{"/pipeline/a.txt"}, "destination": "/pipeline-copy"}},
+			{"request": map[string]any{"operation": "delete", "paths": []string{"{steps.copy.sources}"}}},
+		},
+	})
+	require.NoError(t, err)
+	createResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/pipelines", createBody, accessToken)
+	t.Cleanup(func() { _ = createResp.Body.Close() })
+	require.Equal(t, http.StatusAccepted, createResp.StatusCode)
+
+	type pipelinePayload struct {
+		Data struct {
+			PipelineID string `json:"pipeline_id"`
+			Status     string `json:"status"`
+			Steps      []struct {
+				JobID  string `json:"job_id"`
+				Status string `json:"status"`
+			} `json:"steps"`
+		} `json:"data"`
+	}
+	var created pipelinePayload
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&created))
+	require.NotEmpty(t, created.Data.PipelineID)
+	require.Len(t, created.Data.Steps, 2)
+	require.NotEmpty(t, created.Data.Steps[0].JobID)
+
+	var current pipelinePayload
+	for attempt := 0; attempt < 50; attempt++ {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/pipelines/"+created.Data.PipelineID, accessToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.NewDecoder(resp.Body).Decode(&current)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+		if current.Data.Status != "running" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	require.Equal(t, "completed", current.Data.Status)
+	for _, step := range current.Data.Steps {
+		require.Equal(t, "completed", step.Status)
+		require.NotEmpty(t, step.JobID)
+	}
+
+	_, err = store.Stat("/pipeline-copy/a.txt")
+	require.NoError(t, err)
+	_, err = store.Stat("/pipeline/a.txt")
+	require.Error(t, err)
+}
@@ -0,0 +1,148 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/storage"
+)
+
+func TestFavoritesAndRecentFiles(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for _, filePath := range []string{"/projects/alpha/plan.md", "/projects/beta.txt", "/notes.txt"} {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		_, err = file.Write([]byte("content"))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	send := func(method string, endpoint string, body string, status int, target any) {
+		t.Helper()
+		resp := doAuthJSONRequest(t, method, server.URL+endpoint, []byte(body), accessToken)
+		defer resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode, endpoint)
+		if target != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(target))
+		}
+	}
+	favorites := func() []string {
+		var payload struct {
+			Data struct {
+				Items []struct {
+					Path string `json:"path"`
+					Type string `json:"type"`
+				} `json:"items"`
+			} `json:"data"`
+		}
+		send(http.MethodGet, "/api/v1/favorites", "", http.StatusOK, &payload)
+		paths := make([]string, 0, len(payload.Data.Items))
+		for _, item := range payload.Data.Items {
+			paths = append(paths, item.Path)
+		}
+		return paths
+	}
+	type recentItem struct {
+		Path   string `json:"path"`
+		Action string `json:"action"`
+		Size   int64  `json:"size"`
+	}
+	recent := func() []recentItem {
+		var payload struct {
+			Data struct {
+				Items []recentItem `json:"items"`
+			} `json:"data"`
+		}
+		send(http.MethodGet, "/api/v1/recent", "", http.StatusOK, &payload)
+		return payload.Data.Items
+	}
+
+	send(http.MethodPost, "/api/v1/favorites", `{"path":"/projects/alpha"}`, http.StatusOK, nil)
+	send(http.MethodPost, "/api/v1/favorites", `{"path":"/notes.txt"}`, http.StatusOK, nil)
+	send(http.MethodPost, "/api/v1/favorites", `{"path":"/projects/alpha"}`, http.StatusOK, nil)
+	send(http.MethodPost, "/api/v1/favorites", `{"path":"/missing"}`, http.StatusNotFound, nil)
+	send(http.MethodPost, "/api/v1/favorites", `{"path":"/"}`, http.StatusBadRequest, nil)
+	require.Equal(t, []string{"/projects/alpha", "/notes.txt"}, favorites())
+
+	send(http.MethodPut, "/api/v1/favorites/order", `{"paths":["/notes.txt","/projects/alpha"]}`, http.StatusOK, nil)
+	require.Equal(t, []string{"/notes.txt", "/projects/alpha"}, favorites())
+	send(http.MethodPut, "/api/v1/favorites/order", `{"paths":["/notes.txt"]}`, http.StatusBadRequest, nil)
+
+	resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/files/download?path=/projects/alpha/plan.md", accessToken)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/files/preview?path=/notes.txt", accessToken)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("path", "/projects"))
+	part, err := writer.CreateFormFile("files", "report.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("report"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/files/upload", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	items := recent()
+	require.Len(t, items, 3)
+	require.Equal(t, recentItem{Path: "/projects/report.txt", Action: "upload", Size: 6}, items[0])
+	require.Equal(t, "/notes.txt", items[1].Path)
+	require.Equal(t, "preview", items[1].Action)
+	require.Equal(t, "/projects/alpha/plan.md", items[2].Path)
+	require.Equal(t, "download", items[2].Action)
+
+	// Renames and moves carry favorites and recent files along; renames count
+	// as edits.
+	send(http.MethodPut, "/api/v1/files/rename", `{"path":"/projects","new_name":"work"}`, http.StatusOK, nil)
+	send(http.MethodPut, "/api/v1/files/rename", `{"path":"/notes.txt","new_name":"todo.txt"}`, http.StatusOK, nil)
+	require.Equal(t, []string{"/todo.txt", "/work/alpha"}, favorites())
+	items = recent()
+	require.Len(t, items, 3)
+	require.Equal(t, "/todo.txt", items[0].Path)
+	require.Equal(t, "edit", items[0].Action)
+	require.Equal(t, "/work/report.txt", items[1].Path)
+	require.Equal(t, "/work/alpha/plan.md", items[2].Path)
+
+	// Trashed entries leave the lists; restoring brings their favorites back
+	// in place, and purging the trash drops them for good.
+	send(http.MethodDelete, "/api/v1/files", `{"paths":["/work/alpha"]}`, http.StatusOK, nil)
+	require.Equal(t, []string{"/todo.txt"}, favorites())
+	require.Len(t, recent(), 2)
+	send(http.MethodPost, "/api/v1/files/restore", `{"paths":["/work/alpha"]}`, http.StatusOK, nil)
+	require.Equal(t, []string{"/todo.txt", "/work/alpha"}, favorites())
+	require.Len(t, recent(), 2)
+	send(http.MethodDelete, "/api/v1/files", `{"paths":["/work/alpha"]}`, http.StatusOK, nil)
+	send(http.MethodDelete, "/api/v1/trash", "", http.StatusOK, nil)
+	require.Equal(t, []string{"/todo.txt"}, favorites())
+
+	// So are entries removed behind the API's back.
+	resolved, err := store.Resolve("/todo.txt")
+	require.NoError(t, err)
+	require.NoError(t, store.RemoveAll("/todo.txt"))
+	require.NoFileExists(t, resolved)
+	require.Empty(t, favorites())
+	require.Len(t, recent(), 1)
+
+	send(http.MethodDelete, "/api/v1/favorites", `{"path":"/gone"}`, http.StatusOK, nil)
+}
@@ -0,0 +1,156 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/storage"
+)
+
+func TestSavedSearchFolders(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for filePath, size := range map[string]int{
+		"/contracts/lease.pdf":   3 << 10,
+		"/contracts/supply.pdf":  9 << 10,
+		"/contracts/notes.txt":   1 << 10,
+		"/photos/contract.pdf":   2 << 10,
+		"/contracts/old/nda.pdf": 5 << 10,
+	} {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		_, err = file.Write(bytes.Repeat([]byte("x"), size))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, adminToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+	viewerToken := registerAndLogin(t, server.URL, adminToken, "saved-search-viewer", "viewer")
+
+	type savedSearch struct {
+		ID     string `json:"id"`
+		Path   string `json:"path"`
+		Shared bool   `json:"shared"`
+	}
+	create := func(token string, body map[string]any) (int, savedSearch) {
+		encoded, err := json.Marshal(body)
+		require.NoError(t, err)
+		resp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/saved-searches", encoded, token)
+		defer resp.Body.Close()
+		var payload struct {
+			Data savedSearch `json:"data"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&payload)
+		return resp.StatusCode, payload.Data
+	}
+
+	status, _ := create(adminToken, map[string]any{"name": "broken", "query": map[string]any{"q": "size:>10XB"}})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, pdfs := create(adminToken, map[string]any{
+		"name":  "Contract PDFs",
+		"query": map[string]any{"q": "ext:pdf modified:this-year", "path": "/contracts", "sort": "-size"},
+	})
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, "/.searches/"+pdfs.ID, pdfs.Path)
+
+	status, _ = create(adminToken, map[string]any{"name": "contract pdfs", "query": map[string]any{"ext": "pdf"}})
+	require.Equal(t, http.StatusConflict, status)
+
+	status, shared := create(adminToken, map[string]any{"name": "Text files", "query": map[string]any{"ext": "txt"}, "shared": true})
+	require.Equal(t, http.StatusCreated, status)
+
+	type listPayload struct {
+		Data struct {
+			CurrentPath string `json:"current_path"`
+			ParentPath  string `json:"parent_path"`
+			Items       []struct {
+				Name    string `json:"name"`
+				Path    string `json:"path"`
+				Type    string `json:"type"`
+				Virtual bool   `json:"virtual"`
+			} `json:"items"`
+		} `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	list := func(token string, query string) (int, listPayload) {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/files?"+query, token)
+		defer resp.Body.Close()
+		var payload listPayload
+		_ = json.NewDecoder(resp.Body).Decode(&payload)
+		return resp.StatusCode, payload
+	}
+
+	status, root := list(adminToken, "path=/.searches")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 2, root.Meta.Total)
+	require.Equal(t, "Contract PDFs", root.Data.Items[0].Name)
+	require.True(t, root.Data.Items[0].Virtual)
+	require.Equal(t, "directory", root.Data.Items[0].Type)
+
+	status, folder := list(adminToken, "path="+pdfs.Path)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, pdfs.Path, folder.Data.CurrentPath)
+	require.Equal(t, "/.searches", folder.Data.ParentPath)
+	require.Equal(t, 3, folder.Meta.Total)
+	require.Equal(t, "/contracts/supply.pdf", folder.Data.Items[0].Path)
+
+	status, folder = list(adminToken, "path="+pdfs.Path+"&sort=name&order=asc&limit=2")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, folder.Data.Items, 2)
+	require.Equal(t, "/contracts/old/nda.pdf", folder.Data.Items[1].Path)
+
+	// Private searches are hidden from other users; shared ones are not.
+	status, root = list(viewerToken, "path=/.searches")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, root.Meta.Total)
+	require.Equal(t, shared.Path, root.Data.Items[0].Path)
+
+	status, _ = list(viewerToken, "path="+pdfs.Path)
+	require.Equal(t, http.StatusNotFound, status)
+
+	updateBody, err := json.Marshal(map[string]any{"name": "Renamed"})
+	require.NoError(t, err)
+	updateResp := doAuthJSONRequest(t, http.MethodPut, server.URL+"/api/v1/saved-searches/"+shared.ID, updateBody, viewerToken)
+	t.Cleanup(func() { _ = updateResp.Body.Close() })
+	require.Equal(t, http.StatusForbidden, updateResp.StatusCode)
+
+	treeResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/tree?path=/", adminToken)
+	t.Cleanup(func() { _ = treeResp.Body.Close() })
+	require.Equal(t, http.StatusOK, treeResp.StatusCode)
+	var treePayload struct {
+		Data struct {
+			Nodes []struct {
+				Path    string `json:"path"`
+				Virtual bool   `json:"virtual"`
+			} `json:"nodes"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(treeResp.Body).Decode(&treePayload))
+	last := treePayload.Data.Nodes[len(treePayload.Data.Nodes)-1]
+	require.Equal(t, "/.searches", last.Path)
+	require.True(t, last.Virtual)
+
+	createDirBody, err := json.Marshal(map[string]string{"path": "/.searches", "name": "x"})
+	require.NoError(t, err)
+	createDirResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/directories", createDirBody, adminToken)
+	t.Cleanup(func() { _ = createDirResp.Body.Close() })
+	require.Equal(t, http.StatusForbidden, createDirResp.StatusCode)
+
+	deleteResp := doAuthRequest(t, http.MethodDelete, server.URL+"/api/v1/saved-searches/"+pdfs.ID, adminToken)
+	t.Cleanup(func() { _ = deleteResp.Body.Close() })
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	status, _ = list(adminToken, "path="+pdfs.Path)
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/storage"
+)
+
+func TestScheduleCRUDAndRuns(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	invalidBody, err := json.Marshal(map[string]any{
+		"name":    "broken",
+		"cron":    "0 25 * * *",
+		"request": map[string]any{"operation": "empty_trash"},
+	})
+	require.NoError(t, err)
+	invalidResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/schedules", invalidBody, accessToken)
+	t.Cleanup(func() { _ = invalidResp.Body.Close() })
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+
+	createBody, err := json.Marshal(map[string]any{
+		"name":     "weekly trash",
+		"cron":     "0 3 * * sun",
+		"timezone": "UTC",
+		"request":  map[string]any{"operation": "empty_trash", "older_than": "30d"},
+	})
+	require.NoError(t, err)
+	createResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/schedules", createBody, accessToken)
+	t.Cleanup(func() { _ = createResp.Body.Close() })
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+
+	var createPayload struct {
+		Data struct {
+			ID            string `json:"id"`
+			MisfirePolicy string `json:"misfire_policy"`
+			Enabled       bool   `json:"enabled"`
+			NextRunAt     string `json:"next_run_at"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&createPayload))
+	require.NotEmpty(t, createPayload.Data.ID)
+	require.Equal(t, "run_once", createPayload.Data.MisfirePolicy)
+	require.True(t, createPayload.Data.Enabled)
+	require.NotEmpty(t, createPayload.Data.NextRunAt)
+
+	scheduleURL := server.URL + "/api/v1/schedules/" + createPayload.Data.ID
+
+	updateBody, err := json.Marshal(map[string]any{"enabled": false})
+	require.NoError(t, err)
+	updateResp := doAuthJSONRequest(t, http.MethodPut, scheduleURL, updateBody, accessToken)
+	t.Cleanup(func() { _ = updateResp.Body.Close() })
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+
+	var updatePayload struct {
+		Data struct {
+			Enabled bool `json:"enabled"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(updateResp.Body).Decode(&updatePayload))
+	require.False(t, updatePayload.Data.Enabled)
+
+	listResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/schedules", accessToken)
+	t.Cleanup(func() { _ = listResp.Body.Close() })
+	require.Equal(t, http.StatusOK, listResp.StatusCode)
+
+	var listPayload struct {
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listPayload))
+	require.Equal(t, 1, listPayload.Meta.Total)
+
+	runsResp := doAuthRequest(t, http.MethodGet, scheduleURL+"/runs", accessToken)
+	t.Cleanup(func() { _ = runsResp.Body.Close() })
+	require.Equal(t, http.StatusOK, runsResp.StatusCode)
+
+	deleteResp := doAuthJSONRequest(t, http.MethodDelete, scheduleURL, nil, accessToken)
+	t.Cleanup(func() { _ = deleteResp.Body.Close() })
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	goneResp := doAuthRequest(t, http.MethodGet, scheduleURL, accessToken)
+	t.Cleanup(func() { _ = goneResp.Body.Close() })
+	require.Equal(t, http.StatusNotFound, goneResp.StatusCode)
+}
@@ -0,0 +1,472 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/storage"
+)
+
+func TestSearchIndexFindsEntriesBeyondWalkDepth(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	deepPath := "/" + strings.Repeat("level/", 12) + "deep-report.txt"
+	file, err := store.OpenForWrite(deepPath)
+	require.NoError(t, err)
+	_, err = file.WriteString("deep")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	type searchPayload struct {
+		Data struct {
+			Items []struct {
+				Path     string `json:"path"`
+				MimeType string `json:"mime_type"`
+			} `json:"items"`
+		} `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	search := func(query string) searchPayload {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?q="+query, accessToken)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var payload searchPayload
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		return payload
+	}
+
+	require.Eventually(t, func() bool {
+		return search("deep-report").Meta.Total == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	payload := search("deep-report")
+	require.Equal(t, deepPath, payload.Data.Items[0].Path)
+	require.Equal(t, "text/plain", payload.Data.Items[0].MimeType)
+
+	renameBody, err := json.Marshal(map[string]string{"path": deepPath, "new_name": "renamed-report.txt"})
+	require.NoError(t, err)
+	renameResp := doAuthJSONRequest(t, http.MethodPut, server.URL+"/api/v1/files/rename", renameBody, accessToken)
+	t.Cleanup(func() { _ = renameResp.Body.Close() })
+	require.Equal(t, http.StatusOK, renameResp.StatusCode)
+
+	require.Eventually(t, func() bool {
+		return search("deep-report").Meta.Total == 0 && search("renamed-report").Meta.Total == 1
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestSearchIndexMatchesGoRegexSyntax(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	deepDir := "/" + strings.Repeat("level/", 12)
+	for _, name := range []string{"q3 report.txt", "q3report.txt", "report.txt.bak"} {
+		file, err := store.OpenForWrite(deepDir + name)
+		require.NoError(t, err)
+		_, err = file.WriteString("report")
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	// The files sit beyond the walk depth, so only the index can find them.
+	search := func(regex string) (int, []string) {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?regex="+url.QueryEscape(regex), accessToken)
+		defer resp.Body.Close()
+		var payload struct {
+			Data struct {
+				Items []struct {
+					Path string `json:"path"`
+				} `json:"items"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		paths := make([]string, 0, len(payload.Data.Items))
+		for _, item := range payload.Data.Items {
+			paths = append(paths, strings.TrimPrefix(item.Path, deepDir))
+		}
+		return resp.StatusCode, paths
+	}
+
+	require.Eventually(t, func() bool {
+		_, paths := search(`^q3`)
+		return len(paths) == 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// \b, \z and named groups are Go syntax that PostgreSQL reads differently.
+	status, paths := search(`^(?P<quarter>q3)\b`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"q3 report.txt"}, paths)
+
+	status, paths = search(`report\.txt\z`)
+	require.Equal(t, http.StatusOK, status)
+	require.ElementsMatch(t, []string{"q3 report.txt", "q3report.txt"}, paths)
+
+	status, _ = search(`a{300}`)
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestSearchIndexFollowsTrashAndRestore(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	file, err := store.OpenForWrite("/projects/alpha/budget-2026.txt")
+	require.NoError(t, err)
+	_, err = file.WriteString("budget")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	total := func() int {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?q=budget-2026", accessToken)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var payload struct {
+			Meta struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		return payload.Meta.Total
+	}
+	require.Eventually(t, func() bool { return total() == 1 }, 10*time.Second, 100*time.Millisecond)
+
+	body, err := json.Marshal(map[string]any{"paths": []string{"/projects/alpha"}})
+	require.NoError(t, err)
+	deleteResp := doAuthJSONRequest(t, http.MethodDelete, server.URL+"/api/v1/files", body, accessToken)
+	t.Cleanup(func() { _ = deleteResp.Body.Close() })
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+	require.Eventually(t, func() bool { return total() == 0 }, 10*time.Second, 100*time.Millisecond)
+
+	restoreResp := doAuthJSONRequest(t, http.MethodPost, server.URL+"/api/v1/files/restore", body, accessToken)
+	t.Cleanup(func() { _ = restoreResp.Body.Close() })
+	require.Equal(t, http.StatusOK, restoreResp.StatusCode)
+	require.Eventually(t, func() bool { return total() == 1 }, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestSearchReindexRequiresAdmin(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	server, adminToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	resp := doAuthRequest(t, http.MethodPost, server.URL+"/api/v1/search/reindex", adminToken)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var payload struct {
+		Data struct {
+			Running bool `json:"running"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+	require.True(t, payload.Data.Running)
+
+	editorToken := registerAndLogin(t, server.URL, adminToken, "index-editor", "editor")
+	editorResp := doAuthRequest(t, http.MethodPost, server.URL+"/api/v1/search/reindex", editorToken)
+	t.Cleanup(func() { _ = editorResp.Body.Close() })
+	require.Equal(t, http.StatusForbidden, editorResp.StatusCode)
+}
+
+func TestSearchByContent(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	file, err := store.OpenForWrite("/notes/plan.md")
+	require.NoError(t, err)
+	_, err = file.WriteString("# Plan\nReview the quarterly budget <draft> with finance.")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	type contentPayload struct {
+		Data struct {
+			Content string `json:"content"`
+			Items   []struct {
+				Path         string `json:"path"`
+				MatchContext string `json:"match_context"`
+			} `json:"items"`
+		} `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	// Content search answers 503 until the first crawl finishes; that reads
+	// as no results here.
+	search := func(query string) contentPayload {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?"+query, accessToken)
+		defer resp.Body.Close()
+		var payload contentPayload
+		if resp.StatusCode == http.StatusOK {
+			_ = json.NewDecoder(resp.Body).Decode(&payload)
+		}
+		return payload
+	}
+
+	require.Eventually(t, func() bool {
+		return search("content=budget").Meta.Total == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	payload := search("content=budget")
+	require.Equal(t, "budget", payload.Data.Content)
+	require.Equal(t, "/notes/plan.md", payload.Data.Items[0].Path)
+	require.Contains(t, payload.Data.Items[0].MatchContext, "<mark>budget</mark>")
+	require.Contains(t, payload.Data.Items[0].MatchContext, "&lt;draft&gt;")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("path", "/uploads"))
+	filePart, err := writer.CreateFormFile("files", "memo.txt")
+	require.NoError(t, err)
+	_, err = filePart.Write([]byte("Budget cuts and hiring freeze"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := mustNewRequest(t, http.MethodPost, server.URL+"/api/v1/files/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	uploadResp := doRequest(t, req)
+	t.Cleanup(func() { _ = uploadResp.Body.Close() })
+	require.Equal(t, http.StatusOK, uploadResp.StatusCode)
+
+	require.Eventually(t, func() bool {
+		return search("content=budget").Meta.Total == 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	payload = search("content=budget+-hiring")
+	require.Equal(t, 1, payload.Meta.Total)
+	require.Equal(t, "/notes/plan.md", payload.Data.Items[0].Path)
+
+	payload = search("content=budget&path=/uploads")
+	require.Equal(t, 1, payload.Meta.Total)
+	require.Equal(t, "/uploads/memo.txt", payload.Data.Items[0].Path)
+}
+
+func TestSearchFiltersAndQuerySyntax(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for filePath, size := range map[string]int{
+		"/reports/q1-report.pdf":   3 << 20,
+		"/reports/q2-report.pdf":   1 << 10,
+		"/reports/q3-report.txt":   4 << 20,
+		"/archive/old-report.pdf":  5 << 20,
+		"/photos/report-cover.png": 2 << 20,
+	} {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		_, err = file.Write(bytes.Repeat([]byte("x"), size))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	type searchPayload struct {
+		Data struct {
+			Items []struct {
+				Path string `json:"path"`
+			} `json:"items"`
+		} `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	search := func(query string) (int, []string) {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?"+query, accessToken)
+		defer resp.Body.Close()
+		var payload searchPayload
+		_ = json.NewDecoder(resp.Body).Decode(&payload)
+		paths := make([]string, 0, len(payload.Data.Items))
+		for _, item := range payload.Data.Items {
+			paths = append(paths, item.Path)
+		}
+		return resp.StatusCode, paths
+	}
+
+	// Owner filters need the index; wait for the first crawl.
+	require.Eventually(t, func() bool {
+		status, _ := search("owner=nobody")
+		return status == http.StatusOK
+	}, 10*time.Second, 100*time.Millisecond)
+
+	status, paths := search("q=" + url.QueryEscape(`ext:pdf size:>1MB -path:/archive report`))
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"/reports/q1-report.pdf"}, paths)
+
+	status, paths = search("q=report&category=document&sort=-size")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"/archive/old-report.pdf", "/reports/q3-report.txt", "/reports/q1-report.pdf", "/reports/q2-report.pdf"}, paths)
+
+	status, paths = search("name=" + url.QueryEscape("q?-report.*") + "&max_size=1KB")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"/reports/q2-report.pdf"}, paths)
+
+	status, paths = search("regex=" + url.QueryEscape(`^report-`) + "&mime=" + url.QueryEscape("image/*"))
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"/photos/report-cover.png"}, paths)
+
+	status, _ = search("q=" + url.QueryEscape("size:>10XB"))
+	require.Equal(t, http.StatusBadRequest, status)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("path", "/reports"))
+	filePart, err := writer.CreateFormFile("files", "q4-report.pdf")
+	require.NoError(t, err)
+	_, err = filePart.Write([]byte("%PDF-1.4"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := mustNewRequest(t, http.MethodPost, server.URL+"/api/v1/files/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	uploadResp := doRequest(t, req)
+	t.Cleanup(func() { _ = uploadResp.Body.Close() })
+	require.Equal(t, http.StatusOK, uploadResp.StatusCode)
+
+	require.Eventually(t, func() bool {
+		_, paths := search("q=" + url.QueryEscape("owner:admin"))
+		return len(paths) == 1 && paths[0] == "/reports/q4-report.pdf"
+	}, 10*time.Second, 100*time.Millisecond)
+
+	renameBody, err := json.Marshal(map[string]string{"path": "/reports/q4-report.pdf", "new_name": "annual-report.pdf"})
+	require.NoError(t, err)
+	renameResp := doAuthJSONRequest(t, http.MethodPut, server.URL+"/api/v1/files/rename", renameBody, accessToken)
+	t.Cleanup(func() { _ = renameResp.Body.Close() })
+	require.Equal(t, http.StatusOK, renameResp.StatusCode)
+
+	require.Eventually(t, func() bool {
+		_, paths := search("owner=admin")
+		return len(paths) == 1 && paths[0] == "/reports/annual-report.pdf"
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestSearchStreamAndCursorPagination(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for _, filePath := range []string{"/logs/item-1.log", "/logs/item-2.log", "/item-3.log", "/deep/er/item-4.log", "/item-5.log"} {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	require.Eventually(t, func() bool {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?owner=nobody", accessToken)
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 10*time.Second, 100*time.Millisecond)
+
+	type pagePayload struct {
+		Data struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+		} `json:"data"`
+		Meta struct {
+			Page       int    `json:"page"`
+			Total      int    `json:"total"`
+			NextCursor string `json:"next_cursor"`
+		} `json:"meta"`
+	}
+
+	names := make([]string, 0)
+	cursor := ""
+	for range 3 {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?q=item&limit=2&cursor="+url.QueryEscape(cursor), accessToken)
+		var payload pagePayload
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		_ = resp.Body.Close()
+		require.Equal(t, 5, payload.Meta.Total)
+		for _, item := range payload.Data.Items {
+			names = append(names, item.Name)
+		}
+		cursor = payload.Meta.NextCursor
+	}
+	require.Equal(t, []string{"item-1.log", "item-2.log", "item-3.log", "item-4.log", "item-5.log"}, names)
+	require.Empty(t, cursor)
+
+	type streamRecord struct {
+		Type string `json:"type"`
+		Item struct {
+			Name string `json:"name"`
+		} `json:"item"`
+		Summary struct {
+			Count      int    `json:"count"`
+			Truncated  bool   `json:"truncated"`
+			TimedOut   bool   `json:"timed_out"`
+			NextCursor string `json:"next_cursor"`
+		} `json:"summary"`
+	}
+	stream := func(query string) []streamRecord {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search/stream?"+query, accessToken)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+		records := make([]streamRecord, 0)
+		decoder := json.NewDecoder(resp.Body)
+		for decoder.More() {
+			var record streamRecord
+			require.NoError(t, decoder.Decode(&record))
+			records = append(records, record)
+		}
+		return records
+	}
+
+	records := stream("q=item&limit=3&sort=-name")
+	require.Len(t, records, 4)
+	require.Equal(t, "item-5.log", records[0].Item.Name)
+	require.Equal(t, "summary", records[3].Type)
+	require.Equal(t, 3, records[3].Summary.Count)
+	require.True(t, records[3].Summary.Truncated)
+	require.NotEmpty(t, records[3].Summary.NextCursor)
+
+	records = stream("q=item&limit=3&sort=-name&cursor=" + url.QueryEscape(records[3].Summary.NextCursor))
+	require.Len(t, records, 3)
+	require.Equal(t, "item-2.log", records[0].Item.Name)
+	require.Equal(t, "item-1.log", records[1].Item.Name)
+	require.False(t, records[2].Summary.Truncated)
+	require.Empty(t, records[2].Summary.NextCursor)
+
+	sseResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search/stream?q=item-3&format=sse", accessToken)
+	t.Cleanup(func() { _ = sseResp.Body.Close() })
+	require.Equal(t, "text/event-stream", sseResp.Header.Get("Content-Type"))
+	sseBody, err := io.ReadAll(sseResp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(sseBody), "event: item\n")
+	require.Contains(t, string(sseBody), "event: summary\ndata: {\"count\":1,\"truncated\":false,\"timed_out\":false}")
+
+	badResp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search/stream?q=item&cursor=bogus", accessToken)
+	t.Cleanup(func() { _ = badResp.Body.Close() })
+	require.Equal(t, http.StatusBadRequest, badResp.StatusCode)
+}
@@ -0,0 +1,150 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-file-explorer/internal/storage"
+)
+
+func TestFileTags(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+
+	for _, filePath := range []string{"/docs/a.txt", "/docs/sub/b.txt", "/other.txt"} {
+		file, err := store.OpenForWrite(filePath)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	server, accessToken, _ := newAuthedServer(t, store)
+	t.Cleanup(server.Close)
+
+	send := func(method string, endpoint string, body string, status int, target any) {
+		t.Helper()
+		resp := doAuthJSONRequest(t, method, server.URL+endpoint, []byte(body), accessToken)
+		defer resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode, endpoint)
+		if target != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(target))
+		}
+	}
+	type tagsPayload struct {
+		Data struct {
+			Path string   `json:"path"`
+			Tags []string `json:"tags"`
+		} `json:"data"`
+	}
+	tagsOf := func(apiPath string) []string {
+		var payload tagsPayload
+		send(http.MethodGet, "/api/v1/files/tags?path="+apiPath, "", http.StatusOK, &payload)
+		return payload.Data.Tags
+	}
+	counts := func() map[string]int {
+		var payload struct {
+			Data struct {
+				Items []struct {
+					Name  string `json:"name"`
+					Count int    `json:"count"`
+				} `json:"items"`
+			} `json:"data"`
+		}
+		send(http.MethodGet, "/api/v1/tags", "", http.StatusOK, &payload)
+		result := make(map[string]int)
+		for _, item := range payload.Data.Items {
+			result[item.Name] = item.Count
+		}
+		return result
+	}
+	type listPayload struct {
+		Data struct {
+			Items []struct {
+				Path string   `json:"path"`
+				Tags []string `json:"tags"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+
+	var tagged tagsPayload
+	send(http.MethodPost, "/api/v1/files/tags", `{"path":"/docs","tags":["Project  X","urgent"]}`, http.StatusOK, &tagged)
+	require.Equal(t, []string{"project x", "urgent"}, tagged.Data.Tags)
+	send(http.MethodPost, "/api/v1/files/tags", `{"path":"/docs/sub/b.txt","tags":["urgent","draft"]}`, http.StatusOK, nil)
+	send(http.MethodDelete, "/api/v1/files/tags", `{"path":"/docs/sub/b.txt","tags":["draft"]}`, http.StatusOK, nil)
+	require.Equal(t, map[string]int{"urgent": 2, "project x": 1}, counts())
+
+	send(http.MethodPost, "/api/v1/files/tags", `{"path":"/docs","tags":["#bad"]}`, http.StatusBadRequest, nil)
+	send(http.MethodPost, "/api/v1/files/tags", `{"path":"/","tags":["root"]}`, http.StatusBadRequest, nil)
+	send(http.MethodPost, "/api/v1/files/tags", `{"path":"/missing.txt","tags":["gone"]}`, http.StatusNotFound, nil)
+
+	var listing listPayload
+	send(http.MethodGet, "/api/v1/files?path=/&tag=URGENT", "", http.StatusOK, &listing)
+	require.Len(t, listing.Data.Items, 1)
+	require.Equal(t, "/docs", listing.Data.Items[0].Path)
+	require.Equal(t, []string{"project x", "urgent"}, listing.Data.Items[0].Tags)
+
+	// Tags follow renames and moves, including the tags of nested entries.
+	send(http.MethodPut, "/api/v1/files/rename", `{"path":"/docs","new_name":"projects"}`, http.StatusOK, nil)
+	require.Equal(t, []string{"project x", "urgent"}, tagsOf("/projects"))
+	require.Equal(t, []string{"urgent"}, tagsOf("/projects/sub/b.txt"))
+	send(http.MethodPut, "/api/v1/files/move", `{"sources":["/projects/sub/b.txt"],"destination":"/"}`, http.StatusOK, nil)
+	require.Equal(t, []string{"urgent"}, tagsOf("/b.txt"))
+
+	require.Eventually(t, func() bool {
+		resp := doAuthRequest(t, http.MethodGet, server.URL+"/api/v1/search?tag=urgent&sort=name", accessToken)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return false
+		}
+		var payload listPayload
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		return len(payload.Data.Items) == 2 && payload.Data.Items[0].Path == "/b.txt" && payload.Data.Items[1].Path == "/projects"
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// Trashed entries take their tags along and bring them back on restore.
+	send(http.MethodDelete, "/api/v1/files", `{"paths":["/b.txt"]}`, http.StatusOK, nil)
+	require.Equal(t, map[string]int{"urgent": 1, "project x": 1}, counts())
+	send(http.MethodPost, "/api/v1/files/restore", `{"paths":["/b.txt"]}`, http.StatusOK, nil)
+	require.Equal(t, []string{"urgent"}, tagsOf("/b.txt"))
+
+	// Permanently deleted entries lose their tags.
+	send(http.MethodDelete, "/api/v1/files", `{"paths":["/b.txt"]}`, http.StatusOK, nil)
+	var trash struct {
+		Data struct {
+			Items []struct {
+				ID string `json:"id"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	send(http.MethodGet, "/api/v1/trash", "", http.StatusOK, &trash)
+	require.Len(t, trash.Data.Items, 1)
+	send(http.MethodDelete, "/api/v1/trash/"+trash.Data.Items[0].ID, "", http.StatusOK, nil)
+	file, err := store.OpenForWrite("/b.txt")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.Empty(t, tagsOf("/b.txt"))
+
+	var bulk struct {
+		Data struct {
+			Updated []struct {
+				Path string   `json:"path"`
+				Tags []string `json:"tags"`
+			} `json:"updated"`
+			Failed []struct {
+				Path string `json:"path"`
+			} `json:"failed"`
+		} `json:"data"`
+	}
+	send(http.MethodPost, "/api/v1/files/tags/bulk", `{"paths":["/other.txt","/projects","/missing.txt"],"add":["review"],"remove":["urgent"]}`, http.StatusOK, &bulk)
+	require.Len(t, bulk.Data.Updated, 2)
+	require.Equal(t, []string{"review"}, bulk.Data.Updated[0].Tags)
+	require.Equal(t, []string{"project x", "review"}, bulk.Data.Updated[1].Tags)
+	require.Len(t, bulk.Data.Failed, 1)
+	require.Equal(t, "/missing.txt", bulk.Data.Failed[0].Path)
+	require.Equal(t, map[string]int{"review": 2, "project x": 1}, counts())
+}
@@ -43,7 +43,7 @@ func newTestServer(t *testing.T, store storage.Storage, authRateLimitRPM int) *h
 	require.NoError(t, err)
 
 	// Reset database
-	_, err = db.Pool.Exec(ctx, "TRUNCATE TABLE users, refresh_tokens, audit_entries, shares, trash_records, jobs, job_items RESTART IDENTITY CASCADE")
+	_, err = db.Pool.Exec(ctx, "TRUNCATE TABLE users, refresh_tokens, audit_entries, shares, trash_records, jobs, job_items, job_owner_starts, schedules, schedule_runs, pipelines, pipeline_steps, file_index, file_content, saved_searches, file_tags, file_metadata, metadata_fields, favorites, recent_files RESTART IDENTITY CASCADE")
 	require.NoError(t, err)
 
 	// Repositories
@@ -73,28 +73,70 @@ func newTestServer(t *testing.T, store storage.Storage, authRateLimitRPM int) *h
 	auditService := service.NewAuditService(auditRepo)
 
 	operationsService := service.NewOperationsService(store, trashService, auditService, bus)
-	jobService := service.NewJobService(operationsService, jobRepo, bus)
+	jobService := service.NewJobService(operationsService, jobRepo, bus, service.JobWorkerOptions{Workers: 2, QuickWorkers: 1, MaxPerUser: 2})
+	thumbnailService, err := service.NewThumbnailService(fileService, store, bus, service.ThumbnailOptions{})
+	require.NoError(t, err)
+	fileService.UseThumbnails(thumbnailService)
+	trashService.UseThumbnails(thumbnailService)
+	jobService.UseThumbnails(thumbnailService)
+	pipelineService := service.NewPipelineService(repository.NewPipelineRepository(db.Pool), userRepo, jobService)
+	jobService.OnJobFinished(pipelineService.JobFinished)
+	jobCtx, stopJobs := context.WithCancel(ctx)
+	t.Cleanup(stopJobs)
+	go jobService.Run(jobCtx)
+	go pipelineService.Run(jobCtx)
+	indexService := service.NewIndexService(store, repository.NewIndexRepository(db.Pool), bus, service.IndexOptions{Interval: time.Hour})
+	go indexService.Run(jobCtx)
 	searchService := service.NewSearchService(store, 10, 30*time.Second)
+	searchService.UseIndex(indexService)
+	savedSearchService := service.NewSavedSearchService(repository.NewSavedSearchRepository(db.Pool), searchService, bus)
+	go savedSearchService.Run(jobCtx)
+	tagService := service.NewTagService(repository.NewTagRepository(db.Pool), store, auditService, bus)
+	operationsService.UseTags(tagService)
+	trashService.UseTags(tagService)
+	directoryService.UseTags(tagService)
+	fileService.UseTags(tagService)
+	searchService.UseTags(tagService)
+	metadataService := service.NewMetadataService(repository.NewMetadataRepository(db.Pool), store, auditService, bus)
+	operationsService.UseMetadata(metadataService)
+	trashService.UseMetadata(metadataService)
+	fileService.UseMetadata(metadataService)
+	searchService.UseMetadata(metadataService)
 	shareService := service.NewShareService(shareRepo)
 
 	chunkTempDir := filepath.Join(t.TempDir(), "chunks")
 	chunkedUploadService, err := service.NewChunkedUploadService(store, chunkTempDir, []string{}, bus)
 	require.NoError(t, err)
+	quickAccessService := service.NewQuickAccessService(repository.NewQuickAccessRepository(db.Pool), store, fileService)
+	operationsService.UseQuickAccess(quickAccessService)
+	trashService.UseQuickAccess(quickAccessService)
+	fileService.UseQuickAccess(quickAccessService)
+	metadataService.UseQuickAccess(quickAccessService)
+	chunkedUploadService.UseQuickAccess(quickAccessService)
 
 	// Handlers
 	authMiddleware := middleware.NewAuthMiddleware(authService)
 	authHandler := handler.NewAuthHandler(authService)
-	directoryHandler := handler.NewDirectoryHandler(directoryService)
-	fileHandler := handler.NewFileHandler(fileService, 10*1024*1024)
+	directoryHandler := handler.NewDirectoryHandler(directoryService, savedSearchService)
+	archiveService := service.NewArchiveService(store, 5*time.Minute)
+	fileHandler := handler.NewFileHandler(fileService, archiveService, 10*1024*1024)
 	auditHandler := handler.NewAuditHandler(auditService)
+	archiveService.UseAudit(auditService)
 	operationsHandler := handler.NewOperationsHandler(operationsService)
 	jobsHandler := handler.NewJobsHandler(jobService)
+	scheduleHandler := handler.NewScheduleHandler(service.NewSchedulerService(repository.NewScheduleRepository(db.Pool), userRepo, jobService))
+	pipelineHandler := handler.NewPipelineHandler(pipelineService)
 	searchHandler := handler.NewSearchHandler(searchService)
+	savedSearchHandler := handler.NewSavedSearchHandler(savedSearchService)
 	docsHandler := handler.NewDocsHandler(filepath.Join("..", "..", "docs", "openapi.yaml"))
 	userHandler := handler.NewUserHandler(authService)
 	storageHandler := handler.NewStorageHandler(store, []string{})
 	shareHandler := handler.NewShareHandler(shareService, fileService)
 	chunkedUploadHandler := handler.NewChunkedUploadHandler(chunkedUploadService, 5*1024*1024)
+	archiveHandler := handler.NewArchiveHandler(archiveService)
+	tagHandler := handler.NewTagHandler(tagService)
+	metadataHandler := handler.NewMetadataHandler(metadataService)
+	quickAccessHandler := handler.NewQuickAccessHandler(quickAccessService)
 	hub := websocket.NewHub(bus)
 
 	cfg := &config.Config{
@@ -120,6 +162,11 @@ func newTestServer(t *testing.T, store storage.Storage, authRateLimitRPM int) *h
 		ChunkTempDir:            chunkTempDir,
 		ChunkMaxSize:            5 * 1024 * 1024,
 		ChunkExpiry:             24 * time.Hour,
+		ArchiveTicketTTL:        5 * time.Minute,
+		JobWorkers:              2,
+		JobQuickWorkers:         1,
+		JobMaxPerUser:           2,
+		JobRetention:            720 * time.Hour,
 		DatabaseURL:             dbURL,
 		DBMaxConns:              5,
 		DBMinConns:              1,
@@ -134,13 +181,20 @@ func newTestServer(t *testing.T, store storage.Storage, authRateLimitRPM int) *h
 			File:          fileHandler,
 			Operations:    operationsHandler,
 			Search:        searchHandler,
+			SavedSearches: savedSearchHandler,
 			Audit:         auditHandler,
 			Jobs:          jobsHandler,
+			Schedules:     scheduleHandler,
+			Pipelines:     pipelineHandler,
 			Docs:          docsHandler,
 			User:          userHandler,
 			Storage:       storageHandler,
 			Share:         shareHandler,
 			ChunkedUpload: chunkedUploadHandler,
+			Archive:       archiveHandler,
+			Tags:          tagHandler,
+			Metadata:      metadataHandler,
+			QuickAccess:   quickAccessHandler,
 		},
 		hub,
 	)
@@ -176,6 +230,34 @@ func newAuthedServer(t *testing.T, store storage.Storage) (*httptest.Server, str
 	return server, parsed.Data.AccessToken, parsed.Data.RefreshToken
 }
 
+// registerAndLogin registers a user through the admin account and returns
+// the new user's access token.
+func registerAndLogin(t *testing.T, serverURL string, adminToken string, username string, role string) string {
+	t.Helper()
+
+	registerBody, err := json.Marshal(map[string]string{"username": username, "password": "Password123!", "role": role})
+	require.NoError(t, err)
+	registerResp := doAuthJSONRequest(t, http.MethodPost, serverURL+"/api/v1/auth/register", registerBody, adminToken)
+	t.Cleanup(func() { _ = registerResp.Body.Close() })
+	require.Equal(t, http.StatusCreated, registerResp.StatusCode)
+
+	loginBody, err := json.Marshal(map[string]string{"username": username, "password": "Password123!"})
+	require.NoError(t, err)
+	loginResp, err := http.Post(serverURL+"/api/v1/auth/login", "application/json", bytes.NewReader(loginBody))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = loginResp.Body.Close() })
+	require.Equal(t, http.StatusOK, loginResp.StatusCode)
+
+	var parsed struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(loginResp.Body).Decode(&parsed))
+	require.NotEmpty(t, parsed.Data.AccessToken)
+	return parsed.Data.AccessToken
+}
+
 func mustNewRequest(t *testing.T, method, url string, body io.Reader) *http.Request {
 	t.Helper()
 	req, err := http.NewRequest(method, url, body)